@@ -0,0 +1,9 @@
+// Package optimizerv1 contains the generated gRPC bindings for optimizer.proto.
+//
+// Regenerate after editing the .proto file (requires protoc, protoc-gen-go and
+// protoc-gen-go-grpc on PATH):
+//
+//	go generate ./api/proto/...
+package optimizerv1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative optimizer/v1/optimizer.proto
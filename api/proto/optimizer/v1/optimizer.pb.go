@@ -0,0 +1,812 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: optimizer/v1/optimizer.proto
+
+// Package optimizer.v1 exposes the package optimizer over gRPC.
+// It mirrors the HTTP API: single calculations, batch calculations and the
+// available package sizes, all backed by the same domain optimizer.
+
+package optimizerv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// CalculateRequest asks for the optimal packing of a single quantity.
+type CalculateRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// quantity is the requested quantity; must be non-negative.
+	Quantity int64 `protobuf:"varint,1,opt,name=quantity,proto3" json:"quantity,omitempty"`
+}
+
+func (x *CalculateRequest) Reset() {
+	*x = CalculateRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_optimizer_v1_optimizer_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CalculateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CalculateRequest) ProtoMessage() {}
+
+func (x *CalculateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_optimizer_v1_optimizer_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CalculateRequest.ProtoReflect.Descriptor instead.
+func (*CalculateRequest) Descriptor() ([]byte, []int) {
+	return file_optimizer_v1_optimizer_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CalculateRequest) GetQuantity() int64 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+// CalculateResponse carries the result of a single calculation.
+type CalculateResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Result *OptimizationResult `protobuf:"bytes,1,opt,name=result,proto3" json:"result,omitempty"`
+}
+
+func (x *CalculateResponse) Reset() {
+	*x = CalculateResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_optimizer_v1_optimizer_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CalculateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CalculateResponse) ProtoMessage() {}
+
+func (x *CalculateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_optimizer_v1_optimizer_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CalculateResponse.ProtoReflect.Descriptor instead.
+func (*CalculateResponse) Descriptor() ([]byte, []int) {
+	return file_optimizer_v1_optimizer_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CalculateResponse) GetResult() *OptimizationResult {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+// BatchCalculateRequest asks for the optimal packing of several quantities.
+type BatchCalculateRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// quantities are the requested quantities, computed in order.
+	Quantities []int64 `protobuf:"varint,1,rep,packed,name=quantities,proto3" json:"quantities,omitempty"`
+}
+
+func (x *BatchCalculateRequest) Reset() {
+	*x = BatchCalculateRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_optimizer_v1_optimizer_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BatchCalculateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchCalculateRequest) ProtoMessage() {}
+
+func (x *BatchCalculateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_optimizer_v1_optimizer_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchCalculateRequest.ProtoReflect.Descriptor instead.
+func (*BatchCalculateRequest) Descriptor() ([]byte, []int) {
+	return file_optimizer_v1_optimizer_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *BatchCalculateRequest) GetQuantities() []int64 {
+	if x != nil {
+		return x.Quantities
+	}
+	return nil
+}
+
+// BatchCalculateResponse carries one item per requested quantity, in request order.
+type BatchCalculateResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Items []*BatchItem `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+}
+
+func (x *BatchCalculateResponse) Reset() {
+	*x = BatchCalculateResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_optimizer_v1_optimizer_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BatchCalculateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchCalculateResponse) ProtoMessage() {}
+
+func (x *BatchCalculateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_optimizer_v1_optimizer_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchCalculateResponse.ProtoReflect.Descriptor instead.
+func (*BatchCalculateResponse) Descriptor() ([]byte, []int) {
+	return file_optimizer_v1_optimizer_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *BatchCalculateResponse) GetItems() []*BatchItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+// BatchItem is the outcome of one quantity in a batch.
+type BatchItem struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// quantity is the requested quantity this item refers to.
+	Quantity int64 `protobuf:"varint,1,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	// Types that are assignable to Outcome:
+	//	*BatchItem_Result
+	//	*BatchItem_Error
+	Outcome isBatchItem_Outcome `protobuf_oneof:"outcome"`
+}
+
+func (x *BatchItem) Reset() {
+	*x = BatchItem{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_optimizer_v1_optimizer_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BatchItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchItem) ProtoMessage() {}
+
+func (x *BatchItem) ProtoReflect() protoreflect.Message {
+	mi := &file_optimizer_v1_optimizer_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchItem.ProtoReflect.Descriptor instead.
+func (*BatchItem) Descriptor() ([]byte, []int) {
+	return file_optimizer_v1_optimizer_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BatchItem) GetQuantity() int64 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (m *BatchItem) GetOutcome() isBatchItem_Outcome {
+	if m != nil {
+		return m.Outcome
+	}
+	return nil
+}
+
+func (x *BatchItem) GetResult() *OptimizationResult {
+	if x, ok := x.GetOutcome().(*BatchItem_Result); ok {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *BatchItem) GetError() string {
+	if x, ok := x.GetOutcome().(*BatchItem_Error); ok {
+		return x.Error
+	}
+	return ""
+}
+
+type isBatchItem_Outcome interface {
+	isBatchItem_Outcome()
+}
+
+type BatchItem_Result struct {
+	// result is set when the calculation succeeded.
+	Result *OptimizationResult `protobuf:"bytes,2,opt,name=result,proto3,oneof"`
+}
+
+type BatchItem_Error struct {
+	// error is set when the calculation failed.
+	Error string `protobuf:"bytes,3,opt,name=error,proto3,oneof"`
+}
+
+func (*BatchItem_Result) isBatchItem_Outcome() {}
+
+func (*BatchItem_Error) isBatchItem_Outcome() {}
+
+// ListPackageSizesRequest is intentionally empty.
+type ListPackageSizesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ListPackageSizesRequest) Reset() {
+	*x = ListPackageSizesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_optimizer_v1_optimizer_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListPackageSizesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPackageSizesRequest) ProtoMessage() {}
+
+func (x *ListPackageSizesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_optimizer_v1_optimizer_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPackageSizesRequest.ProtoReflect.Descriptor instead.
+func (*ListPackageSizesRequest) Descriptor() ([]byte, []int) {
+	return file_optimizer_v1_optimizer_proto_rawDescGZIP(), []int{5}
+}
+
+// ListPackageSizesResponse lists the available package sizes.
+type ListPackageSizesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// package_sizes are the configured sizes, largest first.
+	PackageSizes []int64 `protobuf:"varint,1,rep,packed,name=package_sizes,json=packageSizes,proto3" json:"package_sizes,omitempty"`
+	// catalog_version identifies this set of package sizes.
+	CatalogVersion string `protobuf:"bytes,2,opt,name=catalog_version,json=catalogVersion,proto3" json:"catalog_version,omitempty"`
+}
+
+func (x *ListPackageSizesResponse) Reset() {
+	*x = ListPackageSizesResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_optimizer_v1_optimizer_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListPackageSizesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPackageSizesResponse) ProtoMessage() {}
+
+func (x *ListPackageSizesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_optimizer_v1_optimizer_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPackageSizesResponse.ProtoReflect.Descriptor instead.
+func (*ListPackageSizesResponse) Descriptor() ([]byte, []int) {
+	return file_optimizer_v1_optimizer_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListPackageSizesResponse) GetPackageSizes() []int64 {
+	if x != nil {
+		return x.PackageSizes
+	}
+	return nil
+}
+
+func (x *ListPackageSizesResponse) GetCatalogVersion() string {
+	if x != nil {
+		return x.CatalogVersion
+	}
+	return ""
+}
+
+// OptimizationResult is the optimal package combination for a quantity.
+type OptimizationResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// requested is the quantity that was requested.
+	Requested int64 `protobuf:"varint,1,opt,name=requested,proto3" json:"requested,omitempty"`
+	// total_delivered is the quantity delivered by the chosen packages.
+	TotalDelivered int64 `protobuf:"varint,2,opt,name=total_delivered,json=totalDelivered,proto3" json:"total_delivered,omitempty"`
+	// over_delivery is total_delivered - requested.
+	OverDelivery int64 `protobuf:"varint,3,opt,name=over_delivery,json=overDelivery,proto3" json:"over_delivery,omitempty"`
+	// packages lists the package sizes used and their counts, largest size first.
+	Packages []*PackageCount `protobuf:"bytes,4,rep,name=packages,proto3" json:"packages,omitempty"`
+	// catalog_version identifies the package catalog that produced the result.
+	CatalogVersion string `protobuf:"bytes,5,opt,name=catalog_version,json=catalogVersion,proto3" json:"catalog_version,omitempty"`
+}
+
+func (x *OptimizationResult) Reset() {
+	*x = OptimizationResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_optimizer_v1_optimizer_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *OptimizationResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OptimizationResult) ProtoMessage() {}
+
+func (x *OptimizationResult) ProtoReflect() protoreflect.Message {
+	mi := &file_optimizer_v1_optimizer_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OptimizationResult.ProtoReflect.Descriptor instead.
+func (*OptimizationResult) Descriptor() ([]byte, []int) {
+	return file_optimizer_v1_optimizer_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *OptimizationResult) GetRequested() int64 {
+	if x != nil {
+		return x.Requested
+	}
+	return 0
+}
+
+func (x *OptimizationResult) GetTotalDelivered() int64 {
+	if x != nil {
+		return x.TotalDelivered
+	}
+	return 0
+}
+
+func (x *OptimizationResult) GetOverDelivery() int64 {
+	if x != nil {
+		return x.OverDelivery
+	}
+	return 0
+}
+
+func (x *OptimizationResult) GetPackages() []*PackageCount {
+	if x != nil {
+		return x.Packages
+	}
+	return nil
+}
+
+func (x *OptimizationResult) GetCatalogVersion() string {
+	if x != nil {
+		return x.CatalogVersion
+	}
+	return ""
+}
+
+// PackageCount is a package size and how many packages of that size to use.
+type PackageCount struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Size  int64 `protobuf:"varint,1,opt,name=size,proto3" json:"size,omitempty"`
+	Count int64 `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+}
+
+func (x *PackageCount) Reset() {
+	*x = PackageCount{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_optimizer_v1_optimizer_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PackageCount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PackageCount) ProtoMessage() {}
+
+func (x *PackageCount) ProtoReflect() protoreflect.Message {
+	mi := &file_optimizer_v1_optimizer_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PackageCount.ProtoReflect.Descriptor instead.
+func (*PackageCount) Descriptor() ([]byte, []int) {
+	return file_optimizer_v1_optimizer_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *PackageCount) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *PackageCount) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+var File_optimizer_v1_optimizer_proto protoreflect.FileDescriptor
+
+var file_optimizer_v1_optimizer_proto_rawDesc = []byte{
+	0x0a, 0x1c, 0x6f, 0x70, 0x74, 0x69, 0x6d, 0x69, 0x7a, 0x65, 0x72, 0x2f, 0x76, 0x31, 0x2f, 0x6f,
+	0x70, 0x74, 0x69, 0x6d, 0x69, 0x7a, 0x65, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0c,
+	0x6f, 0x70, 0x74, 0x69, 0x6d, 0x69, 0x7a, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x22, 0x2e, 0x0a, 0x10,
+	0x43, 0x61, 0x6c, 0x63, 0x75, 0x6c, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x1a, 0x0a, 0x08, 0x71, 0x75, 0x61, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x08, 0x71, 0x75, 0x61, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x22, 0x4d, 0x0a, 0x11,
+	0x43, 0x61, 0x6c, 0x63, 0x75, 0x6c, 0x61, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x38, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x20, 0x2e, 0x6f, 0x70, 0x74, 0x69, 0x6d, 0x69, 0x7a, 0x65, 0x72, 0x2e, 0x76, 0x31,
+	0x2e, 0x4f, 0x70, 0x74, 0x69, 0x6d, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73,
+	0x75, 0x6c, 0x74, 0x52, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x37, 0x0a, 0x15, 0x42,
+	0x61, 0x74, 0x63, 0x68, 0x43, 0x61, 0x6c, 0x63, 0x75, 0x6c, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x71, 0x75, 0x61, 0x6e, 0x74, 0x69, 0x74, 0x69,
+	0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x03, 0x52, 0x0a, 0x71, 0x75, 0x61, 0x6e, 0x74, 0x69,
+	0x74, 0x69, 0x65, 0x73, 0x22, 0x47, 0x0a, 0x16, 0x42, 0x61, 0x74, 0x63, 0x68, 0x43, 0x61, 0x6c,
+	0x63, 0x75, 0x6c, 0x61, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2d,
+	0x0a, 0x05, 0x69, 0x74, 0x65, 0x6d, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e,
+	0x6f, 0x70, 0x74, 0x69, 0x6d, 0x69, 0x7a, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x61, 0x74,
+	0x63, 0x68, 0x49, 0x74, 0x65, 0x6d, 0x52, 0x05, 0x69, 0x74, 0x65, 0x6d, 0x73, 0x22, 0x86, 0x01,
+	0x0a, 0x09, 0x42, 0x61, 0x74, 0x63, 0x68, 0x49, 0x74, 0x65, 0x6d, 0x12, 0x1a, 0x0a, 0x08, 0x71,
+	0x75, 0x61, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x71,
+	0x75, 0x61, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x12, 0x3a, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c,
+	0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x6f, 0x70, 0x74, 0x69, 0x6d, 0x69,
+	0x7a, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x70, 0x74, 0x69, 0x6d, 0x69, 0x7a, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x48, 0x00, 0x52, 0x06, 0x72, 0x65, 0x73,
+	0x75, 0x6c, 0x74, 0x12, 0x16, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x09, 0x48, 0x00, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x42, 0x09, 0x0a, 0x07, 0x6f,
+	0x75, 0x74, 0x63, 0x6f, 0x6d, 0x65, 0x22, 0x19, 0x0a, 0x17, 0x4c, 0x69, 0x73, 0x74, 0x50, 0x61,
+	0x63, 0x6b, 0x61, 0x67, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x22, 0x68, 0x0a, 0x18, 0x4c, 0x69, 0x73, 0x74, 0x50, 0x61, 0x63, 0x6b, 0x61, 0x67, 0x65,
+	0x53, 0x69, 0x7a, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x23, 0x0a,
+	0x0d, 0x70, 0x61, 0x63, 0x6b, 0x61, 0x67, 0x65, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x73, 0x18, 0x01,
+	0x20, 0x03, 0x28, 0x03, 0x52, 0x0c, 0x70, 0x61, 0x63, 0x6b, 0x61, 0x67, 0x65, 0x53, 0x69, 0x7a,
+	0x65, 0x73, 0x12, 0x27, 0x0a, 0x0f, 0x63, 0x61, 0x74, 0x61, 0x6c, 0x6f, 0x67, 0x5f, 0x76, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x63, 0x61, 0x74,
+	0x61, 0x6c, 0x6f, 0x67, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0xe1, 0x01, 0x0a, 0x12,
+	0x4f, 0x70, 0x74, 0x69, 0x6d, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x75,
+	0x6c, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x65, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x65, 0x64,
+	0x12, 0x27, 0x0a, 0x0f, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x64, 0x65, 0x6c, 0x69, 0x76, 0x65,
+	0x72, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x74, 0x6f, 0x74, 0x61, 0x6c,
+	0x44, 0x65, 0x6c, 0x69, 0x76, 0x65, 0x72, 0x65, 0x64, 0x12, 0x23, 0x0a, 0x0d, 0x6f, 0x76, 0x65,
+	0x72, 0x5f, 0x64, 0x65, 0x6c, 0x69, 0x76, 0x65, 0x72, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x0c, 0x6f, 0x76, 0x65, 0x72, 0x44, 0x65, 0x6c, 0x69, 0x76, 0x65, 0x72, 0x79, 0x12, 0x36,
+	0x0a, 0x08, 0x70, 0x61, 0x63, 0x6b, 0x61, 0x67, 0x65, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x6f, 0x70, 0x74, 0x69, 0x6d, 0x69, 0x7a, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e,
+	0x50, 0x61, 0x63, 0x6b, 0x61, 0x67, 0x65, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x08, 0x70, 0x61,
+	0x63, 0x6b, 0x61, 0x67, 0x65, 0x73, 0x12, 0x27, 0x0a, 0x0f, 0x63, 0x61, 0x74, 0x61, 0x6c, 0x6f,
+	0x67, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0e, 0x63, 0x61, 0x74, 0x61, 0x6c, 0x6f, 0x67, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22,
+	0x38, 0x0a, 0x0c, 0x50, 0x61, 0x63, 0x6b, 0x61, 0x67, 0x65, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12,
+	0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x73,
+	0x69, 0x7a, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x32, 0xa0, 0x02, 0x0a, 0x10, 0x4f, 0x70,
+	0x74, 0x69, 0x6d, 0x69, 0x7a, 0x65, 0x72, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x4c,
+	0x0a, 0x09, 0x43, 0x61, 0x6c, 0x63, 0x75, 0x6c, 0x61, 0x74, 0x65, 0x12, 0x1e, 0x2e, 0x6f, 0x70,
+	0x74, 0x69, 0x6d, 0x69, 0x7a, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x61, 0x6c, 0x63, 0x75,
+	0x6c, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x6f, 0x70,
+	0x74, 0x69, 0x6d, 0x69, 0x7a, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x61, 0x6c, 0x63, 0x75,
+	0x6c, 0x61, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x5b, 0x0a, 0x0e,
+	0x42, 0x61, 0x74, 0x63, 0x68, 0x43, 0x61, 0x6c, 0x63, 0x75, 0x6c, 0x61, 0x74, 0x65, 0x12, 0x23,
+	0x2e, 0x6f, 0x70, 0x74, 0x69, 0x6d, 0x69, 0x7a, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x61,
+	0x74, 0x63, 0x68, 0x43, 0x61, 0x6c, 0x63, 0x75, 0x6c, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x24, 0x2e, 0x6f, 0x70, 0x74, 0x69, 0x6d, 0x69, 0x7a, 0x65, 0x72, 0x2e,
+	0x76, 0x31, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x43, 0x61, 0x6c, 0x63, 0x75, 0x6c, 0x61, 0x74,
+	0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x61, 0x0a, 0x10, 0x4c, 0x69, 0x73,
+	0x74, 0x50, 0x61, 0x63, 0x6b, 0x61, 0x67, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x73, 0x12, 0x25, 0x2e,
+	0x6f, 0x70, 0x74, 0x69, 0x6d, 0x69, 0x7a, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73,
+	0x74, 0x50, 0x61, 0x63, 0x6b, 0x61, 0x67, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x73, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x26, 0x2e, 0x6f, 0x70, 0x74, 0x69, 0x6d, 0x69, 0x7a, 0x65, 0x72,
+	0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x50, 0x61, 0x63, 0x6b, 0x61, 0x67, 0x65, 0x53,
+	0x69, 0x7a, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x4a, 0x5a, 0x48,
+	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x73, 0x69, 0x6e, 0x61, 0x77,
+	0x33, 0x36, 0x39, 0x2f, 0x50, 0x61, 0x63, 0x6b, 0x61, 0x67, 0x65, 0x2d, 0x4f, 0x70, 0x74, 0x69,
+	0x6d, 0x69, 0x7a, 0x65, 0x72, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f,
+	0x6f, 0x70, 0x74, 0x69, 0x6d, 0x69, 0x7a, 0x65, 0x72, 0x2f, 0x76, 0x31, 0x3b, 0x6f, 0x70, 0x74,
+	0x69, 0x6d, 0x69, 0x7a, 0x65, 0x72, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_optimizer_v1_optimizer_proto_rawDescOnce sync.Once
+	file_optimizer_v1_optimizer_proto_rawDescData = file_optimizer_v1_optimizer_proto_rawDesc
+)
+
+func file_optimizer_v1_optimizer_proto_rawDescGZIP() []byte {
+	file_optimizer_v1_optimizer_proto_rawDescOnce.Do(func() {
+		file_optimizer_v1_optimizer_proto_rawDescData = protoimpl.X.CompressGZIP(file_optimizer_v1_optimizer_proto_rawDescData)
+	})
+	return file_optimizer_v1_optimizer_proto_rawDescData
+}
+
+var file_optimizer_v1_optimizer_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_optimizer_v1_optimizer_proto_goTypes = []any{
+	(*CalculateRequest)(nil),         // 0: optimizer.v1.CalculateRequest
+	(*CalculateResponse)(nil),        // 1: optimizer.v1.CalculateResponse
+	(*BatchCalculateRequest)(nil),    // 2: optimizer.v1.BatchCalculateRequest
+	(*BatchCalculateResponse)(nil),   // 3: optimizer.v1.BatchCalculateResponse
+	(*BatchItem)(nil),                // 4: optimizer.v1.BatchItem
+	(*ListPackageSizesRequest)(nil),  // 5: optimizer.v1.ListPackageSizesRequest
+	(*ListPackageSizesResponse)(nil), // 6: optimizer.v1.ListPackageSizesResponse
+	(*OptimizationResult)(nil),       // 7: optimizer.v1.OptimizationResult
+	(*PackageCount)(nil),             // 8: optimizer.v1.PackageCount
+}
+var file_optimizer_v1_optimizer_proto_depIdxs = []int32{
+	7, // 0: optimizer.v1.CalculateResponse.result:type_name -> optimizer.v1.OptimizationResult
+	4, // 1: optimizer.v1.BatchCalculateResponse.items:type_name -> optimizer.v1.BatchItem
+	7, // 2: optimizer.v1.BatchItem.result:type_name -> optimizer.v1.OptimizationResult
+	8, // 3: optimizer.v1.OptimizationResult.packages:type_name -> optimizer.v1.PackageCount
+	0, // 4: optimizer.v1.OptimizerService.Calculate:input_type -> optimizer.v1.CalculateRequest
+	2, // 5: optimizer.v1.OptimizerService.BatchCalculate:input_type -> optimizer.v1.BatchCalculateRequest
+	5, // 6: optimizer.v1.OptimizerService.ListPackageSizes:input_type -> optimizer.v1.ListPackageSizesRequest
+	1, // 7: optimizer.v1.OptimizerService.Calculate:output_type -> optimizer.v1.CalculateResponse
+	3, // 8: optimizer.v1.OptimizerService.BatchCalculate:output_type -> optimizer.v1.BatchCalculateResponse
+	6, // 9: optimizer.v1.OptimizerService.ListPackageSizes:output_type -> optimizer.v1.ListPackageSizesResponse
+	7, // [7:10] is the sub-list for method output_type
+	4, // [4:7] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_optimizer_v1_optimizer_proto_init() }
+func file_optimizer_v1_optimizer_proto_init() {
+	if File_optimizer_v1_optimizer_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_optimizer_v1_optimizer_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*CalculateRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_optimizer_v1_optimizer_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*CalculateResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_optimizer_v1_optimizer_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*BatchCalculateRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_optimizer_v1_optimizer_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*BatchCalculateResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_optimizer_v1_optimizer_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*BatchItem); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_optimizer_v1_optimizer_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*ListPackageSizesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_optimizer_v1_optimizer_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*ListPackageSizesResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_optimizer_v1_optimizer_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*OptimizationResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_optimizer_v1_optimizer_proto_msgTypes[8].Exporter = func(v any, i int) any {
+			switch v := v.(*PackageCount); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_optimizer_v1_optimizer_proto_msgTypes[4].OneofWrappers = []any{
+		(*BatchItem_Result)(nil),
+		(*BatchItem_Error)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_optimizer_v1_optimizer_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_optimizer_v1_optimizer_proto_goTypes,
+		DependencyIndexes: file_optimizer_v1_optimizer_proto_depIdxs,
+		MessageInfos:      file_optimizer_v1_optimizer_proto_msgTypes,
+	}.Build()
+	File_optimizer_v1_optimizer_proto = out.File
+	file_optimizer_v1_optimizer_proto_rawDesc = nil
+	file_optimizer_v1_optimizer_proto_goTypes = nil
+	file_optimizer_v1_optimizer_proto_depIdxs = nil
+}
@@ -0,0 +1,195 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: optimizer/v1/optimizer.proto
+
+// Package optimizer.v1 exposes the package optimizer over gRPC.
+// It mirrors the HTTP API: single calculations, batch calculations and the
+// available package sizes, all backed by the same domain optimizer.
+
+package optimizerv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	OptimizerService_Calculate_FullMethodName        = "/optimizer.v1.OptimizerService/Calculate"
+	OptimizerService_BatchCalculate_FullMethodName   = "/optimizer.v1.OptimizerService/BatchCalculate"
+	OptimizerService_ListPackageSizes_FullMethodName = "/optimizer.v1.OptimizerService/ListPackageSizes"
+)
+
+// OptimizerServiceClient is the client API for OptimizerService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type OptimizerServiceClient interface {
+	// Calculate returns the optimal package combination for one quantity.
+	Calculate(ctx context.Context, in *CalculateRequest, opts ...grpc.CallOption) (*CalculateResponse, error)
+	// BatchCalculate returns the optimal package combination for each quantity.
+	// A failure for one quantity is reported in its item and does not fail the batch.
+	BatchCalculate(ctx context.Context, in *BatchCalculateRequest, opts ...grpc.CallOption) (*BatchCalculateResponse, error)
+	// ListPackageSizes returns the package sizes available for optimization.
+	ListPackageSizes(ctx context.Context, in *ListPackageSizesRequest, opts ...grpc.CallOption) (*ListPackageSizesResponse, error)
+}
+
+type optimizerServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewOptimizerServiceClient(cc grpc.ClientConnInterface) OptimizerServiceClient {
+	return &optimizerServiceClient{cc}
+}
+
+func (c *optimizerServiceClient) Calculate(ctx context.Context, in *CalculateRequest, opts ...grpc.CallOption) (*CalculateResponse, error) {
+	out := new(CalculateResponse)
+	err := c.cc.Invoke(ctx, OptimizerService_Calculate_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *optimizerServiceClient) BatchCalculate(ctx context.Context, in *BatchCalculateRequest, opts ...grpc.CallOption) (*BatchCalculateResponse, error) {
+	out := new(BatchCalculateResponse)
+	err := c.cc.Invoke(ctx, OptimizerService_BatchCalculate_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *optimizerServiceClient) ListPackageSizes(ctx context.Context, in *ListPackageSizesRequest, opts ...grpc.CallOption) (*ListPackageSizesResponse, error) {
+	out := new(ListPackageSizesResponse)
+	err := c.cc.Invoke(ctx, OptimizerService_ListPackageSizes_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// OptimizerServiceServer is the server API for OptimizerService service.
+// All implementations must embed UnimplementedOptimizerServiceServer
+// for forward compatibility
+type OptimizerServiceServer interface {
+	// Calculate returns the optimal package combination for one quantity.
+	Calculate(context.Context, *CalculateRequest) (*CalculateResponse, error)
+	// BatchCalculate returns the optimal package combination for each quantity.
+	// A failure for one quantity is reported in its item and does not fail the batch.
+	BatchCalculate(context.Context, *BatchCalculateRequest) (*BatchCalculateResponse, error)
+	// ListPackageSizes returns the package sizes available for optimization.
+	ListPackageSizes(context.Context, *ListPackageSizesRequest) (*ListPackageSizesResponse, error)
+	mustEmbedUnimplementedOptimizerServiceServer()
+}
+
+// UnimplementedOptimizerServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedOptimizerServiceServer struct {
+}
+
+func (UnimplementedOptimizerServiceServer) Calculate(context.Context, *CalculateRequest) (*CalculateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Calculate not implemented")
+}
+func (UnimplementedOptimizerServiceServer) BatchCalculate(context.Context, *BatchCalculateRequest) (*BatchCalculateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchCalculate not implemented")
+}
+func (UnimplementedOptimizerServiceServer) ListPackageSizes(context.Context, *ListPackageSizesRequest) (*ListPackageSizesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPackageSizes not implemented")
+}
+func (UnimplementedOptimizerServiceServer) mustEmbedUnimplementedOptimizerServiceServer() {}
+
+// UnsafeOptimizerServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to OptimizerServiceServer will
+// result in compilation errors.
+type UnsafeOptimizerServiceServer interface {
+	mustEmbedUnimplementedOptimizerServiceServer()
+}
+
+func RegisterOptimizerServiceServer(s grpc.ServiceRegistrar, srv OptimizerServiceServer) {
+	s.RegisterService(&OptimizerService_ServiceDesc, srv)
+}
+
+func _OptimizerService_Calculate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CalculateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OptimizerServiceServer).Calculate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OptimizerService_Calculate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OptimizerServiceServer).Calculate(ctx, req.(*CalculateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OptimizerService_BatchCalculate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchCalculateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OptimizerServiceServer).BatchCalculate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OptimizerService_BatchCalculate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OptimizerServiceServer).BatchCalculate(ctx, req.(*BatchCalculateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OptimizerService_ListPackageSizes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPackageSizesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OptimizerServiceServer).ListPackageSizes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OptimizerService_ListPackageSizes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OptimizerServiceServer).ListPackageSizes(ctx, req.(*ListPackageSizesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// OptimizerService_ServiceDesc is the grpc.ServiceDesc for OptimizerService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var OptimizerService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "optimizer.v1.OptimizerService",
+	HandlerType: (*OptimizerServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Calculate",
+			Handler:    _OptimizerService_Calculate_Handler,
+		},
+		{
+			MethodName: "BatchCalculate",
+			Handler:    _OptimizerService_BatchCalculate_Handler,
+		},
+		{
+			MethodName: "ListPackageSizes",
+			Handler:    _OptimizerService_ListPackageSizes_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "optimizer/v1/optimizer.proto",
+}
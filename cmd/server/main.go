@@ -2,77 +2,279 @@ package main
 
 import (
 	"context"
-	"log"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc"
 
-	"package-optimizer/internal/api"
-	"package-optimizer/internal/config"
-	"package-optimizer/internal/domain"
+	"github.com/sinaw369/Package-Optimizer/internal/api"
+	"github.com/sinaw369/Package-Optimizer/internal/auth"
+	"github.com/sinaw369/Package-Optimizer/internal/cache"
+	"github.com/sinaw369/Package-Optimizer/internal/catalog"
+	"github.com/sinaw369/Package-Optimizer/internal/cli"
+	"github.com/sinaw369/Package-Optimizer/internal/config"
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+	"github.com/sinaw369/Package-Optimizer/internal/history"
+	"github.com/sinaw369/Package-Optimizer/internal/jobs"
+	"github.com/sinaw369/Package-Optimizer/internal/limits"
+	"github.com/sinaw369/Package-Optimizer/internal/logging"
+	"github.com/sinaw369/Package-Optimizer/internal/metrics"
+	"github.com/sinaw369/Package-Optimizer/internal/reload"
+	"github.com/sinaw369/Package-Optimizer/internal/rpc"
+	"github.com/sinaw369/Package-Optimizer/internal/tracing"
 )
 
 // main is the entry point of the package optimizer application.
-// It sets up the server, configures routes, and starts the HTTP server with graceful shutdown.
+// It runs the subcommand named by the first argument; without one it starts the server.
 func main() {
-	// Load application configuration from environment variables
-	// This includes port number and package sizes
-	cfg, err := config.Load()
+	os.Exit(cli.Main(os.Args[1:], os.Stdin, os.Stdout, os.Stderr, serve))
+}
+
+// serve implements the serve command.
+// It sets up the server, configures routes, and starts the HTTP server with graceful shutdown.
+//
+// Args:
+//   - args: the configuration flags
+//
+// Returns:
+//   - int: the exit code once the server has shut down
+func serve(args []string) int {
+	// Load application configuration from flags, environment variables and the config file
+	// Every invalid setting is reported at once, one per line
+	cfg, err := config.Load(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return cli.ExitOK
+	}
 	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		fmt.Fprintln(os.Stderr, err)
+		return cli.ExitUsage
+	}
+
+	// Print the effective configuration and exit if asked to
+	if cfg.PrintConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			fatal("failed to print configuration", err)
+		}
+		return cli.ExitOK
 	}
 
-	// Create the core optimizer with the configured package sizes
-	// The optimizer will be used by the API handlers to calculate optimal package combinations
-	optimizer := domain.NewOptimizer(cfg.PackageSizes)
+	// Set up structured logging as early as possible
+	// The logger becomes the default, so library output through the log package is structured too
+	// The level is kept in a variable so a configuration reload can change it
+	var logLevel slog.LevelVar
+	level, err := logging.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		fatal("failed to set up logging", err)
+	}
+	logLevel.Set(level)
+	logger, err := logging.NewWithLevel(os.Stderr, cfg.LogFormat, &logLevel)
+	if err != nil {
+		fatal("failed to set up logging", err)
+	}
+	slog.SetDefault(logger)
 
-	// Create the HTTP handler with the optimizer and package sizes
+	// Set up OpenTelemetry tracing before anything creates spans
+	// Incoming W3C trace context is always propagated; spans are exported only if configured
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		Exporter:     cfg.TracingExporter,
+		ServiceName:  "package-optimizer",
+		OTLPEndpoint: cfg.TracingOTLPEndpoint,
+		OTLPProtocol: cfg.TracingOTLPProtocol,
+		OTLPInsecure: cfg.TracingOTLPInsecure,
+		OTLPHeaders:  cfg.TracingOTLPHeaders,
+		FilePath:     cfg.TracingFile,
+		SampleRatio:  cfg.TracingSampleRatio,
+	})
+	if err != nil {
+		fatal("failed to set up tracing", err)
+	}
+
+	// Create the package catalog with the configured package sizes, stock and costs
+	// Its optimizer is used by the API handlers to calculate optimal package combinations,
+	// and it can be replaced at runtime through the admin API
+	// Every solve is reported to the metrics package for DP table size, timing and over-delivery
+	// Quantities over the configured limits are rejected before any table is allocated
+	packageCatalog, err := catalog.New(cfg.PackageSizes, metrics.ObserveSolve, catalog.Settings{
+		Limits: domain.Limits{MaxQuantity: cfg.MaxQuantity, MaxMemory: cfg.MaxSolveMemory},
+		Stock:  cfg.PackageStock,
+		Costs:  cfg.PackageCosts,
+	})
+	if err != nil {
+		fatal("invalid package catalog", err)
+	}
+
+	// Load API keys if authentication is enabled
+	// Without a key file the API is open, as it was before keys were introduced
+	var keyring *auth.Keyring
+	if cfg.AuthKeysFile != "" {
+		keyring, err = auth.LoadKeyring(cfg.AuthKeysFile)
+		if err != nil {
+			fatal("failed to load API keys", err)
+		}
+	} else {
+		slog.Warn("API key authentication is disabled; set AUTH_KEYS_FILE to enable it")
+	}
+
+	// Reload the configuration on SIGHUP and when the configuration file changes
+	// Invalid configurations are rejected and the current one is kept; the catalog,
+	// its limits, the log level and the API keys change without a restart
+	reloadCtx, stopReload := context.WithCancel(context.Background())
+	defer stopReload()
+	reloader := reload.New(cfg, func() (*config.Config, error) { return config.Load(args) },
+		reload.Targets{Catalog: packageCatalog, LogLevel: &logLevel, Keyring: keyring})
+	reloader.Watch(reloadCtx)
+
+	// Open the calculation history store if enabled
+	// Every calculation is appended to a local file so past recommendations can be audited
+	var historyStore *history.Store
+	if cfg.HistoryEnabled {
+		historyStore, err = history.Open(cfg.HistoryPath, history.Options{
+			MaxFileSize: cfg.HistoryMaxFileSize,
+			MaxFiles:    cfg.HistoryMaxFiles,
+		})
+		if err != nil {
+			fatal("failed to open calculation history", err)
+		}
+		defer historyStore.Close()
+	}
+
+	// Create the rate limiter and solver memory guard
+	// One client sending huge quantities must not starve everyone else
+	limiter := limits.New(limits.Config{
+		Rate:        cfg.RateLimit,
+		Burst:       cfg.RateLimitBurst,
+		SolveMemory: cfg.SolverMemoryLimit,
+		SolveWait:   cfg.SolverQueueTimeout,
+	})
+
+	// Start the asynchronous job manager
+	// Expensive calculations run on a bounded worker pool instead of blocking HTTP requests,
+	// sharing the solver memory guard with the synchronous APIs
+	jobManager, err := jobs.NewManager(packageCatalog, jobs.Options{
+		Workers:   cfg.JobWorkers,
+		QueueSize: cfg.JobQueueSize,
+		ResultTTL: cfg.JobResultTTL,
+		StatePath: cfg.JobStatePath,
+		Limiter:   limiter,
+	})
+	if err != nil {
+		fatal("failed to start job manager", err)
+	}
+
+	// Create the result cache if enabled
+	// Results for an old catalog version can no longer be requested, so the cache is
+	// emptied whenever the catalog changes
+	var resultCache *cache.Cache
+	if cfg.CacheSize > 0 {
+		resultCache = cache.New(cfg.CacheSize)
+		packageCatalog.OnChange(func(previous, current *domain.Optimizer) {
+			resultCache.Purge()
+			slog.Info("result cache purged", "previous_version", previous.CatalogVersion(), "version", current.CatalogVersion())
+		})
+	}
+
+	// Create the HTTP handler with the catalog, history store, job manager, API keys, limits and cache
 	// The handler provides the API endpoints for package optimization
-	handler := api.NewHandler(optimizer, cfg.PackageSizes)
+	handler := api.NewHandler(packageCatalog, api.Options{
+		History: historyStore,
+		Jobs:    jobManager,
+		Keyring: keyring,
+		Limiter: limiter,
+		Cache:   resultCache,
+	})
+
+	// Serve the web UI from disk while it is being edited; otherwise the embedded copy is used
+	if cfg.WebDevDir != "" {
+		if err := handler.ServeWebUIFromDisk(cfg.WebDevDir); err != nil {
+			fatal("failed to serve the web UI from disk", err)
+		}
+		slog.Warn("serving the web UI from disk", "dir", cfg.WebDevDir)
+	}
 
 	// Create a new Echo instance for the HTTP server
 	// Echo is a high-performance web framework for Go
+	// Startup is logged through slog, so Echo's own banner is disabled
 	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+
+	// Return errors as JSON carrying the request ID
+	e.HTTPErrorHandler = api.HTTPErrorHandler
+
+	// Take client IPs from the connection, or from X-Forwarded-For behind trusted proxies,
+	// so clients can't pick their own rate limit bucket
+	e.IPExtractor = api.IPExtractor(cfg.TrustedProxies)
+
+	// Build the CORS policy from the configuration
+	// Cross-origin requests from origins outside the allow-list are rejected
+	corsConfig := api.CORSConfig{
+		AllowOrigins:     cfg.CORSAllowedOrigins,
+		AllowMethods:     cfg.CORSAllowedMethods,
+		AllowHeaders:     cfg.CORSAllowedHeaders,
+		ExposeHeaders:    api.DefaultCORSConfig().ExposeHeaders,
+		AllowCredentials: cfg.CORSAllowCredentials,
+		MaxAge:           cfg.CORSMaxAge,
+	}
 
 	// Add middleware to the Echo instance
 	// Middleware functions are executed in order for each request
-	e.Use(api.LoggingMiddleware()) // Log all HTTP requests
-	e.Use(api.CORSMiddleware())    // Enable CORS for web interface
-
-	// Configure API routes under the /api prefix
-	// These routes handle the core functionality of the package optimizer
-	apiGroup := e.Group("/api")
-	apiGroup.GET("/calculate", handler.CalculateHandler)     // Main optimization endpoint
-	apiGroup.GET("/package-sizes", handler.PackageSizesHandler) // Package sizes endpoint
-	apiGroup.GET("/health", handler.HealthHandler)           // Health check endpoint
-
-	// Configure web UI routes
-	// These routes serve the static files for the web interface
-	e.GET("/", handler.ServeWebUI)        // Main web interface
-	e.GET("/style.css", handler.ServeCSS) // CSS styles
-	e.GET("/script.js", handler.ServeJS)  // JavaScript functionality
-
-	// Legacy route for backward compatibility
-	// This allows the old /calculate endpoint to still work
-	e.GET("/calculate", handler.CalculateHandler)
+	e.Use(api.RequestIDMiddleware())      // Assign every request an ID before anything logs
+	e.Use(api.LoggingMiddleware())        // Log all HTTP requests
+	e.Use(api.TracingMiddleware())        // Trace requests with OpenTelemetry
+	e.Use(api.MetricsMiddleware())        // Record Prometheus request metrics
+	e.Use(api.CORSMiddleware(corsConfig)) // Enforce the configured CORS policy
+
+	// Register the API, documentation and web UI routes
+	api.RegisterRoutes(e, handler)
 
 	// Start the server in a goroutine to allow for graceful shutdown
 	go func() {
 		// Log server startup information
-		log.Printf("Starting server on port %s", cfg.Port)
-		log.Printf("Available package sizes: %v", cfg.PackageSizes)
-		log.Printf("API endpoint: http://localhost:%s/api/calculate?qty=<quantity>", cfg.Port)
-		log.Printf("Package sizes endpoint: http://localhost:%s/api/package-sizes", cfg.Port)
-		log.Printf("Web UI: http://localhost:%s", cfg.Port)
+		slog.Info("starting server",
+			"version", cli.Version,
+			"port", cfg.Port,
+			"package_sizes", cfg.PackageSizes,
+			"catalog_version", packageCatalog.Version(),
+			"auth_enabled", keyring != nil,
+			"web_ui", "http://localhost:"+cfg.Port,
+			"api_docs", "http://localhost:"+cfg.Port+"/api/docs",
+		)
 
 		// Start the HTTP server
 		// This will block until the server is stopped
 		if err := e.Start(":" + cfg.Port); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server error: %v", err)
+			fatal("server error", err)
+		}
+	}()
+
+	// Start the gRPC server on its own port, sharing the same optimizer as the HTTP API
+	grpcListener, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+	if err != nil {
+		fatal("failed to listen on gRPC port", err, "port", cfg.GRPCPort)
+	}
+	// The gRPC API shares the HTTP API's keys, quotas, rate limits, solver memory guard and result cache
+	var interceptors []grpc.UnaryServerInterceptor
+	if keyring != nil {
+		interceptors = append(interceptors, rpc.AuthInterceptor(keyring))
+	}
+	interceptors = append(interceptors, rpc.RateLimitInterceptor(limiter))
+	grpcServer := rpc.Register(packageCatalog, rpc.Options{Limiter: limiter, Cache: resultCache},
+		grpc.ChainUnaryInterceptor(interceptors...))
+	go func() {
+		slog.Info("gRPC server listening", "port", cfg.GRPCPort)
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			fatal("gRPC server error", err)
 		}
 	}()
 
@@ -83,18 +285,84 @@ func main() {
 	<-quit
 
 	// Log that shutdown is beginning
-	log.Println("Shutting down server...")
+	// Readiness fails from here on, so load balancers stop routing new requests here.
+	// Event streams only end when their client leaves, so close them now rather than
+	// letting them hold the HTTP shutdown until its timeout.
+	slog.Info("shutting down server")
+	handler.StartShutdown()
+	handler.CloseStreams()
+	stopReload()
 
 	// Perform graceful shutdown with a timeout
-	// This gives the server time to finish processing current requests
+	// This gives the servers time to finish processing current requests
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	// Shutdown the Echo server gracefully
-	if err := e.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+	// Drain the HTTP server, the gRPC server and the job queue side by side, so a slow
+	// step can't use up the time the others need; jobs that can't finish in time are
+	// persisted for the next start. A failed step is logged and the rest still run.
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+	step := func(name string, shutdown func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := shutdown(); err != nil {
+				slog.Error(name+" shutdown failed", "error", err)
+				failed.Store(true)
+			}
+		}()
+	}
+	step("HTTP server", func() error {
+		return e.Shutdown(ctx)
+	})
+	step("gRPC server", func() error {
+		// Force the server closed if in-flight RPCs outlive the timeout
+		grpcStopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(grpcStopped)
+		}()
+		select {
+		case <-grpcStopped:
+			return nil
+		case <-ctx.Done():
+			grpcServer.Stop()
+			return ctx.Err()
+		}
+	})
+	step("job manager", func() error {
+		return jobManager.Shutdown(ctx)
+	})
+	wg.Wait()
+
+	// Flush buffered spans to the exporter, with its own deadline in case the steps
+	// above used up the shared one
+	tracingCtx, cancelTracing := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelTracing()
+	if err := shutdownTracing(tracingCtx); err != nil {
+		slog.Error("tracing shutdown failed", "error", err)
+		failed.Store(true)
+	}
+
+	if failed.Load() {
+		slog.Error("server exited with shutdown errors")
+		return cli.ExitFailure
 	}
 
 	// Log successful shutdown
-	log.Println("Server exited")
+	slog.Info("server exited")
+	return cli.ExitOK
+}
+
+// fatal logs an error through the default logger and exits with status 1.
+// Deferred functions do not run, so callers must release resources first.
+//
+// Args:
+//   - msg: what failed
+//   - err: the cause
+//   - args: additional key/value pairs to log
+func fatal(msg string, err error, args ...any) {
+	slog.Error(msg, append([]any{"error", err}, args...)...)
+	os.Exit(1)
 }
@@ -0,0 +1,240 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sinaw369/Package-Optimizer/internal/auth"
+	"github.com/sinaw369/Package-Optimizer/internal/limits"
+	"github.com/sinaw369/Package-Optimizer/internal/metrics"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HeaderAPIKey is the header clients send their API key in.
+// Keys are also accepted as "Authorization: Bearer <key>".
+const HeaderAPIKey = "X-API-Key"
+
+// Quota headers added to authenticated responses when the key has a quota.
+const (
+	// HeaderQuotaLimit is the number of requests allowed per quota period
+	HeaderQuotaLimit = "X-Quota-Limit"
+	// HeaderQuotaRemaining is the number of requests left in the current period
+	HeaderQuotaRemaining = "X-Quota-Remaining"
+	// HeaderQuotaReset is when the current period ends, as a Unix timestamp
+	HeaderQuotaReset = "X-Quota-Reset"
+)
+
+// quotaChargeKey is the echo.Context key holding the requests charged to the key's
+// quota on top of the request itself (see chargeQuota).
+const quotaChargeKey = "quota_charge"
+
+// refundedStatuses lists the responses that give the request back to the key's quota:
+// requests rejected as invalid or by a limit before any work was done.
+var refundedStatuses = map[int]bool{
+	http.StatusBadRequest:            true,
+	http.StatusRequestEntityTooLarge: true,
+	http.StatusUnprocessableEntity:   true,
+	http.StatusTooManyRequests:       true,
+}
+
+// requireScope creates a middleware that only lets requests through if they present
+// an API key granting the given scope and the key is within its quota.
+// When authentication is disabled (no key file configured) every request is allowed.
+//
+// The request is counted against the quota when it is let through, and refunded if
+// it is then rejected as invalid (400, 413, 422) or by the rate limit or solver guard
+// (429), so clients are only charged for requests that were served.
+//
+// Authenticated requests carry the client in their context (see auth.ClientFromContext),
+// and the client and key ID are added to the access log line.
+//
+// Args:
+//   - scope: the scope the protected routes require
+//
+// Returns:
+//   - echo.MiddlewareFunc: middleware function that can be used with Echo
+//   - HTTP 401 if the key is missing or unknown
+//   - HTTP 403 if the key lacks the scope
+//   - HTTP 429 with Retry-After if the key's quota is exhausted
+func (h *Handler) requireScope(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		// Without a keyring the service runs unauthenticated, as before keys were introduced
+		if h.keyring == nil {
+			return next
+		}
+
+		return func(c echo.Context) error {
+			client, usage, err := h.keyring.Authorize(apiKeyFromRequest(c.Request()), scope)
+			if client.KeyID != "" {
+				addLogAttrs(c, slog.String("client", client.ID), slog.String("key_id", client.KeyID))
+			}
+			if usage.QuotaLimit > 0 {
+				setQuotaHeaders(c, usage)
+			}
+
+			switch {
+			case errors.Is(err, auth.ErrUnauthenticated):
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="package-optimizer"`)
+				return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+			case errors.Is(err, auth.ErrForbidden):
+				metrics.ObserveAPIKeyRequest(client.KeyID, "forbidden")
+				return echo.NewHTTPError(http.StatusForbidden, err.Error()+": "+scope)
+			case errors.Is(err, auth.ErrQuotaExceeded):
+				metrics.ObserveAPIKeyRequest(client.KeyID, "quota_exceeded")
+				c.Response().Header().Set("Retry-After", retryAfter(usage))
+				return echo.NewHTTPError(http.StatusTooManyRequests, err.Error())
+			case err != nil:
+				return err
+			}
+			metrics.ObserveAPIKeyRequest(client.KeyID, "allowed")
+
+			// Hand the client to the handlers
+			req := c.Request()
+			c.SetRequest(req.WithContext(auth.WithClient(req.Context(), client)))
+			err = next(c)
+
+			// Give rejected requests back; errors are turned into responses later by Echo,
+			// so the quota headers can still be corrected
+			status := c.Response().Status
+			var httpErr *echo.HTTPError
+			if errors.As(err, &httpErr) {
+				status = httpErr.Code
+			}
+			if refundedStatuses[status] {
+				charged, _ := c.Get(quotaChargeKey).(int)
+				if usage, ok := h.keyring.Refund(client.KeyID, 1+charged); ok && usage.QuotaLimit > 0 && !c.Response().Committed {
+					setQuotaHeaders(c, usage)
+				}
+			}
+			return err
+		}
+	}
+}
+
+// chargeQuota counts n more requests against the key's quota for a request that
+// requireScope has already let through, when the request does the work of several
+// (e.g., a batch of quantities). The charge is refunded along with the request if
+// the request is then rejected. Nothing is charged when authentication is disabled.
+//
+// Args:
+//   - n: the number of additional requests to count
+//
+// Returns:
+//   - error: HTTP 429 with Retry-After if the quota can't cover n more requests
+func (h *Handler) chargeQuota(c echo.Context, n int) error {
+	client, ok := auth.ClientFromContext(c.Request().Context())
+	if h.keyring == nil || !ok || n <= 0 {
+		return nil
+	}
+
+	usage, err := h.keyring.Charge(client.KeyID, n)
+	if usage.QuotaLimit > 0 {
+		setQuotaHeaders(c, usage)
+	}
+	switch {
+	case errors.Is(err, auth.ErrQuotaExceeded):
+		metrics.ObserveAPIKeyRequest(client.KeyID, "quota_exceeded")
+		c.Response().Header().Set("Retry-After", retryAfter(usage))
+		return echo.NewHTTPError(http.StatusTooManyRequests,
+			fmt.Sprintf("%s: the request counts as %d requests, %d remain", err, n+1, usage.QuotaRemaining+1))
+	case err != nil:
+		// The key was removed by a reload since it was authorized
+		return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+	}
+	c.Set(quotaChargeKey, n)
+	return nil
+}
+
+// requireKey creates a middleware that only lets requests through if they present a
+// known API key, whatever its scopes. The request is not counted against the key's
+// quota, so a client that has used up its quota can still check its usage.
+// When authentication is disabled (no key file configured) every request is allowed.
+//
+// Returns:
+//   - echo.MiddlewareFunc: middleware function that can be used with Echo
+//   - HTTP 401 if the key is missing or unknown
+func (h *Handler) requireKey() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if h.keyring == nil {
+			return next
+		}
+
+		return func(c echo.Context) error {
+			client, err := h.keyring.Authenticate(apiKeyFromRequest(c.Request()))
+			if err != nil {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="package-optimizer"`)
+				return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+			}
+			addLogAttrs(c, slog.String("client", client.ID), slog.String("key_id", client.KeyID))
+
+			req := c.Request()
+			c.SetRequest(req.WithContext(auth.WithClient(req.Context(), client)))
+			return next(c)
+		}
+	}
+}
+
+// UsageHandler handles GET /usage.
+// This endpoint reports request counts and remaining quota per API key. Any known key
+// may call it, and the call itself is not counted. Keys with the catalog-admin scope
+// see every key; other keys see only their own client's keys.
+//
+// Returns:
+//   - HTTP 200 with {"usage":[...]}
+//   - HTTP 404 if authentication is disabled
+//
+// Example:
+//
+//	GET /api/usage
+//	X-API-Key: <key>
+//	Response: {"usage":[{"key_id":"acme-prod","client":"acme","requests":42,"rejected":0,"quota_limit":10000,...}]}
+func (h *Handler) UsageHandler(c echo.Context) error {
+	if h.keyring == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "API key authentication is not enabled")
+	}
+
+	client, _ := auth.ClientFromContext(c.Request().Context())
+	usage := h.keyring.ClientUsage(client.ID)
+	if client.HasScope(auth.ScopeCatalogAdmin) {
+		usage = h.keyring.Usage()
+	}
+
+	return c.JSON(http.StatusOK, map[string][]auth.Usage{"usage": usage})
+}
+
+// apiKeyFromRequest extracts the API key from the X-API-Key header or a bearer token.
+func apiKeyFromRequest(req *http.Request) string {
+	if key := req.Header.Get(HeaderAPIKey); key != "" {
+		return key
+	}
+	scheme, token, ok := strings.Cut(req.Header.Get(echo.HeaderAuthorization), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+// setQuotaHeaders reports the key's quota on the response.
+func setQuotaHeaders(c echo.Context, usage auth.Usage) {
+	header := c.Response().Header()
+	header.Set(HeaderQuotaLimit, strconv.Itoa(usage.QuotaLimit))
+	header.Set(HeaderQuotaRemaining, strconv.Itoa(usage.QuotaRemaining))
+	if usage.QuotaResetsAt != nil {
+		header.Set(HeaderQuotaReset, strconv.FormatInt(usage.QuotaResetsAt.Unix(), 10))
+	}
+}
+
+// retryAfter returns the number of seconds until the key's quota resets, rounded up.
+func retryAfter(usage auth.Usage) string {
+	var wait time.Duration
+	if usage.QuotaResetsAt != nil {
+		wait = time.Until(*usage.QuotaResetsAt)
+	}
+	return limits.RetryAfterSeconds(wait)
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+	"github.com/sinaw369/Package-Optimizer/internal/limits"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MaxBatchQuantities is the most quantities one batch request may contain.
+// Asynchronous jobs take at most as many.
+const MaxBatchQuantities = domain.MaxBatchQuantities
+
+// batchRequest is the JSON body accepted by the batch endpoint.
+type batchRequest struct {
+	// Quantities lists the quantities to optimize, in the order results are wanted
+	Quantities []int `json:"quantities"`
+}
+
+// batchItem is the outcome for one quantity of a batch.
+type batchItem struct {
+	// Quantity is the requested quantity
+	Quantity int `json:"quantity"`
+	// Result is the optimization result, absent if the quantity failed
+	Result *domain.OptimizationResult `json:"result,omitempty"`
+	// Error describes why the quantity failed, absent on success
+	Error string `json:"error,omitempty"`
+	// Status is the HTTP status GET /calculate would have returned for the failure
+	Status int `json:"status,omitempty"`
+}
+
+// batchResponse is the JSON response of the batch endpoint.
+type batchResponse struct {
+	// CatalogVersion identifies the catalog every item was computed with
+	CatalogVersion string `json:"catalog_version"`
+	// Items holds one outcome per requested quantity, in request order
+	Items []batchItem `json:"items"`
+}
+
+// CalculateBatchHandler handles POST /calculate/batch.
+// This endpoint computes several quantities in one request, like the gRPC
+// BatchCalculate call. Every item is computed with the catalog that was current
+// when the request started; results are cached and shared with GET /calculate.
+//
+// A quantity that can't be solved is reported in its item with the status
+// GET /calculate would have returned; the other items are unaffected.
+//
+// Each quantity counts as one request against the client's rate limit and API key
+// quota, so a batch costs the same as the single requests it replaces.
+//
+// Request Body:
+//   - {"quantities": [1201, 5000, 12001]} (at most MaxBatchQuantities)
+//
+// Returns:
+//   - HTTP 200 with one item per quantity
+//   - HTTP 400 if the body is invalid, empty or too large
+//   - HTTP 429 with Retry-After if the client is over its rate, the key's quota can't
+//     cover every quantity, or the solver stays busy
+//
+// Example:
+//
+//	POST /api/calculate/batch {"quantities":[1201,-1]}
+//	Response: {"catalog_version":"c2f56da27d65","items":[
+//	  {"quantity":1201,"result":{"requested":1201,"total_delivered":1250,...}},
+//	  {"quantity":-1,"error":"optimization error: quantity must be non-negative, got -1","status":400}]}
+func (h *Handler) CalculateBatchHandler(c echo.Context) error {
+	// Decode and check the request body
+	var body batchRequest
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body: expected {\"quantities\":[...]}")
+	}
+	if len(body.Quantities) == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "missing 'quantities'")
+	}
+	if len(body.Quantities) > MaxBatchQuantities {
+		return echo.NewHTTPError(http.StatusBadRequest,
+			fmt.Sprintf("too many quantities: at most %d per batch; split larger sets across several requests", MaxBatchQuantities))
+	}
+
+	// Every quantity counts as a request; the middlewares have charged the first one
+	if err := h.chargeQuota(c, len(body.Quantities)-1); err != nil {
+		return err
+	}
+	h.chargeRate(c, len(body.Quantities)-1)
+
+	// Compute every item with the same catalog
+	optimizer := h.catalog.Optimizer()
+	response := batchResponse{CatalogVersion: optimizer.CatalogVersion(), Items: make([]batchItem, len(body.Quantities))}
+	failed, hits, misses, coalesced := 0, 0, 0, 0
+	for i, quantity := range body.Quantities {
+		item := batchItem{Quantity: quantity}
+
+		// Reject quantities over the limits without waiting for the solver
+		err := optimizer.Check(quantity)
+		if err == nil {
+			item.Result = h.lookupResult(optimizer, quantity)
+			if item.Result != nil {
+				hits++
+			} else {
+				misses++
+				var shared bool
+				item.Result, shared, err = h.solveShared(c.Request().Context(), optimizer, quantity)
+				if shared {
+					coalesced++
+				}
+			}
+		}
+
+		// A busy solver or a client that went away fails the whole batch
+		if errors.Is(err, limits.ErrBusy) || (err != nil && c.Request().Context().Err() != nil) {
+			return h.solverError(c, quantity, err)
+		}
+		h.recordHistory(c, optimizer, quantity, item.Result, err)
+
+		if err != nil {
+			item.Result = nil
+			item.Status, item.Error = http.StatusBadRequest, err.Error()
+			var httpErr *echo.HTTPError
+			if errors.As(optimizationError(err), &httpErr) {
+				item.Status, item.Error = httpErr.Code, fmt.Sprint(httpErr.Message)
+			}
+			failed++
+		}
+		response.Items[i] = item
+	}
+
+	// Log the cache and solver totals once rather than once per item
+	addLogAttrs(c, slog.Int("quantities", len(body.Quantities)), slog.Int("failed", failed), slog.Int("coalesced", coalesced))
+	if h.cache != nil {
+		addLogAttrs(c, slog.Int("cache_hits", hits), slog.Int("cache_misses", misses))
+	}
+	return c.JSON(http.StatusOK, response)
+}
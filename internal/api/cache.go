@@ -0,0 +1,115 @@
+package api
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/sinaw369/Package-Optimizer/internal/cache"
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+	"github.com/sinaw369/Package-Optimizer/internal/metrics"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HTTP caching headers used by the calculation endpoints.
+const (
+	// HeaderETag identifies a calculation result
+	HeaderETag = "ETag"
+	// HeaderIfNoneMatch carries the ETags a client already has
+	HeaderIfNoneMatch = "If-None-Match"
+)
+
+// resultCacheControl lets clients keep results but makes them revalidate every time,
+// because the catalog (and with it the result) can change at any moment.
+// Revalidation is cheap: a matching ETag is answered with 304 without solving.
+const resultCacheControl = "private, no-cache"
+
+// resultETag returns the ETag of the result for a quantity. A result depends only on
+// the catalog version, the stock and costs (the options version), the strategy and
+// the quantity, so the ETag is known before solving and conditional requests can be
+// answered without any work. The options version is left out when no stock or costs
+// are configured.
+//
+// Example:
+//
+//	resultETag(optimizer, 1201) // "\"c2f56da27d65-min-over-delivery-1201\""
+//	// With costs 250=1,500=1.8,1000=3.5,2000=6.5:
+//	resultETag(optimizer, 1201) // "\"c2f56da27d65-71114dd2cba7-min-over-delivery-1201\""
+func resultETag(optimizer *domain.Optimizer, quantity int) string {
+	version := optimizer.CatalogVersion()
+	if options := optimizer.OptionsVersion(); options != "" {
+		version += "-" + options
+	}
+	return `"` + version + "-" + optimizer.Strategy().String() + "-" + strconv.Itoa(quantity) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header matches the ETag, using the
+// weak comparison RFC 9110 prescribes for If-None-Match.
+//
+// Args:
+//   - ifNoneMatch: the header value ("*" or a comma-separated list of ETags)
+//   - etag: the current ETag
+//
+// Returns:
+//   - bool: true if the client's copy is current
+func etagMatches(ifNoneMatch, etag string) bool {
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// setCacheHeaders adds the ETag and Cache-Control headers for a result.
+func setCacheHeaders(c echo.Context, etag string) {
+	header := c.Response().Header()
+	header.Set(HeaderETag, etag)
+	header.Set(echo.HeaderCacheControl, resultCacheControl)
+}
+
+// cachedResult looks up a result in the cache, recording the lookup in the metrics
+// and the access log.
+//
+// Returns:
+//   - *domain.OptimizationResult: the cached result (read-only), nil on a miss or if caching is disabled
+func (h *Handler) cachedResult(c echo.Context, optimizer *domain.Optimizer, quantity int) *domain.OptimizationResult {
+	if h.cache == nil {
+		return nil
+	}
+
+	result := h.lookupResult(optimizer, quantity)
+	if result != nil {
+		addLogAttrs(c, slog.String("cache", "hit"))
+		return result
+	}
+	addLogAttrs(c, slog.String("cache", "miss"))
+	return nil
+}
+
+// lookupResult looks up a result in the cache, recording the lookup in the metrics
+// only. Handlers that look up several results log the totals themselves.
+//
+// Returns:
+//   - *domain.OptimizationResult: the cached result (read-only), nil on a miss or if caching is disabled
+func (h *Handler) lookupResult(optimizer *domain.Optimizer, quantity int) *domain.OptimizationResult {
+	if h.cache == nil {
+		return nil
+	}
+
+	result, ok := h.cache.Get(cache.KeyFor(optimizer, quantity))
+	metrics.ObserveCacheLookup(ok)
+	return result
+}
+
+// cacheResult stores a successful result in the cache.
+func (h *Handler) cacheResult(optimizer *domain.Optimizer, quantity int, result *domain.OptimizationResult) {
+	if h.cache == nil || result == nil {
+		return
+	}
+	h.cache.Add(cache.KeyFor(optimizer, quantity), result)
+}
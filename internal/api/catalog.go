@@ -0,0 +1,88 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/sinaw369/Package-Optimizer/internal/auth"
+
+	"github.com/labstack/echo/v4"
+)
+
+// catalogResponse is the JSON representation of the package catalog.
+type catalogResponse struct {
+	// Version identifies the package sizes (see domain.CatalogVersion)
+	Version string `json:"version"`
+	// PackageSizes lists the available package sizes
+	PackageSizes []int `json:"package_sizes"`
+}
+
+// catalogUpdate is the JSON body accepted by the catalog update endpoint.
+type catalogUpdate struct {
+	// PackageSizes lists the new package sizes
+	PackageSizes []int `json:"package_sizes"`
+}
+
+// GetCatalogHandler handles GET /catalog.
+// This endpoint returns the package sizes currently used for optimization and
+// the catalog version recorded with every calculation.
+//
+// Returns:
+//   - HTTP 200 with the catalog
+//
+// Example:
+//
+//	GET /api/catalog
+//	Response: {"version":"c2f56da27d65","package_sizes":[250,500,1000,2000]}
+func (h *Handler) GetCatalogHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.currentCatalog())
+}
+
+// UpdateCatalogHandler handles PUT /catalog.
+// This endpoint replaces the package sizes used for optimization. The change takes
+// effect atomically: calculations already running finish with the old catalog, and
+// every later calculation uses the new one. It requires the catalog-admin scope.
+//
+// The catalog is held in memory; a restart reverts to the configured package sizes.
+//
+// Returns:
+//   - HTTP 200 with the new catalog
+//   - HTTP 400 if the body is malformed or the package sizes are invalid
+//
+// Example:
+//
+//	PUT /api/catalog
+//	Body: {"package_sizes":[250,500,1000,2000,5000]}
+//	Response: {"version":"8a0d3c1f9b2e","package_sizes":[250,500,1000,2000,5000]}
+func (h *Handler) UpdateCatalogHandler(c echo.Context) error {
+	// Decode the request body
+	var req catalogUpdate
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body: expected {\"package_sizes\":[...]}")
+	}
+
+	// Validate and swap in the new catalog; an invalid catalog leaves the current one in place
+	previous := h.catalog.Version()
+	optimizer, err := h.catalog.Update(req.PackageSizes)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid catalog: "+err.Error())
+	}
+	updated := catalogResponse{Version: optimizer.CatalogVersion(), PackageSizes: req.PackageSizes}
+
+	// Record who changed the catalog
+	client, _ := auth.ClientFromContext(c.Request().Context())
+	slog.InfoContext(c.Request().Context(), "catalog updated",
+		"client", client.ID,
+		"previous_version", previous,
+		"version", updated.Version,
+		"package_sizes", updated.PackageSizes,
+	)
+
+	return c.JSON(http.StatusOK, updated)
+}
+
+// currentCatalog builds the JSON representation of the current catalog.
+func (h *Handler) currentCatalog() catalogResponse {
+	packageSizes, version := h.catalog.State()
+	return catalogResponse{Version: version, PackageSizes: packageSizes}
+}
@@ -0,0 +1,186 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/sinaw369/Package-Optimizer/internal/catalog"
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+	"github.com/sinaw369/Package-Optimizer/pkg/optimizer"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MaxComparePoints is the most quantities one catalog comparison may sample.
+const MaxComparePoints = 1000
+
+// compareRequest is the JSON body accepted by the catalog comparison endpoint.
+type compareRequest struct {
+	// PackageSizes is the draft catalog to compare with the current one
+	PackageSizes []int `json:"package_sizes"`
+	// From is the first quantity of the sweep (default 1)
+	From int `json:"from"`
+	// To is the last quantity of the sweep
+	To int `json:"to"`
+	// Step is the distance between sampled quantities (default: the smallest step
+	// that samples at most MaxComparePoints quantities)
+	Step int `json:"step"`
+}
+
+// compareSide summarizes one catalog over the sampled quantities.
+type compareSide struct {
+	// Version identifies the package sizes (see domain.CatalogVersion)
+	Version string `json:"version"`
+	// PackageSizes lists the catalog's package sizes, largest first
+	PackageSizes []int `json:"package_sizes"`
+	// Exact is the number of sampled quantities delivered without over-delivery
+	Exact int `json:"exact"`
+	// AverageOverDelivery is the mean over-delivery across the sampled quantities
+	AverageOverDelivery float64 `json:"average_over_delivery"`
+	// MaxOverDelivery is the worst over-delivery of any sampled quantity
+	MaxOverDelivery int `json:"max_over_delivery"`
+}
+
+// comparePoint is the over-delivery of both catalogs for one sampled quantity.
+type comparePoint struct {
+	// Quantity is the sampled quantity
+	Quantity int `json:"quantity"`
+	// Current is the over-delivery with the current catalog
+	Current int `json:"current"`
+	// Draft is the over-delivery with the draft catalog
+	Draft int `json:"draft"`
+}
+
+// compareResponse is the JSON response of the catalog comparison endpoint.
+type compareResponse struct {
+	// Current summarizes the catalog in use
+	Current compareSide `json:"current"`
+	// Draft summarizes the proposed catalog
+	Draft compareSide `json:"draft"`
+	// Points holds the over-delivery of both catalogs for every sampled quantity
+	Points []comparePoint `json:"points"`
+}
+
+// CompareCatalogHandler handles POST /catalog/compare.
+// This endpoint sweeps a range of quantities with the current catalog and a draft
+// one, reporting the over-delivery of each, so a catalog change can be judged
+// before it is published with PUT /catalog. Only the deliverable totals are
+// computed, not the packings, so a sweep costs about one solve of its largest
+// quantity per catalog. Both catalogs are subject to the server's limits.
+//
+// Request Body:
+//   - {"package_sizes": [250, 500, 1000, 2000, 5000], "from": 1, "to": 10000, "step": 50}
+//
+// Returns:
+//   - HTTP 200 with a summary of both catalogs and the sampled points
+//   - HTTP 400 if the body, the draft package sizes or the range are invalid
+//   - HTTP 413 / 422 if the sweep's largest quantity exceeds the limits
+//   - HTTP 429 with Retry-After if the client is over its rate or the solver stays busy
+//
+// Example:
+//
+//	POST /api/catalog/compare {"package_sizes":[250,500],"to":1000,"step":250}
+//	Response: {"current":{"version":"c2f56da27d65",...,"exact":4},"draft":{...},
+//	  "points":[{"quantity":1,"current":249,"draft":249},{"quantity":251,"current":249,"draft":249},...]}
+func (h *Handler) CompareCatalogHandler(c echo.Context) error {
+	// Decode and check the request body
+	var req compareRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body: expected {\"package_sizes\":[...],\"to\":...}")
+	}
+	if err := catalog.Validate(req.PackageSizes); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid catalog: "+err.Error())
+	}
+	if req.From == 0 {
+		req.From = 1
+	}
+	if req.From < 1 || req.To < req.From {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid range: need 1 <= from <= to")
+	}
+	span := req.To - req.From
+	if req.Step == 0 {
+		req.Step = span/MaxComparePoints + 1
+	}
+	if req.Step < 1 || span/req.Step+1 > MaxComparePoints {
+		return echo.NewHTTPError(http.StatusBadRequest,
+			fmt.Sprintf("invalid step: the sweep may sample at most %d quantities", MaxComparePoints))
+	}
+
+	// The draft gets the same limits as the catalog in use
+	current := h.catalog.Optimizer()
+	draft := domain.NewOptimizer(req.PackageSizes, optimizer.WithLimits(current.Limits()))
+
+	// Analyze both catalogs up to the end of the sweep
+	currentGaps, err := h.analyzeGaps(c, current, req.To)
+	if err != nil {
+		return err
+	}
+	draftGaps, err := h.analyzeGaps(c, draft, req.To)
+	if err != nil {
+		return err
+	}
+
+	// Sample the sweep and summarize each catalog over the samples
+	response := compareResponse{
+		Current: compareSide{Version: current.CatalogVersion(), PackageSizes: current.PackageSizes()},
+		Draft:   compareSide{Version: draft.CatalogVersion(), PackageSizes: draft.PackageSizes()},
+	}
+	currentTotal, draftTotal := 0, 0
+	for quantity := req.From; quantity <= req.To; quantity += req.Step {
+		point := comparePoint{
+			Quantity: quantity,
+			Current:  currentGaps.OverDelivery(quantity),
+			Draft:    draftGaps.OverDelivery(quantity),
+		}
+		response.Points = append(response.Points, point)
+		currentTotal += point.Current
+		draftTotal += point.Draft
+		response.Current.add(point.Current)
+		response.Draft.add(point.Draft)
+	}
+	response.Current.AverageOverDelivery = float64(currentTotal) / float64(len(response.Points))
+	response.Draft.AverageOverDelivery = float64(draftTotal) / float64(len(response.Points))
+
+	addLogAttrs(c, slog.String("draft_version", response.Draft.Version), slog.Int("points", len(response.Points)))
+	return c.JSON(http.StatusOK, response)
+}
+
+// add records the over-delivery of one sampled quantity in the summary.
+func (s *compareSide) add(overDelivery int) {
+	if overDelivery == 0 {
+		s.Exact++
+	}
+	if overDelivery > s.MaxOverDelivery {
+		s.MaxOverDelivery = overDelivery
+	}
+}
+
+// analyzeGaps runs a gap analysis up to a quantity within the solver's memory budget.
+//
+// Returns:
+//   - *domain.GapAnalysis: the analysis
+//   - error: an HTTP error if the quantity exceeds the limits, the solver stays
+//     busy or the client went away
+func (h *Handler) analyzeGaps(c echo.Context, optimizer *domain.Optimizer, upTo int) (*domain.GapAnalysis, error) {
+	if err := optimizer.Check(upTo); err != nil {
+		return nil, optimizationError(err)
+	}
+
+	// The analysis is smaller than a solve, so the solve estimate is a safe reservation
+	ctx := c.Request().Context()
+	release, err := h.reserveSolve(ctx, optimizer, upTo)
+	if err != nil {
+		return nil, h.solverError(c, upTo, err)
+	}
+	defer release()
+
+	analysis, err := optimizer.AnalyzeGaps(ctx, upTo)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, h.solverError(c, upTo, err)
+		}
+		return nil, optimizationError(err)
+	}
+	return analysis, nil
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CORSConfig configures the CORS (Cross-Origin Resource Sharing) policy.
+type CORSConfig struct {
+	// AllowOrigins lists the origins (scheme://host[:port]) allowed to call the API; "*" allows any origin
+	AllowOrigins []string
+	// AllowMethods lists the HTTP methods cross-origin requests may use
+	AllowMethods []string
+	// AllowHeaders lists the request headers cross-origin requests may send
+	AllowHeaders []string
+	// ExposeHeaders lists the response headers browser scripts may read
+	ExposeHeaders []string
+	// AllowCredentials lets browsers send cookies and HTTP authentication; requires explicit origins
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight response; 0 omits the header
+	MaxAge time.Duration
+}
+
+// DefaultCORSConfig returns the policy used when nothing is configured:
+// any origin may call the API without credentials.
+func DefaultCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowOrigins:  []string{"*"},
+		AllowMethods:  []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions},
+		AllowHeaders:  []string{echo.HeaderContentType, echo.HeaderAuthorization, HeaderAPIKey, HeaderRequestID, HeaderIfNoneMatch},
+		ExposeHeaders: []string{HeaderRequestID, HeaderETag, HeaderQuotaLimit, HeaderQuotaRemaining, HeaderQuotaReset, "Retry-After"},
+		MaxAge:        10 * time.Minute,
+	}
+}
+
+// CORSMiddleware creates a middleware that enforces a CORS (Cross-Origin Resource Sharing) policy.
+// This middleware allows web applications from the configured origins to access the API
+// and rejects cross-origin requests from everywhere else.
+//
+// Request Handling:
+//   - No Origin header, or an Origin matching the server itself: not cross-origin, passed through
+//   - Preflight (OPTIONS with Access-Control-Request-Method): answered with 204 if the origin,
+//     method and headers are all allowed, otherwise rejected with 403
+//   - Other cross-origin requests: rejected with 403 if the origin is not allowed, otherwise
+//     passed through with Access-Control-Allow-Origin and Access-Control-Expose-Headers set
+//
+// Unless any origin is allowed without credentials, the allowed origin is echoed back and
+// "Vary: Origin" is added so that caches don't serve one origin's response to another.
+//
+// Args:
+//   - cfg: the CORS policy; AllowCredentials cannot be combined with a "*" origin
+//
+// Returns:
+//   - echo.MiddlewareFunc: middleware function that can be used with Echo
+//
+// Example:
+//
+//	e.Use(api.CORSMiddleware(api.CORSConfig{
+//		AllowOrigins: []string{"https://shop.example.com"},
+//		AllowMethods: []string{"GET", "POST"},
+//	}))
+func CORSMiddleware(cfg CORSConfig) echo.MiddlewareFunc {
+	// Echoing any origin with credentials would let every site make authenticated calls
+	if cfg.AllowCredentials && slices.Contains(cfg.AllowOrigins, "*") {
+		panic("CORS credentials require explicit origins, not \"*\"")
+	}
+
+	// Precompute lookups and header values once
+	allowAny := false
+	origins := make(map[string]bool, len(cfg.AllowOrigins))
+	for _, origin := range cfg.AllowOrigins {
+		if origin == "*" {
+			allowAny = true
+		}
+		origins[strings.ToLower(strings.TrimSuffix(origin, "/"))] = true
+	}
+	methods := make(map[string]bool, len(cfg.AllowMethods))
+	for _, method := range cfg.AllowMethods {
+		methods[strings.ToUpper(method)] = true
+	}
+	headers := make(map[string]bool, len(cfg.AllowHeaders))
+	for _, header := range cfg.AllowHeaders {
+		headers[http.CanonicalHeaderKey(header)] = true
+	}
+
+	allowMethods := strings.Join(cfg.AllowMethods, ", ")
+	allowHeaders := strings.Join(cfg.AllowHeaders, ", ")
+	exposeHeaders := strings.Join(cfg.ExposeHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
+	// The response only varies by Origin when the origin is echoed back
+	reflectOrigin := !allowAny || cfg.AllowCredentials
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			res := c.Response().Header()
+			origin := req.Header.Get(echo.HeaderOrigin)
+			preflight := req.Method == http.MethodOptions && req.Header.Get(echo.HeaderAccessControlRequestMethod) != ""
+
+			if reflectOrigin {
+				res.Add(echo.HeaderVary, echo.HeaderOrigin)
+				if preflight {
+					res.Add(echo.HeaderVary, echo.HeaderAccessControlRequestMethod)
+					res.Add(echo.HeaderVary, echo.HeaderAccessControlRequestHeaders)
+				}
+			}
+
+			// Same-origin requests (browsers send Origin on same-origin POSTs too) are not subject to CORS
+			if origin == "" || sameOrigin(c, origin) {
+				return next(c)
+			}
+
+			// Reject origins outside the allow-list
+			if !allowAny && !origins[strings.ToLower(origin)] {
+				return echo.NewHTTPError(http.StatusForbidden, "origin not allowed")
+			}
+
+			if reflectOrigin {
+				res.Set(echo.HeaderAccessControlAllowOrigin, origin)
+			} else {
+				res.Set(echo.HeaderAccessControlAllowOrigin, "*")
+			}
+			if cfg.AllowCredentials {
+				res.Set(echo.HeaderAccessControlAllowCredentials, "true")
+			}
+
+			// Simple and actual requests continue to the handler
+			if !preflight {
+				if exposeHeaders != "" {
+					res.Set(echo.HeaderAccessControlExposeHeaders, exposeHeaders)
+				}
+				return next(c)
+			}
+
+			// Preflight: check the method and headers the browser intends to send
+			if !methods[strings.ToUpper(req.Header.Get(echo.HeaderAccessControlRequestMethod))] {
+				return echo.NewHTTPError(http.StatusForbidden, "method not allowed by CORS policy")
+			}
+			for _, header := range strings.Split(req.Header.Get(echo.HeaderAccessControlRequestHeaders), ",") {
+				header = strings.TrimSpace(header)
+				if header != "" && !headers[http.CanonicalHeaderKey(header)] {
+					return echo.NewHTTPError(http.StatusForbidden, "header "+header+" not allowed by CORS policy")
+				}
+			}
+
+			res.Set(echo.HeaderAccessControlAllowMethods, allowMethods)
+			if allowHeaders != "" {
+				res.Set(echo.HeaderAccessControlAllowHeaders, allowHeaders)
+			}
+			if cfg.MaxAge > 0 {
+				res.Set(echo.HeaderAccessControlMaxAge, maxAge)
+			}
+			return c.NoContent(http.StatusNoContent)
+		}
+	}
+}
+
+// sameOrigin reports whether origin refers to the server handling the request.
+func sameOrigin(c echo.Context, origin string) bool {
+	return strings.EqualFold(origin, c.Scheme()+"://"+c.Request().Host)
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+	"github.com/sinaw369/Package-Optimizer/internal/logging"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HTTPErrorHandler writes errors returned by handlers and middleware as JSON.
+// It replaces Echo's default error handler so that every error response has the
+// same shape as the rest of the API and carries the request ID, which lets a
+// client report a failure that can be found in the server logs.
+//
+// Args:
+//   - err: the error returned by the handler chain
+//   - c: the Echo context of the failed request
+//
+// Example:
+//
+//	e := echo.New()
+//	e.HTTPErrorHandler = api.HTTPErrorHandler
+//	// GET /api/calculate
+//	// Response: {"error":"missing 'qty' parameter","request_id":"3f9a1c2be8d04d7e"}
+func HTTPErrorHandler(err error, c echo.Context) {
+	// Nothing can be sent once the response has started (e.g., a broken event stream)
+	if c.Response().Committed {
+		return
+	}
+
+	// Work out the status code and the message to expose
+	status := http.StatusInternalServerError
+	message := http.StatusText(status)
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		status = httpErr.Code
+		if m, ok := httpErr.Message.(string); ok {
+			message = m
+		} else {
+			message = fmt.Sprint(httpErr.Message)
+		}
+	}
+
+	// Unexpected failures are logged in full on the request's log line (see
+	// LoggingMiddleware); their details are not sent to the client
+	ctx := c.Request().Context()
+
+	// HEAD responses have no body
+	var writeErr error
+	if c.Request().Method == http.MethodHead {
+		writeErr = c.NoContent(status)
+	} else {
+		writeErr = c.JSON(status, domain.ErrorResponse{
+			Error:     message,
+			RequestID: logging.RequestID(ctx),
+		})
+	}
+	if writeErr != nil {
+		slog.ErrorContext(ctx, "failed to write error response", "error", writeErr)
+	}
+}
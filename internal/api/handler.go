@@ -1,12 +1,24 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"sync"
+	"sync/atomic"
 
-	"package-optimizer/internal/domain"
+	"github.com/sinaw369/Package-Optimizer/internal/auth"
+	"github.com/sinaw369/Package-Optimizer/internal/cache"
+	"github.com/sinaw369/Package-Optimizer/internal/catalog"
+	"github.com/sinaw369/Package-Optimizer/internal/coalesce"
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+	"github.com/sinaw369/Package-Optimizer/internal/history"
+	"github.com/sinaw369/Package-Optimizer/internal/jobs"
+	"github.com/sinaw369/Package-Optimizer/internal/limits"
+	"github.com/sinaw369/Package-Optimizer/internal/metrics"
 
 	"github.com/labstack/echo/v4"
 )
@@ -14,25 +26,70 @@ import (
 // Handler handles HTTP requests for the package optimizer API.
 // It provides endpoints for package optimization calculations and web UI serving.
 type Handler struct {
-	// optimizer is the core optimization engine that calculates optimal package combinations
-	optimizer *domain.Optimizer
-	// packageSizes stores the available package sizes for the API
-	packageSizes []int
+	// catalog holds the available package sizes and the optimizer built from them
+	catalog *catalog.Catalog
+	// history persists every calculation; nil disables the audit log
+	history *history.Store
+	// jobs runs expensive optimizations asynchronously on a worker pool
+	jobs *jobs.Manager
+	// keyring authenticates API keys; nil disables authentication
+	keyring *auth.Keyring
+	// limiter applies rate limits and caps concurrent solver memory; nil disables limits
+	limiter *limits.Limiter
+	// cache holds recent results; nil disables result caching
+	cache *cache.Cache
+	// inflight shares one solve between concurrent requests for the same result
+	inflight coalesce.Group[cache.Key, *domain.OptimizationResult]
+	// routesReady is set once RegisterRoutes has finished
+	routesReady atomic.Bool
+	// shuttingDown is set by StartShutdown; readiness fails from then on
+	shuttingDown atomic.Bool
+	// streamsClosed is closed by CloseStreams to end the open event streams
+	streamsClosed chan struct{}
+	// closeStreams closes streamsClosed once
+	closeStreams sync.Once
+	// webAssets serves the web UI files, embedded unless ServeWebUIFromDisk was called
+	webAssets *webAssets
 }
 
-// NewHandler creates a new handler with the given optimizer.
-// This function initializes the handler with the domain optimizer for package calculations.
+// Options holds the dependencies of a Handler besides its catalog. A nil field
+// disables the feature it provides.
+type Options struct {
+	// History persists every calculation; nil disables the audit log
+	History *history.Store
+	// Jobs runs expensive optimizations asynchronously; the job routes need it
+	Jobs *jobs.Manager
+	// Keyring authenticates API keys for protected routes; nil disables authentication
+	Keyring *auth.Keyring
+	// Limiter applies rate limits and caps concurrent solver memory; nil disables limits
+	Limiter *limits.Limiter
+	// Cache holds recent results; nil disables result caching
+	Cache *cache.Cache
+}
+
+// NewHandler creates a new handler with the given package catalog.
+// This function initializes the handler with the catalog whose optimizer performs package calculations.
 //
 // Args:
-//   - optimizer: the domain optimizer instance for package calculations
-//   - packageSizes: the available package sizes for the API
+//   - catalog: the package catalog (package sizes and optimizer)
+//   - options: the history, jobs, keyring, limiter and cache to use; the zero value disables them all
 //
 // Returns:
 //   - *Handler: configured handler instance
-func NewHandler(optimizer *domain.Optimizer, packageSizes []int) *Handler {
+//
+// Example:
+//
+//	handler := api.NewHandler(packageCatalog, api.Options{Keyring: keyring, Limiter: limiter})
+func NewHandler(catalog *catalog.Catalog, options Options) *Handler {
 	return &Handler{
-		optimizer:    optimizer,
-		packageSizes: packageSizes,
+		catalog:       catalog,
+		history:       options.History,
+		jobs:          options.Jobs,
+		keyring:       options.Keyring,
+		limiter:       options.Limiter,
+		cache:         options.Cache,
+		streamsClosed: make(chan struct{}),
+		webAssets:     embeddedWebAssets,
 	}
 }
 
@@ -40,12 +97,20 @@ func NewHandler(optimizer *domain.Optimizer, packageSizes []int) *Handler {
 // This is the main API endpoint that accepts a quantity parameter and returns
 // the optimal package combination that minimizes over-delivery.
 //
+// Results are cached per catalog version and quantity, and carry an ETag; a request
+// whose If-None-Match matches the current ETag is answered without solving.
+// Concurrent requests for the same quantity share a single solve.
+//
 // Query Parameters:
 //   - qty: the requested quantity (required, must be a positive integer)
 //
 // Returns:
 //   - JSON response with optimization result or error
+//   - HTTP 304 if the If-None-Match header matches the result's ETag
 //   - HTTP 400 if quantity is missing or invalid
+//   - HTTP 413 if the solve would need more memory than a single solve may use
+//   - HTTP 422 if quantity exceeds the maximum quantity
+//   - HTTP 429 with Retry-After if the client is over its rate or the solver stays busy
 //   - HTTP 200 with optimization result on success
 //
 // Example:
@@ -67,19 +132,146 @@ func (h *Handler) CalculateHandler(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid 'qty' parameter: must be an integer")
 	}
 
-	// Use the optimizer to calculate the optimal package combination
-	result, err := h.optimizer.Optimize(quantity)
+	// Use the current catalog's optimizer to calculate the optimal package combination
+	// Quantities over the limits are rejected before waiting for the solver or allocating anything
+	optimizer := h.catalog.Optimizer()
+	if err := optimizer.Check(quantity); err != nil {
+		h.recordHistory(c, optimizer, quantity, nil, err)
+		addLogAttrs(c, resultLogAttrs(quantity, nil)...)
+		return optimizationError(err)
+	}
+
+	// The client already has the current result
+	etag := resultETag(optimizer, quantity)
+	if etagMatches(c.Request().Header.Get(HeaderIfNoneMatch), etag) {
+		h.recordRevalidation(c, optimizer, quantity)
+		addLogAttrs(c, slog.Int("quantity", quantity), slog.String("cache", "not_modified"))
+		setCacheHeaders(c, etag)
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	// Serve the result from the cache, or solve and cache it
+	result := h.cachedResult(c, optimizer, quantity)
+	if result == nil {
+		result, err = h.solve(c, optimizer, quantity)
+		if errors.Is(err, limits.ErrBusy) || (err != nil && c.Request().Context().Err() != nil) {
+			return h.solverError(c, quantity, err)
+		}
+	}
+
+	// Persist the calculation to the history, whether it succeeded or not
+	h.recordHistory(c, optimizer, quantity, result, err)
+
+	// Add the quantity and result summary to the request's log line
+	addLogAttrs(c, resultLogAttrs(quantity, result)...)
+
 	if err != nil {
-		// Log the optimization error for debugging
-		log.Printf("Optimization error: %v", err)
-		// Return error response to client
-		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("optimization error: %v", err))
+		// Return error response to client; the access log records the error
+		return optimizationError(err)
 	}
 
 	// Return the optimization result as JSON response
+	setCacheHeaders(c, etag)
 	return c.JSON(http.StatusOK, result)
 }
 
+// solve computes the result for a quantity and caches it. Concurrent requests for
+// the same catalog version and quantity share one solve: the first request reserves
+// solver memory and runs it, and the others wait for its result. The shared solve is
+// cancelled only when every waiting client has disconnected.
+//
+// Args:
+//   - c: the request context
+//   - optimizer: the optimizer to solve with
+//   - quantity: the requested quantity (already checked against the limits)
+//
+// Returns:
+//   - *domain.OptimizationResult: the result (read-only, it may be shared)
+//   - error: the optimizer error, limits.ErrBusy if the solver stayed busy, or the
+//     context error if this client went away
+func (h *Handler) solve(c echo.Context, optimizer *domain.Optimizer, quantity int) (*domain.OptimizationResult, error) {
+	result, shared, err := h.solveShared(c.Request().Context(), optimizer, quantity)
+	if shared {
+		addLogAttrs(c, slog.Bool("coalesced", true))
+	}
+	return result, err
+}
+
+// solveShared is solve without the access log: it also reports whether the result
+// came from another request's solve, so handlers that solve several quantities can
+// log the totals themselves.
+//
+// Returns:
+//   - *domain.OptimizationResult: the result (read-only, it may be shared)
+//   - bool: whether the solve was shared with a concurrent request
+//   - error: as for solve
+func (h *Handler) solveShared(ctx context.Context, optimizer *domain.Optimizer, quantity int) (*domain.OptimizationResult, bool, error) {
+	result, shared, err := h.inflight.Do(ctx, cache.KeyFor(optimizer, quantity),
+		func(ctx context.Context) (*domain.OptimizationResult, error) {
+			// Wait for the solver to have room for a table of this size
+			release, err := h.reserveSolve(ctx, optimizer, quantity)
+			if err != nil {
+				return nil, err
+			}
+			defer release()
+
+			// The context carries the first request's trace
+			result, err := optimizer.OptimizeContext(ctx, quantity)
+			h.cacheResult(optimizer, quantity, result)
+			return result, err
+		})
+	if shared {
+		metrics.ObserveCoalesced()
+	}
+	return result, shared, err
+}
+
+// optimizationError maps an optimizer error onto an HTTP error.
+//
+// Args:
+//   - err: the error returned by Check or an Optimize method
+//
+// Returns:
+//   - error: HTTP 413 for ErrMemoryLimitExceeded, HTTP 422 for ErrQuantityTooLarge,
+//     HTTP 400 for other invalid input
+func optimizationError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrMemoryLimitExceeded):
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, domain.ErrQuantityTooLarge):
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+	return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("optimization error: %v", err))
+}
+
+// resultLogAttrs summarizes a calculation as log fields.
+// Only totals are logged; the package breakdown can be looked up in the history.
+//
+// Args:
+//   - quantity: the requested quantity
+//   - result: the optimization result, nil if the calculation failed
+//
+// Returns:
+//   - []slog.Attr: the quantity plus delivered total, over-delivery and package count
+func resultLogAttrs(quantity int, result *domain.OptimizationResult) []slog.Attr {
+	attrs := []slog.Attr{slog.Int("quantity", quantity)}
+	if result == nil {
+		return attrs
+	}
+
+	// Count individual packages rather than distinct sizes
+	packages := 0
+	for _, count := range result.Packages {
+		packages += count
+	}
+
+	return append(attrs,
+		slog.Int("total_delivered", result.TotalDelivered),
+		slog.Int("over_delivery", result.OverDelivery),
+		slog.Int("packages", packages),
+	)
+}
+
 // PackageSizesHandler handles the /package-sizes endpoint.
 // This endpoint returns the available package sizes that can be used for optimization.
 //
@@ -94,15 +286,15 @@ func (h *Handler) CalculateHandler(c echo.Context) error {
 func (h *Handler) PackageSizesHandler(c echo.Context) error {
 	// Return the available package sizes as JSON response
 	return c.JSON(http.StatusOK, map[string][]int{
-		"package_sizes": h.packageSizes,
+		"package_sizes": h.catalog.PackageSizes(),
 	})
 }
 
-// HealthHandler handles health check requests.
-// This endpoint is used by load balancers and monitoring systems to check if the service is running.
+// HealthHandler handles the original health check endpoint.
+// It is kept for existing monitors and behaves like the liveness probe; new
+// deployments should use /api/health/live and /api/health/ready.
 //
 // Returns:
-//   - JSON response with service status
 //   - HTTP 200 with {"status":"healthy"}
 //
 // Example:
@@ -110,19 +302,33 @@ func (h *Handler) PackageSizesHandler(c echo.Context) error {
 //	GET /api/health
 //	Response: {"status":"healthy"}
 func (h *Handler) HealthHandler(c echo.Context) error {
-	// Return a simple health status response
-	return c.JSON(http.StatusOK, map[string]string{
-		"status": "healthy",
-	})
+	return h.LiveHandler(c)
+}
+
+// MetricsHandler handles the /metrics endpoint.
+// This endpoint exposes request, solver and error metrics in the Prometheus
+// exposition format for scraping by monitoring systems.
+//
+// Returns:
+//   - HTTP 200 with metrics in the Prometheus text format
+//
+// Example:
+//
+//	GET /metrics
+//	Response: # HELP package_optimizer_http_requests_total Total number of HTTP requests...
+func (h *Handler) MetricsHandler(c echo.Context) error {
+	metrics.Handler().ServeHTTP(c.Response(), c.Request())
+	return nil
 }
 
 // ServeWebUI serves the main web interface.
 // This endpoint serves the HTML page that provides a user-friendly interface
-// for testing the package optimization API.
+// for testing the package optimization API. Like the other web UI files, it is
+// embedded in the binary and carries an ETag, so browsers revalidate it cheaply.
 //
 // Returns:
-//   - HTML content of the web interface
 //   - HTTP 200 with the index.html file
+//   - HTTP 304 if the If-None-Match header matches the file's ETag
 //
 // Example:
 //
@@ -130,15 +336,15 @@ func (h *Handler) HealthHandler(c echo.Context) error {
 //	Response: HTML content of the web interface
 func (h *Handler) ServeWebUI(c echo.Context) error {
 	// Serve the main HTML file for the web interface
-	return c.File("web/static/index.html")
+	return h.webAssets.serve(c, "index.html")
 }
 
 // ServeCSS serves CSS stylesheets for the web interface.
 // This endpoint serves the CSS file that styles the web interface.
 //
 // Returns:
-//   - CSS content for styling the web interface
 //   - HTTP 200 with the style.css file
+//   - HTTP 304 if the If-None-Match header matches the file's ETag
 //
 // Example:
 //
@@ -146,7 +352,7 @@ func (h *Handler) ServeWebUI(c echo.Context) error {
 //	Response: CSS content for styling
 func (h *Handler) ServeCSS(c echo.Context) error {
 	// Serve the CSS file for styling the web interface
-	return c.File("web/static/style.css")
+	return h.webAssets.serve(c, "style.css")
 }
 
 // ServeJS serves JavaScript files for the web interface.
@@ -154,8 +360,8 @@ func (h *Handler) ServeCSS(c echo.Context) error {
 // for the web interface.
 //
 // Returns:
-//   - JavaScript content for web interface functionality
 //   - HTTP 200 with the script.js file
+//   - HTTP 304 if the If-None-Match header matches the file's ETag
 //
 // Example:
 //
@@ -163,5 +369,5 @@ func (h *Handler) ServeCSS(c echo.Context) error {
 //	Response: JavaScript content for interactivity
 func (h *Handler) ServeJS(c echo.Context) error {
 	// Serve the JavaScript file for web interface functionality
-	return c.File("web/static/script.js")
+	return h.webAssets.serve(c, "script.js")
 }
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Health statuses reported by the probes.
+const (
+	// HealthStatusHealthy means the process is alive
+	HealthStatusHealthy = "healthy"
+	// HealthStatusReady means the service can take traffic
+	HealthStatusReady = "ready"
+	// HealthStatusUnready means at least one readiness check failed
+	HealthStatusUnready = "unready"
+)
+
+// healthCheckOK is the result of a passing readiness check.
+const healthCheckOK = "ok"
+
+// selfTestTimeout bounds the readiness self-test, so a wedged solver fails the probe
+// instead of hanging it.
+const selfTestTimeout = 2 * time.Second
+
+// The known answer the solver must reproduce: 1201 items with the default sizes are
+// delivered as 1250, one 1000 and one 250.
+var (
+	selfTestSizes     = []int{250, 500, 1000, 2000}
+	selfTestQuantity  = 1201
+	selfTestDelivered = 1250
+	selfTestPackages  = map[string]int{"1000": 1, "250": 1}
+)
+
+// HealthReport is the body of the readiness probe.
+type HealthReport struct {
+	// Status is HealthStatusReady or HealthStatusUnready
+	Status string `json:"status"`
+	// Checks maps each readiness check to "ok" or the reason it failed
+	Checks map[string]string `json:"checks"`
+}
+
+// StartShutdown marks the service as shutting down. From then on the readiness probe
+// fails, so load balancers stop sending traffic while in-flight requests drain.
+// The liveness probe keeps succeeding until the process exits.
+func (h *Handler) StartShutdown() {
+	h.shuttingDown.Store(true)
+}
+
+// CloseStreams ends every open Server-Sent Events stream (calculation progress and
+// job events), cancelling the solves behind them. Streams stay open for as long as
+// the client listens, so a graceful shutdown would otherwise wait for its timeout;
+// clients reconnect to another instance instead. Streams started afterwards end
+// at once.
+func (h *Handler) CloseStreams() {
+	h.closeStreams.Do(func() { close(h.streamsClosed) })
+}
+
+// LiveHandler handles the liveness probe.
+// It only reports that the process is running and serving HTTP; it does no work, so
+// a busy solver never gets the process restarted.
+//
+// Returns:
+//   - HTTP 200 with {"status":"healthy"}
+//
+// Example:
+//
+//	GET /api/health/live
+//	Response: {"status":"healthy"}
+func (h *Handler) LiveHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]string{
+		"status": HealthStatusHealthy,
+	})
+}
+
+// ReadyHandler handles the readiness probe.
+// The service is ready when its routes are registered, no shutdown has started, the
+// solver passes a known-answer self-test, the catalog solves its own smallest package
+// exactly, and the history store and job manager (if enabled) are usable.
+//
+// Returns:
+//   - HTTP 200 with a HealthReport whose status is "ready"
+//   - HTTP 503 with a HealthReport whose status is "unready" and the failing checks
+//
+// Example:
+//
+//	GET /api/health/ready
+//	Response: {"status":"ready","checks":{"catalog":"ok","history":"ok","jobs":"ok","routes":"ok","self_test":"ok","shutdown":"ok"}}
+func (h *Handler) ReadyHandler(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), selfTestTimeout)
+	defer cancel()
+
+	// Run every check, so a failing probe reports all of its causes at once
+	checks := map[string]error{
+		"routes":    h.checkRoutes(),
+		"shutdown":  h.checkShutdown(),
+		"self_test": selfTest(ctx),
+		"catalog":   h.checkCatalog(ctx),
+	}
+	if h.history != nil {
+		checks["history"] = h.history.Check()
+	}
+	if h.jobs != nil {
+		checks["jobs"] = h.jobs.Check()
+	}
+
+	report := HealthReport{Status: HealthStatusReady, Checks: make(map[string]string, len(checks))}
+	for name, err := range checks {
+		if err != nil {
+			report.Status = HealthStatusUnready
+			report.Checks[name] = err.Error()
+			continue
+		}
+		report.Checks[name] = healthCheckOK
+	}
+
+	if report.Status != HealthStatusReady {
+		return c.JSON(http.StatusServiceUnavailable, report)
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// checkRoutes fails until RegisterRoutes has finished.
+func (h *Handler) checkRoutes() error {
+	if !h.routesReady.Load() {
+		return fmt.Errorf("routes are not registered yet")
+	}
+	return nil
+}
+
+// checkShutdown fails once StartShutdown has been called.
+func (h *Handler) checkShutdown() error {
+	if h.shuttingDown.Load() {
+		return fmt.Errorf("shutting down")
+	}
+	return nil
+}
+
+// checkCatalog checks that the optimizer serving requests is usable by solving its
+// smallest package size, which must be delivered exactly as a single package.
+// The live optimizer is probed with its own sizes, strategy and limits; only its
+// observer is dropped so probes don't show up in the solve metrics.
+func (h *Handler) checkCatalog(ctx context.Context) error {
+	optimizer := h.catalog.Optimizer()
+	sizes := optimizer.PackageSizes()
+	if len(sizes) == 0 {
+		return fmt.Errorf("catalog has no package sizes")
+	}
+
+	smallest := slices.Min(sizes)
+	result, err := optimizer.WithObserver(nil).OptimizeContext(ctx, smallest)
+	if err != nil {
+		return fmt.Errorf("catalog solve failed: %w", err)
+	}
+	if result.TotalDelivered != smallest || len(result.Packages) != 1 {
+		return fmt.Errorf("catalog solve for %d delivered %d in %v", smallest, result.TotalDelivered, result.Packages)
+	}
+	return nil
+}
+
+// selfTest checks the solver against a known answer, independent of the current catalog.
+func selfTest(ctx context.Context) error {
+	result, err := domain.NewOptimizer(selfTestSizes).OptimizeContext(ctx, selfTestQuantity)
+	if err != nil {
+		return fmt.Errorf("self-test solve failed: %w", err)
+	}
+	if result.TotalDelivered != selfTestDelivered || !maps.Equal(result.Packages, selfTestPackages) {
+		return fmt.Errorf("self-test for %d delivered %d in %v, want %d in %v",
+			selfTestQuantity, result.TotalDelivered, result.Packages, selfTestDelivered, selfTestPackages)
+	}
+	return nil
+}
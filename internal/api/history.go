@@ -0,0 +1,349 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sinaw369/Package-Optimizer/internal/auth"
+	"github.com/sinaw369/Package-Optimizer/internal/cache"
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+	"github.com/sinaw369/Package-Optimizer/internal/history"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// defaultHistoryLimit is the page size used when the client doesn't specify one
+	defaultHistoryLimit = 50
+	// maxHistoryLimit caps the page size for JSON responses
+	maxHistoryLimit = 1000
+)
+
+// HistoryHandler handles the /history endpoint.
+// This endpoint returns persisted calculations, newest first, with filtering,
+// paging and export support.
+//
+// Query Parameters:
+//   - from: only records at or after this time (RFC 3339 or YYYY-MM-DD)
+//   - to: only records before this time (RFC 3339 or YYYY-MM-DD)
+//   - client: only records from this client (ignored for API keys without the catalog-admin
+//     scope, which only see their own client's records)
+//   - catalog_version: only records produced by this catalog version
+//   - min_qty / max_qty: only records whose requested quantity is in range
+//   - limit: page size (default 50, max 1000; exports default to all records)
+//   - offset: number of matching records to skip
+//   - format: "json" (default), "csv" or "jsonl"; csv and jsonl are sent as downloads
+//
+// Returns:
+//   - JSON response with the matching records and paging information
+//   - HTTP 400 if a query parameter is invalid
+//   - HTTP 404 if history is disabled
+//
+// Example:
+//
+//	GET /api/history?min_qty=1000&limit=2
+//	Response: {"total":7,"offset":0,"limit":2,"records":[{"id":9,"timestamp":"...","client":"10.0.0.5",...}]}
+func (h *Handler) HistoryHandler(c echo.Context) error {
+	// History is optional; report it as missing when disabled
+	if h.history == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "calculation history is disabled")
+	}
+
+	// Determine the output format
+	format := strings.ToLower(c.QueryParam("format"))
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "csv" && format != "jsonl" {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid 'format' parameter: must be json, csv or jsonl")
+	}
+
+	// Parse the filter from the query string
+	filter, err := parseHistoryFilter(c, format)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// Partners only see their own calculations; catalog admins see everyone's
+	if client, ok := auth.ClientFromContext(c.Request().Context()); ok && !client.HasScope(auth.ScopeCatalogAdmin) {
+		filter.Client = client.ID
+	}
+
+	// Exports are streamed from the store, so their size doesn't matter
+	switch format {
+	case "csv":
+		return h.writeHistoryCSV(c, filter)
+	case "jsonl":
+		return h.writeHistoryJSONL(c, filter)
+	}
+
+	// Query the history store
+	records, total, err := h.history.Query(filter)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to read calculation history").SetInternal(err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"total":   total,
+		"offset":  filter.Offset,
+		"limit":   filter.Limit,
+		"records": records,
+	})
+}
+
+// clientIdentity returns who made the request: the API key's client when the
+// request is authenticated, otherwise the client IP address.
+func clientIdentity(c echo.Context) string {
+	if client, ok := auth.ClientFromContext(c.Request().Context()); ok {
+		return client.ID
+	}
+	return c.RealIP()
+}
+
+// recordHistory persists a calculation to the history store.
+// Failures are logged but never fail the request; the audit log is best-effort
+// from the client's point of view.
+func (h *Handler) recordHistory(c echo.Context, optimizer *domain.Optimizer, quantity int, result *domain.OptimizationResult, calcErr error) {
+	// Skip when history is disabled
+	if h.history == nil {
+		return
+	}
+
+	rec := history.Record{
+		Client:         clientIdentity(c),
+		CatalogVersion: optimizer.CatalogVersion(),
+		Request:        domain.OptimizationRequest{Quantity: quantity},
+		Result:         result,
+	}
+	if calcErr != nil {
+		rec.Error = calcErr.Error()
+	}
+	h.appendHistory(c, rec)
+}
+
+// recordRevalidation persists a call answered with 304 Not Modified to the history
+// store, so the history shows every call a client made, not only those that were
+// calculated. The result is included if it is still cached; the lookup is not counted
+// in the cache metrics because nothing was served from the cache.
+func (h *Handler) recordRevalidation(c echo.Context, optimizer *domain.Optimizer, quantity int) {
+	if h.history == nil {
+		return
+	}
+
+	rec := history.Record{
+		Client:         clientIdentity(c),
+		CatalogVersion: optimizer.CatalogVersion(),
+		Request:        domain.OptimizationRequest{Quantity: quantity},
+		NotModified:    true,
+	}
+	if h.cache != nil {
+		rec.Result, _ = h.cache.Get(cache.KeyFor(optimizer, quantity))
+	}
+	h.appendHistory(c, rec)
+}
+
+// appendHistory writes a record to the history store, logging failures.
+func (h *Handler) appendHistory(c echo.Context, rec history.Record) {
+	if _, err := h.history.Append(rec); err != nil {
+		slog.ErrorContext(c.Request().Context(), "history write failed", "error", err)
+	}
+}
+
+// parseHistoryFilter builds a history filter from the request's query parameters.
+// JSON responses are paged by default; exports return every matching record unless
+// the client asks for a limit.
+func parseHistoryFilter(c echo.Context, format string) (history.Filter, error) {
+	filter := history.Filter{
+		Client:         c.QueryParam("client"),
+		CatalogVersion: c.QueryParam("catalog_version"),
+	}
+
+	// Parse the time range
+	var err error
+	if filter.From, err = parseHistoryTime(c.QueryParam("from")); err != nil {
+		return filter, fmt.Errorf("invalid 'from' parameter: %w", err)
+	}
+	if filter.To, err = parseHistoryTime(c.QueryParam("to")); err != nil {
+		return filter, fmt.Errorf("invalid 'to' parameter: %w", err)
+	}
+
+	// Parse the quantity range
+	if v := c.QueryParam("min_qty"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid 'min_qty' parameter: must be an integer")
+		}
+		filter.MinQuantity = &n
+	}
+	if v := c.QueryParam("max_qty"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid 'max_qty' parameter: must be an integer")
+		}
+		filter.MaxQuantity = &n
+	}
+
+	// Parse paging
+	if format == "json" {
+		filter.Limit = defaultHistoryLimit
+	}
+	if v := c.QueryParam("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return filter, fmt.Errorf("invalid 'limit' parameter: must be a positive integer")
+		}
+		filter.Limit = n
+	}
+	if format == "json" && filter.Limit > maxHistoryLimit {
+		filter.Limit = maxHistoryLimit
+	}
+	if v := c.QueryParam("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return filter, fmt.Errorf("invalid 'offset' parameter: must be a non-negative integer")
+		}
+		filter.Offset = n
+	}
+
+	return filter, nil
+}
+
+// parseHistoryTime parses a time given either as RFC 3339 or as a plain date.
+// An empty string yields the zero time (no restriction).
+func parseHistoryTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("must be RFC 3339 or YYYY-MM-DD")
+	}
+	return t, nil
+}
+
+// historyDownload sends a history export as a file download. The response headers
+// are sent with the first record, or when the export ends, so a history that can't
+// be read is still reported with an error status.
+type historyDownload struct {
+	c           echo.Context
+	contentType string
+	filename    string
+	// header is written once the response headers are sent (e.g., the CSV header row)
+	header  func() error
+	started bool
+}
+
+// start sends the response headers if they haven't been sent yet.
+func (d *historyDownload) start() error {
+	if d.started {
+		return nil
+	}
+	d.started = true
+	res := d.c.Response()
+	res.Header().Set(echo.HeaderContentType, d.contentType)
+	res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+d.filename+`"`)
+	res.WriteHeader(http.StatusOK)
+	if d.header != nil {
+		return d.header()
+	}
+	return nil
+}
+
+// streamHistory writes every record of the page with write, then finishes the download.
+func (h *Handler) streamHistory(d *historyDownload, filter history.Filter, write func(history.Record) error) error {
+	err := h.history.Stream(filter, func(rec history.Record) error {
+		if err := d.start(); err != nil {
+			return err
+		}
+		return write(rec)
+	})
+	if err != nil {
+		if !d.started {
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to read calculation history").SetInternal(err)
+		}
+		// The status is sent, so the request's log line shows success and the truncated
+		// download is all the client can get
+		slog.ErrorContext(d.c.Request().Context(), "history export failed", "error", err)
+		return nil
+	}
+	// An export without records still gets its headers
+	return d.start()
+}
+
+// writeHistoryCSV streams the matching records as a CSV download.
+// Packages are flattened into a single "size:count;size:count" column.
+func (h *Handler) writeHistoryCSV(c echo.Context, filter history.Filter) error {
+	w := csv.NewWriter(c.Response())
+	d := &historyDownload{
+		c:           c,
+		contentType: "text/csv; charset=utf-8",
+		filename:    "history.csv",
+		header: func() error {
+			return w.Write([]string{"id", "timestamp", "client", "catalog_version", "requested", "total_delivered", "over_delivery", "packages", "error", "not_modified"})
+		},
+	}
+	err := h.streamHistory(d, filter, func(rec history.Record) error {
+		row := []string{
+			strconv.FormatInt(rec.ID, 10),
+			rec.Timestamp.Format(time.RFC3339),
+			rec.Client,
+			rec.CatalogVersion,
+			strconv.Itoa(rec.Request.Quantity),
+			"", "", "",
+			rec.Error,
+			strconv.FormatBool(rec.NotModified),
+		}
+		if rec.Result != nil {
+			row[5] = strconv.Itoa(rec.Result.TotalDelivered)
+			row[6] = strconv.Itoa(rec.Result.OverDelivery)
+			row[7] = formatPackages(rec.Result.Packages)
+		}
+		return w.Write(row)
+	})
+	if err != nil {
+		return err
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// writeHistoryJSONL streams the matching records as a JSON Lines download.
+func (h *Handler) writeHistoryJSONL(c echo.Context, filter history.Filter) error {
+	enc := json.NewEncoder(c.Response())
+	d := &historyDownload{c: c, contentType: "application/x-ndjson", filename: "history.jsonl"}
+	return h.streamHistory(d, filter, func(rec history.Record) error {
+		return enc.Encode(rec)
+	})
+}
+
+// formatPackages renders a package map as "size:count" pairs ordered by size.
+//
+// Example:
+//
+//	formatPackages(map[string]int{"1000": 1, "250": 1}) // "250:1;1000:1"
+func formatPackages(packages map[string]int) string {
+	sizes := make([]string, 0, len(packages))
+	for size := range packages {
+		sizes = append(sizes, size)
+	}
+	sort.Slice(sizes, func(i, j int) bool {
+		a, _ := strconv.Atoi(sizes[i])
+		b, _ := strconv.Atoi(sizes[j])
+		return a < b
+	})
+
+	parts := make([]string, len(sizes))
+	for i, size := range sizes {
+		parts[i] = fmt.Sprintf("%s:%d", size, packages[size])
+	}
+	return strings.Join(parts, ";")
+}
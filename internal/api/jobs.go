@@ -0,0 +1,163 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/sinaw369/Package-Optimizer/internal/auth"
+	"github.com/sinaw369/Package-Optimizer/internal/jobs"
+
+	"github.com/labstack/echo/v4"
+)
+
+// jobRequest is the JSON body accepted by the job submission endpoint.
+// Clients send either a single quantity or a list of quantities.
+type jobRequest struct {
+	// Quantity is a single quantity to optimize
+	Quantity *int `json:"quantity"`
+	// Quantities is a list of quantities to optimize in one job
+	Quantities []int `json:"quantities"`
+}
+
+// SubmitJobHandler handles POST /jobs for asynchronous optimization.
+// Expensive calculations (large quantities or many quantities at once) are queued
+// and computed by a bounded worker pool; the client polls the returned job.
+//
+// As for a batch, each quantity counts as one request against the client's rate
+// limit and API key quota, and a job takes at most MaxBatchQuantities quantities.
+//
+// Request Body:
+//   - {"quantity": 1201} or {"quantities": [1201, 5000, 12001]} (at most MaxBatchQuantities)
+//
+// Returns:
+//   - HTTP 202 with the queued job and a Location header pointing at it
+//   - HTTP 400 if the body is invalid or has too many quantities
+//   - HTTP 413 or 422 if a quantity exceeds the memory or quantity limit
+//   - HTTP 429 with Retry-After if the client is over its rate or the key's quota
+//     can't cover every quantity
+//   - HTTP 503 if the queue is full or the server is shutting down
+//
+// Example:
+//
+//	POST /api/jobs {"quantities":[1201,5000]}
+//	Response: {"id":"9f2c...","status":"queued","request":{"quantities":[1201,5000]},"progress":{"completed":0,"total":2},...}
+func (h *Handler) SubmitJobHandler(c echo.Context) error {
+	// Decode the request body
+	var body jobRequest
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body: must be JSON")
+	}
+
+	// Normalize the single-quantity form into a list
+	quantities := body.Quantities
+	if body.Quantity != nil {
+		quantities = append([]int{*body.Quantity}, quantities...)
+	}
+	if len(quantities) == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "missing 'quantity' or 'quantities'")
+	}
+	if len(quantities) > MaxBatchQuantities {
+		return echo.NewHTTPError(http.StatusBadRequest,
+			fmt.Sprintf("too many quantities: at most %d per job", MaxBatchQuantities))
+	}
+	for _, quantity := range quantities {
+		if quantity < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "quantities must be non-negative")
+		}
+	}
+
+	// Reject quantities over the limits now rather than failing the job later
+	optimizer := h.catalog.Optimizer()
+	for _, quantity := range quantities {
+		if err := optimizer.Check(quantity); err != nil {
+			return optimizationError(err)
+		}
+	}
+
+	// Every quantity counts as a request; the middlewares have charged the first one
+	if err := h.chargeQuota(c, len(quantities)-1); err != nil {
+		return err
+	}
+	h.chargeRate(c, len(quantities)-1)
+
+	// Queue the job
+	job, err := h.jobs.Submit(jobs.Request{Quantities: quantities, Client: clientIdentity(c)})
+	switch {
+	case errors.Is(err, jobs.ErrQueueFull):
+		c.Response().Header().Set("Retry-After", "1")
+		return echo.NewHTTPError(http.StatusServiceUnavailable, err.Error())
+	case errors.Is(err, jobs.ErrShuttingDown):
+		return echo.NewHTTPError(http.StatusServiceUnavailable, err.Error())
+	case err != nil:
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// Log the job ID so the request can be tied to the job's later progress
+	addLogAttrs(c, slog.String("job_id", job.ID), slog.Int("quantities", len(quantities)))
+
+	// Point the client at the job resource
+	c.Response().Header().Set(echo.HeaderLocation, c.Echo().Reverse("job", job.ID))
+	return c.JSON(http.StatusAccepted, job)
+}
+
+// GetJobHandler handles GET /jobs/:id.
+// This endpoint returns the job's status, progress and, once finished, its results.
+//
+// Partners only see their own jobs; catalog admins see everyone's.
+//
+// Returns:
+//   - HTTP 200 with the job
+//   - HTTP 404 if the job is unknown, belongs to another client or its result has expired
+//
+// Example:
+//
+//	GET /api/jobs/9f2c...
+//	Response: {"id":"9f2c...","status":"succeeded","results":[{"requested":1201,...}],...}
+func (h *Handler) GetJobHandler(c echo.Context) error {
+	job, err := h.jobs.Get(c.Param("id"))
+	if err != nil || !jobVisible(c, job) {
+		return jobNotFound()
+	}
+	return c.JSON(http.StatusOK, job)
+}
+
+// CancelJobHandler handles DELETE /jobs/:id.
+// Queued jobs are cancelled immediately; running jobs stop at the next cancellation check.
+// Partners can only cancel their own jobs; catalog admins can cancel anyone's.
+//
+// Returns:
+//   - HTTP 200 with the job snapshot after the cancellation request
+//   - HTTP 404 if the job is unknown, belongs to another client or its result has expired
+//
+// Example:
+//
+//	DELETE /api/jobs/9f2c...
+//	Response: {"id":"9f2c...","status":"canceled",...}
+func (h *Handler) CancelJobHandler(c echo.Context) error {
+	// Check the owner first so other clients' jobs are left alone
+	job, err := h.jobs.Get(c.Param("id"))
+	if err != nil || !jobVisible(c, job) {
+		return jobNotFound()
+	}
+	job, err = h.jobs.Cancel(job.ID)
+	if err != nil {
+		return jobNotFound()
+	}
+	return c.JSON(http.StatusOK, job)
+}
+
+// jobVisible reports whether the requesting client may see and cancel the job.
+// Without API keys there is no owner to enforce, as for the history; otherwise only
+// the submitting client and catalog admins may. Other clients get the same 404 as
+// for an unknown job, so job IDs don't leak between clients.
+func jobVisible(c echo.Context, job jobs.Job) bool {
+	client, ok := auth.ClientFromContext(c.Request().Context())
+	return !ok || client.HasScope(auth.ScopeCatalogAdmin) || job.Request.Client == client.ID
+}
+
+// jobNotFound is the error returned for unknown jobs and other clients' jobs.
+func jobNotFound() *echo.HTTPError {
+	return echo.NewHTTPError(http.StatusNotFound, jobs.ErrNotFound.Error())
+}
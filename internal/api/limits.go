@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+
+	"github.com/sinaw369/Package-Optimizer/internal/auth"
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+	"github.com/sinaw369/Package-Optimizer/internal/limits"
+	"github.com/sinaw369/Package-Optimizer/internal/metrics"
+
+	"github.com/labstack/echo/v4"
+)
+
+// IPExtractor returns how the server determines a client's IP address, which keys
+// the rate limit for anonymous clients and is reported in history, logs and traces.
+// Forwarding headers are only believed when they were added by a trusted proxy;
+// otherwise any client could send X-Forwarded-For or X-Real-IP to get a fresh rate
+// limit bucket with every request.
+//
+// Args:
+//   - trustedProxies: proxies allowed to report the client address; empty trusts none
+//
+// Returns:
+//   - echo.IPExtractor: the connection address when no proxy is trusted, otherwise the
+//     nearest X-Forwarded-For entry not added by a trusted proxy
+//
+// Example:
+//
+//	e.IPExtractor = api.IPExtractor(cfg.TrustedProxies)
+func IPExtractor(trustedProxies []*net.IPNet) echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	// Trust exactly the configured proxies, not Echo's default private ranges
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, proxy := range trustedProxies {
+		options = append(options, echo.TrustIPRange(proxy))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
+// rateLimit creates a middleware that applies the per-client token bucket.
+// Authenticated requests are limited per API key, others per client IP, so clients
+// sharing an address through a proxy can still be told apart by their keys.
+// It must run after requireScope so the API client is known.
+//
+// Returns:
+//   - echo.MiddlewareFunc: middleware function that can be used with Echo
+//   - HTTP 429 with Retry-After if the client is over its rate
+func (h *Handler) rateLimit() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		// Without a limiter every request is allowed
+		if h.limiter == nil {
+			return next
+		}
+
+		return func(c echo.Context) error {
+			if ok, wait := h.limiter.Allow(rateLimitKey(c)); !ok {
+				metrics.ObserveLimitRejection("rate")
+				c.Response().Header().Set("Retry-After", limits.RetryAfterSeconds(wait))
+				return echo.NewHTTPError(http.StatusTooManyRequests, "rate limit exceeded")
+			}
+			return next(c)
+		}
+	}
+}
+
+// rateLimitKey identifies the client for the rate limit: "key:<key ID>" for
+// authenticated requests, otherwise "ip:<address>".
+func rateLimitKey(c echo.Context) string {
+	if client, ok := auth.ClientFromContext(c.Request().Context()); ok {
+		return "key:" + client.KeyID
+	}
+	return "ip:" + c.RealIP()
+}
+
+// chargeRate takes n more tokens from the client's bucket for a request the rate
+// limit has already let through, when the request does the work of several.
+// The bucket may go below zero, delaying the client's next requests.
+func (h *Handler) chargeRate(c echo.Context, n int) {
+	if h.limiter == nil {
+		return
+	}
+	h.limiter.Charge(rateLimitKey(c), n)
+}
+
+// reserveSolve reserves solver memory for a calculation, weighted by the memory the
+// solve is estimated to need. The returned function releases the reservation and must
+// be called once the solve has finished.
+//
+// Args:
+//   - ctx: cancels the wait
+//   - optimizer: the optimizer that will run the solve
+//   - quantity: the requested quantity
+//
+// Returns:
+//   - func(): releases the reservation
+//   - error: limits.ErrBusy if the solver stays busy, or ctx.Err() if the wait was
+//     cancelled (see solverError)
+func (h *Handler) reserveSolve(ctx context.Context, optimizer *domain.Optimizer, quantity int) (func(), error) {
+	if h.limiter == nil {
+		return func() {}, nil
+	}
+	return h.limiter.AcquireSolve(ctx, optimizer.EstimateMemory(quantity))
+}
+
+// solverError maps an error from reserveSolve onto an HTTP error.
+//
+// Returns:
+//   - error: HTTP 429 with Retry-After if the solver stayed busy, HTTP 503 if the
+//     client went away while waiting, or err unchanged otherwise
+func (h *Handler) solverError(c echo.Context, quantity int, err error) error {
+	switch {
+	case errors.Is(err, limits.ErrBusy):
+		metrics.ObserveLimitRejection("concurrency")
+		slog.WarnContext(c.Request().Context(), "solver busy", "quantity", quantity)
+		c.Response().Header().Set("Retry-After", limits.RetryAfterSeconds(h.limiter.SolveWait()))
+		return echo.NewHTTPError(http.StatusTooManyRequests, "solver is busy, try again later")
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "request canceled while waiting for the solver")
+	}
+	return err
+}
@@ -1,28 +1,131 @@
 package api
 
 import (
-	"log"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/sinaw369/Package-Optimizer/internal/logging"
+	"github.com/sinaw369/Package-Optimizer/internal/metrics"
+
 	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// LoggingMiddleware creates a middleware that logs HTTP requests.
-// This middleware captures and logs information about each HTTP request including:
-// - HTTP method (GET, POST, etc.)
-// - Request URI (the endpoint being accessed)
-// - Remote address (client IP address)
-// - Request duration (how long the request took to process)
+// HeaderRequestID is the header carrying the request ID on requests and responses.
+const HeaderRequestID = "X-Request-ID"
+
+// maxRequestIDLength bounds client-supplied request IDs so they can't bloat the logs.
+const maxRequestIDLength = 128
+
+// logAttrsKey is the Echo context key under which handlers collect extra log fields.
+const logAttrsKey = "log_attrs"
+
+// RequestIDMiddleware creates a middleware that assigns every request an ID.
+// This middleware:
+// - reuses the client's X-Request-ID header if it is present and well-formed
+// - otherwise generates a random ID
+// - echoes the ID in the X-Request-ID response header
+// - stores the ID in the request context so every log line and error response includes it
 //
-// The middleware logs requests in the format: METHOD URI REMOTE_ADDR DURATION
+// It should be registered first so that later middleware and handlers see the ID.
 //
 // Returns:
 //   - echo.MiddlewareFunc: middleware function that can be used with Echo
 //
-// Example log output:
+// Example:
 //
-//	2025/08/07 12:13:11 GET /api/calculate?qty=1201 [::1]:33284 318.867µs
+//	GET /api/calculate?qty=1201
+//	X-Request-ID: checkout-42
+//	Response header: X-Request-ID: checkout-42
+func RequestIDMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+
+			// Prefer the caller's ID so requests can be followed across services
+			id := req.Header.Get(HeaderRequestID)
+			if !validRequestID(id) {
+				id = newRequestID()
+			}
+
+			// Expose the ID to the client and to everything downstream
+			c.Response().Header().Set(HeaderRequestID, id)
+			c.SetRequest(req.WithContext(logging.WithRequestID(req.Context(), id)))
+
+			// Call the next handler in the middleware chain
+			return next(c)
+		}
+	}
+}
+
+// validRequestID reports whether a client-supplied request ID is safe to reuse:
+// non-empty, bounded in length, and made of visible ASCII characters only.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID generates a random request ID.
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		// The system random source never fails on supported platforms; fall back to the clock
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
+
+// addLogAttrs attaches extra fields to the request's access log line.
+// Handlers use it to log what they did (e.g., the quantity and a result summary)
+// without writing a second log line per request.
+//
+// Args:
+//   - c: the Echo context of the current request
+//   - attrs: the fields to add
+func addLogAttrs(c echo.Context, attrs ...slog.Attr) {
+	existing, _ := c.Get(logAttrsKey).([]slog.Attr)
+	c.Set(logAttrsKey, append(existing, attrs...))
+}
+
+// LoggingMiddleware creates a middleware that logs HTTP requests as structured records.
+// This middleware writes one record per request through the default slog logger with:
+// - HTTP method, request URI and route template
+// - response status
+// - remote address (client IP address)
+// - latency in milliseconds
+// - the request ID (added from the request context by the logger)
+// - any fields the handler added with addLogAttrs, such as quantity and result summary
+//
+// Server errors are logged at error level, client errors at warn level and everything
+// else at info level, so the log level setting can filter out routine traffic. A server
+// error's internal cause (see echo.HTTPError.SetInternal) is logged here and nowhere
+// else, so each failure shows up once.
+//
+// Returns:
+//   - echo.MiddlewareFunc: middleware function that can be used with Echo
+//
+// Example log output (LOG_FORMAT=json):
+//
+//	{"time":"2025-08-07T12:13:11Z","level":"INFO","msg":"request","method":"GET","uri":"/api/calculate?qty=1201",
+//	 "route":"/api/calculate","status":200,"remote":"::1","latency_ms":0.318,"quantity":1201,
+//	 "total_delivered":1250,"over_delivery":49,"packages":2,"request_id":"3f9a1c2be8d04d7e"}
 func LoggingMiddleware() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
@@ -35,14 +138,50 @@ func LoggingMiddleware() echo.MiddlewareFunc {
 			// Calculate the duration of the request
 			duration := time.Since(start)
 
-			// Log the request details including method, URI, remote address, and duration
-			log.Printf(
-				"%s %s %s %v",
-				c.Request().Method,     // HTTP method (GET, POST, etc.)
-				c.Request().RequestURI, // Full request URI including query parameters
-				c.Request().RemoteAddr, // Client's IP address
-				duration,               // Request duration
-			)
+			// Determine the status code and error message; errors are turned into responses later by Echo.
+			// This line is the only log of a failed request, so server errors log their
+			// internal cause, which the client never sees.
+			status := c.Response().Status
+			errMessage := ""
+			if err != nil {
+				var httpErr *echo.HTTPError
+				if errors.As(err, &httpErr) {
+					status = httpErr.Code
+					errMessage = fmt.Sprint(httpErr.Message)
+					if status >= http.StatusInternalServerError && httpErr.Internal != nil {
+						errMessage = httpErr.Internal.Error()
+					}
+				} else {
+					status = http.StatusInternalServerError
+					errMessage = err.Error()
+				}
+			}
+
+			// Log the request details followed by any fields added by the handler
+			req := c.Request()
+			attrs := []slog.Attr{
+				slog.String("method", req.Method),
+				slog.String("uri", req.RequestURI),
+				slog.String("route", c.Path()),
+				slog.Int("status", status),
+				slog.String("remote", c.RealIP()),
+				slog.Float64("latency_ms", float64(duration.Microseconds())/1000),
+			}
+			if handlerAttrs, ok := c.Get(logAttrsKey).([]slog.Attr); ok {
+				attrs = append(attrs, handlerAttrs...)
+			}
+			if err != nil {
+				attrs = append(attrs, slog.String("error", errMessage))
+			}
+
+			level := slog.LevelInfo
+			switch {
+			case status >= http.StatusInternalServerError:
+				level = slog.LevelError
+			case status >= http.StatusBadRequest:
+				level = slog.LevelWarn
+			}
+			slog.LogAttrs(req.Context(), level, "request", attrs...)
 
 			// Return any error from the next handler
 			return err
@@ -50,40 +189,110 @@ func LoggingMiddleware() echo.MiddlewareFunc {
 	}
 }
 
-// CORSMiddleware creates a middleware that adds CORS (Cross-Origin Resource Sharing) headers.
-// This middleware allows web applications from different origins to access the API.
-// It's essential for web interfaces that need to make requests to the API from different domains.
+// TracingMiddleware creates a middleware that traces HTTP requests with OpenTelemetry.
+// This middleware:
+// - extracts incoming W3C trace context (traceparent/tracestate headers)
+// - starts a server span named after the method and route template
+// - passes the span context to handlers so solver spans become its children
+// - records the response status and marks 5xx responses as errors
 //
-// CORS Headers Added:
-//   - Access-Control-Allow-Origin: "*" (allows all origins)
-//   - Access-Control-Allow-Methods: "GET, POST, OPTIONS" (allowed HTTP methods)
-//   - Access-Control-Allow-Headers: "Content-Type" (allowed headers)
+// Returns:
+//   - echo.MiddlewareFunc: middleware function that can be used with Echo
+func TracingMiddleware() echo.MiddlewareFunc {
+	tracer := otel.Tracer("github.com/sinaw369/Package-Optimizer/internal/api")
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+
+			// Continue the caller's trace if it sent trace context headers
+			ctx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+
+			// Name the span after the route template to keep span names bounded
+			route := c.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+			ctx, span := tracer.Start(ctx, req.Method+" "+route,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.request.method", req.Method),
+					attribute.String("http.route", route),
+					attribute.String("url.path", req.URL.Path),
+					attribute.String("client.address", c.RealIP()),
+				),
+			)
+			defer span.End()
+
+			// Hand the traced context to the handlers
+			c.SetRequest(req.WithContext(ctx))
+
+			// Call the next handler in the middleware chain
+			err := next(c)
+
+			// Record the status code the client will receive
+			status := c.Response().Status
+			if err != nil {
+				var httpErr *echo.HTTPError
+				if errors.As(err, &httpErr) {
+					status = httpErr.Code
+				} else {
+					status = http.StatusInternalServerError
+				}
+				span.RecordError(err)
+			}
+			span.SetAttributes(attribute.Int("http.response.status_code", status))
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+
+			// Return any error from the next handler
+			return err
+		}
+	}
+}
+
+// MetricsMiddleware creates a middleware that records Prometheus metrics for HTTP requests.
+// This middleware captures, per method, route and status code:
+// - the number of requests
+// - the request latency
+// - error responses, classified by type
 //
-// Special Handling:
-//   - OPTIONS requests are handled immediately with a 200 status (preflight requests)
+// The route label uses the registered route template (e.g., "/api/jobs/:id") rather than
+// the raw URL, so the number of time series stays bounded.
 //
 // Returns:
 //   - echo.MiddlewareFunc: middleware function that can be used with Echo
-//
-// Note: In production, you might want to restrict Access-Control-Allow-Origin
-// to specific domains for security reasons.
-func CORSMiddleware() echo.MiddlewareFunc {
+func MetricsMiddleware() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			// Add CORS headers to allow cross-origin requests
-			c.Response().Header().Set("Access-Control-Allow-Origin", "*")
-			c.Response().Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-			c.Response().Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-			// Handle preflight OPTIONS requests
-			// These are sent by browsers before making actual requests to check CORS permissions
-			if c.Request().Method == "OPTIONS" {
-				// Return immediately with 200 status for preflight requests
-				return c.NoContent(http.StatusOK)
+			// Record the start time of the request
+			start := time.Now()
+
+			// Call the next handler in the middleware chain
+			err := next(c)
+
+			// Determine the status code; errors are turned into responses later by Echo
+			status := c.Response().Status
+			if err != nil {
+				var httpErr *echo.HTTPError
+				if errors.As(err, &httpErr) {
+					status = httpErr.Code
+				} else {
+					status = http.StatusInternalServerError
+				}
 			}
 
-			// Continue to the next handler for non-OPTIONS requests
-			return next(c)
+			// Requests that matched no route share a single label
+			route := c.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+
+			metrics.ObserveRequest(c.Request().Method, route, status, time.Since(start).Seconds())
+
+			// Return any error from the next handler
+			return err
 		}
 	}
 }
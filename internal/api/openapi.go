@@ -0,0 +1,54 @@
+package api
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// openAPISpec is the maintained OpenAPI 3 document describing every route.
+// It is embedded so the binary can serve it regardless of the working directory.
+//
+//go:embed openapi/openapi.json
+var openAPISpec []byte
+
+// docsPage is a self-contained documentation page that renders openAPISpec.
+// It has no external dependencies, so the docs work offline.
+//
+//go:embed openapi/docs.html
+var docsPage []byte
+
+// OpenAPISpec returns the raw OpenAPI 3 document served at /api/openapi.json.
+func OpenAPISpec() []byte {
+	return openAPISpec
+}
+
+// OpenAPIHandler handles the /openapi.json endpoint.
+// This endpoint returns the OpenAPI 3 document describing the HTTP API.
+//
+// Returns:
+//   - HTTP 200 with the OpenAPI document as JSON
+//
+// Example:
+//
+//	GET /api/openapi.json
+//	Response: {"openapi":"3.0.3","info":{"title":"Package Optimizer API",...},...}
+func (h *Handler) OpenAPIHandler(c echo.Context) error {
+	return c.Blob(http.StatusOK, echo.MIMEApplicationJSONCharsetUTF8, openAPISpec)
+}
+
+// DocsHandler handles the /docs endpoint.
+// This endpoint serves an interactive documentation page generated from the
+// OpenAPI document, including "try it" forms for every operation.
+//
+// Returns:
+//   - HTTP 200 with the documentation page as HTML
+//
+// Example:
+//
+//	GET /api/docs
+//	Response: HTML documentation page
+func (h *Handler) DocsHandler(c echo.Context) error {
+	return c.HTMLBlob(http.StatusOK, docsPage)
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"github.com/sinaw369/Package-Optimizer/internal/auth"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RegisterRoutes registers every HTTP route served by the handler on the Echo instance.
+// Keeping registration in one place lets the server and the tests share the exact
+// same routing table (for example, to check it against the OpenAPI document).
+//
+// Args:
+//   - e: the Echo instance to register routes on
+//   - h: the handler providing the endpoint implementations
+func RegisterRoutes(e *echo.Echo, h *Handler) {
+	// Route middleware enforcing API key scopes (no-ops when authentication is disabled)
+	calculate := h.requireScope(auth.ScopeCalculate)
+	catalogAdmin := h.requireScope(auth.ScopeCatalogAdmin)
+	anyKey := h.requireKey()
+	// Per-client rate limit for routes that start solves; runs after authentication
+	limited := h.rateLimit()
+
+	// Configure API routes under the /api prefix
+	// These routes handle the core functionality of the package optimizer
+	apiGroup := e.Group("/api")
+	apiGroup.GET("/calculate", h.CalculateHandler, calculate, limited)              // Main optimization endpoint
+	apiGroup.GET("/calculate/stream", h.CalculateStreamHandler, calculate, limited) // Optimization with SSE progress
+	apiGroup.POST("/calculate/batch", h.CalculateBatchHandler, calculate, limited)  // Several quantities in one request
+	apiGroup.GET("/package-sizes", h.PackageSizesHandler)                           // Package sizes endpoint
+	apiGroup.GET("/health", h.HealthHandler)                                        // Health check endpoint (same as live)
+	apiGroup.GET("/health/live", h.LiveHandler)                                     // Liveness probe
+	apiGroup.GET("/health/ready", h.ReadyHandler)                                   // Readiness probe with solver self-test
+	apiGroup.GET("/history", h.HistoryHandler, calculate)                           // Calculation history and export
+	apiGroup.POST("/jobs", h.SubmitJobHandler, calculate, limited)                  // Submit an asynchronous job
+	apiGroup.GET("/jobs/:id", h.GetJobHandler, calculate).Name = "job"              // Job status and result
+	apiGroup.GET("/jobs/:id/events", h.JobEventsHandler, calculate)                 // Job progress as SSE
+	apiGroup.DELETE("/jobs/:id", h.CancelJobHandler, calculate)                     // Cancel a job
+	apiGroup.GET("/usage", h.UsageHandler, anyKey)                                  // API key usage and quota
+
+	// Configure package catalog routes
+	// Anyone may read the catalog; changing it requires the catalog-admin scope and
+	// comparing a draft with it runs solves like a calculation
+	apiGroup.GET("/catalog", h.GetCatalogHandler)                                  // Current package catalog
+	apiGroup.PUT("/catalog", h.UpdateCatalogHandler, catalogAdmin)                 // Replace the package catalog
+	apiGroup.POST("/catalog/compare", h.CompareCatalogHandler, calculate, limited) // Compare a draft catalog with the current one
+
+	// Configure API documentation routes
+	// These routes serve the OpenAPI document and an offline documentation page
+	apiGroup.GET("/openapi.json", h.OpenAPIHandler) // OpenAPI 3 document
+	apiGroup.GET("/docs", h.DocsHandler)            // Interactive API documentation
+
+	// Configure the Prometheus metrics endpoint
+	e.GET("/metrics", h.MetricsHandler) // Prometheus scrape endpoint
+
+	// Configure web UI routes
+	// These routes serve the static files for the web interface
+	e.GET("/", h.ServeWebUI)        // Main web interface
+	e.GET("/style.css", h.ServeCSS) // CSS styles
+	e.GET("/script.js", h.ServeJS)  // JavaScript functionality
+
+	// Legacy route for backward compatibility
+	// This allows the old /calculate endpoint to still work
+	e.GET("/calculate", h.CalculateHandler, calculate, limited)
+
+	// Every route is in place, so the readiness probe may now succeed
+	h.routesReady.Store(true)
+}
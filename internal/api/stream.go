@@ -0,0 +1,224 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+	"github.com/sinaw369/Package-Optimizer/internal/jobs"
+	"github.com/sinaw369/Package-Optimizer/internal/logging"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CalculateStreamHandler handles the /calculate/stream endpoint.
+// It performs the same calculation as CalculateHandler but streams progress to the
+// client as Server-Sent Events while the DP table is being filled.
+//
+// Query Parameters:
+//   - qty: the requested quantity (required, must be a positive integer)
+//
+// Events:
+//   - progress: {"rows_filled":..,"total_rows":..,"best":{...}} roughly once per percent
+//   - result: the final optimization result
+//   - error: {"error":"...","request_id":"..."} if the calculation fails
+//
+// Returns:
+//   - HTTP 400 if quantity is missing or invalid (before the stream starts)
+//   - HTTP 413 or 422 if quantity exceeds the memory or quantity limit (before the stream starts)
+//   - HTTP 429 with Retry-After if the solver stays busy (before the stream starts)
+//   - HTTP 200 with a text/event-stream body otherwise
+//
+// Example:
+//
+//	GET /api/calculate/stream?qty=500000
+//	event: progress
+//	data: {"rows_filled":5020,"total_rows":502000}
+func (h *Handler) CalculateStreamHandler(c echo.Context) error {
+	// Extract and parse the quantity parameter
+	qtyStr := c.QueryParam("qty")
+	if qtyStr == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "missing 'qty' parameter")
+	}
+	quantity, err := strconv.Atoi(qtyStr)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid 'qty' parameter: must be an integer")
+	}
+
+	// Reject quantities over the limits and wait for the solver to have room
+	// before committing to a stream
+	optimizer := h.catalog.Optimizer()
+	if err := optimizer.Check(quantity); err != nil {
+		h.recordHistory(c, optimizer, quantity, nil, err)
+		addLogAttrs(c, resultLogAttrs(quantity, nil)...)
+		return optimizationError(err)
+	}
+
+	// A cached result needs no progress: send it straight away
+	if result := h.cachedResult(c, optimizer, quantity); result != nil {
+		startEventStream(c)
+		h.recordHistory(c, optimizer, quantity, result, nil)
+		addLogAttrs(c, resultLogAttrs(quantity, result)...)
+		return writeEvent(c, "result", result)
+	}
+
+	// Streams report the progress of their own solve, so they are not coalesced
+	ctx, cancel := h.streamContext(c.Request().Context())
+	defer cancel()
+	release, err := h.reserveSolve(ctx, optimizer, quantity)
+	if err != nil {
+		return h.solverError(c, quantity, err)
+	}
+
+	// Start the event stream
+	startEventStream(c)
+
+	// Run the calculation, streaming each progress report to the client.
+	// The context is cancelled when the client disconnects or the server shuts down,
+	// which stops the solve.
+	result, err := optimizer.OptimizeWithProgress(ctx, quantity, func(p domain.Progress) {
+		if err := writeEvent(c, "progress", p); err != nil {
+			slog.DebugContext(ctx, "stream write failed", "error", err)
+		}
+	})
+	release()
+	h.cacheResult(optimizer, quantity, result)
+
+	// Persist the calculation to the history, unless the client or the server went away
+	if ctx.Err() == nil {
+		h.recordHistory(c, optimizer, quantity, result, err)
+	}
+
+	// Add the quantity and result summary to the request's log line
+	addLogAttrs(c, resultLogAttrs(quantity, result)...)
+
+	if h.streamsAreClosed() {
+		return writeEvent(c, "error", domain.ErrorResponse{Error: errStreamClosed, RequestID: logging.RequestID(ctx)})
+	}
+	if err != nil {
+		return writeEvent(c, "error", domain.ErrorResponse{
+			Error:     fmt.Sprintf("optimization error: %v", err),
+			RequestID: logging.RequestID(ctx),
+		})
+	}
+	return writeEvent(c, "result", result)
+}
+
+// JobEventsHandler handles the /jobs/:id/events endpoint.
+// It streams the job's state as Server-Sent Events every time it changes, which
+// lets clients follow batch progress (completed quantities and the DP progress of
+// the current one) without polling.
+//
+// Events:
+//   - progress: the job snapshot, sent on every status or progress change
+//   - done: the final job snapshot, sent once the job reaches a terminal state
+//
+// Returns:
+//   - HTTP 404 if the job is unknown, belongs to another client or its result has expired
+//   - HTTP 200 with a text/event-stream body otherwise
+//
+// Example:
+//
+//	GET /api/jobs/9f2c.../events
+//	event: progress
+//	data: {"id":"9f2c...","status":"running","progress":{"completed":3,"total":10,"current":{...}},...}
+func (h *Handler) JobEventsHandler(c echo.Context) error {
+	id := c.Param("id")
+
+	// Look up the job before committing to a stream so unknown IDs get a 404
+	job, changed, err := h.jobs.Watch(id)
+	if err != nil || !jobVisible(c, job) {
+		return jobNotFound()
+	}
+
+	startEventStream(c)
+	ctx := c.Request().Context()
+
+	for {
+		// Terminal states end the stream
+		if isFinished(job.Status) {
+			return writeEvent(c, "done", job)
+		}
+		if err := writeEvent(c, "progress", job); err != nil {
+			return err
+		}
+
+		// Wait for the next change, for the client to disconnect or for the server to shut down
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-h.streamsClosed:
+			return writeEvent(c, "error", domain.ErrorResponse{Error: errStreamClosed, RequestID: logging.RequestID(ctx)})
+		case <-changed:
+		}
+
+		job, changed, err = h.jobs.Watch(id)
+		if err != nil {
+			// The job expired while we were watching it
+			return writeEvent(c, "error", domain.ErrorResponse{Error: err.Error(), RequestID: logging.RequestID(ctx)})
+		}
+	}
+}
+
+// errStreamClosed is the error event sent on streams ended by CloseStreams.
+const errStreamClosed = "server is shutting down; reconnect to continue"
+
+// streamContext returns a context for a stream's work that is also cancelled when
+// CloseStreams is called. The cancel function must be called once the stream ends.
+func (h *Handler) streamContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	go func() {
+		select {
+		case <-h.streamsClosed:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// streamsAreClosed reports whether CloseStreams has been called.
+func (h *Handler) streamsAreClosed() bool {
+	select {
+	case <-h.streamsClosed:
+		return true
+	default:
+		return false
+	}
+}
+
+// startEventStream writes the Server-Sent Events response headers.
+func startEventStream(c echo.Context) {
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	// Ask reverse proxies such as nginx not to buffer the stream
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+}
+
+// writeEvent writes a single Server-Sent Event with a JSON payload and flushes it.
+func writeEvent(c echo.Context, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	res := c.Response()
+	if _, err := fmt.Fprintf(res, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return err
+	}
+	res.Flush()
+	return nil
+}
+
+// isFinished reports whether a job status is terminal.
+func isFinished(status jobs.Status) bool {
+	return status == jobs.StatusSucceeded || status == jobs.StatusFailed || status == jobs.StatusCanceled
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+
+	"github.com/sinaw369/Package-Optimizer/web"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Cache-Control values for web UI files. Embedded files only change with a new
+// binary, so browsers may keep them but must revalidate (cheaply, with the ETag) to
+// pick up a deploy. Files served from disk are being edited and are never cached.
+const (
+	webAssetCacheControl = "no-cache"
+	devAssetCacheControl = "no-store"
+)
+
+// assetContentTypes fixes the content types of the web UI file types, so they
+// don't depend on the host's MIME database.
+var assetContentTypes = map[string]string{
+	".html": echo.MIMETextHTMLCharsetUTF8,
+	".css":  "text/css; charset=utf-8",
+	".js":   echo.MIMEApplicationJavaScriptCharsetUTF8,
+	".json": echo.MIMEApplicationJSONCharsetUTF8,
+	".svg":  "image/svg+xml",
+	".ico":  "image/x-icon",
+	".png":  "image/png",
+}
+
+// embeddedWebAssets serves the files embedded in the binary. It is shared by every
+// handler, since the embedded files never change.
+var embeddedWebAssets = newEmbeddedAssets(web.Static())
+
+// webAsset is a web UI file ready to be served.
+type webAsset struct {
+	// data is the file content
+	data []byte
+	// contentType is sent in the Content-Type header
+	contentType string
+	// etag identifies the content; empty for files served from disk
+	etag string
+}
+
+// webAssets serves the web UI files, either embedded in the binary or from a
+// directory on disk during development.
+type webAssets struct {
+	// files holds the web UI files
+	files fs.FS
+	// dev reads every file on every request, so edits show up on reload
+	dev bool
+	// embedded holds the files preloaded with their ETags; nil in dev mode
+	embedded map[string]*webAsset
+}
+
+// newEmbeddedAssets loads the embedded web UI files and computes their ETags once.
+func newEmbeddedAssets(files fs.FS) *webAssets {
+	assets := &webAssets{files: files, embedded: make(map[string]*webAsset)}
+	err := fs.WalkDir(files, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		asset, err := readAsset(files, name)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(asset.data)
+		asset.etag = `"` + hex.EncodeToString(sum[:8]) + `"`
+		assets.embedded[name] = asset
+		return nil
+	})
+	if err != nil {
+		// The embedded files are part of the binary; failing to read them is a build problem
+		panic("failed to load embedded web UI files: " + err.Error())
+	}
+	return assets
+}
+
+// readAsset reads a web UI file and determines its content type.
+func readAsset(files fs.FS, name string) (*webAsset, error) {
+	data, err := fs.ReadFile(files, name)
+	if err != nil {
+		return nil, err
+	}
+	contentType, ok := assetContentTypes[path.Ext(name)]
+	if !ok {
+		if contentType = mime.TypeByExtension(path.Ext(name)); contentType == "" {
+			contentType = http.DetectContentType(data)
+		}
+	}
+	return &webAsset{data: data, contentType: contentType}, nil
+}
+
+// serve writes a web UI file, answering 304 Not Modified when the client's copy is current.
+//
+// Args:
+//   - c: the request context
+//   - name: the file's path within the web UI files (e.g., "index.html")
+//
+// Returns:
+//   - error: HTTP 404 if the file doesn't exist
+func (a *webAssets) serve(c echo.Context, name string) error {
+	header := c.Response().Header()
+	header.Set("X-Content-Type-Options", "nosniff")
+
+	// Files on disk are read on every request, so edits show up on reload
+	if a.dev {
+		asset, err := readAsset(a.files, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			return echo.NewHTTPError(http.StatusNotFound, "file not found")
+		}
+		if err != nil {
+			return err
+		}
+		header.Set(echo.HeaderCacheControl, devAssetCacheControl)
+		return c.Blob(http.StatusOK, asset.contentType, asset.data)
+	}
+
+	asset, ok := a.embedded[name]
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotFound, "file not found")
+	}
+	header.Set(HeaderETag, asset.etag)
+	header.Set(echo.HeaderCacheControl, webAssetCacheControl)
+	if etagMatches(c.Request().Header.Get(HeaderIfNoneMatch), asset.etag) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	return c.Blob(http.StatusOK, asset.contentType, asset.data)
+}
+
+// ServeWebUIFromDisk makes the handler serve the web UI files from a directory
+// instead of the copies embedded in the binary, re-reading them on every request
+// so edits show up on reload. It is meant for development and must be called
+// before the server starts.
+//
+// Args:
+//   - dir: the directory holding index.html, style.css and script.js (e.g., "web/static")
+//
+// Returns:
+//   - error: if dir is not a readable directory
+func (h *Handler) ServeWebUIFromDisk(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return errors.New(dir + " is not a directory")
+	}
+	h.webAssets = &webAssets{files: os.DirFS(dir), dev: true}
+	return nil
+}
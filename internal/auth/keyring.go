@@ -0,0 +1,533 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scopes that can be granted to an API key.
+const (
+	// ScopeCalculate allows optimization requests, jobs and history
+	ScopeCalculate = "calculate"
+	// ScopeCatalogAdmin allows changing the package catalog and viewing every client's usage
+	ScopeCatalogAdmin = "catalog-admin"
+)
+
+// Errors returned by Keyring.Authorize.
+var (
+	// ErrUnauthenticated means no key or an unknown key was presented
+	ErrUnauthenticated = errors.New("missing or invalid API key")
+	// ErrForbidden means the key lacks the required scope
+	ErrForbidden = errors.New("API key does not have the required scope")
+	// ErrQuotaExceeded means the key has used up its quota for the current period
+	ErrQuotaExceeded = errors.New("API key quota exceeded")
+)
+
+// Quota limits how many requests a key may make per period.
+// A zero Requests value means unlimited.
+type Quota struct {
+	// Requests is the number of requests allowed per period
+	Requests int `json:"requests"`
+	// Period is the length of a quota window (e.g., "24h")
+	Period Duration `json:"period"`
+}
+
+// KeyConfig describes one API key in the key file.
+// Only the SHA-256 hash of the key is stored, never the key itself.
+type KeyConfig struct {
+	// ID names the key (e.g., "acme-prod"); usage is reported per ID
+	ID string `json:"id"`
+	// Client is the identity of the partner the key belongs to; one client may own several keys
+	Client string `json:"client"`
+	// KeySHA256 is the hex-encoded SHA-256 hash of the key (see HashKey)
+	KeySHA256 string `json:"key_sha256"`
+	// Scopes lists the granted scopes (calculate, catalog-admin)
+	Scopes []string `json:"scopes"`
+	// Quota limits the key's request rate; omitted means unlimited
+	Quota Quota `json:"quota"`
+}
+
+// Client is the identity behind an authenticated request.
+type Client struct {
+	// KeyID is the ID of the key that was presented
+	KeyID string
+	// ID is the client identity
+	ID string
+	// Scopes lists the scopes granted to the key
+	Scopes []string
+}
+
+// HasScope reports whether the client was granted the given scope.
+func (c Client) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Usage reports how much a key has been used.
+type Usage struct {
+	// KeyID is the key the usage belongs to
+	KeyID string `json:"key_id"`
+	// Client is the key's client identity
+	Client string `json:"client"`
+	// Requests is the number of accepted requests since the server started
+	Requests int64 `json:"requests"`
+	// Rejected is the number of requests refused because the quota was exhausted
+	Rejected int64 `json:"rejected"`
+	// LastUsed is when the key last made an accepted request
+	LastUsed *time.Time `json:"last_used,omitempty"`
+	// QuotaLimit is the number of requests allowed per period (0 means unlimited)
+	QuotaLimit int `json:"quota_limit"`
+	// QuotaRemaining is the number of requests left in the current period
+	QuotaRemaining int `json:"quota_remaining"`
+	// QuotaResetsAt is when the current quota period ends
+	QuotaResetsAt *time.Time `json:"quota_resets_at,omitempty"`
+}
+
+// key is an API key loaded from the key file together with its usage counters.
+type key struct {
+	// config is the key's configuration
+	config KeyConfig
+	// requests counts accepted requests
+	requests int64
+	// rejected counts requests refused by the quota
+	rejected int64
+	// lastUsed is the time of the last accepted request
+	lastUsed time.Time
+	// windowStart is when the current quota period began
+	windowStart time.Time
+	// windowRequests counts accepted requests in the current quota period
+	windowRequests int
+}
+
+// Keyring holds the configured API keys and tracks their usage.
+// Usage counters live in memory and start from zero when the server restarts.
+//
+// Keyring is safe for concurrent use.
+type Keyring struct {
+	// mu guards the keys and their usage counters
+	mu sync.Mutex
+	// byHash indexes keys by the hex SHA-256 hash of the key
+	byHash map[string]*key
+	// ordered lists keys in file order for usage reports
+	ordered []*key
+}
+
+// keyFile is the on-disk format of the key file.
+type keyFile struct {
+	// Keys lists every API key
+	Keys []KeyConfig `json:"keys"`
+}
+
+// LoadKeyring reads API keys from a JSON key file.
+//
+// Args:
+//   - path: location of the key file
+//
+// Returns:
+//   - *Keyring: keyring containing every key in the file
+//   - error: if the file cannot be read or contains an invalid key
+//
+// Example key file:
+//
+//	{
+//	  "keys": [
+//	    {
+//	      "id": "acme-prod",
+//	      "client": "acme",
+//	      "key_sha256": "5f6c4b0e...",
+//	      "scopes": ["calculate"],
+//	      "quota": {"requests": 10000, "period": "24h"}
+//	    }
+//	  ]
+//	}
+func LoadKeyring(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read API key file: %w", err)
+	}
+
+	var file keyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse API key file %s: %w", path, err)
+	}
+
+	keyring, err := NewKeyring(file.Keys)
+	if err != nil {
+		return nil, fmt.Errorf("API key file %s: %w", path, err)
+	}
+	return keyring, nil
+}
+
+// NewKeyring creates a keyring from key configurations.
+//
+// Args:
+//   - keys: the API keys; IDs and hashes must be unique
+//
+// Returns:
+//   - *Keyring: keyring containing the keys
+//   - error: if a key is invalid
+func NewKeyring(keys []KeyConfig) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("at least one API key is required")
+	}
+
+	k := &Keyring{byHash: make(map[string]*key, len(keys))}
+	ids := make(map[string]bool, len(keys))
+	for i, cfg := range keys {
+		if err := validateKey(cfg); err != nil {
+			return nil, fmt.Errorf("keys[%d]: %w", i, err)
+		}
+		if ids[cfg.ID] {
+			return nil, fmt.Errorf("keys[%d]: duplicate id %q", i, cfg.ID)
+		}
+		hash := normalizeHash(cfg.KeySHA256)
+		if k.byHash[hash] != nil {
+			return nil, fmt.Errorf("keys[%d]: key_sha256 is already used by another key", i)
+		}
+
+		ids[cfg.ID] = true
+		entry := &key{config: cfg}
+		k.byHash[hash] = entry
+		k.ordered = append(k.ordered, entry)
+	}
+	return k, nil
+}
+
+// HashKey returns the hex-encoded SHA-256 hash of an API key, as stored in the key file.
+//
+// Example:
+//
+//	auth.HashKey("s3cret") // "..." (equivalent to: printf '%s' s3cret | sha256sum)
+func HashKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// Authorize authenticates an API key, checks that it grants the scope and
+// counts the request against the key's quota.
+//
+// Args:
+//   - apiKey: the key presented by the client
+//   - scope: the scope the request requires
+//
+// Returns:
+//   - Client: the authenticated client (zero if authentication failed)
+//   - Usage: the key's usage after this request (zero if authentication failed)
+//   - error: ErrUnauthenticated, ErrForbidden or ErrQuotaExceeded; nil if the request may proceed
+func (k *Keyring) Authorize(apiKey, scope string) (Client, Usage, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	entry := k.lookupLocked(apiKey)
+	if entry == nil {
+		return Client{}, Usage{}, ErrUnauthenticated
+	}
+
+	client := entry.client()
+	if !client.HasScope(scope) {
+		return client, Usage{}, ErrForbidden
+	}
+
+	now := time.Now()
+	quota := entry.config.Quota
+	if quota.Requests > 0 {
+		// Start a new quota period once the current one has ended
+		if now.Sub(entry.windowStart) >= time.Duration(quota.Period) {
+			entry.windowStart = now
+			entry.windowRequests = 0
+		}
+		if entry.windowRequests >= quota.Requests {
+			entry.rejected++
+			return client, entry.usageLocked(now), ErrQuotaExceeded
+		}
+		entry.windowRequests++
+	}
+
+	entry.requests++
+	entry.lastUsed = now
+	return client, entry.usageLocked(now), nil
+}
+
+// Authenticate identifies the client presenting an API key, whatever its scopes,
+// without counting a request against the key. It is meant for endpoints that only
+// report on the key itself, such as its usage.
+//
+// Args:
+//   - apiKey: the key presented by the client
+//
+// Returns:
+//   - Client: the authenticated client
+//   - error: ErrUnauthenticated if the key is missing or unknown
+func (k *Keyring) Authenticate(apiKey string) (Client, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	entry := k.lookupLocked(apiKey)
+	if entry == nil {
+		return Client{}, ErrUnauthenticated
+	}
+	return entry.client(), nil
+}
+
+// Charge counts n more requests against a key whose request Authorize has already
+// let through, when the request does the work of several (e.g., a batch of
+// quantities). Either all n requests are counted or, if fewer than n remain in the
+// quota period, none are and the request is reported as rejected.
+//
+// Args:
+//   - keyID: the ID of the key the request was counted against
+//   - n: the number of additional requests to count; nothing is counted if n is not positive
+//
+// Returns:
+//   - Usage: the key's usage after the charge
+//   - error: ErrQuotaExceeded if the quota can't cover n more requests,
+//     ErrUnauthenticated if no key has that ID
+//
+// Example:
+//
+//	// A batch of 50 quantities was authorized as one request; charge the other 49
+//	usage, err := keyring.Charge("acme-prod", 49)
+func (k *Keyring) Charge(keyID string, n int) (Usage, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	entry := k.byIDLocked(keyID)
+	if entry == nil {
+		return Usage{}, ErrUnauthenticated
+	}
+
+	now := time.Now()
+	if n <= 0 {
+		return entry.usageLocked(now), nil
+	}
+	quota := entry.config.Quota
+	if quota.Requests > 0 {
+		if now.Sub(entry.windowStart) >= time.Duration(quota.Period) {
+			entry.windowStart = now
+			entry.windowRequests = 0
+		}
+		if entry.windowRequests+n > quota.Requests {
+			entry.rejected++
+			return entry.usageLocked(now), ErrQuotaExceeded
+		}
+		entry.windowRequests += n
+	}
+
+	entry.requests += int64(n)
+	entry.lastUsed = now
+	return entry.usageLocked(now), nil
+}
+
+// Refund gives back requests counted by Authorize and Charge that were rejected
+// before any work was done (an invalid request, or one refused by a rate or solver
+// limit), so only requests that were actually served use up the key's quota.
+//
+// Args:
+//   - keyID: the ID of the key the requests were counted against
+//   - n: the number of requests to give back
+//
+// Returns:
+//   - Usage: the key's usage after the refund
+//   - bool: false if no key has that ID
+func (k *Keyring) Refund(keyID string, n int) (Usage, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	entry := k.byIDLocked(keyID)
+	if entry == nil {
+		return Usage{}, false
+	}
+	entry.requests = max(entry.requests-int64(n), 0)
+	entry.windowRequests = max(entry.windowRequests-n, 0)
+	return entry.usageLocked(time.Now()), true
+}
+
+// Replace swaps the keyring's keys for those of next, typically a keyring loaded
+// again from the key file. Keys that keep their ID keep their usage counters and
+// quota period; new keys start from zero and removed keys stop working at once.
+//
+// Args:
+//   - next: the keyring holding the new keys; it must not be used afterwards
+//
+// Example:
+//
+//	next, err := auth.LoadKeyring(path)
+//	if err == nil {
+//	    keyring.Replace(next)
+//	}
+func (k *Keyring) Replace(next *Keyring) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	previous := make(map[string]*key, len(k.ordered))
+	for _, entry := range k.ordered {
+		previous[entry.config.ID] = entry
+	}
+	for _, entry := range next.ordered {
+		if old := previous[entry.config.ID]; old != nil {
+			entry.requests, entry.rejected, entry.lastUsed = old.requests, old.rejected, old.lastUsed
+			entry.windowStart, entry.windowRequests = old.windowStart, old.windowRequests
+		}
+	}
+	k.byHash, k.ordered = next.byHash, next.ordered
+}
+
+// lookupLocked returns the key matching apiKey, or nil if there is none. The caller
+// must hold the keyring lock.
+// Keys are looked up by hash so raw keys are never compared or kept in memory.
+func (k *Keyring) lookupLocked(apiKey string) *key {
+	if apiKey == "" {
+		return nil
+	}
+	return k.byHash[HashKey(apiKey)]
+}
+
+// byIDLocked returns the key with the given ID, or nil if there is none. The caller
+// must hold the keyring lock.
+func (k *Keyring) byIDLocked(keyID string) *key {
+	for _, entry := range k.ordered {
+		if entry.config.ID == keyID {
+			return entry
+		}
+	}
+	return nil
+}
+
+// Usage returns the usage of every key, in key file order.
+func (k *Keyring) Usage() []Usage {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := time.Now()
+	usage := make([]Usage, 0, len(k.ordered))
+	for _, entry := range k.ordered {
+		usage = append(usage, entry.usageLocked(now))
+	}
+	return usage
+}
+
+// ClientUsage returns the usage of every key belonging to a client, sorted by key ID.
+func (k *Keyring) ClientUsage(clientID string) []Usage {
+	usage := make([]Usage, 0, 1)
+	for _, u := range k.Usage() {
+		if u.Client == clientID {
+			usage = append(usage, u)
+		}
+	}
+	sort.Slice(usage, func(i, j int) bool { return usage[i].KeyID < usage[j].KeyID })
+	return usage
+}
+
+// client returns the client identified by the key.
+func (e *key) client() Client {
+	return Client{KeyID: e.config.ID, ID: e.config.Client, Scopes: e.config.Scopes}
+}
+
+// usageLocked builds a usage report for the key. The caller must hold the keyring lock.
+func (e *key) usageLocked(now time.Time) Usage {
+	usage := Usage{
+		KeyID:      e.config.ID,
+		Client:     e.config.Client,
+		Requests:   e.requests,
+		Rejected:   e.rejected,
+		QuotaLimit: e.config.Quota.Requests,
+	}
+	if !e.lastUsed.IsZero() {
+		lastUsed := e.lastUsed.UTC()
+		usage.LastUsed = &lastUsed
+	}
+
+	quota := e.config.Quota
+	if quota.Requests > 0 {
+		// A period that has already ended has its full quota available again
+		usage.QuotaRemaining = quota.Requests
+		if !e.windowStart.IsZero() && now.Sub(e.windowStart) < time.Duration(quota.Period) {
+			usage.QuotaRemaining = quota.Requests - e.windowRequests
+			resetsAt := e.windowStart.Add(time.Duration(quota.Period)).UTC()
+			usage.QuotaResetsAt = &resetsAt
+		}
+	}
+	return usage
+}
+
+// validateKey checks a key configuration.
+func validateKey(cfg KeyConfig) error {
+	if cfg.ID == "" {
+		return fmt.Errorf("id is required")
+	}
+	if cfg.Client == "" {
+		return fmt.Errorf("client is required")
+	}
+	if hash, err := hex.DecodeString(normalizeHash(cfg.KeySHA256)); err != nil || len(hash) != sha256.Size {
+		return fmt.Errorf("key_sha256 must be a hex-encoded SHA-256 hash")
+	}
+	if len(cfg.Scopes) == 0 {
+		return fmt.Errorf("at least one scope is required")
+	}
+	for _, scope := range cfg.Scopes {
+		if scope != ScopeCalculate && scope != ScopeCatalogAdmin {
+			return fmt.Errorf("unknown scope %q: must be %s or %s", scope, ScopeCalculate, ScopeCatalogAdmin)
+		}
+	}
+	if cfg.Quota.Requests < 0 {
+		return fmt.Errorf("quota.requests cannot be negative")
+	}
+	if cfg.Quota.Requests > 0 && cfg.Quota.Period <= 0 {
+		return fmt.Errorf("quota.period must be a positive duration when quota.requests is set")
+	}
+	return nil
+}
+
+// normalizeHash lower-cases a hex hash so upper-case hashes in the key file still match.
+func normalizeHash(hash string) string {
+	return strings.ToLower(strings.TrimSpace(hash))
+}
+
+// Duration is a time.Duration that is written as a string such as "24h" in JSON.
+type Duration time.Duration
+
+// UnmarshalJSON parses a duration string (e.g., "1h30m").
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"24h\"")
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalJSON writes the duration as a string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// clientKey is the context key under which the authenticated client is stored.
+type clientKey struct{}
+
+// WithClient returns a copy of ctx carrying the authenticated client.
+func WithClient(ctx context.Context, client Client) context.Context {
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+// ClientFromContext returns the authenticated client stored in ctx, if any.
+func ClientFromContext(ctx context.Context) (Client, bool) {
+	client, ok := ctx.Value(clientKey{}).(Client)
+	return client, ok
+}
@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+)
+
+// Key identifies a cached optimization result. A result depends only on the package
+// sizes (identified by the catalog version), the stock and costs (identified by the
+// options version), the strategy and the quantity, so equal keys always map to equal
+// results.
+type Key struct {
+	// CatalogVersion identifies the package sizes (see domain.CatalogVersion)
+	CatalogVersion string
+	// OptionsVersion identifies the stock and costs; empty if neither is set
+	// (see domain.Optimizer.OptionsVersion)
+	OptionsVersion string
+	// Strategy is the optimization strategy (see domain.Optimizer.Strategy)
+	Strategy string
+	// Quantity is the requested quantity
+	Quantity int
+}
+
+// KeyFor returns the key of the result for a quantity solved by the given optimizer.
+//
+// Example:
+//
+//	cache.KeyFor(optimizer, 1201) // Key{CatalogVersion: "c2f56da27d65", Strategy: "min-over-delivery", Quantity: 1201}
+func KeyFor(optimizer *domain.Optimizer, quantity int) Key {
+	return Key{
+		CatalogVersion: optimizer.CatalogVersion(),
+		OptionsVersion: optimizer.OptionsVersion(),
+		Strategy:       optimizer.Strategy().String(),
+		Quantity:       quantity,
+	}
+}
+
+// entry is a cached result together with its key, kept in the recency list.
+type entry struct {
+	key    Key
+	result *domain.OptimizationResult
+}
+
+// Cache is an in-process LRU cache of optimization results.
+// When the cache is full, adding a result evicts the least recently used one.
+//
+// Cached results are shared between callers and must be treated as read-only.
+//
+// Cache is safe for concurrent use.
+type Cache struct {
+	// capacity is the maximum number of cached results
+	capacity int
+
+	// mu guards entries and order
+	mu sync.Mutex
+	// entries maps keys to their element in order
+	entries map[Key]*list.Element
+	// order holds the entries, most recently used first
+	order *list.List
+}
+
+// New creates a cache holding at most capacity results.
+//
+// Args:
+//   - capacity: the maximum number of cached results (must be positive)
+//
+// Returns:
+//   - *Cache: empty cache ready for use
+//
+// Example:
+//
+//	results := cache.New(10000)
+//	key := cache.KeyFor(optimizer, 1201)
+//	if result, ok := results.Get(key); ok {
+//	    return result
+//	}
+func New(capacity int) *Cache {
+	if capacity < 1 {
+		panic("cache capacity must be positive")
+	}
+	return &Cache{
+		capacity: capacity,
+		entries:  make(map[Key]*list.Element, capacity),
+		order:    list.New(),
+	}
+}
+
+// Get returns the cached result for key and marks it as recently used.
+//
+// Returns:
+//   - *domain.OptimizationResult: the cached result (read-only)
+//   - bool: whether the key was cached
+func (c *Cache) Get(key Key) (*domain.OptimizationResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*entry).result, true
+}
+
+// Add caches the result for key, evicting the least recently used result if the
+// cache is full. The caller must not modify the result afterwards.
+func (c *Cache) Add(key Key, result *domain.OptimizationResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Replace an existing entry in place
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*entry).result = result
+		c.order.MoveToFront(element)
+		return
+	}
+
+	// Make room by evicting the least recently used entry
+	if c.order.Len() >= c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry).key)
+	}
+
+	c.entries[key] = c.order.PushFront(&entry{key: key, result: result})
+}
+
+// Purge removes every cached result. It is called when the catalog changes, since
+// results for the old catalog version can no longer be requested.
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[Key]*list.Element, c.capacity)
+	c.order.Init()
+}
+
+// Len returns the number of cached results.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
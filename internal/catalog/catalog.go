@@ -0,0 +1,265 @@
+package catalog
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+	"github.com/sinaw369/Package-Optimizer/pkg/optimizer"
+)
+
+// snapshot is an immutable view of the catalog: the package sizes as configured
+// and the optimizer built from them.
+type snapshot struct {
+	// packageSizes holds the sizes in the order they were configured
+	packageSizes []int
+	// optimizer is built from packageSizes
+	optimizer *domain.Optimizer
+}
+
+// Catalog holds the package sizes currently on offer and the optimizer built from them.
+// The catalog can be replaced at runtime (e.g., through the admin API); readers always
+// see either the old or the new catalog, never a mix, because both are swapped together
+// with a single atomic store.
+//
+// Request handlers should call Optimizer once per request and use that optimizer
+// throughout, so a concurrent update can't change the catalog halfway through.
+//
+// Catalog is safe for concurrent use.
+type Catalog struct {
+	// current is the active snapshot
+	current atomic.Pointer[snapshot]
+	// observer is installed on every optimizer the catalog builds
+	observer domain.SolveObserver
+
+	// mu serializes updates and guards settings and listeners
+	mu sync.Mutex
+	// settings are applied to every optimizer the catalog builds
+	settings Settings
+	// listeners are notified after every successful update
+	listeners []func(previous, current *domain.Optimizer)
+}
+
+// Settings are applied to every optimizer a catalog builds, whatever its package sizes.
+type Settings struct {
+	// Limits are the quantity and memory limits every solve must respect
+	Limits domain.Limits
+	// Stock is the number of packages available per size; nil (or a missing size) is unlimited
+	Stock map[int]int
+	// Costs is the price of each package size; nil leaves packings unpriced.
+	// When set, every size the catalog offers needs a price.
+	Costs map[int]float64
+}
+
+// New creates a catalog from the given package sizes.
+//
+// Args:
+//   - packageSizes: the available package sizes
+//   - observer: receives statistics about every solve (may be nil)
+//   - settings: the limits, stock and costs every solve must respect
+//
+// Returns:
+//   - *Catalog: catalog ready for use
+//   - error: if the package sizes are invalid, or a size has no cost while costs are set
+//
+// Example:
+//
+//	cat, err := catalog.New([]int{250, 500, 1000, 2000}, metrics.ObserveSolve,
+//	    catalog.Settings{Limits: domain.Limits{MaxQuantity: 10000000}})
+//	result, err := cat.Optimizer().Optimize(1201)
+func New(packageSizes []int, observer domain.SolveObserver, settings Settings) (*Catalog, error) {
+	c := &Catalog{observer: observer, settings: settings}
+	snap, err := c.build(packageSizes, settings)
+	if err != nil {
+		return nil, err
+	}
+	c.current.Store(snap)
+	return c, nil
+}
+
+// Optimizer returns the optimizer for the current catalog.
+func (c *Catalog) Optimizer() *domain.Optimizer {
+	return c.current.Load().optimizer
+}
+
+// PackageSizes returns a copy of the current package sizes in their configured order.
+func (c *Catalog) PackageSizes() []int {
+	sizes := c.current.Load().packageSizes
+	return append([]int(nil), sizes...)
+}
+
+// Version returns the version of the current catalog (see domain.CatalogVersion).
+func (c *Catalog) Version() string {
+	return c.Optimizer().CatalogVersion()
+}
+
+// State returns the current package sizes (in their configured order) together with
+// their version, read from the same snapshot so they always match.
+func (c *Catalog) State() (packageSizes []int, version string) {
+	snap := c.current.Load()
+	return append([]int(nil), snap.packageSizes...), snap.optimizer.CatalogVersion()
+}
+
+// Update validates the given package sizes and, if they are valid, atomically
+// replaces the current catalog. Listeners registered with OnChange are notified
+// after the swap when the catalog actually changed.
+//
+// Args:
+//   - packageSizes: the new package sizes
+//
+// Returns:
+//   - *domain.Optimizer: the optimizer for the new catalog
+//   - error: if the package sizes are invalid, or a size has no cost while costs are
+//     set; the current catalog is left unchanged
+func (c *Catalog) Update(packageSizes []int) (*domain.Optimizer, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.swapLocked(packageSizes, c.settings)
+}
+
+// Reconfigure is like Update, but also replaces the limits, stock and costs applied
+// to the catalog's optimizers (e.g., after the configuration was reloaded). Solves
+// already running finish under the old settings.
+//
+// Args:
+//   - packageSizes: the new package sizes
+//   - settings: the new limits, stock and costs
+//
+// Returns:
+//   - *domain.Optimizer: the optimizer for the new catalog
+//   - error: if the package sizes are invalid, or a size has no cost while costs are
+//     set; the catalog and its settings are left unchanged
+func (c *Catalog) Reconfigure(packageSizes []int, settings Settings) (*domain.Optimizer, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	optimizer, err := c.swapLocked(packageSizes, settings)
+	if err != nil {
+		return nil, err
+	}
+	c.settings = settings
+	return optimizer, nil
+}
+
+// swapLocked builds the snapshot for the package sizes and settings and swaps it in,
+// notifying the listeners if the version, the stock or the costs changed. The caller
+// must hold mu.
+func (c *Catalog) swapLocked(packageSizes []int, settings Settings) (*domain.Optimizer, error) {
+	// Validation failures change nothing
+	snap, err := c.build(packageSizes, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := c.current.Swap(snap)
+
+	// Re-publishing the same sizes, stock and costs is not a change; new limits are
+	// not either, since they never change a result
+	if previous.optimizer.CatalogVersion() == snap.optimizer.CatalogVersion() &&
+		previous.optimizer.OptionsVersion() == snap.optimizer.OptionsVersion() {
+		return snap.optimizer, nil
+	}
+	for _, listener := range c.listeners {
+		listener(previous.optimizer, snap.optimizer)
+	}
+	return snap.optimizer, nil
+}
+
+// OnChange registers a function that is called after every update that changes
+// the catalog version, the stock or the costs. Listeners run synchronously, one at
+// a time, and must not call Update.
+func (c *Catalog) OnChange(listener func(previous, current *domain.Optimizer)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.listeners = append(c.listeners, listener)
+}
+
+// build validates the package sizes and creates the snapshot for them.
+func (c *Catalog) build(packageSizes []int, settings Settings) (*snapshot, error) {
+	sizes := append([]int(nil), packageSizes...)
+	built, err := newOptimizer(sizes, settings, optimizer.WithObserver(c.observer))
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot{packageSizes: sizes, optimizer: built}, nil
+}
+
+// newOptimizer validates the package sizes and builds an optimizer for them with the
+// settings. Stock and costs for sizes the catalog doesn't offer are left out, so the
+// same settings serve every catalog.
+func newOptimizer(packageSizes []int, settings Settings, options ...optimizer.Option) (*domain.Optimizer, error) {
+	if err := Validate(packageSizes); err != nil {
+		return nil, err
+	}
+
+	options = append(options, optimizer.WithLimits(settings.Limits))
+	if settings.Stock != nil {
+		stock := maps.Clone(settings.Stock)
+		maps.DeleteFunc(stock, func(size, _ int) bool { return !slices.Contains(packageSizes, size) })
+		if len(stock) > 0 {
+			options = append(options, optimizer.WithStock(stock))
+		}
+	}
+	if settings.Costs != nil {
+		costs := maps.Clone(settings.Costs)
+		maps.DeleteFunc(costs, func(size int, _ float64) bool { return !slices.Contains(packageSizes, size) })
+		options = append(options, optimizer.WithCosts(costs))
+	}
+	return optimizer.New(packageSizes, options...)
+}
+
+// NewOptimizer builds an optimizer for package sizes outside a catalog (e.g., for a
+// one-off calculation), applying the settings the way a catalog applies them to its
+// own optimizers.
+//
+// Args:
+//   - packageSizes: the package sizes
+//   - settings: the limits, stock and costs to apply
+//   - options: further options, such as the strategy
+//
+// Returns:
+//   - *domain.Optimizer: the optimizer
+//   - error: if the package sizes are invalid, or a size has no cost while costs are set
+//
+// Example:
+//
+//	o, err := catalog.NewOptimizer([]int{250, 500}, catalog.Settings{Costs: map[int]float64{250: 1, 500: 1.8}})
+func NewOptimizer(packageSizes []int, settings Settings, options ...optimizer.Option) (*domain.Optimizer, error) {
+	return newOptimizer(packageSizes, settings, options...)
+}
+
+// Settings returns the limits, stock and costs applied to the catalog's optimizers.
+func (c *Catalog) Settings() Settings {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.settings
+}
+
+// Validate checks that package sizes can be used to build a catalog:
+// at least one size, every size positive, and no duplicates.
+//
+// Args:
+//   - packageSizes: the package sizes to check
+//
+// Returns:
+//   - error: describing the first problem found, or nil if the sizes are valid
+func Validate(packageSizes []int) error {
+	if len(packageSizes) == 0 {
+		return fmt.Errorf("package sizes cannot be empty")
+	}
+
+	seen := make(map[int]bool, len(packageSizes))
+	for _, size := range packageSizes {
+		if size <= 0 {
+			return fmt.Errorf("package size must be positive, got %d", size)
+		}
+		if seen[size] {
+			return fmt.Errorf("duplicate package size %d", size)
+		}
+		seen[size] = true
+	}
+	return nil
+}
@@ -0,0 +1,295 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/sinaw369/Package-Optimizer/internal/config"
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+)
+
+// formatTable is the bulk output format for people: an aligned table.
+const formatTable = "table"
+
+// bulkWindow is how many orders per worker may be in flight at once. Results are
+// written in input order, so a slow order holds back at most this many finished ones.
+const bulkWindow = 16
+
+// outcome is the result of one bulk order.
+type outcome struct {
+	order
+	// result is the solution; nil if the order failed
+	result *domain.OptimizationResult
+}
+
+// failed reports whether the order could not be read or solved.
+func (o outcome) failed() bool {
+	return o.err != nil
+}
+
+// bulkSummary counts the orders a bulk run processed.
+type bulkSummary struct {
+	// solved and failed count the orders written with a result and with an error
+	solved, failed int
+}
+
+// Bulk implements the bulk command: it streams orders from CSV or JSONL through the
+// optimizer and writes one result per order as CSV, JSONL or a table. Orders are
+// solved in parallel but written in input order. An order that can't be read or
+// solved is written with its error and reported on stderr; the run continues.
+//
+// Args:
+//   - args: the arguments after "bulk" (flags)
+//   - stdin: the input when no --input file is given
+//   - stdout: the output when no --output file is given
+//   - stderr: where per-order errors and the summary are written
+//
+// Returns:
+//   - int: ExitOK if every order was solved, ExitFailure if any failed or the input
+//     could not be read, ExitUsage for bad flags or input columns
+//
+// Example:
+//
+//	package-optimizer bulk --input orders.csv --id-column "Order No" --quantity-column Qty
+//	cat orders.jsonl | package-optimizer bulk --input-format jsonl --output-format table
+func Bulk(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := newFlagSet("bulk", "", stderr)
+	inputPath := flags.String("input", "-", "file to read orders from, - for standard input")
+	inputFormat := flags.String("input-format", "", "input format: csv or jsonl (default: from the file extension, else csv)")
+	outputPath := flags.String("output", "-", "file to write results to, - for standard output")
+	outputFormat := flags.String("output-format", "", "output format: csv, jsonl or table (default: from the file extension, else csv)")
+	idColumn := flags.String("id-column", "order_id", "column (CSV) or field (JSONL) holding the order ID; orders without one are numbered by line")
+	quantityColumn := flags.String("quantity-column", "quantity", "column (CSV) or field (JSONL) holding the quantity")
+	workers := flags.Int("workers", runtime.GOMAXPROCS(0), "orders solved in parallel; each may use up to limits.max_solve_memory_mb")
+	configFlags := config.RegisterFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return exitCode(err)
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(stderr, "bulk: unexpected argument %q; use --input to name the input file\n", flags.Arg(0))
+		return ExitUsage
+	}
+
+	// Resolve the formats, defaulting to the file extensions
+	if *inputFormat == "" {
+		*inputFormat = formatFromPath(*inputPath, formatCSV)
+	}
+	if *outputFormat == "" {
+		*outputFormat = formatFromPath(*outputPath, formatCSV)
+	}
+	if err := parseFormat("input format", *inputFormat, formatCSV, formatJSONL); err != nil {
+		fmt.Fprintf(stderr, "bulk: %v\n", err)
+		return ExitUsage
+	}
+	if err := parseFormat("output format", *outputFormat, formatCSV, formatJSONL, formatTable); err != nil {
+		fmt.Fprintf(stderr, "bulk: %v\n", err)
+		return ExitUsage
+	}
+	if *workers < 1 {
+		fmt.Fprintf(stderr, "bulk: --workers must be at least 1, got %d\n", *workers)
+		return ExitUsage
+	}
+
+	optimizer, _, code := loadOptimizer(configFlags, "bulk", stderr)
+	if optimizer == nil {
+		return code
+	}
+
+	// Open the input and find the order columns
+	input := stdin
+	if *inputPath != "-" {
+		file, err := os.Open(*inputPath)
+		if err != nil {
+			fmt.Fprintf(stderr, "bulk: %v\n", err)
+			return ExitFailure
+		}
+		defer file.Close()
+		input = file
+	}
+	columns := orderColumns{id: *idColumn, quantity: *quantityColumn, requireID: isFlagSet(flags, "id-column")}
+	var orders orderReader
+	if *inputFormat == formatJSONL {
+		orders = newJSONLOrders(input, columns)
+	} else {
+		csvInput, err := newCSVOrders(input, columns)
+		if err != nil {
+			fmt.Fprintf(stderr, "bulk: %v\n", err)
+			return ExitUsage
+		}
+		orders = csvInput
+	}
+
+	// Open the output; results are buffered and flushed at the end
+	output := stdout
+	if *outputPath != "-" {
+		file, err := os.Create(*outputPath)
+		if err != nil {
+			fmt.Fprintf(stderr, "bulk: %v\n", err)
+			return ExitFailure
+		}
+		defer file.Close()
+		output = file
+	}
+	buffered := bufio.NewWriter(output)
+	results := newResultWriter(*outputFormat, buffered)
+
+	// Solve; Ctrl-C stops reading and writes what has been solved
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	summary, err := runBulk(ctx, orders, optimizer, *workers, func(o outcome) error {
+		if o.failed() {
+			fmt.Fprintf(stderr, "bulk: line %d (order %s): %v\n", o.line, o.id, o.err)
+		}
+		return results.write(o)
+	})
+	if closeErr := results.close(); err == nil {
+		err = closeErr
+	}
+	if flushErr := buffered.Flush(); err == nil {
+		err = flushErr
+	}
+
+	fmt.Fprintf(stderr, "bulk: %d orders: %d solved, %d failed\n", summary.solved+summary.failed, summary.solved, summary.failed)
+	if err != nil {
+		fmt.Fprintf(stderr, "bulk: %v\n", err)
+		return ExitFailure
+	}
+	if summary.failed > 0 {
+		return ExitFailure
+	}
+	return ExitOK
+}
+
+// runBulk solves orders on a pool of workers and passes the outcomes to write in
+// input order. At most workers*bulkWindow orders are in flight, so memory stays
+// bounded however large the input is.
+//
+// Args:
+//   - ctx: cancels the run; orders not yet read are skipped
+//   - orders: the input
+//   - optimizer: solves the orders
+//   - workers: number of orders solved in parallel
+//   - write: receives every outcome, in input order; an error stops the run
+//
+// Returns:
+//   - bulkSummary: counts of the orders written
+//   - error: if the input could not be read, write failed or ctx was cancelled
+func runBulk(ctx context.Context, orders orderReader, optimizer *domain.Optimizer, workers int, write func(outcome) error) (bulkSummary, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// The reader takes a window slot per order; the writer frees it once the order is written
+	window := make(chan struct{}, workers*bulkWindow)
+	jobs := make(chan order)
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(jobs)
+		for seq := 0; ; seq++ {
+			next, err := orders.next()
+			if err != nil {
+				if err == io.EOF {
+					err = nil
+				}
+				readErr <- err
+				return
+			}
+			select {
+			case window <- struct{}{}:
+			case <-ctx.Done():
+				readErr <- ctx.Err()
+				return
+			}
+			next.seq = seq
+			jobs <- next
+		}
+	}()
+
+	// Solve on the workers
+	outcomes := make(chan outcome, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for next := range jobs {
+				solved := outcome{order: next}
+				if next.err == nil {
+					solved.result, solved.err = optimizer.OptimizeContext(ctx, next.quantity)
+				}
+				outcomes <- solved
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(outcomes)
+	}()
+
+	// Write in input order, holding back outcomes that finish early
+	var summary bulkSummary
+	var writeErr error
+	pending := make(map[int]outcome)
+	next := 0
+	for solved := range outcomes {
+		pending[solved.seq] = solved
+		for {
+			ready, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			<-window
+
+			// After a write error, keep draining so the workers can finish
+			if writeErr != nil {
+				continue
+			}
+			if writeErr = write(ready); writeErr != nil {
+				cancel()
+				continue
+			}
+			if ready.failed() {
+				summary.failed++
+			} else {
+				summary.solved++
+			}
+		}
+	}
+
+	if writeErr != nil {
+		return summary, writeErr
+	}
+	return summary, <-readErr
+}
+
+// formatFromPath picks a format from a file extension, or returns def.
+func formatFromPath(path, def string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return formatCSV
+	case ".jsonl", ".ndjson":
+		return formatJSONL
+	}
+	return def
+}
+
+// isFlagSet reports whether the named flag was given on the command line.
+func isFlagSet(flags *flag.FlagSet, name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
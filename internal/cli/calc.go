@@ -0,0 +1,177 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sinaw369/Package-Optimizer/internal/catalog"
+	"github.com/sinaw369/Package-Optimizer/internal/config"
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+)
+
+// Output formats of the calc and version commands.
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+// Calc implements the calc command: it computes the packages for one quantity with
+// the configured package sizes and limits, and prints the result, without starting
+// a server. The configuration flags, environment variables and file apply as for
+// serve.
+//
+// Args:
+//   - args: the arguments after "calc": flags and the quantity
+//   - stdout: where the result is written
+//   - stderr: where errors are written
+//
+// Returns:
+//   - int: ExitOK, ExitFailure if the quantity can't be solved, or ExitUsage
+//
+// Example:
+//
+//	package-optimizer calc 1201
+//	package-optimizer calc --package-sizes 23,31,53 --format json 500000
+func Calc(args []string, stdout, stderr io.Writer) int {
+	flags := newFlagSet("calc", "<quantity>", stderr)
+	format := flags.String("format", formatText, "output format: text or json")
+	configFlags := config.RegisterFlags(flags)
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return exitCode(err)
+	}
+	if err := parseFormat("format", *format, formatText, formatJSON); err != nil {
+		fmt.Fprintf(stderr, "calc: %v\n", err)
+		return ExitUsage
+	}
+	if len(positional) != 1 {
+		fmt.Fprintln(stderr, "calc: exactly one quantity is required")
+		flags.Usage()
+		return ExitUsage
+	}
+	quantity, err := strconv.Atoi(positional[0])
+	if err != nil {
+		fmt.Fprintf(stderr, "calc: invalid quantity %q: must be an integer\n", positional[0])
+		return ExitUsage
+	}
+
+	// Build the optimizer the server would use
+	optimizer, _, code := loadOptimizer(configFlags, "calc", stderr)
+	if optimizer == nil {
+		return code
+	}
+
+	// Solve; Ctrl-C cancels a long solve
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	result, err := optimizer.OptimizeContext(ctx, quantity)
+	if err != nil {
+		fmt.Fprintf(stderr, "calc: %v\n", err)
+		return ExitFailure
+	}
+
+	if *format == formatJSON {
+		if err := json.NewEncoder(stdout).Encode(result); err != nil {
+			fmt.Fprintf(stderr, "calc: %v\n", err)
+			return ExitFailure
+		}
+		return ExitOK
+	}
+	writeResult(stdout, result)
+	return ExitOK
+}
+
+// loadOptimizer loads the configuration and builds an optimizer from its package
+// sizes, limits, stock and costs, reporting problems to stderr.
+//
+// Returns:
+//   - *domain.Optimizer: the optimizer, or nil on failure
+//   - catalog.Settings: the configured limits, stock and costs
+//   - int: the exit code to return on failure
+func loadOptimizer(configFlags *config.Flags, name string, stderr io.Writer) (*domain.Optimizer, catalog.Settings, int) {
+	cfg, err := configFlags.Load()
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", name, err)
+		var validationErr *config.ValidationError
+		if errors.As(err, &validationErr) {
+			return nil, catalog.Settings{}, ExitUsage
+		}
+		return nil, catalog.Settings{}, ExitFailure
+	}
+
+	settings := catalog.Settings{
+		Limits: domain.Limits{MaxQuantity: cfg.MaxQuantity, MaxMemory: cfg.MaxSolveMemory},
+		Stock:  cfg.PackageStock,
+		Costs:  cfg.PackageCosts,
+	}
+	optimizer, err := catalog.NewOptimizer(cfg.PackageSizes, settings)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: invalid catalog: %v\n", name, err)
+		return nil, settings, ExitUsage
+	}
+	return optimizer, settings, ExitOK
+}
+
+// writeResult prints a result for people.
+//
+// Example output:
+//
+//	Quantity:   1201
+//	Delivered:  1250 (49 over)
+//	Packages:   1 x 1000, 1 x 250 (2 packages)
+//	Cost:       4.5 (only when package costs are configured)
+func writeResult(w io.Writer, result *domain.OptimizationResult) {
+	fmt.Fprintf(w, "Quantity:   %d\n", result.Requested)
+	fmt.Fprintf(w, "Delivered:  %d (%d over)\n", result.TotalDelivered, result.OverDelivery)
+	fmt.Fprintf(w, "Packages:   %s\n", formatPackages(result.Packages))
+	if result.Cost != 0 {
+		fmt.Fprintf(w, "Cost:       %g\n", result.Cost)
+	}
+}
+
+// formatPackages lists package counts, largest size first, with the total.
+//
+// Example:
+//
+//	formatPackages(map[string]int{"250": 1, "1000": 1}) // "1 x 1000, 1 x 250 (2 packages)"
+func formatPackages(packages map[string]int) string {
+	items, total := packageList(packages)
+	switch total {
+	case 0:
+		return "none"
+	case 1:
+		return items[0] + " (1 package)"
+	}
+	return fmt.Sprintf("%s (%d packages)", strings.Join(items, ", "), total)
+}
+
+// packageList lists package counts as "count x size", largest size first.
+//
+// Returns:
+//   - []string: one item per package size
+//   - int: the total number of packages
+func packageList(packages map[string]int) ([]string, int) {
+	sizes := make([]int, 0, len(packages))
+	for size := range packages {
+		n, _ := strconv.Atoi(size)
+		sizes = append(sizes, n)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(sizes)))
+
+	items := make([]string, len(sizes))
+	total := 0
+	for i, size := range sizes {
+		count := packages[strconv.Itoa(size)]
+		items[i] = fmt.Sprintf("%d x %d", count, size)
+		total += count
+	}
+	return items, total
+}
@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Exit codes returned by the commands.
+const (
+	// ExitOK means the command succeeded
+	ExitOK = 0
+	// ExitFailure means the command ran but failed (e.g., the configuration is invalid)
+	ExitFailure = 1
+	// ExitUsage means the command line was wrong
+	ExitUsage = 2
+)
+
+// programName is the name commands use in usage and error messages.
+const programName = "package-optimizer"
+
+// command is a subcommand of the binary.
+type command struct {
+	// name is the word that selects the command
+	name string
+	// summary describes the command in the usage message
+	summary string
+	// run executes the command with the arguments that follow its name
+	run func(args []string, stdout, stderr io.Writer) int
+}
+
+// Main runs the command selected by the first argument and returns the process
+// exit code. Without a command, or when the first argument is a flag, the server
+// is started, so existing deployments that pass only flags keep working.
+//
+// Args:
+//   - args: command-line arguments, without the program name
+//   - stdin: where commands read input (e.g., bulk orders)
+//   - stdout: where results are written
+//   - stderr: where errors and usage are written
+//   - serve: runs the server with the given flags (the serve command)
+//
+// Returns:
+//   - int: the exit code (ExitOK, ExitFailure or ExitUsage)
+//
+// Example:
+//
+//	func main() {
+//	    os.Exit(cli.Main(os.Args[1:], os.Stdin, os.Stdout, os.Stderr, serve))
+//	}
+func Main(args []string, stdin io.Reader, stdout, stderr io.Writer, serve func(args []string) int) int {
+	commands := []command{
+		{name: "serve", summary: "run the HTTP and gRPC servers (the default)",
+			run: func(args []string, _, _ io.Writer) int { return serve(args) }},
+		{name: "calc", summary: "compute the packages for a quantity and print them", run: Calc},
+		{name: "bulk", summary: "compute the packages for a file of orders (CSV or JSONL)",
+			run: func(args []string, stdout, stderr io.Writer) int { return Bulk(args, stdin, stdout, stderr) }},
+		{name: "repl", summary: "try package sizes and quantities interactively",
+			run: func(args []string, stdout, stderr io.Writer) int { return REPL(args, stdin, stdout, stderr) }},
+		{name: "validate-config", summary: "check the configuration and exit", run: ValidateConfig},
+		{name: "version", summary: "print version information", run: PrintVersion},
+	}
+
+	// No command: run the server, passing any flags through
+	if len(args) == 0 || (strings.HasPrefix(args[0], "-") && !isHelp(args[0])) {
+		return serve(args)
+	}
+	if isHelp(args[0]) || args[0] == "help" {
+		usage(stdout, commands)
+		return ExitOK
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:], stdout, stderr)
+		}
+	}
+	fmt.Fprintf(stderr, "%s: unknown command %q\n\n", programName, args[0])
+	usage(stderr, commands)
+	return ExitUsage
+}
+
+// isHelp reports whether the argument asks for help.
+func isHelp(arg string) bool {
+	return arg == "-h" || arg == "-help" || arg == "--help"
+}
+
+// usage writes the list of commands.
+func usage(w io.Writer, commands []command) {
+	fmt.Fprintf(w, "Usage: %s <command> [flags]\n\nCommands:\n", programName)
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-16s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "\nRun '%s <command> -h' for the flags of a command.\n", programName)
+}
+
+// newFlagSet creates the flag set of a command, writing its usage to stderr.
+func newFlagSet(name, arguments string, stderr io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: %s %s [flags] %s\n\nFlags:\n", programName, name, arguments)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parseInterspersed parses flags that may appear before or after the positional
+// arguments (e.g., "calc 1201 --format json") and returns the positional arguments.
+func parseInterspersed(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// parseFormat checks a --format style flag value against the accepted formats.
+func parseFormat(flagName, value string, accepted ...string) error {
+	for _, format := range accepted {
+		if value == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown %s %q: must be %s", flagName, value, strings.Join(accepted, ", "))
+}
+
+// exitCode returns the exit code for a flag parsing error: ExitOK if help was requested.
+func exitCode(err error) int {
+	if err == flag.ErrHelp {
+		return ExitOK
+	}
+	return ExitUsage
+}
@@ -0,0 +1,222 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Input formats of the bulk command.
+const (
+	formatCSV   = "csv"
+	formatJSONL = "jsonl"
+)
+
+// maxJSONLine is the longest JSONL line the bulk command accepts.
+const maxJSONLine = 1 << 20
+
+// order is one order read from the bulk input.
+type order struct {
+	// seq is the position of the order in the input, starting at 0
+	seq int
+	// line is the input line the order starts on, for error messages
+	line int
+	// id identifies the order; the line number if the input has no ID column
+	id string
+	// rawQuantity is the quantity as written in the input
+	rawQuantity string
+	// quantity is the parsed quantity, valid only if err is nil
+	quantity int
+	// err is why the order could not be read; the order is reported, not solved
+	err error
+}
+
+// orderReader reads orders one at a time.
+type orderReader interface {
+	// next returns the next order, or io.EOF at the end of the input. Problems with a
+	// single order are returned in order.err; an error aborts the input.
+	next() (order, error)
+}
+
+// orderColumns maps the bulk input's columns (CSV) or fields (JSONL) to order attributes.
+type orderColumns struct {
+	// id is the column holding the order ID
+	id string
+	// quantity is the column holding the quantity
+	quantity string
+	// requireID is true if the ID column was chosen explicitly and must exist;
+	// otherwise orders without one are identified by their line number
+	requireID bool
+}
+
+// csvOrders reads orders from CSV with a header row.
+type csvOrders struct {
+	reader *csv.Reader
+	// idIndex and quantityIndex are the column positions; idIndex is -1 without an ID column
+	idIndex       int
+	quantityIndex int
+}
+
+// newCSVOrders reads the header row and locates the ID and quantity columns.
+// Column names are matched case-insensitively.
+//
+// Returns:
+//   - *csvOrders: reader positioned at the first order
+//   - error: if the header can't be read or a required column is missing
+func newCSVOrders(r io.Reader, columns orderColumns) (*csvOrders, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // rows with missing columns are per-row errors
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("the input is empty; a header row is required")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the header row: %w", err)
+	}
+	if len(header) > 0 {
+		// Spreadsheets often save CSV with a byte order mark
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	orders := &csvOrders{reader: reader, idIndex: columnIndex(header, columns.id), quantityIndex: columnIndex(header, columns.quantity)}
+	if orders.quantityIndex < 0 {
+		return nil, fmt.Errorf("no %q column; the header has %s", columns.quantity, strings.Join(header, ", "))
+	}
+	if orders.idIndex < 0 && columns.requireID {
+		return nil, fmt.Errorf("no %q column; the header has %s", columns.id, strings.Join(header, ", "))
+	}
+	return orders, nil
+}
+
+// columnIndex returns the position of the named column, or -1.
+func columnIndex(header []string, name string) int {
+	for i, column := range header {
+		if strings.EqualFold(strings.TrimSpace(column), name) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (o *csvOrders) next() (order, error) {
+	record, err := o.reader.Read()
+	if err == io.EOF {
+		return order{}, io.EOF
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		// A malformed row; the reader continues with the next one
+		return order{line: parseErr.StartLine, id: strconv.Itoa(parseErr.StartLine), err: parseErr.Err}, nil
+	}
+	if err != nil {
+		return order{}, err
+	}
+
+	line, _ := o.reader.FieldPos(0)
+	next := order{line: line, id: strconv.Itoa(line)}
+	if o.idIndex >= 0 && o.idIndex < len(record) && record[o.idIndex] != "" {
+		next.id = record[o.idIndex]
+	}
+	if o.quantityIndex >= len(record) {
+		next.err = errors.New("missing quantity")
+		return next, nil
+	}
+	next.rawQuantity = strings.TrimSpace(record[o.quantityIndex])
+	next.quantity, next.err = parseQuantity(next.rawQuantity)
+	return next, nil
+}
+
+// jsonlOrders reads orders from JSON Lines: one JSON object per line.
+type jsonlOrders struct {
+	scanner *bufio.Scanner
+	columns orderColumns
+	// line is the number of the last line read
+	line int
+}
+
+// newJSONLOrders creates a reader for JSON Lines. Blank lines are skipped.
+func newJSONLOrders(r io.Reader, columns orderColumns) *jsonlOrders {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxJSONLine)
+	return &jsonlOrders{scanner: scanner, columns: columns}
+}
+
+func (o *jsonlOrders) next() (order, error) {
+	// Skip blank lines
+	var text []byte
+	for len(text) == 0 {
+		if !o.scanner.Scan() {
+			if err := o.scanner.Err(); err != nil {
+				return order{}, fmt.Errorf("line %d: %w", o.line+1, err)
+			}
+			return order{}, io.EOF
+		}
+		o.line++
+		text = bytes.TrimSpace(o.scanner.Bytes())
+	}
+
+	next := order{line: o.line, id: strconv.Itoa(o.line)}
+	var fields map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(text))
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil {
+		next.err = fmt.Errorf("invalid JSON object: %w", err)
+		return next, nil
+	}
+
+	if id := field(fields, o.columns.id); id != nil {
+		next.id = fmt.Sprint(id)
+	} else if o.columns.requireID {
+		next.err = fmt.Errorf("missing %q", o.columns.id)
+		return next, nil
+	}
+
+	switch quantity := field(fields, o.columns.quantity).(type) {
+	case nil:
+		next.err = fmt.Errorf("missing %q", o.columns.quantity)
+	case json.Number:
+		next.rawQuantity = quantity.String()
+		next.quantity, next.err = parseQuantity(next.rawQuantity)
+	case string:
+		next.rawQuantity = strings.TrimSpace(quantity)
+		next.quantity, next.err = parseQuantity(next.rawQuantity)
+	default:
+		next.rawQuantity = fmt.Sprint(quantity)
+		next.err = fmt.Errorf("invalid quantity %v: must be an integer", quantity)
+	}
+	return next, nil
+}
+
+// field returns the named field of a JSON object, matching the name case-insensitively.
+// It returns nil if the field is absent or null.
+func field(fields map[string]any, name string) any {
+	if value, ok := fields[name]; ok {
+		return value
+	}
+	for key, value := range fields {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return nil
+}
+
+// parseQuantity parses a quantity written in the bulk input.
+func parseQuantity(raw string) (int, error) {
+	if raw == "" {
+		return 0, errors.New("missing quantity")
+	}
+	quantity, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid quantity %q: must be an integer", raw)
+	}
+	return quantity, nil
+}
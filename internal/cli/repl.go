@@ -0,0 +1,371 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sinaw369/Package-Optimizer/internal/catalog"
+	"github.com/sinaw369/Package-Optimizer/internal/config"
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+	"github.com/sinaw369/Package-Optimizer/pkg/optimizer"
+)
+
+// Defaults of the REPL commands.
+const (
+	// defaultAlternatives is how many packings "alternatives" shows
+	defaultAlternatives = 5
+	// defaultLargestGaps is how many of the widest gaps "gaps" lists
+	defaultLargestGaps = 5
+	// defaultGapRange is how many largest packages "gaps" analyzes by default
+	defaultGapRange = 4
+)
+
+// errQuit ends the REPL.
+var errQuit = errors.New("quit")
+
+// replCommand is a command of the REPL.
+type replCommand struct {
+	// usage shows the command and its arguments
+	usage string
+	// summary describes the command in the help
+	summary string
+	// run executes the command with its arguments
+	run func(r *repl, ctx context.Context, args []string) error
+}
+
+// replCommands are the REPL commands, by name.
+var replCommands map[string]replCommand
+
+// init fills replCommands; help lists them, so they can't be a plain initializer.
+func init() {
+	replCommands = map[string]replCommand{
+		"help":         {"help", "show this help", (*repl).help},
+		"sizes":        {"sizes [list]", "show the package sizes, or set them (e.g., sizes 23,31,53)", (*repl).sizes},
+		"strategy":     {"strategy [name]", "show the optimization strategy, or select one of " + strings.Join(strategyNames(), ", "), (*repl).strategy},
+		"calc":         {"calc <quantity>", "compute the packages for a quantity (a bare number works too)", (*repl).calc},
+		"alternatives": {"alternatives <quantity> [n]", "show the best packings for the next deliverable totals", (*repl).alternatives},
+		"compare":      {"compare <sizes> <quantity>...", "compare the current sizes with other sizes for some quantities", (*repl).compare},
+		"gaps":         {"gaps [up-to]", "show which quantities can't be delivered exactly", (*repl).gaps},
+		"quit":         {"quit", "leave (Ctrl-D works too)", func(*repl, context.Context, []string) error { return errQuit }},
+	}
+}
+
+// replAliases are shorter names for REPL commands.
+var replAliases = map[string]string{"alt": "alternatives", "exit": "quit", "?": "help"}
+
+// repl is the state of an interactive session.
+type repl struct {
+	// optimizer solves with the current package sizes and strategy and the configured settings
+	optimizer *domain.Optimizer
+	// settings are the configured limits, stock and costs, applied to every package size tried
+	settings catalog.Settings
+	// out receives the command output
+	out io.Writer
+}
+
+// REPL implements the repl command: an interactive session for trying package sizes
+// and quantities without editing the configuration. It starts with the configured
+// package sizes, limits, stock and costs and uses the same optimizer as the server. Ctrl-C
+// cancels a long computation; Ctrl-D or "quit" leaves.
+//
+// Args:
+//   - args: the arguments after "repl" (configuration flags)
+//   - stdin: where commands are read
+//   - stdout: where prompts and results are written
+//   - stderr: where errors are written
+//
+// Returns:
+//   - int: ExitOK, or ExitUsage for bad flags or configuration
+//
+// Example:
+//
+//	$ package-optimizer repl
+//	> sizes 23,31,53
+//	> calc 500000
+//	> compare 250,500,1000,2000 1201 12001
+func REPL(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := newFlagSet("repl", "", stderr)
+	configFlags := config.RegisterFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return exitCode(err)
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(stderr, "repl: unexpected argument %q\n", flags.Arg(0))
+		return ExitUsage
+	}
+	optimizer, settings, code := loadOptimizer(configFlags, "repl", stderr)
+	if optimizer == nil {
+		return code
+	}
+
+	session := &repl{optimizer: optimizer, settings: settings, out: stdout}
+	fmt.Fprintf(stdout, "Package sizes %s. Type \"help\" for commands.\n", formatSizes(optimizer.PackageSizes()))
+
+	scanner := bufio.NewScanner(stdin)
+	for {
+		fmt.Fprint(stdout, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(stdout)
+			break
+		}
+		if err := session.execute(scanner.Text()); err == errQuit {
+			break
+		} else if err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+		}
+	}
+	return ExitOK
+}
+
+// execute runs one line of input. Ctrl-C cancels the command without leaving.
+func (r *repl) execute(line string) error {
+	words := strings.Fields(line)
+	if len(words) == 0 {
+		return nil
+	}
+	name, args := strings.ToLower(words[0]), words[1:]
+	if alias, ok := replAliases[name]; ok {
+		name = alias
+	}
+	cmd, ok := replCommands[name]
+	if !ok {
+		// A bare number is a calculation
+		if _, err := strconv.Atoi(name); err != nil {
+			return fmt.Errorf("unknown command %q; type \"help\" for commands", words[0])
+		}
+		cmd, args = replCommands["calc"], words
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	err := cmd.run(r, ctx, args)
+	if errors.Is(err, context.Canceled) {
+		return errors.New("cancelled")
+	}
+	return err
+}
+
+func (r *repl) help(_ context.Context, _ []string) error {
+	names := make([]string, 0, len(replCommands))
+	for name := range replCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w := tabwriter.NewWriter(r.out, 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s\t%s\n", replCommands[name].usage, replCommands[name].summary)
+	}
+	return w.Flush()
+}
+
+func (r *repl) sizes(_ context.Context, args []string) error {
+	if len(args) > 0 {
+		sizes, err := parseSizes(strings.Join(args, ","))
+		if err != nil {
+			return err
+		}
+		if r.optimizer, err = r.newOptimizer(sizes, r.optimizer.Strategy()); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(r.out, "package sizes %s (catalog version %s)\n", formatSizes(r.optimizer.PackageSizes()), r.optimizer.CatalogVersion())
+	return nil
+}
+
+// strategy shows or selects the strategy the optimizer solves with. The optimizer
+// implements a single strategy today, so selecting is only useful in scripts that
+// name it explicitly; the output says so rather than suggesting a choice.
+func (r *repl) strategy(_ context.Context, args []string) error {
+	if len(args) > 1 {
+		return errors.New("usage: strategy [name]")
+	}
+	if len(args) == 1 {
+		if err := parseFormat("strategy", args[0], strategyNames()...); err != nil {
+			return err
+		}
+		next, err := r.newOptimizer(r.optimizer.PackageSizes(), optimizer.Strategy(args[0]))
+		if err != nil {
+			return err
+		}
+		r.optimizer = next
+	}
+
+	if names := strategyNames(); len(names) > 1 {
+		fmt.Fprintf(r.out, "strategy %s (available: %s)\n", r.optimizer.Strategy(), strings.Join(names, ", "))
+	} else {
+		fmt.Fprintf(r.out, "strategy %s (the only strategy available)\n", r.optimizer.Strategy())
+	}
+	return nil
+}
+
+// newOptimizer builds an optimizer for the given sizes and strategy with the
+// session's limits, stock and costs.
+//
+// Returns:
+//   - *domain.Optimizer: the optimizer
+//   - error: if costs are configured but a size has none
+func (r *repl) newOptimizer(sizes []int, strategy optimizer.Strategy) (*domain.Optimizer, error) {
+	return catalog.NewOptimizer(sizes, r.settings, optimizer.WithStrategy(strategy))
+}
+
+// strategyNames lists the names of the strategies the optimizer implements.
+func strategyNames() []string {
+	var names []string
+	for _, strategy := range optimizer.Strategies() {
+		names = append(names, strategy.String())
+	}
+	return names
+}
+
+func (r *repl) calc(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: calc <quantity>")
+	}
+	quantity, err := parseQuantity(args[0])
+	if err != nil {
+		return err
+	}
+	result, err := r.optimizer.OptimizeContext(ctx, quantity)
+	if err != nil {
+		return err
+	}
+	writeResult(r.out, result)
+	return nil
+}
+
+func (r *repl) alternatives(ctx context.Context, args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errors.New("usage: alternatives <quantity> [n]")
+	}
+	quantity, err := parseQuantity(args[0])
+	if err != nil {
+		return err
+	}
+	n := defaultAlternatives
+	if len(args) == 2 {
+		if n, err = strconv.Atoi(args[1]); err != nil || n < 1 {
+			return fmt.Errorf("invalid count %q: must be a positive integer", args[1])
+		}
+	}
+
+	alternatives, err := r.optimizer.Alternatives(ctx, quantity, n)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(r.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tDELIVERED\tOVER\tPACKAGES")
+	for i, result := range alternatives {
+		fmt.Fprintf(w, "%d\t%d\t%d\t%s\n", i+1, result.TotalDelivered, result.OverDelivery, formatPackages(result.Packages))
+	}
+	return w.Flush()
+}
+
+func (r *repl) compare(ctx context.Context, args []string) error {
+	if len(args) < 2 {
+		return errors.New("usage: compare <sizes> <quantity>...")
+	}
+	sizes, err := parseSizes(args[0])
+	if err != nil {
+		return err
+	}
+	other, err := r.newOptimizer(sizes, r.optimizer.Strategy())
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(r.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "QUANTITY\tCURRENT %s\tOTHER %s\n", formatSizes(r.optimizer.PackageSizes()), formatSizes(other.PackageSizes()))
+	var totals [2]int
+	for _, arg := range args[1:] {
+		quantity, err := parseQuantity(arg)
+		if err != nil {
+			return err
+		}
+		cells := make([]string, 2)
+		for i, optimizer := range []*domain.Optimizer{r.optimizer, other} {
+			result, err := optimizer.OptimizeContext(ctx, quantity)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				cells[i] = "error: " + err.Error()
+				continue
+			}
+			totals[i] += result.OverDelivery
+			cells[i] = fmt.Sprintf("%d (+%d) %s", result.TotalDelivered, result.OverDelivery, formatPackages(result.Packages))
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", quantity, cells[0], cells[1])
+	}
+	fmt.Fprintf(w, "total over-delivery\t%d\t%d\n", totals[0], totals[1])
+	return w.Flush()
+}
+
+func (r *repl) gaps(ctx context.Context, args []string) error {
+	if len(args) > 1 {
+		return errors.New("usage: gaps [up-to]")
+	}
+	upTo := defaultGapRange * r.optimizer.PackageSizes()[0]
+	if len(args) == 1 {
+		var err error
+		if upTo, err = parseQuantity(args[0]); err != nil {
+			return err
+		}
+	}
+
+	analysis, err := r.optimizer.AnalyzeGaps(ctx, upTo)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(r.out, "quantities 1-%d with package sizes %s:\n", analysis.UpTo, formatSizes(r.optimizer.PackageSizes()))
+	fmt.Fprintf(r.out, "  delivered exactly:      %d (%.1f%%)\n", analysis.Exact, 100*float64(analysis.Exact)/float64(analysis.UpTo))
+	fmt.Fprintf(r.out, "  average over-delivery:  %.1f\n", analysis.AverageOverDelivery)
+	fmt.Fprintf(r.out, "  worst over-delivery:    %d\n", analysis.MaxOverDelivery)
+	fmt.Fprintf(r.out, "  gaps:                   %d\n", len(analysis.Gaps))
+
+	// List the widest gaps, earliest first among equals
+	widest := append([]domain.Gap(nil), analysis.Gaps...)
+	sort.SliceStable(widest, func(i, j int) bool { return widest[i].MaxOverDelivery() > widest[j].MaxOverDelivery() })
+	if len(widest) > defaultLargestGaps {
+		widest = widest[:defaultLargestGaps]
+	}
+	for _, gap := range widest {
+		fmt.Fprintf(r.out, "    %d-%d delivered as %d (up to +%d)\n", gap.From, gap.To, gap.Delivered, gap.MaxOverDelivery())
+	}
+	return nil
+}
+
+// parseSizes parses a comma-separated list of package sizes and checks that the
+// catalog would accept them.
+func parseSizes(list string) ([]int, error) {
+	var sizes []int
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		size, err := strconv.Atoi(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid package size %q: must be an integer", item)
+		}
+		sizes = append(sizes, size)
+	}
+	if err := catalog.Validate(sizes); err != nil {
+		return nil, err
+	}
+	return sizes, nil
+}
+
+// formatSizes formats package sizes as a list (e.g., "[2000 1000 500 250]").
+func formatSizes(sizes []int) string {
+	return fmt.Sprint(sizes)
+}
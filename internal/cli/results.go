@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+)
+
+// resultWriter writes bulk outcomes in one output format.
+type resultWriter interface {
+	// write writes one outcome
+	write(o outcome) error
+	// close writes anything buffered; the writer must not be used afterwards
+	close() error
+}
+
+// newResultWriter creates the writer for an output format (csv, jsonl or table).
+func newResultWriter(format string, w io.Writer) resultWriter {
+	switch format {
+	case formatJSONL:
+		return &jsonlResults{encoder: json.NewEncoder(w)}
+	case formatTable:
+		return newTableResults(w)
+	}
+	return newCSVResults(w)
+}
+
+// csvHeader is the header row of the CSV output.
+var csvHeader = []string{"order_id", "quantity", "total_delivered", "over_delivery", "packages", "error"}
+
+// csvResults writes one CSV row per order. Failed orders have only the ID, the
+// quantity as written in the input and the error.
+//
+// Example output:
+//
+//	order_id,quantity,total_delivered,over_delivery,packages,error
+//	A-1,1201,1250,49,"1 x 1000, 1 x 250",
+//	A-2,abc,,,,"invalid quantity ""abc"": must be an integer"
+type csvResults struct {
+	writer *csv.Writer
+	// started is true once the header has been written
+	started bool
+}
+
+func newCSVResults(w io.Writer) *csvResults {
+	return &csvResults{writer: csv.NewWriter(w)}
+}
+
+func (r *csvResults) write(o outcome) error {
+	if !r.started {
+		r.started = true
+		if err := r.writer.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+
+	row := []string{o.id, o.rawQuantity, "", "", "", ""}
+	if o.failed() {
+		row[5] = o.err.Error()
+	} else {
+		items, _ := packageList(o.result.Packages)
+		row[2] = strconv.Itoa(o.result.TotalDelivered)
+		row[3] = strconv.Itoa(o.result.OverDelivery)
+		row[4] = strings.Join(items, ", ")
+	}
+	return r.writer.Write(row)
+}
+
+func (r *csvResults) close() error {
+	if !r.started {
+		// Write the header even for an empty input, so the output is valid CSV
+		r.started = true
+		r.writer.Write(csvHeader)
+	}
+	r.writer.Flush()
+	return r.writer.Error()
+}
+
+// bulkRecord is one line of the JSONL output.
+//
+// Example output:
+//
+//	{"line":2,"order_id":"A-1","quantity":1201,"result":{"requested":1201,"total_delivered":1250,"over_delivery":49,"packages":{"1000":1,"250":1}}}
+//	{"line":3,"order_id":"A-2","error":"invalid quantity \"abc\": must be an integer"}
+type bulkRecord struct {
+	// Line is the input line of the order
+	Line int `json:"line"`
+	// OrderID identifies the order
+	OrderID string `json:"order_id"`
+	// Quantity is the requested quantity, absent if it could not be read
+	Quantity *int `json:"quantity,omitempty"`
+	// Result is the solution, in the same form as the API's, absent on error
+	Result *domain.OptimizationResult `json:"result,omitempty"`
+	// Error is why the order failed, absent on success
+	Error string `json:"error,omitempty"`
+}
+
+// jsonlResults writes one JSON object per order.
+type jsonlResults struct {
+	encoder *json.Encoder
+}
+
+func (r *jsonlResults) write(o outcome) error {
+	record := bulkRecord{Line: o.line, OrderID: o.id, Result: o.result}
+	if o.rawQuantity != "" {
+		if quantity, err := strconv.Atoi(o.rawQuantity); err == nil {
+			record.Quantity = &quantity
+		}
+	}
+	if o.failed() {
+		record.Error = o.err.Error()
+	}
+	return r.encoder.Encode(record)
+}
+
+func (r *jsonlResults) close() error {
+	return nil
+}
+
+// tableResults writes an aligned table for people. Column widths depend on every
+// row, so the table is written when the run ends.
+//
+// Example output:
+//
+//	ORDER  QUANTITY  DELIVERED  OVER  PACKAGES
+//	A-1    1201      1250       49    1 x 1000, 1 x 250
+//	A-2    abc       -          -     error: invalid quantity "abc": must be an integer
+type tableResults struct {
+	writer *tabwriter.Writer
+}
+
+func newTableResults(w io.Writer) *tableResults {
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "ORDER\tQUANTITY\tDELIVERED\tOVER\tPACKAGES")
+	return &tableResults{writer: writer}
+}
+
+func (r *tableResults) write(o outcome) error {
+	if o.failed() {
+		_, err := fmt.Fprintf(r.writer, "%s\t%s\t-\t-\terror: %v\n", o.id, o.rawQuantity, o.err)
+		return err
+	}
+	items, _ := packageList(o.result.Packages)
+	_, err := fmt.Fprintf(r.writer, "%s\t%s\t%d\t%d\t%s\n",
+		o.id, o.rawQuantity, o.result.TotalDelivered, o.result.OverDelivery, strings.Join(items, ", "))
+	return err
+}
+
+func (r *tableResults) close() error {
+	return r.writer.Flush()
+}
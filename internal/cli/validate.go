@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/sinaw369/Package-Optimizer/internal/auth"
+	"github.com/sinaw369/Package-Optimizer/internal/catalog"
+	"github.com/sinaw369/Package-Optimizer/internal/config"
+)
+
+// ValidateConfig implements the validate-config command: it loads the configuration
+// the server would use and reports every problem, without starting anything. Besides
+// the settings themselves it checks that the catalog accepts the package sizes and
+// that the API key file, if any, can be loaded.
+//
+// Args:
+//   - args: the arguments after "validate-config" (configuration flags)
+//   - stdout: where the confirmation is written
+//   - stderr: where problems are written
+//
+// Returns:
+//   - int: ExitOK if the configuration is valid, ExitFailure if not, ExitUsage for bad flags
+//
+// Example:
+//
+//	package-optimizer validate-config --config /etc/package-optimizer/config.yaml
+func ValidateConfig(args []string, stdout, stderr io.Writer) int {
+	flags := newFlagSet("validate-config", "", stderr)
+	configFlags := config.RegisterFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return exitCode(err)
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(stderr, "validate-config: unexpected argument %q\n", flags.Arg(0))
+		return ExitUsage
+	}
+
+	cfg, err := configFlags.Load()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitFailure
+	}
+
+	// Check what the server checks when it starts, beyond the settings themselves
+	var problems []error
+	settings := catalog.Settings{Stock: cfg.PackageStock, Costs: cfg.PackageCosts}
+	if _, err := catalog.NewOptimizer(cfg.PackageSizes, settings); err != nil {
+		problems = append(problems, fmt.Errorf("catalog: %w", err))
+	}
+	if cfg.AuthKeysFile != "" {
+		if _, err := auth.LoadKeyring(cfg.AuthKeysFile); err != nil {
+			problems = append(problems, fmt.Errorf("auth.keys_file: %w", err))
+		}
+	}
+	if len(problems) > 0 {
+		fmt.Fprintln(stderr, errors.Join(problems...))
+		return ExitFailure
+	}
+
+	source := "defaults and environment"
+	if cfg.File != "" {
+		source = cfg.File
+	}
+	fmt.Fprintf(stdout, "configuration is valid (%s)\n", source)
+	return ExitOK
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+)
+
+// Version is the release version of the binary. It is set at build time:
+//
+//	go build -ldflags "-X github.com/sinaw369/Package-Optimizer/internal/cli.Version=1.4.0" ./cmd/server
+var Version = "dev"
+
+// BuildInfo describes the running binary.
+type BuildInfo struct {
+	// Version is the release version (see Version)
+	Version string `json:"version"`
+	// Commit is the VCS revision the binary was built from, if known
+	Commit string `json:"commit,omitempty"`
+	// Modified is true if the working tree had uncommitted changes at build time
+	Modified bool `json:"modified,omitempty"`
+	// GoVersion is the Go toolchain that built the binary
+	GoVersion string `json:"go_version"`
+	// Platform is the operating system and architecture (e.g., "linux/amd64")
+	Platform string `json:"platform"`
+}
+
+// ReadBuildInfo returns the version and build information of the running binary.
+// The commit is read from the VCS information the Go toolchain embeds.
+func ReadBuildInfo() BuildInfo {
+	info := BuildInfo{
+		Version:   Version,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				info.Commit = setting.Value
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+	return info
+}
+
+// String formats the build information on one line.
+//
+// Example:
+//
+//	package-optimizer 1.4.0 (commit 6f733ef, go1.22.5, linux/amd64)
+func (b BuildInfo) String() string {
+	commit := "unknown commit"
+	if b.Commit != "" {
+		commit = "commit " + shortCommit(b.Commit)
+		if b.Modified {
+			commit += "+modified"
+		}
+	}
+	return fmt.Sprintf("%s %s (%s, %s, %s)", programName, b.Version, commit, b.GoVersion, b.Platform)
+}
+
+// shortCommit abbreviates a commit hash the way git does.
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
+
+// PrintVersion implements the version command.
+//
+// Args:
+//   - args: the arguments after "version" (--format)
+//   - stdout: where the version is written
+//   - stderr: where errors are written
+//
+// Returns:
+//   - int: ExitOK, or ExitUsage for bad flags
+func PrintVersion(args []string, stdout, stderr io.Writer) int {
+	flags := newFlagSet("version", "", stderr)
+	format := flags.String("format", formatText, "output format: text or json")
+	if err := flags.Parse(args); err != nil {
+		return exitCode(err)
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(stderr, "version: unexpected argument %q\n", flags.Arg(0))
+		return ExitUsage
+	}
+
+	info := ReadBuildInfo()
+	switch *format {
+	case formatText:
+		fmt.Fprintln(stdout, info)
+	case formatJSON:
+		json.NewEncoder(stdout).Encode(info)
+	default:
+		fmt.Fprintf(stderr, "version: unknown format %q: must be text or json\n", *format)
+		return ExitUsage
+	}
+	return ExitOK
+}
@@ -0,0 +1,130 @@
+package coalesce
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// call is a computation in flight, shared by every caller waiting for it.
+type call[V any] struct {
+	// done is closed once result and err are set
+	done chan struct{}
+	// result and err are the outcome of the computation
+	result V
+	err    error
+	// waiters is the number of callers still waiting; guarded by the group's mutex
+	waiters int
+	// cancel stops the computation once no caller is waiting any more
+	cancel context.CancelFunc
+}
+
+// Group coalesces concurrent calls with the same key into a single computation:
+// the first caller starts it, and callers arriving while it runs wait for the same
+// result instead of computing it again. Once the computation finishes, the next call
+// with the key starts a new one; results are not cached (see the cache package).
+//
+// Cancellation is per caller: a caller whose context is cancelled stops waiting and
+// gets ctx.Err(), while the computation continues for the others. The computation's
+// own context is cancelled only when every waiting caller has gone. It carries the
+// values (such as the trace) of the caller that started it.
+//
+// The zero value is ready to use. Group is safe for concurrent use.
+type Group[K comparable, V any] struct {
+	// mu guards calls and the waiter counts
+	mu sync.Mutex
+	// calls holds the computations in flight by key
+	calls map[K]*call[V]
+}
+
+// Do returns the result of fn for key, sharing one execution of fn between all
+// concurrent callers with the same key. fn runs in its own goroutine.
+//
+// Args:
+//   - ctx: the caller's context; cancelling it stops this caller's wait
+//   - key: identifies the computation
+//   - fn: computes the result; it should stop when its context is cancelled
+//
+// Returns:
+//   - V: the result of fn
+//   - bool: true if the result was computed for another caller (the call was coalesced)
+//   - error: the error returned by fn (shared by every waiting caller), or ctx.Err()
+//
+// Example:
+//
+//	var group coalesce.Group[int, *domain.OptimizationResult]
+//	result, shared, err := group.Do(ctx, quantity, func(ctx context.Context) (*domain.OptimizationResult, error) {
+//	    return optimizer.OptimizeContext(ctx, quantity)
+//	})
+func (g *Group[K, V]) Do(ctx context.Context, key K, fn func(context.Context) (V, error)) (V, bool, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[K]*call[V])
+	}
+	c, shared := g.calls[key]
+	if !shared {
+		// Detach the computation from the first caller's cancellation, but keep its values
+		runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		c = &call[V]{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = c
+		go g.run(runCtx, key, c, fn)
+	}
+	c.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.result, shared, c.err
+	case <-ctx.Done():
+		g.leave(key, c)
+		var zero V
+		return zero, shared, ctx.Err()
+	}
+}
+
+// run executes fn and publishes its outcome to the waiting callers.
+// A panic in fn is reported as an error rather than crashing the process, since it
+// happens outside any caller's goroutine.
+func (g *Group[K, V]) run(ctx context.Context, key K, c *call[V], fn func(context.Context) (V, error)) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.err = fmt.Errorf("coalesced call panicked: %v", r)
+		}
+
+		// Later calls start a new computation
+		g.mu.Lock()
+		if g.calls[key] == c {
+			delete(g.calls, key)
+		}
+		g.mu.Unlock()
+
+		c.cancel()
+		close(c.done)
+	}()
+
+	c.result, c.err = fn(ctx)
+}
+
+// leave records that a caller stopped waiting, and cancels the computation if it
+// was the last one. The computation is removed at once so later callers don't join
+// a cancelled computation.
+func (g *Group[K, V]) leave(key K, c *call[V]) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	c.waiters--
+	if c.waiters > 0 {
+		return
+	}
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+	c.cancel()
+}
+
+// InFlight returns the number of computations currently running.
+func (g *Group[K, V]) InFlight() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.calls)
+}
@@ -1,89 +1,614 @@
 package config
 
 import (
+	"flag"
 	"fmt"
+	"io"
+	"net"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
-// Config holds the application configuration loaded from environment variables.
+// Config holds the application configuration.
 // This structure contains all the configurable parameters for the package optimizer service.
+// Each setting can come from a command-line flag, an environment variable, the
+// configuration file or its default, in that order of precedence (see Load).
 type Config struct {
 	// Port is the HTTP server port (e.g., "8080")
 	Port string
+	// GRPCPort is the gRPC server port (e.g., "9090")
+	GRPCPort string
+	// TrustedProxies lists the proxies whose X-Forwarded-For header identifies the client; empty trusts none
+	TrustedProxies []*net.IPNet
+	// WebDevDir is a directory to serve the web UI from instead of the embedded files; empty uses the embedded files
+	WebDevDir string
 	// PackageSizes is a slice of available package sizes for optimization
 	// These are the fixed-size packages that can be used to fulfill orders
 	PackageSizes []int
+	// CatalogName names the entry of Catalogs whose sizes are used as PackageSizes; empty uses catalog.package_sizes
+	CatalogName string
+	// Catalogs holds named package size lists, selected with CatalogName
+	Catalogs map[string][]int
+	// PackageCosts is the price of each package size; nil leaves packings unpriced
+	PackageCosts map[int]float64
+	// PackageStock is the number of packages available per size; nil (or a missing size) is unlimited
+	PackageStock map[int]int
+	// HistoryEnabled controls whether calculations are persisted to the history file
+	HistoryEnabled bool
+	// HistoryPath is the location of the JSON Lines calculation history file
+	HistoryPath string
+	// HistoryMaxFileSize is the size in bytes at which the history file is rotated; 0 never rotates
+	HistoryMaxFileSize int64
+	// HistoryMaxFiles is the number of rotated history files kept
+	HistoryMaxFiles int
+	// JobWorkers is the number of asynchronous jobs computed concurrently
+	JobWorkers int
+	// JobQueueSize is the number of asynchronous jobs that may wait for a worker
+	JobQueueSize int
+	// JobResultTTL is how long finished job results are kept
+	JobResultTTL time.Duration
+	// JobStatePath is where queued jobs are persisted on shutdown
+	JobStatePath string
+	// TracingExporter selects where traces are sent: none, otlp, stdout or file
+	TracingExporter string
+	// TracingOTLPEndpoint is the OTLP collector address (host:port)
+	TracingOTLPEndpoint string
+	// TracingOTLPProtocol is the OTLP transport: http or grpc
+	TracingOTLPProtocol string
+	// TracingOTLPInsecure disables TLS for the OTLP exporter
+	TracingOTLPInsecure bool
+	// TracingOTLPHeaders are sent with every OTLP export (e.g., collector credentials)
+	TracingOTLPHeaders map[string]string
+	// TracingFile is where the file exporter writes spans
+	TracingFile string
+	// TracingSampleRatio is the fraction of new traces that are recorded
+	TracingSampleRatio float64
+	// LogFormat is the log output format: json or text
+	LogFormat string
+	// LogLevel is the minimum level logged: debug, info, warn or error
+	LogLevel string
+	// CORSAllowedOrigins lists the origins allowed to make cross-origin requests ("*" for any)
+	CORSAllowedOrigins []string
+	// CORSAllowedMethods lists the HTTP methods allowed in cross-origin requests
+	CORSAllowedMethods []string
+	// CORSAllowedHeaders lists the request headers allowed in cross-origin requests
+	CORSAllowedHeaders []string
+	// CORSAllowCredentials lets browsers send credentials with cross-origin requests
+	CORSAllowCredentials bool
+	// CORSMaxAge is how long browsers may cache preflight responses
+	CORSMaxAge time.Duration
+	// AuthKeysFile is the JSON file listing API keys; empty disables authentication
+	AuthKeysFile string
+	// MaxQuantity is the largest quantity accepted; 0 means only the overflow guard applies
+	MaxQuantity int
+	// MaxSolveMemory is the largest estimated memory, in bytes, a single solve may need; 0 disables the check
+	MaxSolveMemory int64
+	// CacheSize is the number of results kept in the in-process cache; 0 disables caching
+	CacheSize int
+	// RateLimit is the average number of calculation requests per second allowed per client; 0 disables it
+	RateLimit float64
+	// RateLimitBurst is the number of calculation requests a client may make at once
+	RateLimitBurst int
+	// SolverMemoryLimit is the estimated memory, in bytes, of the solves allowed to run at once; 0 disables it
+	SolverMemoryLimit int64
+	// SolverQueueTimeout is how long a solve may wait for memory before it is rejected
+	SolverQueueTimeout time.Duration
+
+	// WatchInterval is how often the configuration file is checked for changes; 0 disables watching
+	WatchInterval time.Duration
+
+	// File is the configuration file that was read; empty if none was used
+	File string
+	// PrintConfig is set by --print-config: the caller should print the configuration and exit
+	PrintConfig bool
+
+	// values records the effective value of every setting and where it came from
+	values []value
+}
+
+// Sources a setting's value can come from, in increasing order of precedence.
+const (
+	sourceDefault = "default"
+	sourceFile    = "file"
+	sourceEnv     = "env"
+	sourceFlag    = "flag"
+)
+
+// value is the effective raw value of a setting.
+type value struct {
+	// setting is the setting the value belongs to
+	setting *setting
+	// raw is the value as written in its source
+	raw string
+	// source describes where the value came from (e.g., "env MAX_QUANTITY")
+	source string
+}
+
+// settingFlag is the command-line flag of a setting. It keeps the raw value, which
+// is parsed together with the values from the other sources.
+type settingFlag struct {
+	// value is the raw value given on the command line
+	value string
+	// isBool lets boolean settings be given without a value (--history-enabled)
+	isBool bool
+}
+
+// String implements flag.Value.
+func (f *settingFlag) String() string { return f.value }
+
+// Set implements flag.Value.
+func (f *settingFlag) Set(value string) error {
+	f.value = value
+	return nil
+}
+
+// IsBoolFlag implements the flag package's boolFlag interface.
+func (f *settingFlag) IsBoolFlag() bool { return f.isBool }
+
+// FieldError describes an invalid setting.
+type FieldError struct {
+	// Field is the setting's path in the configuration file (e.g., "limits.max_quantity")
+	Field string
+	// Source is where the value came from (e.g., "env MAX_QUANTITY" or "file config.yaml")
+	Source string
+	// Message describes the problem
+	Message string
+}
+
+// Error implements the error interface.
+func (e FieldError) Error() string {
+	if e.Source == "" {
+		return e.Field + ": " + e.Message
+	}
+	return fmt.Sprintf("%s: %s (from %s)", e.Field, e.Message, e.Source)
 }
 
-// Load loads configuration from environment variables.
-// This function reads the PORT and PACKAGE_SIZES environment variables
-// and returns a configured Config struct.
+// ValidationError lists every problem found in the configuration, so they can all
+// be fixed at once instead of one per restart.
+type ValidationError struct {
+	// Errors holds the problems in the order the settings are defined
+	Errors []FieldError
+}
+
+// Error implements the error interface, listing one problem per line.
+func (e *ValidationError) Error() string {
+	problems := "problems"
+	if len(e.Errors) == 1 {
+		problems = "problem"
+	}
+	lines := []string{fmt.Sprintf("invalid configuration (%d %s):", len(e.Errors), problems)}
+	for _, fieldErr := range e.Errors {
+		lines = append(lines, "  - "+fieldErr.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Load loads the configuration from the command line, the environment, the
+// configuration file and the defaults.
+//
+// Every setting (see settings.go) has a path in the configuration file (e.g.,
+// "limits.max_quantity"), an environment variable (MAX_QUANTITY) and a flag
+// (--max-quantity). The value is taken from the first of these that is set:
+// flag, environment variable, configuration file, default.
 //
-// Environment Variables:
-//   - PORT: HTTP server port (default: "8080")
-//   - PACKAGE_SIZES: Comma-separated list of package sizes (default: "250,500,1000,2000")
+// The configuration file is named by --config or CONFIG_FILE and may be YAML
+// (.yaml, .yml), JSON (.json) or TOML (.toml). --print-config sets PrintConfig.
+//
+// Args:
+//   - args: command-line arguments, without the program name
 //
 // Returns:
 //   - *Config: configured application settings
-//   - error: if package sizes are invalid or cannot be parsed
+//   - error: a *ValidationError listing every invalid setting, flag.ErrHelp if help
+//     was requested (usage has been printed), or an error if the flags or the file
+//     cannot be read
 //
 // Example:
 //
-//	export PORT=3000
-//	export PACKAGE_SIZES="100,200,500,1000"
-func Load() (*Config, error) {
-	// Get port from environment variable with default value
-	port := getEnv("PORT", "8080")
-
-	// Get package sizes from environment variable with default value
-	packageSizesStr := getEnv("PACKAGE_SIZES", "250,500,1000,2000")
+//	cfg, err := config.Load(os.Args[1:]) // package-optimizer --config config.yaml --port 3000
+func Load(args []string) (*Config, error) {
+	flags := flag.NewFlagSet("package-optimizer", flag.ContinueOnError)
+	configFlags := RegisterFlags(flags)
+	printConfig := flags.Bool("print-config", false, "print the effective configuration, with secrets redacted, and exit")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument %q", flags.Arg(0))
+	}
 
-	// Parse the package sizes string into a slice of integers
-	packageSizes, err := parsePackageSizes(packageSizesStr)
+	cfg, err := configFlags.Load()
 	if err != nil {
-		return nil, fmt.Errorf("invalid package sizes: %w", err)
+		return nil, err
+	}
+	cfg.PrintConfig = *printConfig
+	return cfg, nil
+}
+
+// Flags are the configuration flags registered on a flag set. Commands with flags
+// of their own register the configuration flags next to them, parse the command
+// line, and then call Load.
+type Flags struct {
+	// set is the flag set the flags are registered on
+	set *flag.FlagSet
+	// configFile is the value of --config
+	configFile *string
+	// values holds the flag of every setting by key
+	values map[string]*settingFlag
+}
+
+// RegisterFlags registers --config and one flag per setting on the flag set.
+//
+// Example:
+//
+//	flags := flag.NewFlagSet("calc", flag.ContinueOnError)
+//	format := flags.String("format", "text", "output format")
+//	configFlags := config.RegisterFlags(flags)
+//	if err := flags.Parse(args); err != nil { ... }
+//	cfg, err := configFlags.Load()
+func RegisterFlags(flags *flag.FlagSet) *Flags {
+	f := &Flags{
+		set:        flags,
+		configFile: flags.String("config", os.Getenv("CONFIG_FILE"), "configuration `file` (YAML, JSON or TOML; env CONFIG_FILE)"),
+		values:     make(map[string]*settingFlag, len(settings)),
+	}
+	for i := range settings {
+		s := &settings[i]
+		f.values[s.key] = &settingFlag{isBool: s.kind == kindBool}
+		flags.Var(f.values[s.key], s.flagName(), fmt.Sprintf("%s (env %s, default %q)", s.usage, s.env, s.def))
+	}
+	return f
+}
+
+// Load loads the configuration, taking flag values from the parsed flag set
+// (see the package-level Load for the sources and their precedence).
+//
+// Returns:
+//   - *Config: configured application settings
+//   - error: a *ValidationError listing every invalid setting, or an error if the
+//     configuration file cannot be read
+func (f *Flags) Load() (*Config, error) {
+	setFlags := make(map[string]bool)
+	f.set.Visit(func(fl *flag.Flag) { setFlags[fl.Name] = true })
+
+	// Read the configuration file, if any; problems with its values are reported
+	// together with every other invalid setting
+	configFile := *f.configFile
+	var fileValues map[string]string
+	var problems []FieldError
+	if configFile != "" {
+		var err error
+		fileValues, problems, err = readFile(configFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Resolve and parse every setting, collecting all problems instead of stopping at the first
+	cfg := &Config{File: configFile}
+	for i := range settings {
+		s := &settings[i]
+		v := value{setting: s, raw: s.def, source: sourceDefault}
+		if raw, ok := fileValues[s.key]; ok {
+			v.raw, v.source = raw, sourceFile+" "+configFile
+		}
+		if raw := os.Getenv(s.env); raw != "" {
+			v.raw, v.source = raw, sourceEnv+" "+s.env
+		}
+		if setFlags[s.flagName()] {
+			v.raw, v.source = f.values[s.key].value, sourceFlag+" --"+s.flagName()
+		}
+		v.raw = strings.TrimSpace(v.raw)
+		cfg.values = append(cfg.values, v)
+
+		if err := s.apply(cfg, v.raw); err != nil {
+			problems = append(problems, FieldError{Field: s.key, Source: v.source, Message: err.Error()})
+		}
+	}
+	problems = append(problems, cfg.validate()...)
+
+	if len(problems) > 0 {
+		return nil, &ValidationError{Errors: problems}
+	}
+	return cfg, nil
+}
+
+// validate checks rules that involve more than one setting, and selects the catalog
+// named by catalog.name.
+func (c *Config) validate() []FieldError {
+	var problems []FieldError
+	if err := c.selectCatalog(); err != nil {
+		problems = append(problems, FieldError{Field: "catalog.name", Source: c.source("catalog.name"), Message: err.Error()})
+	} else if missing := c.missingCost(); missing != 0 {
+		problems = append(problems, FieldError{
+			Field:   "catalog.costs",
+			Source:  c.source("catalog.costs"),
+			Message: fmt.Sprintf("no cost for package size %d", missing),
+		})
+	}
+	if c.CORSAllowCredentials && slices.Contains(c.CORSAllowedOrigins, "*") {
+		// Browsers refuse credentials with a wildcard origin; echoing any origin instead would be unsafe
+		problems = append(problems, FieldError{
+			Field:   "cors.allow_credentials",
+			Source:  c.source("cors.allow_credentials"),
+			Message: "credentials require explicit cors.allowed_origins, not \"*\"",
+		})
+	}
+	return problems
+}
+
+// selectCatalog replaces PackageSizes with the sizes of the catalog named by
+// CatalogName, if one is named; a named catalog takes precedence over
+// catalog.package_sizes.
+//
+// Returns:
+//   - error: if no catalog has that name
+func (c *Config) selectCatalog() error {
+	if c.CatalogName == "" {
+		return nil
+	}
+	sizes, ok := c.Catalogs[c.CatalogName]
+	if !ok {
+		return fmt.Errorf("no catalog named %q in catalogs", c.CatalogName)
+	}
+	c.PackageSizes = slices.Clone(sizes)
+	return nil
+}
+
+// missingCost returns the first package size without a price when costs are
+// configured, or 0 if every size has one.
+func (c *Config) missingCost() int {
+	if len(c.PackageCosts) == 0 {
+		return 0
+	}
+	for _, size := range c.PackageSizes {
+		if _, ok := c.PackageCosts[size]; !ok {
+			return size
+		}
+	}
+	return 0
+}
+
+// source returns where the value of the setting at key came from.
+func (c *Config) source(key string) string {
+	for _, v := range c.values {
+		if v.setting.key == key {
+			return v.source
+		}
 	}
+	return ""
+}
 
-	// Return the configured application settings
-	return &Config{
-		Port:         port,
-		PackageSizes: packageSizes,
-	}, nil
+// Change is a setting whose value differs between two configurations.
+type Change struct {
+	// Field is the setting's path in the configuration file (e.g., "catalog.package_sizes")
+	Field string
+	// Old is the previous value, redacted if the setting is secret
+	Old string
+	// New is the new value, redacted if the setting is secret
+	New string
 }
 
-// getEnv gets an environment variable with a default value.
-// If the environment variable is not set or is empty, it returns the default value.
+// Diff lists the settings whose values differ between two configurations, in the
+// order the settings are defined. Values are compared after normalization, so
+// "250,500" and "250, 500" are equal, and a value that merely moved to another
+// source (e.g., from the environment to the file) is not a change.
 //
 // Args:
-//   - key: the environment variable name
-//   - defaultValue: the default value to return if the environment variable is not set
+//   - previous: the configuration in effect
+//   - next: the candidate configuration
 //
 // Returns:
-//   - string: the environment variable value or the default value
+//   - []Change: the changed settings; empty if nothing changed
 //
 // Example:
 //
-//	port := getEnv("PORT", "8080") // Returns "8080" if PORT is not set
-func getEnv(key, defaultValue string) string {
-	// Check if the environment variable is set
-	if value := os.Getenv(key); value != "" {
-		return value
+//	for _, change := range config.Diff(old, cfg) {
+//	    slog.Info("setting changed", "field", change.Field, "old", change.Old, "new", change.New)
+//	}
+func Diff(previous, next *Config) []Change {
+	var changes []Change
+	for i, v := range next.values {
+		old := previous.values[i]
+		if old.normalized() == v.normalized() {
+			continue
+		}
+		changes = append(changes, Change{Field: v.setting.key, Old: old.display(), New: v.display()})
+	}
+	return changes
+}
+
+// Merge returns a copy of previous with the values of the given settings taken from
+// next. It records a partly applied reload: the settings that were applied come from
+// the new configuration, and the others keep the values still in effect, so they
+// show up again in the next Diff.
+//
+// Args:
+//   - previous: the configuration in effect
+//   - next: a valid candidate configuration
+//   - fields: the settings to take from next (e.g., "catalog.package_sizes")
+//
+// Returns:
+//   - *Config: the merged configuration
+//
+// Example:
+//
+//	current = config.Merge(current, next, []string{"logging.level"})
+func Merge(previous, next *Config, fields []string) *Config {
+	merged := *previous
+	merged.values = slices.Clone(previous.values)
+	for i, v := range next.values {
+		if !slices.Contains(fields, v.setting.key) {
+			continue
+		}
+		// The value was valid in next, so applying it again cannot fail
+		merged.values[i] = v
+		v.setting.apply(&merged, v.raw)
+	}
+	// Settings applied one by one may have reset the sizes of the named catalog
+	merged.selectCatalog()
+	return &merged
+}
+
+// Changed reports whether the setting at field is among the changes.
+func Changed(changes []Change, field string) bool {
+	for _, change := range changes {
+		if change.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Print writes the effective configuration as YAML in the configuration file format,
+// annotating each setting with where its value came from. Secret values are redacted.
+// The output can be saved and used as a configuration file.
+//
+// Args:
+//   - w: where to write the configuration
+//
+// Returns:
+//   - error: if writing fails
+//
+// Example output:
+//
+//	server:
+//	  port: 3000 # flag --port
+//	  grpc_port: 9090 # default
+func (c *Config) Print(w io.Writer) error {
+	// Group the settings into one mapping per section, in definition order
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	sections := make(map[string]*yaml.Node)
+	for _, v := range c.values {
+		valueNode := v.node()
+		valueNode.LineComment = v.source
+
+		// Settings outside any section (e.g., catalogs) sit at the top level
+		section, name, ok := strings.Cut(v.setting.key, ".")
+		if !ok {
+			root.Content = append(root.Content, stringNode(section), valueNode)
+			continue
+		}
+		sectionNode, ok := sections[section]
+		if !ok {
+			sectionNode = &yaml.Node{Kind: yaml.MappingNode}
+			sections[section] = sectionNode
+			root.Content = append(root.Content, stringNode(section), sectionNode)
+		}
+		sectionNode.Content = append(sectionNode.Content, stringNode(name), valueNode)
 	}
-	// Return the default value if the environment variable is not set
-	return defaultValue
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// parseList splits a comma-separated setting into its trimmed, non-empty items.
+//
+// Args:
+//   - value: comma-separated string (e.g., "GET, POST")
+//
+// Returns:
+//   - []string: the items in order (e.g., []string{"GET", "POST"})
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseOrigins parses a comma-separated list of CORS origins.
+// Each origin must be "*" or a scheme and host (e.g., "https://shop.example.com:8443")
+// without a path, because browsers send origins in exactly that form.
+//
+// Args:
+//   - value: comma-separated origins
+//
+// Returns:
+//   - []string: the validated origins
+//   - error: if the list is empty or an origin is malformed
+//
+// Example:
+//
+//	origins, err := parseOrigins("https://a.example.com, http://localhost:3000")
+func parseOrigins(value string) ([]string, error) {
+	origins := parseList(value)
+	if len(origins) == 0 {
+		return nil, fmt.Errorf("at least one origin is required")
+	}
+
+	for i, origin := range origins {
+		if origin == "*" {
+			continue
+		}
+		// Accept a trailing slash, which is a common copy-paste artifact
+		origin = strings.TrimSuffix(origin, "/")
+		scheme, host, ok := strings.Cut(origin, "://")
+		if !ok || (scheme != "http" && scheme != "https") || host == "" || strings.ContainsAny(host, "/?#") {
+			return nil, fmt.Errorf("origin %q must look like https://host[:port]", origins[i])
+		}
+		origins[i] = origin
+	}
+	return origins, nil
+}
+
+// parseCIDRs parses a comma-separated list of IP addresses and CIDR ranges.
+// A plain address is a range holding only that address.
+//
+// Args:
+//   - value: comma-separated addresses and ranges; empty gives no ranges
+//
+// Returns:
+//   - []*net.IPNet: the parsed ranges
+//   - error: if an entry is neither an address nor a CIDR range
+//
+// Example:
+//
+//	proxies, err := parseCIDRs("10.0.0.0/8, 192.0.2.10")
+func parseCIDRs(value string) ([]*net.IPNet, error) {
+	var ranges []*net.IPNet
+	for _, item := range parseList(value) {
+		if !strings.Contains(item, "/") {
+			ip := net.ParseIP(item)
+			if ip == nil {
+				return nil, fmt.Errorf("%q is not an IP address or CIDR range", item)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			ranges = append(ranges, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(item)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an IP address or CIDR range", item)
+		}
+		ranges = append(ranges, ipNet)
+	}
+	return ranges, nil
 }
 
 // parsePackageSizes parses a comma-separated string of package sizes into a slice of integers.
-// This function validates that all package sizes are positive integers.
+// This function validates that all package sizes are distinct positive integers.
 //
 // Args:
 //   - sizesStr: comma-separated string of package sizes (e.g., "250,500,1000,2000")
 //
 // Returns:
 //   - []int: slice of validated package sizes
-//   - error: if the string is empty, contains invalid numbers, or has non-positive values
+//   - error: if the string is empty, contains invalid numbers, or has non-positive or repeated values
 //
 // Example:
 //
@@ -97,6 +622,7 @@ func parsePackageSizes(sizesStr string) ([]int, error) {
 	// Split the comma-separated string into individual size strings
 	sizes := strings.Split(sizesStr, ",")
 	result := make([]int, 0, len(sizes))
+	seen := make(map[int]bool, len(sizes))
 
 	// Process each package size string
 	for _, sizeStr := range sizes {
@@ -119,6 +645,12 @@ func parsePackageSizes(sizesStr string) ([]int, error) {
 			return nil, fmt.Errorf("package size must be positive, got %d", size)
 		}
 
+		// Reject repeated sizes, which are almost always a typo in the list
+		if seen[size] {
+			return nil, fmt.Errorf("duplicate package size %d", size)
+		}
+		seen[size] = true
+
 		// Add the valid package size to the result slice
 		result = append(result, size)
 	}
@@ -0,0 +1,323 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// redacted replaces secret values in printed configurations.
+const redacted = "[REDACTED]"
+
+// readFile reads a configuration file and flattens it into raw setting values keyed
+// by their path (e.g., "limits.max_quantity"), the same form environment variables
+// and flags have. The format is chosen by the file extension.
+//
+// Example (YAML):
+//
+//	catalog:
+//	  package_sizes: [250, 500, 1000, 2000]
+//	limits:
+//	  max_quantity: 1000000
+//
+// Args:
+//   - path: the configuration file (.yaml, .yml, .json or .toml)
+//
+// Returns:
+//   - map[string]string: the raw values of the settings present in the file
+//   - []FieldError: unknown settings and values of the wrong shape
+//   - error: if the file cannot be read or parsed
+func readFile(path string) (map[string]string, []FieldError, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read config file: %w", err)
+	}
+
+	// Decode into a generic document; values are validated per setting afterwards
+	var doc map[string]any
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &doc)
+	case ".json":
+		// Keep numbers as written, so large integers are not rounded through float64
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err = decoder.Decode(&doc)
+	case ".toml":
+		_, err = toml.Decode(string(data), &doc)
+	default:
+		return nil, nil, fmt.Errorf("config file %s: unsupported format %q (use .yaml, .yml, .json or .toml)", path, ext)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	var problems []FieldError
+	flatten("", doc, sourceFile+" "+path, values, &problems)
+	return values, problems, nil
+}
+
+// flatten walks a decoded configuration document, storing the raw value of every
+// setting it finds and recording unknown keys as problems.
+func flatten(prefix string, node map[string]any, source string, values map[string]string, problems *[]FieldError) {
+	// Visit keys in order so problems are reported deterministically
+	keys := make([]string, 0, len(node))
+	for key := range node {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		if s := lookupSetting(path); s != nil {
+			raw, set, err := fileValue(s.kind, node[key])
+			if err != nil {
+				*problems = append(*problems, FieldError{Field: path, Source: source, Message: err.Error()})
+			} else if set {
+				values[path] = raw
+			}
+			continue
+		}
+
+		section, ok := node[key].(map[string]any)
+		if !ok || !isSection(path) {
+			*problems = append(*problems, FieldError{Field: path, Source: source, Message: "unknown setting"})
+			continue
+		}
+		flatten(path, section, source, values, problems)
+	}
+}
+
+// isSection reports whether any setting lives under the given path.
+func isSection(path string) bool {
+	for i := range settings {
+		if strings.HasPrefix(settings[i].key, path+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// fileValue converts a decoded configuration file value into the raw string form
+// the setting parsers accept.
+//
+// Returns:
+//   - string: the raw value
+//   - bool: false if the value is null, which leaves the setting unset
+//   - error: if the value has the wrong shape (e.g., a table for a number)
+func fileValue(k kind, v any) (string, bool, error) {
+	if v == nil {
+		return "", false, nil
+	}
+
+	switch value := v.(type) {
+	case []any:
+		if k != kindList {
+			return "", false, fmt.Errorf("must be a single value, not a list")
+		}
+		items := make([]string, len(value))
+		for i, item := range value {
+			raw, ok := scalarString(item)
+			if !ok {
+				return "", false, fmt.Errorf("list items must be single values")
+			}
+			items[i] = raw
+		}
+		return strings.Join(items, ","), true, nil
+	case map[string]any:
+		return tableValue(k, value)
+	case map[any]any:
+		// YAML decodes tables with non-string keys (e.g., package sizes) this way
+		table := make(map[string]any, len(value))
+		for name, item := range value {
+			key, ok := scalarString(name)
+			if !ok {
+				return "", false, fmt.Errorf("table keys must be single values")
+			}
+			table[key] = item
+		}
+		return tableValue(k, table)
+	}
+
+	raw, ok := scalarString(v)
+	if !ok {
+		return "", false, fmt.Errorf("unsupported value %v", v)
+	}
+	return raw, true, nil
+}
+
+// tableValue converts a decoded table into the raw name=value form of a kindMap or
+// kindListMap setting. The arrays of a kindListMap table become space-separated items.
+func tableValue(k kind, table map[string]any) (string, bool, error) {
+	if k != kindMap && k != kindListMap {
+		return "", false, fmt.Errorf("must be a single value, not a table")
+	}
+
+	pairs := make(map[string]string, len(table))
+	for name, item := range table {
+		if list, ok := item.([]any); ok && k == kindListMap {
+			items := make([]string, len(list))
+			for i, listItem := range list {
+				if items[i], ok = scalarString(listItem); !ok {
+					return "", false, fmt.Errorf("list items must be single values")
+				}
+			}
+			pairs[name] = strings.Join(items, " ")
+			continue
+		}
+		raw, ok := scalarString(item)
+		if !ok {
+			if k == kindListMap {
+				return "", false, fmt.Errorf("table values must be lists of single values")
+			}
+			return "", false, fmt.Errorf("table values must be single values")
+		}
+		pairs[name] = raw
+	}
+	return formatMap(pairs), true, nil
+}
+
+// scalarString formats a decoded scalar (string, number or boolean) as a string.
+func scalarString(v any) (string, bool) {
+	switch value := v.(type) {
+	case string:
+		return value, true
+	case bool:
+		return strconv.FormatBool(value), true
+	case int:
+		return strconv.Itoa(value), true
+	case int64:
+		return strconv.FormatInt(value, 10), true
+	case uint64:
+		return strconv.FormatUint(value, 10), true
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), true
+	case json.Number:
+		return value.String(), true
+	}
+	return "", false
+}
+
+// normalized returns the value in a canonical form, so equal values written
+// differently (spacing, "1" for "true", ...) compare equal.
+func (v value) normalized() string {
+	switch v.setting.kind {
+	case kindList:
+		return strings.Join(parseList(v.raw), ",")
+	case kindMap:
+		pairs, _ := parseMap(v.raw)
+		return formatMap(pairs)
+	case kindListMap:
+		pairs, _ := parseMap(v.raw)
+		for name, items := range pairs {
+			pairs[name] = strings.Join(strings.Fields(items), " ")
+		}
+		return formatMap(pairs)
+	case kindBool:
+		b, _ := strconv.ParseBool(v.raw)
+		return strconv.FormatBool(b)
+	case kindDuration:
+		d, _ := parseDuration(v.raw, true)
+		return d.String()
+	case kindInt:
+		n, _ := strconv.ParseInt(v.raw, 10, 64)
+		return strconv.FormatInt(n, 10)
+	case kindFloat:
+		f, _ := strconv.ParseFloat(v.raw, 64)
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return v.raw
+}
+
+// display returns the normalized value for logs, with secrets redacted.
+func (v value) display() string {
+	if v.setting.secret && v.raw != "" {
+		return redacted
+	}
+	return v.normalized()
+}
+
+// node returns the YAML node printing the value, typed by the setting's kind and
+// with secrets redacted. The names in a secret table stay visible.
+func (v value) node() *yaml.Node {
+	s := v.setting
+	switch s.kind {
+	case kindList:
+		list := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+		for _, item := range parseList(v.raw) {
+			if s.secret {
+				item = redacted
+			}
+			list.Content = append(list.Content, scalarNode(item))
+		}
+		return list
+	case kindMap:
+		pairs, _ := parseMap(v.raw)
+		table := &yaml.Node{Kind: yaml.MappingNode, Style: yaml.FlowStyle}
+		names := make([]string, 0, len(pairs))
+		for name := range pairs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			item := pairs[name]
+			if s.secret {
+				item = redacted
+			}
+			table.Content = append(table.Content, stringNode(name), stringNode(item))
+		}
+		return table
+	case kindListMap:
+		pairs, _ := parseMap(v.raw)
+		table := &yaml.Node{Kind: yaml.MappingNode, Style: yaml.FlowStyle}
+		for _, name := range sortedNames(pairs) {
+			list := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+			for _, item := range strings.Fields(pairs[name]) {
+				list.Content = append(list.Content, scalarNode(item))
+			}
+			table.Content = append(table.Content, stringNode(name), list)
+		}
+		return table
+	}
+
+	if s.secret && v.raw != "" {
+		return stringNode(redacted)
+	}
+	switch s.kind {
+	case kindInt:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: v.raw}
+	case kindFloat:
+		// Untagged, so whole numbers print as "1" rather than "!!float 1"
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: v.raw}
+	case kindBool:
+		b, _ := strconv.ParseBool(v.raw)
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(b)}
+	}
+	return stringNode(v.raw)
+}
+
+// scalarNode returns a YAML scalar that is an integer if the value is one, and a string otherwise.
+func scalarNode(value string) *yaml.Node {
+	if _, err := strconv.Atoi(value); err == nil {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: value}
+	}
+	return stringNode(value)
+}
+
+// stringNode returns a YAML string scalar, quoted where YAML requires it.
+func stringNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
@@ -0,0 +1,441 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// kind is the type of a setting's value. It decides how values from the
+// configuration file are read and how the value is printed.
+type kind int
+
+const (
+	kindString kind = iota
+	kindInt
+	kindFloat
+	kindBool
+	kindDuration
+	// kindList values are comma-separated lists, or arrays in the configuration file
+	kindList
+	// kindMap values are comma-separated name=value pairs, or tables in the configuration file
+	kindMap
+	// kindListMap values are comma-separated name=list pairs with space-separated list
+	// items, or tables of arrays in the configuration file
+	kindListMap
+)
+
+// setting describes one configuration setting and everywhere it can be set.
+type setting struct {
+	// key is the setting's path in the configuration file (e.g., "limits.max_quantity")
+	key string
+	// env is the environment variable that sets it (e.g., "MAX_QUANTITY")
+	env string
+	// def is the default value
+	def string
+	// kind is the type of the value
+	kind kind
+	// secret values are redacted when the configuration is printed
+	secret bool
+	// usage describes the setting in --help
+	usage string
+	// apply parses and validates the value and stores it in the configuration
+	apply func(cfg *Config, value string) error
+}
+
+// flagName returns the command-line flag for the setting, derived from its
+// environment variable (MAX_QUANTITY becomes max-quantity).
+func (s *setting) flagName() string {
+	return strings.ReplaceAll(strings.ToLower(s.env), "_", "-")
+}
+
+// lookupSetting returns the setting with the given configuration file path, or nil.
+func lookupSetting(key string) *setting {
+	for i := range settings {
+		if settings[i].key == key {
+			return &settings[i]
+		}
+	}
+	return nil
+}
+
+// settings lists every configuration setting. The order is the order of --help,
+// of --print-config and of validation errors.
+var settings = []setting{
+	// Servers
+	{key: "server.port", env: "PORT", def: "8080", kind: kindInt, usage: "HTTP server port",
+		apply: func(c *Config, v string) (err error) { c.Port, err = parsePort(v); return }},
+	{key: "server.grpc_port", env: "GRPC_PORT", def: "9090", kind: kindInt, usage: "gRPC server port",
+		apply: func(c *Config, v string) (err error) { c.GRPCPort, err = parsePort(v); return }},
+	{key: "server.trusted_proxies", env: "TRUSTED_PROXIES", def: "", kind: kindList, usage: "comma-separated proxy addresses or CIDR ranges whose X-Forwarded-For is trusted for client IPs; empty uses the connection address",
+		apply: func(c *Config, v string) (err error) { c.TrustedProxies, err = parseCIDRs(v); return }},
+
+	// Web UI
+	{key: "server.web_dev_dir", env: "WEB_DEV_DIR", def: "", kind: kindString, usage: "serve the web UI from this directory, re-read on every request, instead of the embedded copy (for development)",
+		apply: func(c *Config, v string) error { c.WebDevDir = v; return nil }},
+
+	// Configuration file
+	{key: "config.watch_interval", env: "CONFIG_WATCH_INTERVAL", def: "5s", kind: kindDuration, usage: "how often the configuration file is checked for changes, 0 to disable",
+		apply: func(c *Config, v string) (err error) { c.WatchInterval, err = parseDuration(v, true); return }},
+
+	// Package catalog
+	// The sizes on offer come from catalog.package_sizes, or from the entry of catalogs
+	// named by catalog.name (see Config.selectCatalog). Costs and stock are keyed by
+	// size and apply to whichever catalog is in effect, including one set with
+	// PUT /api/catalog; entries for sizes the catalog doesn't offer are ignored.
+	{key: "catalog.package_sizes", env: "PACKAGE_SIZES", def: "250,500,1000,2000", kind: kindList, usage: "comma-separated package sizes",
+		apply: func(c *Config, v string) (err error) { c.PackageSizes, err = parsePackageSizes(v); return }},
+	{key: "catalog.name", env: "CATALOG", def: "", kind: kindString, usage: "use the package sizes of this entry of catalogs instead of catalog.package_sizes; empty uses catalog.package_sizes",
+		apply: func(c *Config, v string) error { c.CatalogName = v; return nil }},
+	{key: "catalog.costs", env: "PACKAGE_COSTS", def: "", kind: kindMap, usage: "comma-separated size=price pairs; when set, every size needs a price and the cheapest packing wins among those with the least over-delivery",
+		apply: func(c *Config, v string) (err error) { c.PackageCosts, err = parseCosts(v); return }},
+	{key: "catalog.stock", env: "PACKAGE_STOCK", def: "", kind: kindMap, usage: "comma-separated size=count pairs limiting the packages of a size a packing may use; sizes not listed are unlimited",
+		apply: func(c *Config, v string) (err error) { c.PackageStock, err = parseStock(v); return }},
+	{key: "catalogs", env: "CATALOGS", def: "", kind: kindListMap, usage: "named package size lists selectable with catalog.name, as comma-separated name=size size ... entries",
+		apply: func(c *Config, v string) (err error) { c.Catalogs, err = parseCatalogs(v); return }},
+
+	// Calculation history
+	{key: "history.enabled", env: "HISTORY_ENABLED", def: "true", kind: kindBool, usage: "persist every calculation to the history file",
+		apply: func(c *Config, v string) (err error) { c.HistoryEnabled, err = parseBool(v); return }},
+	{key: "history.path", env: "HISTORY_PATH", def: "data/history.jsonl", kind: kindString, usage: "calculation history file",
+		apply: func(c *Config, v string) (err error) { c.HistoryPath, err = parseNonEmpty(v); return }},
+	{key: "history.max_file_mb", env: "HISTORY_MAX_FILE_MB", def: "64", kind: kindInt, usage: "size at which the history file is rotated, 0 to never rotate",
+		apply: func(c *Config, v string) (err error) { c.HistoryMaxFileSize, err = parseMegabytes(v); return }},
+	{key: "history.max_files", env: "HISTORY_MAX_FILES", def: "4", kind: kindInt, usage: "number of rotated history files kept; older ones are deleted",
+		apply: func(c *Config, v string) (err error) { c.HistoryMaxFiles, err = parseInt(v, 0); return }},
+
+	// Asynchronous jobs
+	{key: "jobs.workers", env: "JOB_WORKERS", def: strconv.Itoa(runtime.NumCPU()), kind: kindInt, usage: "number of jobs computed concurrently",
+		apply: func(c *Config, v string) (err error) { c.JobWorkers, err = parseInt(v, 1); return }},
+	{key: "jobs.queue_size", env: "JOB_QUEUE_SIZE", def: "100", kind: kindInt, usage: "number of jobs that may wait for a worker",
+		apply: func(c *Config, v string) (err error) { c.JobQueueSize, err = parseInt(v, 1); return }},
+	{key: "jobs.result_ttl", env: "JOB_RESULT_TTL", def: "15m", kind: kindDuration, usage: "how long finished job results are kept",
+		apply: func(c *Config, v string) (err error) { c.JobResultTTL, err = parseDuration(v, false); return }},
+	{key: "jobs.state_path", env: "JOB_STATE_PATH", def: "data/jobs.json", kind: kindString, usage: "where queued jobs are persisted on shutdown (empty disables persistence)",
+		apply: func(c *Config, v string) error { c.JobStatePath = v; return nil }},
+
+	// Tracing
+	{key: "tracing.exporter", env: "TRACING_EXPORTER", def: "none", kind: kindString, usage: "trace exporter: none, otlp, stdout or file",
+		apply: func(c *Config, v string) (err error) {
+			c.TracingExporter, err = parseOneOf(v, "none", "otlp", "stdout", "file")
+			return
+		}},
+	{key: "tracing.otlp_endpoint", env: "TRACING_OTLP_ENDPOINT", def: "localhost:4318", kind: kindString, usage: "OTLP collector address (host:port)",
+		apply: func(c *Config, v string) error { c.TracingOTLPEndpoint = v; return nil }},
+	{key: "tracing.otlp_protocol", env: "TRACING_OTLP_PROTOCOL", def: "http", kind: kindString, usage: "OTLP transport: http or grpc",
+		apply: func(c *Config, v string) (err error) {
+			c.TracingOTLPProtocol, err = parseOneOf(v, "http", "grpc")
+			return
+		}},
+	{key: "tracing.otlp_insecure", env: "TRACING_OTLP_INSECURE", def: "true", kind: kindBool, usage: "disable TLS for the OTLP exporter",
+		apply: func(c *Config, v string) (err error) { c.TracingOTLPInsecure, err = parseBool(v); return }},
+	{key: "tracing.otlp_headers", env: "TRACING_OTLP_HEADERS", def: "", kind: kindMap, secret: true, usage: "comma-separated name=value headers sent to the OTLP collector",
+		apply: func(c *Config, v string) (err error) { c.TracingOTLPHeaders, err = parseMap(v); return }},
+	{key: "tracing.file", env: "TRACING_FILE", def: "data/traces.jsonl", kind: kindString, usage: "file written by the file exporter",
+		apply: func(c *Config, v string) error { c.TracingFile = v; return nil }},
+	{key: "tracing.sample_ratio", env: "TRACING_SAMPLE_RATIO", def: "1", kind: kindFloat, usage: "fraction of new traces to record, 0 to 1",
+		apply: func(c *Config, v string) (err error) { c.TracingSampleRatio, err = parseRatio(v); return }},
+
+	// Logging
+	{key: "logging.format", env: "LOG_FORMAT", def: "text", kind: kindString, usage: "log output format: json or text",
+		apply: func(c *Config, v string) (err error) { c.LogFormat, err = parseOneOf(v, "json", "text"); return }},
+	{key: "logging.level", env: "LOG_LEVEL", def: "info", kind: kindString, usage: "minimum log level: debug, info, warn or error",
+		apply: func(c *Config, v string) (err error) {
+			c.LogLevel, err = parseOneOf(v, "debug", "info", "warn", "error")
+			return
+		}},
+
+	// CORS
+	{key: "cors.allowed_origins", env: "CORS_ALLOWED_ORIGINS", def: "*", kind: kindList, usage: `comma-separated origins allowed to call the API, or "*"`,
+		apply: func(c *Config, v string) (err error) { c.CORSAllowedOrigins, err = parseOrigins(v); return }},
+	{key: "cors.allowed_methods", env: "CORS_ALLOWED_METHODS", def: "GET,POST,PUT,DELETE,OPTIONS", kind: kindList, usage: "comma-separated methods allowed cross-origin",
+		apply: func(c *Config, v string) error {
+			c.CORSAllowedMethods = parseList(strings.ToUpper(v))
+			if len(c.CORSAllowedMethods) == 0 {
+				return fmt.Errorf("at least one method is required")
+			}
+			return nil
+		}},
+	{key: "cors.allowed_headers", env: "CORS_ALLOWED_HEADERS", def: "Content-Type,Authorization,X-API-Key,X-Request-ID,If-None-Match", kind: kindList, usage: "comma-separated request headers allowed cross-origin",
+		apply: func(c *Config, v string) error { c.CORSAllowedHeaders = parseList(v); return nil }},
+	{key: "cors.allow_credentials", env: "CORS_ALLOW_CREDENTIALS", def: "false", kind: kindBool, usage: "allow cookies and HTTP authentication cross-origin",
+		apply: func(c *Config, v string) (err error) { c.CORSAllowCredentials, err = parseBool(v); return }},
+	{key: "cors.max_age", env: "CORS_MAX_AGE", def: "10m", kind: kindDuration, usage: "how long browsers may cache preflight responses",
+		apply: func(c *Config, v string) (err error) { c.CORSMaxAge, err = parseDuration(v, true); return }},
+
+	// Authentication
+	{key: "auth.keys_file", env: "AUTH_KEYS_FILE", def: "", kind: kindString, usage: "JSON file listing API keys (empty disables authentication)",
+		apply: func(c *Config, v string) error { c.AuthKeysFile = v; return nil }},
+
+	// Limits
+	{key: "limits.max_quantity", env: "MAX_QUANTITY", def: "10000000", kind: kindInt, usage: "largest quantity accepted, 0 for no limit",
+		apply: func(c *Config, v string) (err error) { c.MaxQuantity, err = parseInt(v, 0); return }},
+	{key: "limits.max_solve_memory_mb", env: "MAX_SOLVE_MEMORY_MB", def: "1024", kind: kindInt, usage: "largest estimated memory a single solve may need, 0 for no limit",
+		apply: func(c *Config, v string) (err error) { c.MaxSolveMemory, err = parseMegabytes(v); return }},
+	{key: "limits.rate_limit_rps", env: "RATE_LIMIT_RPS", def: "10", kind: kindFloat, usage: "average calculation requests per second per client, 0 to disable",
+		apply: func(c *Config, v string) error {
+			rate, err := strconv.ParseFloat(v, 64)
+			if err != nil || rate < 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+				return fmt.Errorf("must be a non-negative number, got %q", v)
+			}
+			c.RateLimit = rate
+			return nil
+		}},
+	{key: "limits.rate_limit_burst", env: "RATE_LIMIT_BURST", def: "20", kind: kindInt, usage: "calculation requests a client may make at once",
+		apply: func(c *Config, v string) (err error) { c.RateLimitBurst, err = parseInt(v, 1); return }},
+	{key: "limits.solver_memory_limit_mb", env: "SOLVER_MEMORY_LIMIT_MB", def: "1024", kind: kindInt, usage: "estimated memory of the solves allowed to run at once, 0 to disable",
+		apply: func(c *Config, v string) (err error) { c.SolverMemoryLimit, err = parseMegabytes(v); return }},
+	{key: "limits.solver_queue_timeout", env: "SOLVER_QUEUE_TIMEOUT", def: "2s", kind: kindDuration, usage: "how long a solve may wait for memory before it is rejected",
+		apply: func(c *Config, v string) (err error) { c.SolverQueueTimeout, err = parseDuration(v, true); return }},
+
+	// Result cache
+	{key: "cache.size", env: "CACHE_SIZE", def: "10000", kind: kindInt, usage: "number of results kept in the in-process cache, 0 to disable",
+		apply: func(c *Config, v string) (err error) { c.CacheSize, err = parseInt(v, 0); return }},
+}
+
+// parsePort validates a TCP port number.
+//
+// Returns:
+//   - string: the port, as used in listen addresses
+//   - error: if the value is not a number between 1 and 65535
+func parsePort(value string) (string, error) {
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return "", fmt.Errorf("must be a port number between 1 and 65535, got %q", value)
+	}
+	return strconv.Itoa(port), nil
+}
+
+// parseInt parses an integer setting and validates that it is at least min.
+//
+// Args:
+//   - value: the string value to parse
+//   - min: the smallest accepted value (0 or 1)
+//
+// Returns:
+//   - int: the parsed value
+//   - error: if the value is not an integer of at least min
+func parseInt(value string, min int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < min {
+		if min > 0 {
+			return 0, fmt.Errorf("must be a positive integer, got %q", value)
+		}
+		return 0, fmt.Errorf("must be a non-negative integer, got %q", value)
+	}
+	return n, nil
+}
+
+// parseMegabytes parses a non-negative number of mebibytes and returns it in bytes.
+func parseMegabytes(value string) (int64, error) {
+	mb, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || mb < 0 || mb > math.MaxInt64>>20 {
+		return 0, fmt.Errorf("must be a non-negative number of megabytes, got %q", value)
+	}
+	return mb << 20, nil
+}
+
+// parseBool parses a boolean setting ("true", "false", "1", "0", ...).
+func parseBool(value string) (bool, error) {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("must be true or false, got %q", value)
+	}
+	return b, nil
+}
+
+// parseDuration parses a duration setting such as "15m" or "2s".
+//
+// Args:
+//   - value: the string value to parse
+//   - allowZero: whether 0 is accepted (negative durations never are)
+func parseDuration(value string, allowZero bool) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	switch {
+	case err != nil:
+		return 0, fmt.Errorf("must be a duration such as \"30s\" or \"15m\", got %q", value)
+	case d < 0 || (d == 0 && !allowZero):
+		if allowZero {
+			return 0, fmt.Errorf("must be a non-negative duration, got %q", value)
+		}
+		return 0, fmt.Errorf("must be a positive duration, got %q", value)
+	}
+	return d, nil
+}
+
+// parseRatio parses a number between 0 and 1.
+func parseRatio(value string) (float64, error) {
+	ratio, err := strconv.ParseFloat(value, 64)
+	if err != nil || ratio < 0 || ratio > 1 {
+		return 0, fmt.Errorf("must be a number between 0 and 1, got %q", value)
+	}
+	return ratio, nil
+}
+
+// parseNonEmpty validates that a setting is not empty.
+func parseNonEmpty(value string) (string, error) {
+	if value == "" {
+		return "", fmt.Errorf("must not be empty")
+	}
+	return value, nil
+}
+
+// parseOneOf validates that a setting is one of the allowed values, ignoring case.
+//
+// Example:
+//
+//	format, err := parseOneOf("JSON", "json", "text") // Returns "json", nil
+func parseOneOf(value string, allowed ...string) (string, error) {
+	lower := strings.ToLower(value)
+	for _, option := range allowed {
+		if lower == option {
+			return option, nil
+		}
+	}
+	return "", fmt.Errorf("must be one of %s, got %q", strings.Join(allowed, ", "), value)
+}
+
+// parseMap parses comma-separated name=value pairs.
+//
+// Example:
+//
+//	headers, err := parseMap("x-api-key=secret, x-team=ops") // map[x-api-key:secret x-team:ops]
+func parseMap(value string) (map[string]string, error) {
+	items := parseList(value)
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	pairs := make(map[string]string, len(items))
+	for _, item := range items {
+		name, val, ok := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			// Don't echo the item: it may hold a secret
+			return nil, fmt.Errorf("must be comma-separated name=value pairs")
+		}
+		pairs[name] = strings.TrimSpace(val)
+	}
+	return pairs, nil
+}
+
+// formatMap formats name=value pairs as parseMap reads them, sorted by name.
+func formatMap(pairs map[string]string) string {
+	names := sortedNames(pairs)
+	items := make([]string, len(names))
+	for i, name := range names {
+		items[i] = name + "=" + pairs[name]
+	}
+	return strings.Join(items, ",")
+}
+
+// sortedNames returns the names of the pairs in order, so they are read and reported deterministically.
+func sortedNames(pairs map[string]string) []string {
+	names := make([]string, 0, len(pairs))
+	for name := range pairs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseCosts parses comma-separated size=price pairs.
+//
+// Returns:
+//   - map[int]float64: the price of each size; nil if the value is empty
+//   - error: if a size is not a positive integer or a price is not a non-negative number
+//
+// Example:
+//
+//	costs, err := parseCosts("250=1, 500=1.8") // map[250:1 500:1.8]
+func parseCosts(value string) (map[int]float64, error) {
+	pairs, err := parseMap(value)
+	if err != nil || len(pairs) == 0 {
+		return nil, err
+	}
+
+	costs := make(map[int]float64, len(pairs))
+	for _, name := range sortedNames(pairs) {
+		raw := pairs[name]
+		size, err := parseSizeKey(name)
+		if err != nil {
+			return nil, err
+		}
+		cost, err := strconv.ParseFloat(raw, 64)
+		if err != nil || cost < 0 || math.IsInf(cost, 0) || math.IsNaN(cost) {
+			return nil, fmt.Errorf("cost of package size %d must be a non-negative number, got %q", size, raw)
+		}
+		costs[size] = cost
+	}
+	return costs, nil
+}
+
+// parseStock parses comma-separated size=count pairs.
+//
+// Returns:
+//   - map[int]int: the packages available per size; nil if the value is empty
+//   - error: if a size is not a positive integer or a count is not a non-negative integer
+//
+// Example:
+//
+//	stock, err := parseStock("2000=10, 1000=0") // map[1000:0 2000:10]
+func parseStock(value string) (map[int]int, error) {
+	pairs, err := parseMap(value)
+	if err != nil || len(pairs) == 0 {
+		return nil, err
+	}
+
+	stock := make(map[int]int, len(pairs))
+	for _, name := range sortedNames(pairs) {
+		raw := pairs[name]
+		size, err := parseSizeKey(name)
+		if err != nil {
+			return nil, err
+		}
+		count, err := strconv.Atoi(raw)
+		if err != nil || count < 0 {
+			return nil, fmt.Errorf("stock of package size %d must be a non-negative integer, got %q", size, raw)
+		}
+		stock[size] = count
+	}
+	return stock, nil
+}
+
+// parseSizeKey parses the package size naming an entry of catalog.costs or catalog.stock.
+func parseSizeKey(name string) (int, error) {
+	size, err := strconv.Atoi(name)
+	if err != nil || size <= 0 {
+		return 0, fmt.Errorf("%q is not a package size", name)
+	}
+	return size, nil
+}
+
+// parseCatalogs parses named package size lists, each written as name=size size ...
+//
+// Returns:
+//   - map[string][]int: the sizes of each catalog; nil if the value is empty
+//   - error: if an entry is malformed or its sizes are invalid
+//
+// Example:
+//
+//	catalogs, err := parseCatalogs("retail=250 500 1000, bulk=1000 5000") // map[bulk:[1000 5000] retail:[250 500 1000]]
+func parseCatalogs(value string) (map[string][]int, error) {
+	pairs, err := parseMap(value)
+	if err != nil || len(pairs) == 0 {
+		return nil, err
+	}
+
+	catalogs := make(map[string][]int, len(pairs))
+	for _, name := range sortedNames(pairs) {
+		sizes, err := parsePackageSizes(strings.Join(strings.Fields(pairs[name]), ","))
+		if err != nil {
+			return nil, fmt.Errorf("catalog %q: %w", name, err)
+		}
+		catalogs[name] = sizes
+	}
+	return catalogs, nil
+}
@@ -0,0 +1,470 @@
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+)
+
+// Record represents a single persisted calculation.
+// Every call to the calculate endpoint produces one record, which makes it possible
+// to answer questions like "what did the system recommend for order X last Tuesday?".
+type Record struct {
+	// ID is a monotonically increasing identifier assigned by the store
+	ID int64 `json:"id"`
+
+	// Timestamp is the time the calculation was performed (UTC)
+	Timestamp time.Time `json:"timestamp"`
+
+	// Client identifies the caller (e.g., the client IP address)
+	Client string `json:"client"`
+
+	// CatalogVersion identifies the package catalog that produced the result
+	CatalogVersion string `json:"catalog_version"`
+
+	// Request is the optimization request as received from the client
+	Request domain.OptimizationRequest `json:"request"`
+
+	// Result is the optimization result, nil if the calculation failed
+	Result *domain.OptimizationResult `json:"result,omitempty"`
+
+	// Error is the error message if the calculation failed
+	Error string `json:"error,omitempty"`
+
+	// NotModified is set when the client revalidated a result it already had (HTTP 304).
+	// Nothing was calculated; Result holds the result if it was still cached
+	NotModified bool `json:"not_modified,omitempty"`
+}
+
+// Filter describes which records to return from a query.
+// Zero values mean "no restriction" for the corresponding field.
+type Filter struct {
+	// From only matches records at or after this time
+	From time.Time
+	// To only matches records strictly before this time
+	To time.Time
+	// Client only matches records from this client
+	Client string
+	// CatalogVersion only matches records produced by this catalog
+	CatalogVersion string
+	// MinQuantity only matches records with a requested quantity >= MinQuantity
+	MinQuantity *int
+	// MaxQuantity only matches records with a requested quantity <= MaxQuantity
+	MaxQuantity *int
+	// Offset is the number of matching records to skip (newest first)
+	Offset int
+	// Limit is the maximum number of records to return (0 means no limit)
+	Limit int
+}
+
+// Options configures how much history a Store keeps.
+type Options struct {
+	// MaxFileSize is the size in bytes at which the history file is rotated; 0 never rotates
+	MaxFileSize int64
+	// MaxFiles is the number of rotated files kept next to the history file (history.jsonl.1
+	// is the newest). Older files are deleted, so the history takes at most about
+	// (MaxFiles+1) * MaxFileSize bytes.
+	MaxFiles int
+}
+
+// Store is an embedded, file-based calculation history.
+// Records are appended to a JSON Lines file, one record per line, so the history
+// survives restarts without requiring an external database. When the file reaches
+// its maximum size it is rotated, and the oldest rotated files are deleted.
+//
+// Store is safe for concurrent use.
+type Store struct {
+	// mu serializes writes and rotation and protects nextID and size
+	mu sync.Mutex
+	// path is the location of the JSON Lines file
+	path string
+	// opts bounds the size of the history
+	opts Options
+	// file is the open append-only handle to the history file
+	file *os.File
+	// size is the current size of the history file in bytes
+	size int64
+	// nextID is the identifier assigned to the next appended record
+	nextID int64
+}
+
+// maxLineSize is the largest record line the store will read back.
+// Results contain one entry per package size, so records are normally tiny.
+const maxLineSize = 1 << 20
+
+// readChunkSize is how much of a history file is read at a time, from the end.
+const readChunkSize = 64 * 1024
+
+// Open opens (or creates) the history file at the given path.
+// Missing parent directories are created. The newest persisted record is read
+// to continue the ID sequence where the previous process left off.
+//
+// Args:
+//   - path: location of the JSON Lines history file
+//   - opts: rotation and retention of the history
+//
+// Returns:
+//   - *Store: ready-to-use history store
+//   - error: if the file cannot be created, opened, or read
+//
+// Example:
+//
+//	store, err := history.Open("data/history.jsonl", history.Options{MaxFileSize: 64 << 20, MaxFiles: 4})
+func Open(path string, opts Options) (*Store, error) {
+	// Ensure the directory for the history file exists
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create history directory: %w", err)
+	}
+
+	store := &Store{
+		path:   path,
+		opts:   opts,
+		nextID: 1,
+	}
+	if err := store.openFile(); err != nil {
+		return nil, err
+	}
+
+	// Continue the ID sequence from the newest persisted record
+	err := store.each(func(rec Record) bool {
+		store.nextID = rec.ID + 1
+		return false
+	})
+	if err != nil {
+		store.file.Close()
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// openFile opens the history file for appending, creating it if necessary.
+func (s *Store) openFile() error {
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0o644)
+	if err != nil {
+		return fmt.Errorf("open history file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("open history file: %w", err)
+	}
+	s.file = file
+	s.size = info.Size()
+	return nil
+}
+
+// Append persists a record and returns it with its assigned ID.
+// The timestamp is set to the current time if the caller left it empty. The
+// history file is rotated first if the record would take it over its maximum size.
+func (s *Store) Append(rec Record) (Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Assign the next ID and default the timestamp
+	rec.ID = s.nextID
+	if rec.Timestamp.IsZero() {
+		rec.Timestamp = time.Now().UTC()
+	}
+
+	// Encode the record as a single JSON line
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return Record{}, fmt.Errorf("encode history record: %w", err)
+	}
+	line = append(line, '\n')
+
+	// Start a new file rather than grow this one past its maximum size
+	if s.opts.MaxFileSize > 0 && s.size > 0 && s.size+int64(len(line)) > s.opts.MaxFileSize {
+		if err := s.rotate(); err != nil {
+			return Record{}, err
+		}
+	}
+
+	// Append the line to the history file
+	if _, err := s.file.Write(line); err != nil {
+		return Record{}, fmt.Errorf("write history record: %w", err)
+	}
+
+	s.size += int64(len(line))
+	s.nextID++
+	return rec, nil
+}
+
+// rotate renames the history file to history.jsonl.1, shifting older rotated
+// files up by one and deleting the oldest, then starts an empty history file.
+// The history file is reopened even if rotating fails, so a failed rotation doesn't
+// stop later records from being written.
+// The caller must hold s.mu.
+func (s *Store) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return fmt.Errorf("rotate history file: %w", err)
+	}
+
+	err := s.shiftFiles()
+	if openErr := s.openFile(); openErr != nil {
+		return openErr
+	}
+	if err != nil {
+		return fmt.Errorf("rotate history file: %w", err)
+	}
+	return nil
+}
+
+// shiftFiles moves the closed history file out of the way: it becomes the first
+// rotated file, or is deleted when no rotated files are kept.
+func (s *Store) shiftFiles() error {
+	// Without rotated files to keep, the full file is simply dropped
+	if s.opts.MaxFiles == 0 {
+		return os.Remove(s.path)
+	}
+
+	// Make room for the newest rotated file
+	if err := removeIfExists(rotatedPath(s.path, s.opts.MaxFiles)); err != nil {
+		return err
+	}
+	for i := s.opts.MaxFiles - 1; i >= 1; i-- {
+		if err := renameIfExists(rotatedPath(s.path, i), rotatedPath(s.path, i+1)); err != nil {
+			return err
+		}
+	}
+	return os.Rename(s.path, rotatedPath(s.path, 1))
+}
+
+// rotatedPath returns the path of the n-th rotated history file (n >= 1), or the
+// history file itself for n == 0.
+func rotatedPath(path string, n int) string {
+	if n == 0 {
+		return path
+	}
+	return path + "." + strconv.Itoa(n)
+}
+
+// removeIfExists deletes a file, ignoring a file that doesn't exist.
+func removeIfExists(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// renameIfExists renames a file, ignoring a file that doesn't exist.
+func renameIfExists(from, to string) error {
+	if err := os.Rename(from, to); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Query returns the records matching the filter, newest first, together with
+// the total number of matching records before paging was applied. Only the
+// requested page is kept in memory; use Stream to export many records.
+//
+// Args:
+//   - filter: restrictions and paging to apply
+//
+// Returns:
+//   - []Record: the requested page of matching records
+//   - int: total number of matching records
+//   - error: if the history file cannot be read
+func (s *Store) Query(filter Filter) ([]Record, int, error) {
+	page := []Record{}
+	total := 0
+	err := s.each(func(rec Record) bool {
+		if !filter.matches(rec) {
+			return true
+		}
+		// Keep the records inside the requested page, but count every match
+		if total >= filter.Offset && (filter.Limit == 0 || len(page) < filter.Limit) {
+			page = append(page, rec)
+		}
+		total++
+		return true
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return page, total, nil
+}
+
+// Stream passes the records matching the filter to fn, newest first, applying
+// the filter's offset and limit. Records are read from disk as they are passed
+// on, so an export of the whole history doesn't hold it in memory.
+//
+// Args:
+//   - filter: restrictions and paging to apply
+//   - fn: called for every record of the page; an error stops the stream
+//
+// Returns:
+//   - error: the error returned by fn, or an error if the history cannot be read
+func (s *Store) Stream(filter Filter, fn func(Record) error) error {
+	skipped, sent := 0, 0
+	var fnErr error
+	err := s.each(func(rec Record) bool {
+		if !filter.matches(rec) {
+			return true
+		}
+		if skipped < filter.Offset {
+			skipped++
+			return true
+		}
+		if fnErr = fn(rec); fnErr != nil {
+			return false
+		}
+		sent++
+		return filter.Limit == 0 || sent < filter.Limit
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	return err
+}
+
+// Check reports whether the store can still write records: the history file must
+// be open and still present at its path (for example, not removed by a cleanup job).
+//
+// Returns:
+//   - error: nil if the store is usable, or the reason it is not
+func (s *Store) Check() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.file.Stat(); err != nil {
+		return fmt.Errorf("history file is not usable: %w", err)
+	}
+	if _, err := os.Stat(s.path); err != nil {
+		return fmt.Errorf("history file is missing: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying history file.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// each passes every record to fn, newest first, until fn returns false.
+func (s *Store) each(fn func(Record) bool) error {
+	// Open every file under the lock, so a concurrent rotation can't make the scan
+	// miss or repeat a file; open handles keep working after a rename
+	s.mu.Lock()
+	var files []*os.File
+	for n := 0; n <= s.opts.MaxFiles; n++ {
+		file, err := os.Open(rotatedPath(s.path, n))
+		if errors.Is(err, fs.ErrNotExist) && n > 0 {
+			continue
+		}
+		if err != nil {
+			s.mu.Unlock()
+			closeAll(files)
+			return fmt.Errorf("open history file: %w", err)
+		}
+		files = append(files, file)
+	}
+	s.mu.Unlock()
+	defer closeAll(files)
+
+	for _, file := range files {
+		more, err := scanReverse(file, fn)
+		if err != nil || !more {
+			return err
+		}
+	}
+	return nil
+}
+
+// closeAll closes every file.
+func closeAll(files []*os.File) {
+	for _, file := range files {
+		file.Close()
+	}
+}
+
+// scanReverse decodes the records of a history file from its last line to its
+// first and passes them to fn until it returns false. Lines that cannot be decoded
+// (e.g., a partial write after a crash, or a write in progress) are skipped.
+//
+// Returns:
+//   - bool: false if fn stopped the scan
+//   - error: if the file cannot be read or holds a line over maxLineSize
+func scanReverse(file *os.File, fn func(Record) bool) (bool, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return false, fmt.Errorf("read history file: %w", err)
+	}
+
+	// emit decodes one line and passes it on
+	emit := func(line []byte) bool {
+		var rec Record
+		if len(bytes.TrimSpace(line)) == 0 || json.Unmarshal(line, &rec) != nil {
+			return true
+		}
+		return fn(rec)
+	}
+
+	// Read the file backwards in chunks; tail holds the start of the file's last
+	// unfinished line, which continues into the chunk read before
+	var tail []byte
+	for end := info.Size(); end > 0; {
+		start := end - readChunkSize
+		if start < 0 {
+			start = 0
+		}
+		chunk := make([]byte, end-start, end-start+int64(len(tail)))
+		if _, err := file.ReadAt(chunk, start); err != nil && !errors.Is(err, io.EOF) {
+			return false, fmt.Errorf("read history file: %w", err)
+		}
+		data := append(chunk, tail...)
+
+		// Every line after a newline in data is complete
+		for i := bytes.LastIndexByte(data, '\n'); i >= 0; i = bytes.LastIndexByte(data, '\n') {
+			if !emit(data[i+1:]) {
+				return false, nil
+			}
+			data = data[:i]
+		}
+		if len(data) > maxLineSize {
+			return false, fmt.Errorf("read history file: line longer than %d bytes", maxLineSize)
+		}
+		tail = data
+		end = start
+	}
+
+	// The first line of the file has no newline before it
+	return emit(tail), nil
+}
+
+// matches reports whether the record satisfies every restriction in the filter.
+func (f Filter) matches(rec Record) bool {
+	if !f.From.IsZero() && rec.Timestamp.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !rec.Timestamp.Before(f.To) {
+		return false
+	}
+	if f.Client != "" && rec.Client != f.Client {
+		return false
+	}
+	if f.CatalogVersion != "" && rec.CatalogVersion != f.CatalogVersion {
+		return false
+	}
+	if f.MinQuantity != nil && rec.Request.Quantity < *f.MinQuantity {
+		return false
+	}
+	if f.MaxQuantity != nil && rec.Request.Quantity > *f.MaxQuantity {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,562 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sinaw369/Package-Optimizer/internal/catalog"
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+	"github.com/sinaw369/Package-Optimizer/internal/limits"
+)
+
+// Status describes where a job is in its lifecycle.
+type Status string
+
+const (
+	// StatusQueued means the job is waiting for a free worker
+	StatusQueued Status = "queued"
+	// StatusRunning means a worker is currently computing the job
+	StatusRunning Status = "running"
+	// StatusSucceeded means every quantity in the job was optimized
+	StatusSucceeded Status = "succeeded"
+	// StatusFailed means the optimizer returned an error
+	StatusFailed Status = "failed"
+	// StatusCanceled means the job was cancelled by the client
+	StatusCanceled Status = "canceled"
+)
+
+var (
+	// ErrNotFound is returned when a job ID is unknown or its result has expired
+	ErrNotFound = errors.New("job not found")
+	// ErrQueueFull is returned when the job queue has no free capacity
+	ErrQueueFull = errors.New("job queue is full")
+	// ErrShuttingDown is returned when jobs are submitted after shutdown started
+	ErrShuttingDown = errors.New("job manager is shutting down")
+)
+
+// Request describes the work a job should perform.
+// A job optimizes one or more quantities with the same catalog.
+type Request struct {
+	// Quantities are the requested quantities to optimize, in order
+	Quantities []int `json:"quantities"`
+	// Client identifies who submitted the job (the API key's client or the client IP);
+	// it is kept across restarts so resumed jobs stay with their owner
+	Client string `json:"client,omitempty"`
+}
+
+// Progress reports how much of a job has been completed.
+type Progress struct {
+	// Completed is the number of quantities already optimized
+	Completed int `json:"completed"`
+	// Total is the number of quantities in the job
+	Total int `json:"total"`
+	// Current reports DP progress for the quantity being optimized right now
+	Current *domain.Progress `json:"current,omitempty"`
+}
+
+// Job is a snapshot of an asynchronous optimization job.
+type Job struct {
+	// ID uniquely identifies the job
+	ID string `json:"id"`
+	// Status is the current lifecycle state
+	Status Status `json:"status"`
+	// Request is the work submitted by the client
+	Request Request `json:"request"`
+	// Progress reports how many quantities have been optimized so far
+	Progress Progress `json:"progress"`
+	// Results holds one result per quantity once the job has succeeded
+	Results []*domain.OptimizationResult `json:"results,omitempty"`
+	// Error is the failure reason for failed jobs
+	Error string `json:"error,omitempty"`
+	// CreatedAt is when the job was submitted
+	CreatedAt time.Time `json:"created_at"`
+	// StartedAt is when a worker picked up the job
+	StartedAt *time.Time `json:"started_at,omitempty"`
+	// FinishedAt is when the job reached a terminal state
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	// ExpiresAt is when a finished job will be removed
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// Options configures a Manager.
+type Options struct {
+	// Workers is the number of jobs computed concurrently
+	Workers int
+	// QueueSize is the number of jobs that may wait for a worker
+	QueueSize int
+	// ResultTTL is how long finished jobs are kept before they are removed
+	ResultTTL time.Duration
+	// StatePath is where queued jobs are persisted on shutdown (empty disables persistence)
+	StatePath string
+	// Limiter is the solver memory guard shared with the synchronous APIs (nil disables
+	// it); each quantity waits for its memory reservation instead of being rejected
+	Limiter *limits.Limiter
+}
+
+// entry is the manager's internal bookkeeping for a job.
+type entry struct {
+	// job is the externally visible state, guarded by Manager.mu
+	job Job
+	// cancel aborts the job's context while it is running
+	cancel context.CancelFunc
+	// canceledByClient distinguishes client cancellation from shutdown
+	canceledByClient bool
+	// changed is closed (and replaced) whenever the job's state changes
+	changed chan struct{}
+}
+
+// Manager runs optimization jobs on a bounded worker pool.
+// Submitted jobs wait in a fixed-size queue; finished jobs are kept for the
+// configured TTL so clients can poll for the result. On shutdown the manager
+// drains the queue and, if it runs out of time, persists the remaining jobs
+// so they are resumed by the next process.
+//
+// Manager is safe for concurrent use.
+type Manager struct {
+	// catalog provides the optimizer that computes job results
+	catalog *catalog.Catalog
+	// opts holds the manager configuration
+	opts Options
+
+	// mu guards jobs and closed
+	mu sync.Mutex
+	// jobs indexes every known job by ID
+	jobs map[string]*entry
+	// closed is set once shutdown has started
+	closed bool
+
+	// queue feeds queued jobs to the workers
+	queue chan *entry
+	// workers tracks running worker goroutines
+	workers sync.WaitGroup
+	// baseCtx is the parent of every job context; cancelled on forced shutdown
+	baseCtx context.Context
+	// abort cancels baseCtx
+	abort context.CancelFunc
+	// stopSweep stops the expired-job sweeper
+	stopSweep chan struct{}
+}
+
+// NewManager creates a job manager and starts its workers.
+// Jobs persisted by a previous process at opts.StatePath are re-queued.
+//
+// Args:
+//   - catalog: the package catalog whose current optimizer computes job results
+//   - opts: worker pool, queue and retention settings
+//
+// Returns:
+//   - *Manager: running job manager
+//   - error: if persisted jobs exist but cannot be read
+func NewManager(catalog *catalog.Catalog, opts Options) (*Manager, error) {
+	// Apply sensible minimums so a misconfiguration can't deadlock the pool
+	if opts.Workers < 1 {
+		opts.Workers = 1
+	}
+	if opts.QueueSize < 1 {
+		opts.QueueSize = 1
+	}
+
+	// Load jobs left over from the previous process
+	pending, err := loadState(opts.StatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make room for every restored job even if the queue was shrunk
+	queueSize := opts.QueueSize
+	if len(pending) > queueSize {
+		queueSize = len(pending)
+	}
+
+	ctx, abort := context.WithCancel(context.Background())
+	m := &Manager{
+		catalog:   catalog,
+		opts:      opts,
+		jobs:      make(map[string]*entry),
+		queue:     make(chan *entry, queueSize),
+		baseCtx:   ctx,
+		abort:     abort,
+		stopSweep: make(chan struct{}),
+	}
+
+	// Re-queue restored jobs
+	for _, job := range pending {
+		e := &entry{job: job, changed: make(chan struct{})}
+		e.job.Status = StatusQueued
+		m.jobs[job.ID] = e
+		m.queue <- e
+	}
+	if len(pending) > 0 {
+		slog.Info("restored queued jobs", "count", len(pending), "path", opts.StatePath)
+	}
+
+	// Start the worker pool and the expiry sweeper
+	for i := 0; i < opts.Workers; i++ {
+		m.workers.Add(1)
+		go m.worker()
+	}
+	go m.sweep()
+
+	return m, nil
+}
+
+// Submit queues a new job and returns its initial snapshot.
+//
+// Returns:
+//   - Job: the queued job
+//   - error: ErrQueueFull if there is no capacity, ErrShuttingDown after shutdown started,
+//     or a validation error if the request is empty
+func (m *Manager) Submit(req Request) (Job, error) {
+	if len(req.Quantities) == 0 {
+		return Job{}, fmt.Errorf("at least one quantity is required")
+	}
+
+	id, err := newID()
+	if err != nil {
+		return Job{}, err
+	}
+
+	e := &entry{
+		job: Job{
+			ID:        id,
+			Status:    StatusQueued,
+			Request:   req,
+			Progress:  Progress{Total: len(req.Quantities)},
+			CreatedAt: time.Now().UTC(),
+		},
+		changed: make(chan struct{}),
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Refuse new work once shutdown has started (the queue is closed)
+	if m.closed {
+		return Job{}, ErrShuttingDown
+	}
+
+	// Enqueue without blocking; a full queue is reported to the client
+	select {
+	case m.queue <- e:
+	default:
+		return Job{}, ErrQueueFull
+	}
+
+	m.jobs[id] = e
+	return e.job, nil
+}
+
+// Get returns a snapshot of the job with the given ID.
+func (m *Manager) Get(id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	return e.job, nil
+}
+
+// Watch returns a snapshot of the job and a channel that is closed the next time
+// the job changes (status or progress). Callers re-invoke Watch after each change
+// to keep following the job.
+func (m *Manager) Watch(id string) (Job, <-chan struct{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.jobs[id]
+	if !ok {
+		return Job{}, nil, ErrNotFound
+	}
+	return e.job, e.changed, nil
+}
+
+// Cancel cancels a queued or running job and returns its snapshot.
+// Cancelling a job that already finished is a no-op.
+func (m *Manager) Cancel(id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+
+	switch e.job.Status {
+	case StatusQueued:
+		// The worker will skip the job when it dequeues it
+		e.canceledByClient = true
+		m.finishLocked(e, StatusCanceled, "canceled by client")
+	case StatusRunning:
+		// The worker observes the cancelled context and records the final state
+		e.canceledByClient = true
+		e.cancel()
+	}
+
+	return e.job, nil
+}
+
+// Check reports whether the manager accepts new jobs.
+//
+// Returns:
+//   - error: ErrShuttingDown once shutdown has started, nil otherwise
+func (m *Manager) Check() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return ErrShuttingDown
+	}
+	return nil
+}
+
+// Shutdown stops accepting jobs and waits for queued and running jobs to finish.
+// If ctx expires first, running jobs are interrupted and every unfinished job is
+// persisted to the state file so the next process can resume it.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	// Stop intake; workers exit once the queue is drained
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil
+	}
+	m.closed = true
+	close(m.queue)
+	m.mu.Unlock()
+	close(m.stopSweep)
+
+	done := make(chan struct{})
+	go func() {
+		m.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		// Everything drained in time
+		m.abort()
+		return nil
+	case <-ctx.Done():
+	}
+
+	// Out of time: interrupt running jobs and let the workers skip the rest
+	m.abort()
+	<-done
+
+	return m.persist()
+}
+
+// worker computes queued jobs until the queue is closed.
+func (m *Manager) worker() {
+	defer m.workers.Done()
+	for e := range m.queue {
+		m.run(e)
+	}
+}
+
+// run computes a single job and records its outcome.
+func (m *Manager) run(e *entry) {
+	m.mu.Lock()
+	// Skip jobs cancelled while queued, and leave jobs queued once shutdown aborted
+	if e.job.Status != StatusQueued || m.baseCtx.Err() != nil {
+		m.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(m.baseCtx)
+	defer cancel()
+	e.cancel = cancel
+	now := time.Now().UTC()
+	e.job.Status = StatusRunning
+	e.job.StartedAt = &now
+	quantities := e.job.Request.Quantities
+	m.notifyLocked(e)
+	m.mu.Unlock()
+
+	// Publish DP progress for the quantity currently being optimized
+	reportProgress := func(p domain.Progress) {
+		m.mu.Lock()
+		e.job.Progress.Current = &p
+		m.notifyLocked(e)
+		m.mu.Unlock()
+	}
+
+	// Optimize each quantity in order, publishing progress as we go
+	// The whole job uses the catalog that was current when it started
+	optimizer := m.catalog.Optimizer()
+	results := make([]*domain.OptimizationResult, 0, len(quantities))
+	var runErr error
+	for _, quantity := range quantities {
+		result, err := m.optimize(ctx, optimizer, quantity, reportProgress)
+		if err != nil {
+			runErr = err
+			break
+		}
+		results = append(results, result)
+
+		m.mu.Lock()
+		e.job.Progress.Completed = len(results)
+		e.job.Progress.Current = nil
+		m.notifyLocked(e)
+		m.mu.Unlock()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch {
+	case runErr == nil:
+		e.job.Results = results
+		m.finishLocked(e, StatusSucceeded, "")
+	case e.canceledByClient:
+		m.finishLocked(e, StatusCanceled, "canceled by client")
+	case errors.Is(runErr, context.Canceled) && m.baseCtx.Err() != nil:
+		// Interrupted by shutdown: put the job back so it is persisted
+		e.job.Status = StatusQueued
+		e.job.StartedAt = nil
+		e.job.Progress.Completed = 0
+		e.job.Progress.Current = nil
+		m.notifyLocked(e)
+	default:
+		m.finishLocked(e, StatusFailed, runErr.Error())
+	}
+}
+
+// optimize solves one quantity of a job once the solver memory guard has room for it.
+// Jobs have no client waiting on a deadline, so the worker waits for its turn rather
+// than failing the job when synchronous solves keep the solver busy.
+func (m *Manager) optimize(ctx context.Context, optimizer *domain.Optimizer, quantity int, progress domain.ProgressFunc) (*domain.OptimizationResult, error) {
+	if m.opts.Limiter != nil {
+		release, err := m.opts.Limiter.WaitSolve(ctx, optimizer.EstimateMemory(quantity))
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
+	return optimizer.OptimizeWithProgress(ctx, quantity, progress)
+}
+
+// finishLocked moves a job into a terminal state and schedules its expiry.
+// The caller must hold m.mu.
+func (m *Manager) finishLocked(e *entry, status Status, errMsg string) {
+	now := time.Now().UTC()
+	expires := now.Add(m.opts.ResultTTL)
+	e.job.Status = status
+	e.job.Error = errMsg
+	e.job.FinishedAt = &now
+	e.job.ExpiresAt = &expires
+	e.job.Progress.Current = nil
+	m.notifyLocked(e)
+}
+
+// notifyLocked wakes every watcher of the job. The caller must hold m.mu.
+func (m *Manager) notifyLocked(e *entry) {
+	close(e.changed)
+	e.changed = make(chan struct{})
+}
+
+// sweep periodically removes finished jobs whose TTL has elapsed.
+func (m *Manager) sweep() {
+	// Sweep often enough that expired jobs don't linger much past their TTL
+	interval := m.opts.ResultTTL / 2
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stopSweep:
+			return
+		case now := <-ticker.C:
+			m.mu.Lock()
+			for id, e := range m.jobs {
+				if e.job.ExpiresAt != nil && now.After(*e.job.ExpiresAt) {
+					delete(m.jobs, id)
+				}
+			}
+			m.mu.Unlock()
+		}
+	}
+}
+
+// persist writes every still-queued job to the state file.
+func (m *Manager) persist() error {
+	if m.opts.StatePath == "" {
+		return nil
+	}
+
+	m.mu.Lock()
+	var pending []Job
+	for _, e := range m.jobs {
+		if e.job.Status == StatusQueued {
+			pending = append(pending, e.job)
+		}
+	}
+	m.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(pending)
+	if err != nil {
+		return fmt.Errorf("encode queued jobs: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(m.opts.StatePath), 0o755); err != nil {
+		return fmt.Errorf("create job state directory: %w", err)
+	}
+	if err := os.WriteFile(m.opts.StatePath, data, 0o644); err != nil {
+		return fmt.Errorf("write queued jobs: %w", err)
+	}
+
+	slog.Info("persisted queued jobs", "count", len(pending), "path", m.opts.StatePath)
+	return nil
+}
+
+// loadState reads jobs persisted by a previous process and removes the state file.
+// A missing file simply means there is nothing to resume.
+func loadState(path string) ([]Job, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read queued jobs: %w", err)
+	}
+
+	var pending []Job
+	if err := json.Unmarshal(data, &pending); err != nil {
+		return nil, fmt.Errorf("decode queued jobs: %w", err)
+	}
+
+	// Remove the file so the same jobs aren't restored twice
+	if err := os.Remove(path); err != nil {
+		return nil, fmt.Errorf("remove job state file: %w", err)
+	}
+
+	return pending, nil
+}
+
+// newID generates a random job identifier.
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate job ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,319 @@
+package limits
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrBusy is returned by AcquireSolve when the solver's memory budget stays
+// exhausted for longer than the configured wait.
+var ErrBusy = errors.New("solver is busy")
+
+// sweepInterval is how often idle rate limit buckets are discarded.
+const sweepInterval = time.Minute
+
+// Config holds the rate and concurrency limits.
+type Config struct {
+	// Rate is the number of requests per second each client may make on average; 0 disables rate limiting
+	Rate float64
+	// Burst is the number of requests a client may make at once after being idle
+	Burst int
+	// SolveMemory is the total estimated memory, in bytes, of the solves allowed to run at once; 0 disables the guard
+	SolveMemory int64
+	// SolveWait is how long a solve may wait for memory to become available before it is rejected
+	SolveWait time.Duration
+}
+
+// bucket is the token bucket of a single client.
+type bucket struct {
+	// tokens is the number of requests the client may make right now
+	tokens float64
+	// updated is when tokens was last brought up to date
+	updated time.Time
+}
+
+// waiter is a solve waiting for memory to become available.
+type waiter struct {
+	// weight is the memory the solve needs
+	weight int64
+	// ready is closed once the memory has been reserved for the solve
+	ready chan struct{}
+}
+
+// Limiter protects the solver from being monopolized: a token bucket per client
+// limits request rates, and a weighted semaphore caps the estimated memory of the
+// solves running at once, so a few huge quantities can't starve everyone else.
+//
+// Solves wait in FIFO order, so a large solve at the head of the queue is not
+// overtaken indefinitely by smaller ones.
+//
+// Limiter is safe for concurrent use.
+type Limiter struct {
+	// cfg holds the configured limits
+	cfg Config
+
+	// bucketsMu guards buckets and lastSweep
+	bucketsMu sync.Mutex
+	// buckets holds the token bucket of every client seen recently
+	buckets map[string]*bucket
+	// lastSweep is when idle buckets were last discarded
+	lastSweep time.Time
+
+	// solveMu guards inUse and waiters
+	solveMu sync.Mutex
+	// inUse is the estimated memory of the solves currently running
+	inUse int64
+	// waiters are the solves waiting for memory, oldest first
+	waiters []*waiter
+}
+
+// New creates a limiter with the given limits.
+//
+// Args:
+//   - cfg: the rate and concurrency limits
+//
+// Returns:
+//   - *Limiter: limiter ready for use
+//
+// Example:
+//
+//	limiter := limits.New(limits.Config{Rate: 10, Burst: 20, SolveMemory: 512 << 20, SolveWait: 2 * time.Second})
+func New(cfg Config) *Limiter {
+	if cfg.Burst < 1 {
+		cfg.Burst = 1
+	}
+	return &Limiter{
+		cfg:       cfg,
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow takes a token from the client's bucket.
+//
+// Args:
+//   - key: identifies the client (e.g., "key:acme-prod" or "ip:203.0.113.7")
+//
+// Returns:
+//   - bool: whether the request may proceed
+//   - time.Duration: when it may not, how long until the next token is available
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l.cfg.Rate <= 0 {
+		return true, 0
+	}
+
+	l.bucketsMu.Lock()
+	defer l.bucketsMu.Unlock()
+
+	b := l.bucketLocked(key)
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.cfg.Rate * float64(time.Second))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// Charge takes n more tokens from the client's bucket for a request that Allow has
+// already let through, when the request does the work of several (e.g., a batch of
+// quantities). The bucket may go below zero: the client then has to wait until it
+// has refilled before its next request is allowed, so a large batch costs as much
+// as the single requests it replaces even when it exceeds the burst.
+//
+// Args:
+//   - key: identifies the client, as passed to Allow
+//   - n: the number of tokens to take; nothing is taken if n is not positive
+//
+// Example:
+//
+//	// A batch of 50 quantities was admitted with one token; charge the other 49
+//	limiter.Charge("key:acme-prod", 49)
+func (l *Limiter) Charge(key string, n int) {
+	if l.cfg.Rate <= 0 || n <= 0 {
+		return
+	}
+
+	l.bucketsMu.Lock()
+	defer l.bucketsMu.Unlock()
+
+	l.bucketLocked(key).tokens -= float64(n)
+}
+
+// bucketLocked returns the client's bucket, refilled for the time elapsed since its
+// last request. The caller must hold bucketsMu.
+func (l *Limiter) bucketLocked(key string) *bucket {
+	now := time.Now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.cfg.Burst), updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens = min(float64(l.cfg.Burst), b.tokens+now.Sub(b.updated).Seconds()*l.cfg.Rate)
+	b.updated = now
+	return b
+}
+
+// sweep discards the buckets of clients idle long enough for their bucket to be
+// full again, which is indistinguishable from a new bucket.
+// The caller must hold bucketsMu.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.updated).Seconds()*l.cfg.Rate >= float64(l.cfg.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// AcquireSolve reserves memory for a solve, waiting up to the configured SolveWait
+// for running solves to release enough. A solve estimated to need more than the whole
+// budget is treated as needing exactly the budget, so it runs alone.
+//
+// Args:
+//   - ctx: cancels the wait (e.g., when the client disconnects)
+//   - weight: the solve's estimated memory in bytes (see domain.Optimizer.EstimateMemory)
+//
+// Returns:
+//   - func(): releases the reservation; must be called once the solve has finished
+//   - error: ErrBusy if the memory didn't become available in time, or ctx.Err()
+//
+// Example:
+//
+//	release, err := limiter.AcquireSolve(ctx, optimizer.EstimateMemory(quantity))
+//	if err != nil {
+//	    return err
+//	}
+//	defer release()
+func (l *Limiter) AcquireSolve(ctx context.Context, weight int64) (func(), error) {
+	timer := time.NewTimer(l.cfg.SolveWait)
+	defer timer.Stop()
+	return l.acquireSolve(ctx, weight, timer.C)
+}
+
+// WaitSolve reserves memory for a solve like AcquireSolve, but waits for as long as
+// ctx allows instead of giving up after SolveWait. It is meant for background work,
+// such as asynchronous jobs, that has no client waiting on it: the solve takes its
+// turn in the same line as the synchronous ones instead of being rejected.
+//
+// Args:
+//   - ctx: cancels the wait (e.g., when the job is cancelled or the server shuts down)
+//   - weight: the solve's estimated memory in bytes (see domain.Optimizer.EstimateMemory)
+//
+// Returns:
+//   - func(): releases the reservation; must be called once the solve has finished
+//   - error: ctx.Err() if the context ended before the memory became available
+func (l *Limiter) WaitSolve(ctx context.Context, weight int64) (func(), error) {
+	return l.acquireSolve(ctx, weight, nil)
+}
+
+// acquireSolve implements AcquireSolve and WaitSolve. The wait ends with ErrBusy when
+// timeout fires; a nil timeout never fires.
+func (l *Limiter) acquireSolve(ctx context.Context, weight int64, timeout <-chan time.Time) (func(), error) {
+	if l.cfg.SolveMemory <= 0 {
+		return func() {}, nil
+	}
+	weight = min(max(weight, 1), l.cfg.SolveMemory)
+
+	l.solveMu.Lock()
+	// Run straight away if there is room and nobody is queued ahead
+	if len(l.waiters) == 0 && l.inUse+weight <= l.cfg.SolveMemory {
+		l.inUse += weight
+		l.solveMu.Unlock()
+		return l.releaseFunc(weight), nil
+	}
+	w := &waiter{weight: weight, ready: make(chan struct{})}
+	l.waiters = append(l.waiters, w)
+	l.solveMu.Unlock()
+
+	var err error
+	select {
+	case <-w.ready:
+		return l.releaseFunc(weight), nil
+	case <-timeout:
+		err = ErrBusy
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	l.solveMu.Lock()
+	defer l.solveMu.Unlock()
+	select {
+	case <-w.ready:
+		// The memory was reserved just as we gave up; hand it back
+		l.inUse -= weight
+	default:
+		l.removeWaiter(w)
+	}
+	l.grant()
+	return nil, err
+}
+
+// SolveWait returns how long a solve may wait for memory before it is rejected.
+func (l *Limiter) SolveWait() time.Duration {
+	return l.cfg.SolveWait
+}
+
+// RetryAfterSeconds formats a wait as a Retry-After value in whole seconds, rounded
+// up and at least one.
+//
+// Example:
+//
+//	limits.RetryAfterSeconds(1500 * time.Millisecond) // "2"
+func RetryAfterSeconds(wait time.Duration) string {
+	seconds := int((wait + time.Second - 1) / time.Second)
+	return strconv.Itoa(max(seconds, 1))
+}
+
+// SolveMemoryInUse returns the estimated memory of the solves currently running.
+func (l *Limiter) SolveMemoryInUse() int64 {
+	l.solveMu.Lock()
+	defer l.solveMu.Unlock()
+	return l.inUse
+}
+
+// releaseFunc returns a function that releases a reservation exactly once.
+func (l *Limiter) releaseFunc(weight int64) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.solveMu.Lock()
+			defer l.solveMu.Unlock()
+			l.inUse -= weight
+			l.grant()
+		})
+	}
+}
+
+// grant reserves memory for queued solves, oldest first, while they fit.
+// The caller must hold solveMu.
+func (l *Limiter) grant() {
+	for len(l.waiters) > 0 {
+		next := l.waiters[0]
+		if l.inUse+next.weight > l.cfg.SolveMemory {
+			return
+		}
+		l.inUse += next.weight
+		l.waiters = l.waiters[1:]
+		close(next.ready)
+	}
+}
+
+// removeWaiter removes a solve that gave up waiting from the queue.
+// The caller must hold solveMu.
+func (l *Limiter) removeWaiter(w *waiter) {
+	for i, queued := range l.waiters {
+		if queued == w {
+			l.waiters = append(l.waiters[:i], l.waiters[i+1:]...)
+			return
+		}
+	}
+}
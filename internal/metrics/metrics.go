@@ -0,0 +1,234 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes every metric exported by the service.
+const namespace = "package_optimizer"
+
+var (
+	// httpRequests counts HTTP requests by method, route template and status code
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Total number of HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	// httpDuration tracks HTTP request latency by method, route template and status code
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency in seconds by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// solveDuration tracks how long the optimizer takes per solve
+	solveDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "solve_duration_seconds",
+		Help:      "Time spent solving a single optimization in seconds.",
+		Buckets:   prometheus.ExponentialBuckets(0.0001, 4, 10), // 100µs .. ~26s
+	})
+
+	// tableRows tracks the size of the DP table built per solve
+	tableRows = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "dp_table_rows",
+		Help:      "Number of DP table rows filled per solve.",
+		Buckets:   prometheus.ExponentialBuckets(1000, 4, 10), // 1k .. ~262M
+	})
+
+	// overDelivery tracks the absolute over-delivery of successful solves
+	overDelivery = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "over_delivery_units",
+		Help:      "Over-delivered units per successful solve.",
+		Buckets:   []float64{0, 1, 10, 50, 100, 250, 500, 1000, 2500, 5000},
+	})
+
+	// overDeliveryRatio tracks over-delivery relative to the requested quantity
+	overDeliveryRatio = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "over_delivery_ratio",
+		Help:      "Over-delivery divided by the requested quantity per successful solve.",
+		Buckets:   []float64{0, 0.001, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2},
+	})
+
+	// apiKeyRequests counts authenticated requests by API key and outcome
+	apiKeyRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "api_key_requests_total",
+		Help:      "Total number of requests presenting a known API key, by key and result.",
+	}, []string{"key", "result"})
+
+	// limitRejections counts requests rejected by the rate limiter or the solver memory guard
+	limitRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "limit_rejections_total",
+		Help:      "Total number of requests rejected by a rate or concurrency limit, by limit.",
+	}, []string{"limit"})
+
+	// cacheLookups counts result cache lookups by outcome
+	cacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_lookups_total",
+		Help:      "Total number of result cache lookups, by result (hit or miss).",
+	}, []string{"result"})
+
+	// coalescedRequests counts requests that shared another request's solve
+	coalescedRequests = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "coalesced_requests_total",
+		Help:      "Total number of requests served by a concurrent identical request's solve.",
+	})
+
+	// configReloads counts configuration reloads by result
+	configReloads = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "config_reloads_total",
+		Help:      "Total number of configuration reloads by result (success, failure).",
+	}, []string{"result"})
+
+	// errorsTotal counts errors by type, from both HTTP responses and the solver
+	errorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "errors_total",
+		Help:      "Total number of errors by type.",
+	}, []string{"type"})
+)
+
+// Handler returns the HTTP handler that serves metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveRequest records a completed HTTP request.
+//
+// Args:
+//   - method: the HTTP method (GET, POST, ...)
+//   - route: the route template (e.g., "/api/jobs/:id"), not the raw path, to bound cardinality
+//   - status: the response status code
+//   - seconds: the request duration in seconds
+func ObserveRequest(method, route string, status int, seconds float64) {
+	statusLabel := strconv.Itoa(status)
+	httpRequests.WithLabelValues(method, route, statusLabel).Inc()
+	httpDuration.WithLabelValues(method, route, statusLabel).Observe(seconds)
+
+	// Classify error responses
+	if status >= http.StatusBadRequest {
+		RecordError(httpErrorType(status))
+	}
+}
+
+// ObserveSolve records statistics about a single solve.
+// It is installed on the optimizer with domain.Optimizer.WithObserver.
+func ObserveSolve(stats domain.SolveStats) {
+	if stats.Err != nil {
+		if errors.Is(stats.Err, context.Canceled) || errors.Is(stats.Err, context.DeadlineExceeded) {
+			RecordError("solve_canceled")
+		} else if errors.Is(stats.Err, domain.ErrQuantityTooLarge) || errors.Is(stats.Err, domain.ErrMemoryLimitExceeded) {
+			RecordError("solve_limit_exceeded")
+		} else {
+			RecordError("solve_invalid_input")
+		}
+		return
+	}
+
+	solveDuration.Observe(stats.Duration.Seconds())
+	if stats.TableRows > 0 {
+		tableRows.Observe(float64(stats.TableRows))
+	}
+	if stats.Result != nil {
+		overDelivery.Observe(float64(stats.Result.OverDelivery))
+		if stats.Result.Requested > 0 {
+			overDeliveryRatio.Observe(float64(stats.Result.OverDelivery) / float64(stats.Result.Requested))
+		}
+	}
+}
+
+// ObserveAPIKeyRequest records a request made with a known API key.
+// Unknown keys are not recorded, so the key label stays bounded by the key file.
+//
+// Args:
+//   - keyID: the ID of the key from the key file
+//   - result: "allowed", "forbidden" or "quota_exceeded"
+func ObserveAPIKeyRequest(keyID, result string) {
+	apiKeyRequests.WithLabelValues(keyID, result).Inc()
+}
+
+// ObserveLimitRejection records a request rejected by a limit.
+//
+// Args:
+//   - limit: "rate" for the per-client rate limit, "concurrency" for the solver memory guard
+func ObserveLimitRejection(limit string) {
+	limitRejections.WithLabelValues(limit).Inc()
+}
+
+// ObserveCacheLookup records a result cache lookup.
+//
+// Args:
+//   - hit: whether the result was found in the cache
+func ObserveCacheLookup(hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cacheLookups.WithLabelValues(result).Inc()
+}
+
+// ObserveCoalesced records a request that waited for another request's solve
+// instead of solving itself.
+func ObserveCoalesced() {
+	coalescedRequests.Inc()
+}
+
+// ObserveConfigReload records a configuration reload.
+// A failed reload leaves the previous configuration in effect.
+func ObserveConfigReload(ok bool) {
+	result := "success"
+	if !ok {
+		result = "failure"
+	}
+	configReloads.WithLabelValues(result).Inc()
+}
+
+// RecordError increments the error counter for the given error type.
+func RecordError(errorType string) {
+	errorsTotal.WithLabelValues(errorType).Inc()
+}
+
+// httpErrorType maps an HTTP status code onto an error type label.
+func httpErrorType(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "bad_request"
+	case http.StatusUnauthorized:
+		return "unauthorized"
+	case http.StatusForbidden:
+		return "forbidden"
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusRequestEntityTooLarge:
+		return "too_large"
+	case http.StatusUnprocessableEntity:
+		return "unprocessable"
+	case http.StatusTooManyRequests:
+		return "rate_limited"
+	case http.StatusServiceUnavailable:
+		return "unavailable"
+	}
+	if status >= http.StatusInternalServerError {
+		return "internal"
+	}
+	return "client_error"
+}
@@ -0,0 +1,301 @@
+package reload
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sinaw369/Package-Optimizer/internal/auth"
+	"github.com/sinaw369/Package-Optimizer/internal/catalog"
+	"github.com/sinaw369/Package-Optimizer/internal/config"
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+	"github.com/sinaw369/Package-Optimizer/internal/logging"
+	"github.com/sinaw369/Package-Optimizer/internal/metrics"
+)
+
+// Triggers reported in the reload logs.
+const (
+	// TriggerSignal is a reload requested with SIGHUP
+	TriggerSignal = "SIGHUP"
+	// TriggerFile is a reload caused by a change to the configuration file
+	TriggerFile = "file change"
+)
+
+// Targets are the running components a reload updates.
+type Targets struct {
+	// Catalog receives the new package sizes, limits, stock and costs
+	Catalog *catalog.Catalog
+	// LogLevel receives the new log level (may be nil)
+	LogLevel *slog.LevelVar
+	// Keyring receives the keys read again from auth.keys_file (nil when
+	// authentication is disabled)
+	Keyring *auth.Keyring
+}
+
+// Reloader reloads the configuration without a restart, on SIGHUP and whenever the
+// configuration file changes.
+//
+// A reload loads and validates the whole configuration first; if anything is
+// invalid, the current configuration stays in effect. Otherwise the changed
+// settings are applied and logged with their old and new values. The catalog is
+// swapped atomically, so requests already running finish on the old catalog.
+//
+// The catalog settings (package sizes, named catalogs, costs and stock), the solve
+// limits, the log level, the watch interval and the API keys are applied; the key
+// file is read again on every reload, so keys can be added, revoked or rotated with
+// SIGHUP. Every other setting takes effect after a restart:
+// it is logged as pending and stays pending, so Current reports only what is in effect.
+//
+// Reloader is safe for concurrent use.
+type Reloader struct {
+	// load reads the configuration from its sources
+	load func() (*config.Config, error)
+	// targets are the components updated by a reload
+	targets Targets
+
+	// mu serializes reloads and guards current
+	mu sync.Mutex
+	// current is the configuration in effect
+	current *config.Config
+}
+
+// New creates a reloader for a service started with the given configuration.
+//
+// Args:
+//   - cfg: the configuration in effect
+//   - load: reads the configuration again (normally config.Load with the original arguments)
+//   - targets: the components to update
+//
+// Returns:
+//   - *Reloader: reloader ready for use; call Watch to reload automatically
+//
+// Example:
+//
+//	reloader := reload.New(cfg, func() (*config.Config, error) { return config.Load(os.Args[1:]) },
+//	    reload.Targets{Catalog: packageCatalog, LogLevel: &logLevel, Keyring: keyring})
+//	reloader.Watch(ctx)
+func New(cfg *config.Config, load func() (*config.Config, error), targets Targets) *Reloader {
+	return &Reloader{load: load, targets: targets, current: cfg}
+}
+
+// Current returns the configuration in effect.
+func (r *Reloader) Current() *config.Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Reload loads the configuration and applies what changed.
+//
+// Args:
+//   - trigger: what caused the reload, for the logs (e.g., TriggerSignal)
+//
+// Returns:
+//   - []config.Change: the settings that changed; empty if nothing did
+//   - error: if the configuration is invalid or could not be applied; the current
+//     configuration then stays in effect
+func (r *Reloader) Reload(trigger string) ([]config.Change, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Validate the whole configuration, and read the key file, before touching anything
+	next, err := r.load()
+	var keys *auth.Keyring
+	if err == nil {
+		keys, err = r.validate(next)
+	}
+	if err != nil {
+		metrics.ObserveConfigReload(false)
+		slog.Error("configuration reload failed; keeping the current configuration", "trigger", trigger, "error", err)
+		return nil, err
+	}
+
+	// Only record what was applied, so settings needing a restart stay pending
+	changes := config.Diff(r.current, next)
+	applied := r.apply(next, changes, keys)
+	r.current = config.Merge(r.current, next, applied)
+	metrics.ObserveConfigReload(true)
+
+	if len(changes) == 0 {
+		slog.Info("configuration reloaded; nothing changed", "trigger", trigger)
+		return nil, nil
+	}
+
+	// Log the diff, and which changes still need a restart
+	diff := make([]any, len(changes))
+	var restart []string
+	for i, change := range changes {
+		diff[i] = slog.String(change.Field, change.Old+" -> "+change.New)
+		if !slices.Contains(applied, change.Field) {
+			restart = append(restart, change.Field)
+		}
+	}
+	slog.Info("configuration reloaded", "trigger", trigger, slog.Group("changes", diff...))
+	if len(restart) > 0 {
+		slog.Warn("some changed settings take effect only after a restart", "settings", restart)
+	}
+	return changes, nil
+}
+
+// validate checks what config.Load can't: that the catalog accepts the package sizes
+// it will offer together with the costs and, when authentication is enabled, that the
+// key file is valid.
+//
+// Returns:
+//   - *auth.Keyring: the keys read from the key file, nil if they are not reloaded
+//   - error: the first problem found
+func (r *Reloader) validate(next *config.Config) (*auth.Keyring, error) {
+	if err := catalog.Validate(next.PackageSizes); err != nil {
+		return nil, fmt.Errorf("catalog.package_sizes: %w", err)
+	}
+	// Sizes kept from the admin API must have a price too
+	if _, err := catalog.NewOptimizer(r.packageSizes(next), catalogSettings(next)); err != nil {
+		return nil, fmt.Errorf("catalog.costs: %w", err)
+	}
+
+	// Turning authentication on or off needs a restart; otherwise read the keys again
+	if r.targets.Keyring == nil || next.AuthKeysFile == "" {
+		return nil, nil
+	}
+	keys, err := auth.LoadKeyring(next.AuthKeysFile)
+	if err != nil {
+		return nil, fmt.Errorf("auth.keys_file: %w", err)
+	}
+	return keys, nil
+}
+
+// apply updates the targets for the changed settings. The new configuration has
+// been validated, so applying it cannot fail.
+//
+// Returns:
+//   - []string: the changed settings that were applied
+func (r *Reloader) apply(next *config.Config, changes []config.Change, keys *auth.Keyring) []string {
+	var applied []string
+	changed := func(fields ...string) []string {
+		var found []string
+		for _, field := range fields {
+			if config.Changed(changes, field) {
+				found = append(found, field)
+			}
+		}
+		return found
+	}
+
+	catalogChanges := changed("catalog.package_sizes", "catalog.name", "catalog.costs", "catalog.stock", "catalogs",
+		"limits.max_quantity", "limits.max_solve_memory_mb")
+	if len(catalogChanges) > 0 {
+		if _, err := r.targets.Catalog.Reconfigure(r.packageSizes(next), catalogSettings(next)); err != nil {
+			// Sizes and costs were validated above
+			slog.Error("catalog reconfiguration failed", "error", err)
+		} else {
+			applied = append(applied, catalogChanges...)
+		}
+	}
+
+	if r.targets.LogLevel != nil && config.Changed(changes, "logging.level") {
+		if level, err := logging.ParseLevel(next.LogLevel); err == nil {
+			r.targets.LogLevel.Set(level)
+			applied = append(applied, "logging.level")
+		}
+	}
+
+	// Watch reads the interval from Current before every poll
+	applied = append(applied, changed("config.watch_interval")...)
+
+	if keys != nil {
+		r.targets.Keyring.Replace(keys)
+		applied = append(applied, changed("auth.keys_file")...)
+		slog.Info("API keys reloaded", "file", next.AuthKeysFile)
+	}
+	return applied
+}
+
+// packageSizes returns the sizes the catalog offers under the next configuration:
+// the configured ones if they changed, and otherwise the catalog's own, so sizes set
+// through the admin API survive reloads that don't touch them.
+func (r *Reloader) packageSizes(next *config.Config) []int {
+	if !slices.Equal(r.current.PackageSizes, next.PackageSizes) {
+		return next.PackageSizes
+	}
+	return r.targets.Catalog.PackageSizes()
+}
+
+// catalogSettings returns the limits, stock and costs of a configuration.
+func catalogSettings(cfg *config.Config) catalog.Settings {
+	return catalog.Settings{
+		Limits: domain.Limits{MaxQuantity: cfg.MaxQuantity, MaxMemory: cfg.MaxSolveMemory},
+		Stock:  cfg.PackageStock,
+		Costs:  cfg.PackageCosts,
+	}
+}
+
+// Watch reloads the configuration on SIGHUP and, if a configuration file is in use,
+// whenever it changes, until ctx is cancelled. The file is polled every
+// config.watch_interval; editors that replace the file instead of writing it in
+// place are detected too. Watch returns immediately; SIGHUP is handled from the
+// moment it returns.
+func (r *Reloader) Watch(ctx context.Context) {
+	// Register before returning, so an early SIGHUP doesn't terminate the process
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	// Take the file's state now too, so changes made after Watch returns are detected
+	last := statFile(r.Current().File)
+
+	go func() {
+		defer signal.Stop(hangup)
+
+		for {
+			// Poll only when a file is in use; the interval may change on reload
+			var poll <-chan time.Time
+			var timer *time.Timer
+			if cfg := r.Current(); cfg.File != "" && cfg.WatchInterval > 0 {
+				timer = time.NewTimer(cfg.WatchInterval)
+				poll = timer.C
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-hangup:
+				r.Reload(TriggerSignal)
+				last = statFile(r.Current().File)
+			case <-poll:
+				if state := statFile(r.Current().File); state != last {
+					last = state
+					r.Reload(TriggerFile)
+				}
+			}
+			if timer != nil {
+				timer.Stop()
+			}
+		}
+	}()
+}
+
+// fileState identifies a version of the configuration file.
+type fileState struct {
+	// modTime and size change whenever the file is written or replaced
+	modTime time.Time
+	size    int64
+	// exists is false if the file could not be read
+	exists bool
+}
+
+// statFile returns the state of the file at path (the zero state if path is empty).
+func statFile(path string) fileState {
+	if path == "" {
+		return fileState{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{}
+	}
+	return fileState{modTime: info.ModTime(), size: info.Size(), exists: true}
+}
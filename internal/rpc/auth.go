@@ -0,0 +1,100 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	optimizerv1 "github.com/sinaw369/Package-Optimizer/api/proto/optimizer/v1"
+	"github.com/sinaw369/Package-Optimizer/internal/auth"
+	"github.com/sinaw369/Package-Optimizer/internal/metrics"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// openMethods lists the RPCs anyone may call without an API key, like their HTTP
+// counterparts: the ones that only read the catalog.
+var openMethods = map[string]bool{
+	optimizerv1.OptimizerService_ListPackageSizes_FullMethodName: true,
+}
+
+// AuthInterceptor creates a unary interceptor that requires every RPC except the open
+// ones (ListPackageSizes, as GET /api/package-sizes) to present an API key with the
+// calculate scope, using the same keys and quotas as the HTTP API. Open RPCs are
+// neither authenticated nor counted. A BatchCalculate call counts one request per quantity. Calls that fail with
+// InvalidArgument or ResourceExhausted are not counted against the quota.
+// The key is read from the "x-api-key" metadata entry or an "authorization: Bearer <key>" entry.
+//
+// Args:
+//   - keyring: the API keys allowed to call the service
+//
+// Returns:
+//   - grpc.UnaryServerInterceptor: interceptor to install with grpc.UnaryInterceptor
+//
+// Errors:
+//   - codes.Unauthenticated if the key is missing or unknown
+//   - codes.PermissionDenied if the key lacks the calculate scope
+//   - codes.ResourceExhausted if the key's quota is exhausted
+func AuthInterceptor(keyring *auth.Keyring) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if openMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		client, _, err := keyring.Authorize(apiKeyFromMetadata(ctx), auth.ScopeCalculate)
+		switch {
+		case errors.Is(err, auth.ErrUnauthenticated):
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		case errors.Is(err, auth.ErrForbidden):
+			metrics.ObserveAPIKeyRequest(client.KeyID, "forbidden")
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		case errors.Is(err, auth.ErrQuotaExceeded):
+			metrics.ObserveAPIKeyRequest(client.KeyID, "quota_exceeded")
+			return nil, status.Error(codes.ResourceExhausted, err.Error())
+		case err != nil:
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+
+		// A batch counts one request per quantity
+		cost := requestCost(req)
+		if _, err := keyring.Charge(client.KeyID, cost-1); err != nil {
+			keyring.Refund(client.KeyID, 1)
+			if !errors.Is(err, auth.ErrQuotaExceeded) {
+				// The key was removed by a reload since it was authorized
+				return nil, status.Error(codes.Unauthenticated, err.Error())
+			}
+			metrics.ObserveAPIKeyRequest(client.KeyID, "quota_exceeded")
+			return nil, status.Errorf(codes.ResourceExhausted, "%v: the call counts as %d requests", err, cost)
+		}
+		metrics.ObserveAPIKeyRequest(client.KeyID, "allowed")
+
+		resp, err := handler(auth.WithClient(ctx, client), req)
+
+		// Give back calls rejected as invalid or by a limit, as the HTTP API does
+		if code := status.Code(err); code == codes.InvalidArgument || code == codes.ResourceExhausted {
+			keyring.Refund(client.KeyID, cost)
+		}
+		return resp, err
+	}
+}
+
+// apiKeyFromMetadata extracts the API key from the incoming request metadata.
+func apiKeyFromMetadata(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get("x-api-key"); len(values) > 0 {
+		return values[0]
+	}
+	for _, value := range md.Get("authorization") {
+		scheme, token, ok := strings.Cut(value, " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+	return ""
+}
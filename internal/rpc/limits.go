@@ -0,0 +1,87 @@
+package rpc
+
+import (
+	"context"
+	"net"
+
+	optimizerv1 "github.com/sinaw369/Package-Optimizer/api/proto/optimizer/v1"
+	"github.com/sinaw369/Package-Optimizer/internal/auth"
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+	"github.com/sinaw369/Package-Optimizer/internal/limits"
+	"github.com/sinaw369/Package-Optimizer/internal/metrics"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// limitedMethods lists the RPCs subject to the rate limit: the ones that solve.
+var limitedMethods = map[string]bool{
+	optimizerv1.OptimizerService_Calculate_FullMethodName:      true,
+	optimizerv1.OptimizerService_BatchCalculate_FullMethodName: true,
+}
+
+// RateLimitInterceptor creates a unary interceptor that applies the per-client token
+// bucket to the calculation RPCs. Clients are identified as in the HTTP API (by API
+// key when authenticated, otherwise by address), so a client's HTTP and gRPC calls
+// draw from the same bucket, and a batch takes one token per quantity (see
+// limits.Limiter.Charge). It must run after AuthInterceptor so the API client is
+// known.
+//
+// Args:
+//   - limiter: the limiter shared with the HTTP API
+//
+// Returns:
+//   - grpc.UnaryServerInterceptor: interceptor to install with grpc.ChainUnaryInterceptor
+//
+// Errors:
+//   - codes.ResourceExhausted with a "retry-after" header (seconds) if the client is over its rate
+func RateLimitInterceptor(limiter *limits.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !limitedMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		key := clientKey(ctx)
+		if ok, wait := limiter.Allow(key); !ok {
+			metrics.ObserveLimitRejection("rate")
+			grpc.SetHeader(ctx, metadata.Pairs("retry-after", limits.RetryAfterSeconds(wait)))
+			return nil, status.Error(codes.ResourceExhausted, "rate limit exceeded")
+		}
+		limiter.Charge(key, requestCost(req)-1)
+		return handler(ctx, req)
+	}
+}
+
+// requestCost returns how many requests a call counts as against the rate limit and
+// quota: one per quantity for BatchCalculate, one for any other call. A batch over
+// domain.MaxBatchQuantities is rejected before any work and counts as one.
+func requestCost(req interface{}) int {
+	batch, ok := req.(*optimizerv1.BatchCalculateRequest)
+	if !ok {
+		return 1
+	}
+	if n := len(batch.GetQuantities()); n > 1 && n <= domain.MaxBatchQuantities {
+		return n
+	}
+	return 1
+}
+
+// clientKey identifies the caller for the rate limit: "key:<key ID>" for
+// authenticated calls, otherwise "ip:<address>" of the connection's peer.
+func clientKey(ctx context.Context) string {
+	if client, ok := auth.ClientFromContext(ctx); ok {
+		return "key:" + client.KeyID
+	}
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "ip:unknown"
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return "ip:" + p.Addr.String()
+	}
+	return "ip:" + host
+}
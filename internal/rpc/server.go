@@ -0,0 +1,223 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	optimizerv1 "github.com/sinaw369/Package-Optimizer/api/proto/optimizer/v1"
+	"github.com/sinaw369/Package-Optimizer/internal/cache"
+	"github.com/sinaw369/Package-Optimizer/internal/catalog"
+	"github.com/sinaw369/Package-Optimizer/internal/coalesce"
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+	"github.com/sinaw369/Package-Optimizer/internal/limits"
+	"github.com/sinaw369/Package-Optimizer/internal/metrics"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Options configures the protections the gRPC service shares with the HTTP API.
+// Zero values disable the corresponding protection.
+type Options struct {
+	// Limiter caps the estimated memory of concurrent solves; install
+	// RateLimitInterceptor with the same limiter for the per-client rate limit
+	Limiter *limits.Limiter
+	// Cache holds recent results, shared with the HTTP API
+	Cache *cache.Cache
+}
+
+// Server implements the OptimizerService gRPC API.
+// It is a thin adapter over the same package catalog used by the HTTP handlers,
+// so both transports always return identical results. Solves go through the same
+// limits and result cache as HTTP calculations, and concurrent calls for the same
+// result share one solve.
+type Server struct {
+	optimizerv1.UnimplementedOptimizerServiceServer
+
+	// catalog provides the optimizer shared with the HTTP API
+	catalog *catalog.Catalog
+	// opts holds the solver memory limit and the result cache
+	opts Options
+	// inflight shares one solve between concurrent calls for the same result
+	inflight coalesce.Group[cache.Key, *domain.OptimizationResult]
+}
+
+// NewServer creates a gRPC service implementation backed by the given catalog.
+//
+// Args:
+//   - catalog: the package catalog whose current optimizer performs calculations
+//   - opts: the limiter and result cache shared with the HTTP API
+//
+// Returns:
+//   - *Server: service implementation ready to be registered
+func NewServer(catalog *catalog.Catalog, opts Options) *Server {
+	return &Server{catalog: catalog, opts: opts}
+}
+
+// Register creates a gRPC server with the optimizer service registered on it.
+//
+// Args:
+//   - catalog: the package catalog whose current optimizer performs calculations
+//   - opts: the limiter and result cache shared with the HTTP API
+//   - serverOpts: additional gRPC server options (interceptors, credentials, ...)
+//
+// Returns:
+//   - *grpc.Server: server ready to Serve on a listener
+//
+// Example:
+//
+//	server := rpc.Register(packageCatalog, rpc.Options{Limiter: limiter, Cache: results},
+//	    grpc.ChainUnaryInterceptor(rpc.AuthInterceptor(keyring), rpc.RateLimitInterceptor(limiter)))
+func Register(catalog *catalog.Catalog, opts Options, serverOpts ...grpc.ServerOption) *grpc.Server {
+	server := grpc.NewServer(serverOpts...)
+	optimizerv1.RegisterOptimizerServiceServer(server, NewServer(catalog, opts))
+	return server
+}
+
+// Calculate returns the optimal package combination for one quantity.
+func (s *Server) Calculate(ctx context.Context, req *optimizerv1.CalculateRequest) (*optimizerv1.CalculateResponse, error) {
+	optimizer := s.catalog.Optimizer()
+	result, err := s.solve(ctx, optimizer, req.GetQuantity())
+	if err != nil {
+		return nil, s.toStatus(ctx, err)
+	}
+	return &optimizerv1.CalculateResponse{Result: toProto(result, optimizer.CatalogVersion())}, nil
+}
+
+// BatchCalculate returns the optimal package combination for each quantity, for at
+// most domain.MaxBatchQuantities quantities. Invalid quantities are reported per
+// item; a busy solver or cancellation fails the whole call. Every item is computed
+// with the catalog that was current when the call started.
+func (s *Server) BatchCalculate(ctx context.Context, req *optimizerv1.BatchCalculateRequest) (*optimizerv1.BatchCalculateResponse, error) {
+	if n := len(req.GetQuantities()); n > domain.MaxBatchQuantities {
+		return nil, status.Errorf(codes.InvalidArgument,
+			"too many quantities: %d, at most %d per batch; split larger sets across several calls", n, domain.MaxBatchQuantities)
+	}
+
+	optimizer := s.catalog.Optimizer()
+	items := make([]*optimizerv1.BatchItem, 0, len(req.GetQuantities()))
+	for _, quantity := range req.GetQuantities() {
+		item := &optimizerv1.BatchItem{Quantity: quantity}
+
+		result, err := s.solve(ctx, optimizer, quantity)
+		switch {
+		case err == nil:
+			item.Outcome = &optimizerv1.BatchItem_Result{Result: toProto(result, optimizer.CatalogVersion())}
+		case errors.Is(err, limits.ErrBusy) || ctx.Err() != nil:
+			return nil, s.toStatus(ctx, err)
+		default:
+			item.Outcome = &optimizerv1.BatchItem_Error{Error: err.Error()}
+		}
+
+		items = append(items, item)
+	}
+	return &optimizerv1.BatchCalculateResponse{Items: items}, nil
+}
+
+// ListPackageSizes returns the package sizes available for optimization.
+func (s *Server) ListPackageSizes(ctx context.Context, req *optimizerv1.ListPackageSizesRequest) (*optimizerv1.ListPackageSizesResponse, error) {
+	optimizer := s.catalog.Optimizer()
+	sizes := optimizer.PackageSizes()
+	resp := &optimizerv1.ListPackageSizesResponse{
+		PackageSizes:   make([]int64, len(sizes)),
+		CatalogVersion: optimizer.CatalogVersion(),
+	}
+	for i, size := range sizes {
+		resp.PackageSizes[i] = int64(size)
+	}
+	return resp, nil
+}
+
+// solve returns the result for a quantity: from the cache if possible, otherwise
+// by solving once the limiter has room for the solve's table. Concurrent calls for
+// the same result share one solve.
+//
+// Returns:
+//   - *domain.OptimizationResult: the result (read-only, it may be shared)
+//   - error: the optimizer error, limits.ErrBusy if the solver stayed busy, or the
+//     context error if the call was cancelled
+func (s *Server) solve(ctx context.Context, optimizer *domain.Optimizer, quantity int64) (*domain.OptimizationResult, error) {
+	// Quantities are int64 on the wire but int in the optimizer
+	if quantity > math.MaxInt {
+		return nil, fmt.Errorf("%w: %d does not fit in an int", domain.ErrQuantityTooLarge, quantity)
+	}
+	// Reject quantities over the limits without waiting for the solver
+	if err := optimizer.Check(int(quantity)); err != nil {
+		return nil, err
+	}
+
+	key := cache.KeyFor(optimizer, int(quantity))
+	if s.opts.Cache != nil {
+		result, ok := s.opts.Cache.Get(key)
+		metrics.ObserveCacheLookup(ok)
+		if ok {
+			return result, nil
+		}
+	}
+
+	result, shared, err := s.inflight.Do(ctx, key, func(ctx context.Context) (*domain.OptimizationResult, error) {
+		// Wait for the solver to have room for a table of this size
+		if s.opts.Limiter != nil {
+			release, err := s.opts.Limiter.AcquireSolve(ctx, optimizer.EstimateMemory(int(quantity)))
+			if err != nil {
+				return nil, err
+			}
+			defer release()
+		}
+
+		result, err := optimizer.OptimizeContext(ctx, int(quantity))
+		if s.opts.Cache != nil && result != nil {
+			s.opts.Cache.Add(key, result)
+		}
+		return result, err
+	})
+	if shared {
+		metrics.ObserveCoalesced()
+	}
+	return result, err
+}
+
+// toProto converts a domain result into its protobuf representation.
+// Packages are listed largest size first so the output is deterministic.
+func toProto(result *domain.OptimizationResult, catalogVersion string) *optimizerv1.OptimizationResult {
+	msg := &optimizerv1.OptimizationResult{
+		Requested:      int64(result.Requested),
+		TotalDelivered: int64(result.TotalDelivered),
+		OverDelivery:   int64(result.OverDelivery),
+		CatalogVersion: catalogVersion,
+	}
+
+	for sizeStr, count := range result.Packages {
+		size, _ := strconv.Atoi(sizeStr)
+		msg.Packages = append(msg.Packages, &optimizerv1.PackageCount{Size: int64(size), Count: int64(count)})
+	}
+	sort.Slice(msg.Packages, func(i, j int) bool {
+		return msg.Packages[i].Size > msg.Packages[j].Size
+	})
+
+	return msg
+}
+
+// toStatus maps optimizer and limiter errors onto gRPC status codes. A busy solver
+// is reported as ResourceExhausted with a "retry-after" header in seconds, like the
+// HTTP API's Retry-After.
+func (s *Server) toStatus(ctx context.Context, err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	if errors.Is(err, limits.ErrBusy) {
+		metrics.ObserveLimitRejection("concurrency")
+		grpc.SetHeader(ctx, metadata.Pairs("retry-after", limits.RetryAfterSeconds(s.opts.Limiter.SolveWait())))
+		return status.Error(codes.ResourceExhausted, "solver is busy, try again later")
+	}
+	if errors.Is(err, domain.ErrMemoryLimitExceeded) {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	return status.Error(codes.InvalidArgument, err.Error())
+}
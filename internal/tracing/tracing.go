@@ -0,0 +1,169 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Exporter names accepted by Options.Exporter.
+const (
+	// ExporterNone disables tracing (spans are still propagated but not recorded)
+	ExporterNone = "none"
+	// ExporterOTLP sends spans to an OpenTelemetry collector over OTLP
+	ExporterOTLP = "otlp"
+	// ExporterStdout writes spans as JSON to standard output
+	ExporterStdout = "stdout"
+	// ExporterFile writes spans as JSON to a file
+	ExporterFile = "file"
+)
+
+// Options configures tracing.
+type Options struct {
+	// Exporter selects where spans are sent: none, otlp, stdout or file
+	Exporter string
+	// ServiceName is reported as the service.name resource attribute
+	ServiceName string
+	// OTLPEndpoint is the collector address (host:port) for the otlp exporter
+	OTLPEndpoint string
+	// OTLPProtocol is "http" (OTLP/HTTP) or "grpc" (OTLP/gRPC)
+	OTLPProtocol string
+	// OTLPInsecure disables TLS when talking to the collector
+	OTLPInsecure bool
+	// OTLPHeaders are sent with every export (e.g., collector credentials)
+	OTLPHeaders map[string]string
+	// FilePath is where the file exporter writes spans
+	FilePath string
+	// SampleRatio is the fraction of new traces to record (0..1); incoming sampled traces are always recorded
+	SampleRatio float64
+}
+
+// Setup installs the global tracer provider and W3C trace context propagation.
+// The returned function flushes buffered spans and releases exporter resources;
+// it must be called during shutdown.
+//
+// Args:
+//   - ctx: context used while creating the exporter
+//   - opts: exporter and sampling settings
+//
+// Returns:
+//   - func(context.Context) error: shutdown function
+//   - error: if the exporter cannot be created
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	// Always propagate incoming W3C trace context and baggage, even when not exporting,
+	// so this service doesn't break traces that pass through it
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if opts.Exporter == "" || opts.Exporter == ExporterNone {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	// Create the span exporter
+	exporter, closer, err := newExporter(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Describe this service on every span
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(opts.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("create tracing resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			if closeErr := closer.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		return err
+	}, nil
+}
+
+// newExporter creates the span exporter selected by opts.Exporter.
+// The returned closer (may be nil) releases resources owned by the exporter, such as files.
+func newExporter(ctx context.Context, opts Options) (sdktrace.SpanExporter, io.Closer, error) {
+	switch opts.Exporter {
+	case ExporterOTLP:
+		exporter, err := newOTLPExporter(ctx, opts)
+		return exporter, nil, err
+
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, nil, fmt.Errorf("create stdout trace exporter: %w", err)
+		}
+		return exporter, nil, nil
+
+	case ExporterFile:
+		if err := os.MkdirAll(filepath.Dir(opts.FilePath), 0o755); err != nil {
+			return nil, nil, fmt.Errorf("create trace file directory: %w", err)
+		}
+		file, err := os.OpenFile(opts.FilePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("open trace file: %w", err)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, nil, fmt.Errorf("create file trace exporter: %w", err)
+		}
+		return exporter, file, nil
+	}
+
+	return nil, nil, fmt.Errorf("unknown trace exporter %q: must be none, otlp, stdout or file", opts.Exporter)
+}
+
+// newOTLPExporter creates an OTLP exporter using the configured protocol.
+func newOTLPExporter(ctx context.Context, opts Options) (sdktrace.SpanExporter, error) {
+	switch opts.OTLPProtocol {
+	case "grpc":
+		clientOpts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(opts.OTLPEndpoint), otlptracegrpc.WithHeaders(opts.OTLPHeaders)}
+		if opts.OTLPInsecure {
+			clientOpts = append(clientOpts, otlptracegrpc.WithInsecure())
+		}
+		exporter, err := otlptracegrpc.New(ctx, clientOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("create OTLP/gRPC trace exporter: %w", err)
+		}
+		return exporter, nil
+
+	case "http", "":
+		clientOpts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(opts.OTLPEndpoint), otlptracehttp.WithHeaders(opts.OTLPHeaders)}
+		if opts.OTLPInsecure {
+			clientOpts = append(clientOpts, otlptracehttp.WithInsecure())
+		}
+		exporter, err := otlptracehttp.New(ctx, clientOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("create OTLP/HTTP trace exporter: %w", err)
+		}
+		return exporter, nil
+	}
+
+	return nil, fmt.Errorf("unknown OTLP protocol %q: must be http or grpc", opts.OTLPProtocol)
+}
@@ -0,0 +1,324 @@
+// Package client is a Go client for the package optimizer HTTP API.
+//
+// It provides typed methods for the calculation, batch, package size and catalog
+// endpoints, retries transient failures with exponential backoff, honours
+// context cancellation and deadlines, and maps error responses onto *APIError
+// values that can be matched with errors.Is against the Err* sentinels.
+//
+// Example:
+//
+//	c, err := client.New("http://localhost:8080", client.Options{APIKey: os.Getenv("OPTIMIZER_API_KEY")})
+//	if err != nil {
+//	    return err
+//	}
+//	result, err := c.Calculate(ctx, 1201)
+//	if errors.Is(err, client.ErrQuantityTooLarge) {
+//	    // split the order
+//	}
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers the client sends.
+const (
+	headerAPIKey    = "X-API-Key"
+	headerRequestID = "X-Request-ID"
+)
+
+// DefaultUserAgent identifies the client to the server unless Options.UserAgent is set.
+const DefaultUserAgent = "package-optimizer-client/1"
+
+// RetryPolicy controls how failed requests are retried.
+//
+// Connection errors and the statuses 429, 502, 503 and 504 are retried; every API
+// operation is idempotent, so this is safe for all of them. The wait before each
+// retry doubles from InitialBackoff up to MaxBackoff, with random jitter, unless the
+// server asks for a specific wait with Retry-After.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first (default 3;
+	// 1 disables retries)
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry (default 100ms)
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between attempts, including waits asked for with
+	// Retry-After (default 5s)
+	MaxBackoff time.Duration
+}
+
+// withDefaults fills in the zero fields of the policy.
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 3
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = 100 * time.Millisecond
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = 5 * time.Second
+	}
+	return p
+}
+
+// backoff returns the wait before the given retry (1 for the first), with full jitter.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	wait := p.InitialBackoff
+	for i := 1; i < retry && wait < p.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > p.MaxBackoff {
+		wait = p.MaxBackoff
+	}
+	return time.Duration(rand.Int63n(int64(wait)) + 1)
+}
+
+// Options configures a Client. The zero value is a client without authentication
+// that uses http.DefaultClient and the default retry policy.
+type Options struct {
+	// HTTPClient sends the requests (default http.DefaultClient); set its Timeout
+	// or use contexts to bound each attempt
+	HTTPClient *http.Client
+	// APIKey is sent in the X-API-Key header, if set
+	APIKey string
+	// UserAgent is sent in the User-Agent header (default DefaultUserAgent)
+	UserAgent string
+	// Retry controls how failed requests are retried
+	Retry RetryPolicy
+}
+
+// Client calls the package optimizer HTTP API. It is safe for concurrent use.
+type Client struct {
+	// baseURL is the server's root URL; API paths are resolved against it
+	baseURL *url.URL
+	// options holds the configuration, with defaults applied
+	options Options
+}
+
+// New creates a client for the server at baseURL.
+//
+// Args:
+//   - baseURL: the server's root URL (e.g., "http://localhost:8080"); a path prefix is kept
+//   - options: authentication, HTTP client and retry configuration
+//
+// Returns:
+//   - *Client: client ready for use
+//   - error: if baseURL is not an absolute http or https URL
+//
+// Example:
+//
+//	c, err := client.New("https://optimizer.internal", client.Options{
+//	    APIKey: apiKey,
+//	    Retry:  client.RetryPolicy{MaxAttempts: 5},
+//	})
+func New(baseURL string, options Options) (*Client, error) {
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q: must be an absolute http or https URL", baseURL)
+	}
+	parsed.Path = strings.TrimSuffix(parsed.Path, "/")
+
+	if options.HTTPClient == nil {
+		options.HTTPClient = http.DefaultClient
+	}
+	if options.UserAgent == "" {
+		options.UserAgent = DefaultUserAgent
+	}
+	options.Retry = options.Retry.withDefaults()
+	return &Client{baseURL: parsed, options: options}, nil
+}
+
+// Calculate returns the optimal package combination for a quantity.
+//
+// Returns:
+//   - *Result: the packages to ship
+//   - error: an *APIError matching ErrInvalidRequest, ErrMemoryLimitExceeded or
+//     ErrQuantityTooLarge if the quantity can't be solved, or a transport error
+func (c *Client) Calculate(ctx context.Context, quantity int) (*Result, error) {
+	var result Result
+	query := url.Values{"qty": {strconv.Itoa(quantity)}}
+	if err := c.do(ctx, http.MethodGet, "/api/calculate", query, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CalculateBatch returns the optimal package combinations for several quantities
+// (at most MaxBatchQuantities) in one request. A quantity that can't be solved is
+// reported in its item (see BatchItem.Err) without failing the call.
+//
+// Returns:
+//   - *BatchResult: one item per quantity, in order
+//   - error: if the request as a whole failed
+func (c *Client) CalculateBatch(ctx context.Context, quantities []int) (*BatchResult, error) {
+	var result BatchResult
+	body := struct {
+		Quantities []int `json:"quantities"`
+	}{quantities}
+	if err := c.do(ctx, http.MethodPost, "/api/calculate/batch", nil, body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// PackageSizes returns the package sizes currently available.
+func (c *Client) PackageSizes(ctx context.Context) ([]int, error) {
+	var result struct {
+		PackageSizes []int `json:"package_sizes"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/package-sizes", nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return result.PackageSizes, nil
+}
+
+// Catalog returns the current package catalog and its version.
+func (c *Client) Catalog(ctx context.Context) (*Catalog, error) {
+	var catalog Catalog
+	if err := c.do(ctx, http.MethodGet, "/api/catalog", nil, nil, &catalog); err != nil {
+		return nil, err
+	}
+	return &catalog, nil
+}
+
+// UpdateCatalog replaces the package sizes used for optimization. It needs an API
+// key with the catalog-admin scope when the server requires keys.
+//
+// Returns:
+//   - *Catalog: the new catalog
+//   - error: an *APIError matching ErrInvalidRequest if the sizes are invalid, or
+//     ErrUnauthorized / ErrForbidden without a suitable key
+func (c *Client) UpdateCatalog(ctx context.Context, packageSizes []int) (*Catalog, error) {
+	var catalog Catalog
+	body := struct {
+		PackageSizes []int `json:"package_sizes"`
+	}{packageSizes}
+	if err := c.do(ctx, http.MethodPut, "/api/catalog", nil, body, &catalog); err != nil {
+		return nil, err
+	}
+	return &catalog, nil
+}
+
+// do sends a request, retrying transient failures, and decodes the JSON response.
+//
+// Args:
+//   - ctx: bounds the whole call, including the waits between attempts
+//   - method, path, query: the request line
+//   - body: encoded as the JSON request body, if not nil
+//   - out: receives the decoded response
+//
+// Returns:
+//   - error: an *APIError for error responses, ctx.Err() if cancelled while waiting
+//     to retry, or the last transport error
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	// Encode the body once; every attempt sends the same bytes
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+	target := *c.baseURL
+	target.Path += path
+	target.RawQuery = query.Encode()
+
+	policy := c.options.Retry
+	for attempt := 1; ; attempt++ {
+		err := c.attempt(ctx, method, target.String(), payload, out)
+		wait, retryable := retryDelay(err)
+		if err == nil || !retryable || attempt >= policy.MaxAttempts || ctx.Err() != nil {
+			return err
+		}
+
+		// Wait as long as the server asked, or back off exponentially
+		if wait <= 0 {
+			wait = policy.backoff(attempt)
+		}
+		if wait > policy.MaxBackoff {
+			wait = policy.MaxBackoff
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt sends one request and decodes its response.
+func (c *Client) attempt(ctx context.Context, method, target string, payload []byte, out any) error {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.options.UserAgent)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.options.APIKey != "" {
+		req.Header.Set(headerAPIKey, c.options.APIKey)
+	}
+
+	resp, err := c.options.HTTPClient.Do(req)
+	if err != nil {
+		return &transportError{err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return newAPIError(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", req.URL.Path, err)
+	}
+	return nil
+}
+
+// retryDelay reports whether a failed attempt may be retried and how long the
+// server asked to wait (0 if it didn't say).
+func retryDelay(err error) (time.Duration, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return apiErr.RetryAfter, true
+		}
+		return 0, false
+	}
+	var transportErr *transportError
+	if errors.As(err, &transportErr) {
+		// Cancellation is final; connection failures are worth another try
+		return 0, !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	return 0, false
+}
+
+// transportError marks errors from sending the request or receiving the response.
+type transportError struct {
+	err error
+}
+
+func (e *transportError) Error() string { return e.err.Error() }
+func (e *transportError) Unwrap() error { return e.err }
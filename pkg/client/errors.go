@@ -0,0 +1,107 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Errors an *APIError matches with errors.Is, by response status.
+var (
+	// ErrInvalidRequest matches 400 responses (e.g., a negative quantity or invalid package sizes)
+	ErrInvalidRequest = errors.New("invalid request")
+	// ErrUnauthorized matches 401 responses: the API key is missing or unknown
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden matches 403 responses: the API key lacks the scope for the operation
+	ErrForbidden = errors.New("forbidden")
+	// ErrNotFound matches 404 responses
+	ErrNotFound = errors.New("not found")
+	// ErrMemoryLimitExceeded matches 413 responses: the solve would need too much memory
+	ErrMemoryLimitExceeded = errors.New("solve would exceed the memory limit")
+	// ErrQuantityTooLarge matches 422 responses: the quantity is over the server's maximum
+	ErrQuantityTooLarge = errors.New("quantity too large")
+	// ErrRateLimited matches 429 responses: the client is over its rate or quota, or the solver is busy
+	ErrRateLimited = errors.New("rate limited")
+	// ErrUnavailable matches 502, 503 and 504 responses
+	ErrUnavailable = errors.New("service unavailable")
+)
+
+// statusErrors maps response statuses to the sentinel they match.
+var statusErrors = map[int]error{
+	http.StatusBadRequest:            ErrInvalidRequest,
+	http.StatusUnauthorized:          ErrUnauthorized,
+	http.StatusForbidden:             ErrForbidden,
+	http.StatusNotFound:              ErrNotFound,
+	http.StatusRequestEntityTooLarge: ErrMemoryLimitExceeded,
+	http.StatusUnprocessableEntity:   ErrQuantityTooLarge,
+	http.StatusTooManyRequests:       ErrRateLimited,
+	http.StatusBadGateway:            ErrUnavailable,
+	http.StatusServiceUnavailable:    ErrUnavailable,
+	http.StatusGatewayTimeout:        ErrUnavailable,
+}
+
+// APIError is an error response from the server.
+//
+// Example:
+//
+//	var apiErr *client.APIError
+//	if errors.As(err, &apiErr) {
+//	    log.Printf("request %s failed: %s", apiErr.RequestID, apiErr.Message)
+//	}
+type APIError struct {
+	// StatusCode is the HTTP status of the response
+	StatusCode int
+	// Message is the server's error message
+	Message string
+	// RequestID identifies the request in the server logs, if known
+	RequestID string
+	// RetryAfter is how long the server asked the client to wait, if it did
+	RetryAfter time.Duration
+}
+
+// Error formats the error with its status and request ID.
+func (e *APIError) Error() string {
+	message := fmt.Sprintf("package optimizer: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+	if e.RequestID != "" {
+		message += " (request " + e.RequestID + ")"
+	}
+	return message
+}
+
+// Is reports whether the error matches one of the Err* sentinels.
+func (e *APIError) Is(target error) bool {
+	return statusErrors[e.StatusCode] == target
+}
+
+// newAPIError builds an *APIError from an error response.
+func newAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Message:    http.StatusText(resp.StatusCode),
+		RequestID:  resp.Header.Get(headerRequestID),
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	// The API answers errors with {"error": "...", "request_id": "..."}; proxies may not
+	var body struct {
+		Error     string `json:"error"`
+		RequestID string `json:"request_id"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if json.Unmarshal(data, &body) == nil && body.Error != "" {
+		apiErr.Message = body.Error
+		if body.RequestID != "" {
+			apiErr.RequestID = body.RequestID
+		}
+	} else if text := strings.TrimSpace(string(data)); text != "" && len(text) < 512 {
+		apiErr.Message = text
+	}
+	return apiErr
+}
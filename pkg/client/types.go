@@ -0,0 +1,62 @@
+package client
+
+// MaxBatchQuantities is the most quantities the server accepts in one CalculateBatch call.
+const MaxBatchQuantities = 1000
+
+// Result is the optimal package combination for one quantity.
+type Result struct {
+	// Requested is the quantity that was asked for
+	Requested int `json:"requested"`
+	// TotalDelivered is the quantity the packages add up to (at least Requested)
+	TotalDelivered int `json:"total_delivered"`
+	// OverDelivery is TotalDelivered - Requested
+	OverDelivery int `json:"over_delivery"`
+	// Packages maps package sizes (as strings, e.g., "250") to the number of packages
+	Packages map[string]int `json:"packages"`
+}
+
+// PackageCount returns the total number of packages in the result.
+func (r *Result) PackageCount() int {
+	total := 0
+	for _, count := range r.Packages {
+		total += count
+	}
+	return total
+}
+
+// BatchResult is the response to CalculateBatch.
+type BatchResult struct {
+	// CatalogVersion identifies the catalog every item was computed with
+	CatalogVersion string `json:"catalog_version"`
+	// Items holds one outcome per requested quantity, in request order
+	Items []BatchItem `json:"items"`
+}
+
+// BatchItem is the outcome for one quantity of a batch: either a result or an error.
+type BatchItem struct {
+	// Quantity is the requested quantity
+	Quantity int `json:"quantity"`
+	// Result is the package combination, nil if the quantity failed
+	Result *Result `json:"result,omitempty"`
+	// Error describes why the quantity failed, empty on success
+	Error string `json:"error,omitempty"`
+	// Status is the HTTP status Calculate would have failed with, 0 on success
+	Status int `json:"status,omitempty"`
+}
+
+// Err returns the item's failure as an *APIError (matching the Err* sentinels like
+// Calculate's errors), or nil if the item succeeded.
+func (i BatchItem) Err() error {
+	if i.Error == "" {
+		return nil
+	}
+	return &APIError{StatusCode: i.Status, Message: i.Error}
+}
+
+// Catalog is the set of package sizes the server optimizes with.
+type Catalog struct {
+	// Version identifies the package sizes; results record the version they were computed with
+	Version string `json:"version"`
+	// PackageSizes lists the available package sizes
+	PackageSizes []int `json:"package_sizes"`
+}
@@ -0,0 +1,129 @@
+package optimizer
+
+import (
+	"context"
+	"errors"
+)
+
+// Alternatives returns up to n packings for a quantity, best first: the optimal
+// result, then the best packing for each next larger total the catalog can deliver.
+// They show what the optimizer traded away, e.g., when a slightly larger delivery
+// would need far fewer packages.
+//
+// Each alternative is a full solve for one more than the previous total, so
+// Alternatives costs about n times Optimize, and stops early once that quantity
+// exceeds the optimizer's limits or its stock.
+//
+// Args:
+//   - ctx: cancels the solves
+//   - quantity: the requested quantity
+//   - n: the maximum number of packings to return
+//
+// Returns:
+//   - []*Result: the packings, each with Requested set to quantity
+//   - error: if the quantity itself can't be solved, or ctx was cancelled
+//
+// Example:
+//
+//	alternatives, err := optimizer.Alternatives(ctx, 1201, 3)
+//	// 1250 (1 x 1000, 1 x 250), 1500 (1 x 1000, 1 x 500), 1750 (...)
+func (o *Optimizer) Alternatives(ctx context.Context, quantity, n int) ([]*Result, error) {
+	var alternatives []*Result
+	target := quantity
+	for len(alternatives) < n {
+		// The best packing for at least target is the next deliverable total
+		result, err := o.OptimizeContext(ctx, target)
+		if err != nil {
+			if len(alternatives) > 0 && (errors.Is(err, ErrQuantityTooLarge) || errors.Is(err, ErrMemoryLimitExceeded) ||
+				errors.Is(err, ErrInsufficientStock)) {
+				break
+			}
+			return nil, err
+		}
+		result.Requested = quantity
+		result.OverDelivery = result.TotalDelivered - quantity
+		alternatives = append(alternatives, result)
+		target = result.TotalDelivered + 1
+	}
+	return alternatives, nil
+}
+
+// AnalyzeGaps reports which quantities from 1 to upTo the catalog can deliver
+// exactly, and how much the others are over-delivered. Only the deliverable
+// totals are computed, not the packings, so it is much cheaper than solving every
+// quantity. The optimizer's limits apply to upTo; its stock and costs don't, since
+// the analysis describes the catalog rather than what is on hand.
+//
+// Args:
+//   - ctx: cancels the analysis
+//   - upTo: the largest quantity to analyze
+//
+// Returns:
+//   - *GapAnalysis: coverage statistics and the over-delivered ranges
+//   - error: if upTo exceeds the limits or ctx was cancelled
+//
+// Example:
+//
+//	analysis, err := MustNew([]int{250, 500}).AnalyzeGaps(ctx, 1000)
+//	// analysis.Exact == 4, analysis.Gaps[0] == Gap{From: 1, To: 249, Delivered: 250}
+func (o *Optimizer) AnalyzeGaps(ctx context.Context, upTo int) (*GapAnalysis, error) {
+	if upTo < 1 {
+		return nil, errors.New("quantity must be positive")
+	}
+	if err := o.Check(upTo); err != nil {
+		return nil, err
+	}
+
+	// reachable[i] is true if some combination of packages totals exactly i.
+	// Every quantity is covered within one largest package, so the table stops there.
+	rows := o.tableRows(upTo)
+	reachable := make([]bool, rows+1)
+	reachable[0] = true
+	for i := 1; i <= rows; i++ {
+		if i%cancelCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		for _, size := range o.packageSizes {
+			if size <= i && reachable[i-size] {
+				reachable[i] = true
+				break
+			}
+		}
+	}
+
+	// Walk down from the top, tracking the next deliverable total for each quantity
+	analysis := &GapAnalysis{UpTo: upTo}
+	next := rows
+	for !reachable[next] {
+		next--
+	}
+	totalOver := 0
+	for q := upTo; q >= 1; q-- {
+		if reachable[q] {
+			next = q
+			analysis.Exact++
+			continue
+		}
+		over := next - q
+		totalOver += over
+		if over > analysis.MaxOverDelivery {
+			analysis.MaxOverDelivery = over
+		}
+
+		// Extend the gap found at q+1, or start a new one
+		if last := len(analysis.Gaps) - 1; last >= 0 && analysis.Gaps[last].From == q+1 {
+			analysis.Gaps[last].From = q
+		} else {
+			analysis.Gaps = append(analysis.Gaps, Gap{From: q, To: q, Delivered: next})
+		}
+	}
+	analysis.AverageOverDelivery = float64(totalOver) / float64(upTo)
+
+	// The gaps were found top-down
+	for i, j := 0, len(analysis.Gaps)-1; i < j; i, j = i+1, j-1 {
+		analysis.Gaps[i], analysis.Gaps[j] = analysis.Gaps[j], analysis.Gaps[i]
+	}
+	return analysis, nil
+}
@@ -0,0 +1,189 @@
+package optimizer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// constrained reports whether solves must respect stock or costs, which the
+// unconstrained search in findOptimalSolution doesn't model.
+func (o *Optimizer) constrained() bool {
+	return o.stock != nil || o.costs != nil
+}
+
+// pass is one sweep over the DP table adding packages of a single size: either any
+// number of them (unbounded), or exactly count of them at most once.
+type pass struct {
+	size      int
+	count     int
+	unbounded bool
+}
+
+// passes plans the sweeps of a constrained solve over a table of the given rows.
+// Sizes without a stock limit get one unbounded sweep. A size with a limit is split
+// into bundles of 1, 2, 4, ... packages (and a remainder), each used at most once,
+// so every count up to the limit can be formed from log2(limit) sweeps.
+func (o *Optimizer) passes(rows int) []pass {
+	var passes []pass
+	for _, size := range o.packageSizes {
+		limit, limited := o.stock[size]
+		if !limited {
+			passes = append(passes, pass{size: size, count: 1, unbounded: true})
+			continue
+		}
+
+		// More packages than fit in the table can never be used
+		limit = min(limit, rows/size)
+		for bundle := 1; limit > 0; bundle *= 2 {
+			count := min(bundle, limit)
+			passes = append(passes, pass{size: size, count: count})
+			limit -= count
+		}
+	}
+	return passes
+}
+
+// findConstrainedSolution finds the optimal package combination when the stock is
+// limited or packages have costs.
+//
+// Algorithm Overview:
+//  1. For every total up to quantity + the largest package size, find the best
+//     packing that delivers exactly that total, as chosen by preferred (the same rule
+//     findOptimalSolution uses)
+//  2. The table is built one sweep per package size (see passes), so stock limits
+//     are respected
+//  3. The answer is the smallest reachable total of at least quantity, which
+//     minimizes over-delivery
+//
+// A minimal packing of at least quantity always delivers less than quantity + the
+// largest package size (dropping any package would fall short), so the table is
+// the same size as in findOptimalSolution.
+//
+// Time Complexity: O(n × p) where n is the table size and p the number of sweeps
+// Space Complexity: O(n) for the DP arrays
+//
+// Args:
+//   - ctx: context checked periodically so long solves can be cancelled
+//   - quantity: the requested quantity
+//   - progress: optional callback reporting filled rows and the best solution so far
+//
+// Returns:
+//   - *solution: the optimal solution found
+//   - error: ErrInsufficientStock if no packing reaches quantity, or ctx.Err() if the
+//     context was cancelled during the solve
+func (o *Optimizer) findConstrainedSolution(ctx context.Context, quantity int, progress ProgressFunc) (*solution, error) {
+	rows := o.tableRows(quantity)
+	passes := o.passes(rows)
+
+	_, buildSpan := tracer.Start(ctx, "optimizer.table_build", trace.WithAttributes(
+		attribute.Int("optimizer.table_rows", rows),
+		attribute.Int("optimizer.table_passes", len(passes)),
+	))
+
+	// counts[i] is the number of packages in the best packing of exactly i (-1 if
+	// unreachable), costs[i] its price and packageCounts[i] the packing itself
+	counts := make([]int, rows+1)
+	costs := make([]float64, rows+1)
+	packageCounts := make([][]PackageCount, rows+1)
+	for i := range counts {
+		counts[i] = -1
+	}
+	counts[0] = 0
+	packageCounts[0] = []PackageCount{}
+
+	// relax improves the packing of total i by adding p's packages to the packing of from
+	cells := 0
+	relax := func(i, from int, p pass) {
+		if counts[from] < 0 {
+			return
+		}
+		count := counts[from] + p.count
+		cost := costs[from] + float64(p.count)*o.costs[p.size]
+		if counts[i] >= 0 && !o.preferred(cost, count, func() []PackageCount {
+			return addPackages(packageCounts[from], p.size, p.count)
+		}, costs[i], counts[i], packageCounts[i]) {
+			return
+		}
+		counts[i], costs[i] = count, cost
+		packageCounts[i] = addPackages(packageCounts[from], p.size, p.count)
+	}
+
+	for n, p := range passes {
+		weight := p.size * p.count
+		for step := 0; step <= rows-weight; step++ {
+			// Periodically check whether the caller has given up on this solve
+			if cells++; cells%cancelCheckInterval == 0 {
+				if err := ctx.Err(); err != nil {
+					buildSpan.RecordError(err)
+					buildSpan.SetStatus(codes.Error, err.Error())
+					buildSpan.End()
+					return nil, err
+				}
+			}
+
+			// Unbounded sweeps go up so a size can be added again; bundles go down so
+			// each is used at most once
+			if p.unbounded {
+				i := weight + step
+				relax(i, i-weight, p)
+			} else {
+				i := rows - step
+				relax(i, i-weight, p)
+			}
+		}
+
+		// Report progress to the caller if requested, once per sweep
+		if progress != nil {
+			report := Progress{RowsFilled: rows * (n + 1) / len(passes), TotalRows: rows}
+			if best := smallestReachable(counts, quantity); best >= 0 {
+				report.Best = o.newResult(quantity, &solution{totalDelivered: best, packages: packageCounts[best]})
+			}
+			progress(report)
+		}
+	}
+	buildSpan.End()
+
+	_, backtrackSpan := tracer.Start(ctx, "optimizer.backtrack")
+	defer backtrackSpan.End()
+
+	best := smallestReachable(counts, quantity)
+	if best < 0 {
+		return nil, fmt.Errorf("%w: no packing of at least %d fits the stock", ErrInsufficientStock, quantity)
+	}
+
+	packages := make([]PackageCount, len(packageCounts[best]))
+	copy(packages, packageCounts[best])
+	sort.Slice(packages, func(i, j int) bool {
+		return packages[i].Size > packages[j].Size
+	})
+	return &solution{totalDelivered: best, packages: packages}, nil
+}
+
+// smallestReachable returns the smallest total of at least quantity that some
+// packing reaches, or -1 if there is none.
+func smallestReachable(counts []int, quantity int) int {
+	for i := quantity; i < len(counts); i++ {
+		if counts[i] >= 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// addPackages returns a copy of packages with count more packages of the given size.
+func addPackages(packages []PackageCount, size, count int) []PackageCount {
+	added := make([]PackageCount, len(packages), len(packages)+1)
+	copy(added, packages)
+	for i := range added {
+		if added[i].Size == size {
+			added[i].Count += count
+			return added
+		}
+	}
+	return append(added, PackageCount{Size: size, Count: count})
+}
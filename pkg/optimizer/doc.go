@@ -0,0 +1,35 @@
+// Package optimizer finds the package combinations that fulfil an order quantity
+// with the least over-delivery, and among those the fewest packages.
+//
+// It is the solver the package optimizer server runs, published so other Go
+// services can embed it instead of calling the API or copying the code:
+//
+//	o, err := optimizer.New([]int{250, 500, 1000, 2000})
+//	if err != nil {
+//	    return err
+//	}
+//	result, err := o.OptimizeContext(ctx, 12001)
+//	// result.Packages: map[2000:6 250:1], result.OverDelivery: 249
+//
+// New accepts functional options: WithStrategy selects the objective (see
+// Strategies), WithLimits rejects quantities whose solve would be too large or
+// need too much memory before any memory is allocated, WithStock limits how many
+// packages of each size are available, WithCosts prefers the cheapest packing
+// among those with the least over-delivery, and WithObserver reports statistics
+// about every solve. An Optimizer is immutable and safe for concurrent use;
+// WithLimits and WithObserver (as methods) derive modified copies.
+//
+// Solves use dynamic programming over a table with one row per quantity up to the
+// requested quantity plus the largest package size, so time and memory grow
+// linearly with the quantity; use EstimateMemory and Limits to bound them.
+//
+// # Compatibility
+//
+// The package follows semantic versioning together with the module: within a
+// major version, exported identifiers are not removed or changed incompatibly,
+// and the result for a given strategy, set of package sizes, stock, costs and
+// quantity does not change. New options, strategies and methods may be added in minor versions.
+// Error messages may change; match errors with errors.Is against the exported
+// Err* values. Tracing span and attribute names are informational and not
+// covered.
+package optimizer
@@ -0,0 +1,105 @@
+package optimizer_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sinaw369/Package-Optimizer/pkg/optimizer"
+)
+
+func Example() {
+	o, err := optimizer.New([]int{250, 500, 1000, 2000})
+	if err != nil {
+		panic(err)
+	}
+
+	result, err := o.OptimizeContext(context.Background(), 12001)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(result.TotalDelivered, result.OverDelivery, result.Packages)
+	// Output: 12250 249 map[2000:6 250:1]
+}
+
+func ExampleNew() {
+	// Package sizes may be given in any order
+	o, err := optimizer.New([]int{53, 23, 31}, optimizer.WithStrategy(optimizer.StrategyMinOverDelivery))
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(o.PackageSizes(), o.Strategy())
+
+	_, err = optimizer.New([]int{250, 500}, optimizer.WithStrategy("min-cost"))
+	fmt.Println(errors.Is(err, optimizer.ErrUnknownStrategy))
+	// Output:
+	// [53 31 23] min-over-delivery
+	// true
+}
+
+func ExampleWithLimits() {
+	o := optimizer.MustNew([]int{250, 500, 1000, 2000}, optimizer.WithLimits(optimizer.Limits{MaxQuantity: 10_000}))
+
+	// Check rejects a quantity without solving it; every Optimize method does the same
+	if err := o.Check(20_000); errors.Is(err, optimizer.ErrQuantityTooLarge) {
+		fmt.Println(err)
+	}
+	// Output: quantity too large: 20000 exceeds the maximum of 10000
+}
+
+func ExampleWithStock() {
+	// Only one 2000 package is left
+	o := optimizer.MustNew([]int{250, 500, 1000, 2000}, optimizer.WithStock(map[int]int{2000: 1}))
+
+	result, err := o.Optimize(5000)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(result.TotalDelivered, result.Packages)
+
+	// Stock that can't cover a quantity is an error
+	_, err = optimizer.MustNew([]int{250, 500}, optimizer.WithStock(map[int]int{250: 1, 500: 1})).Optimize(1000)
+	fmt.Println(errors.Is(err, optimizer.ErrInsufficientStock))
+	// Output:
+	// 5000 map[1000:3 2000:1]
+	// true
+}
+
+func ExampleWithCosts() {
+	// Two small packages are cheaper than one large one
+	o := optimizer.MustNew([]int{250, 500}, optimizer.WithCosts(map[int]float64{250: 1, 500: 3}))
+
+	result, err := o.Optimize(500)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(result.TotalDelivered, result.Packages, result.Cost)
+	// Output: 500 map[250:2] 2
+}
+
+func ExampleOptimizer_Alternatives() {
+	o := optimizer.MustNew([]int{250, 500, 1000, 2000})
+
+	alternatives, err := o.Alternatives(context.Background(), 1201, 3)
+	if err != nil {
+		panic(err)
+	}
+	for _, alternative := range alternatives {
+		fmt.Println(alternative.TotalDelivered, alternative.Packages)
+	}
+	// Output:
+	// 1250 map[1000:1 250:1]
+	// 1500 map[1000:1 500:1]
+	// 1750 map[1000:1 250:1 500:1]
+}
+
+func ExampleOptimizer_AnalyzeGaps() {
+	o := optimizer.MustNew([]int{250, 500})
+
+	analysis, err := o.AnalyzeGaps(context.Background(), 1000)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(analysis.Exact, analysis.MaxOverDelivery, analysis.Gaps[0])
+	// Output: 4 249 {1 249 250}
+}
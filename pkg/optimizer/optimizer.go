@@ -0,0 +1,741 @@
+package optimizer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Strategy names an optimization strategy: the objective the optimizer minimizes.
+// A strategy's name is part of its contract; results computed with the same
+// strategy, package sizes and quantity are always equal.
+type Strategy string
+
+// StrategyMinOverDelivery minimizes over-delivery first, then the number of packages.
+// It is the default strategy.
+const StrategyMinOverDelivery Strategy = "min-over-delivery"
+
+// Strategies lists the strategies the optimizer implements.
+func Strategies() []Strategy {
+	return []Strategy{StrategyMinOverDelivery}
+}
+
+// String returns the strategy's name.
+func (s Strategy) String() string {
+	return string(s)
+}
+
+// ErrUnknownStrategy is returned by New for a strategy the optimizer doesn't implement.
+var ErrUnknownStrategy = errors.New("unknown strategy")
+
+// Errors returned by Check (and by every Optimize method) for quantities the optimizer
+// refuses to solve. They are returned before the DP table is allocated.
+var (
+	// ErrQuantityTooLarge is returned for quantities above the configured maximum, or
+	// so large that the DP table size would overflow
+	ErrQuantityTooLarge = errors.New("quantity too large")
+	// ErrMemoryLimitExceeded is returned when a solve's estimated memory exceeds the configured limit
+	ErrMemoryLimitExceeded = errors.New("solve would exceed the memory limit")
+)
+
+// ErrInsufficientStock is returned by the Optimize methods when the stock configured
+// with WithStock can't cover the requested quantity.
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// tracer creates spans for the optimizer phases. It is a no-op until a
+// tracer provider is installed (see the tracing package).
+var tracer = otel.Tracer("github.com/sinaw369/Package-Optimizer/pkg/optimizer")
+
+// Optimizer handles package optimization calculations using dynamic programming.
+// It finds the optimal combination of packages that minimizes over-delivery
+// while using the fewest number of packages when over-delivery is tied.
+//
+// An Optimizer is immutable and safe for concurrent use.
+type Optimizer struct {
+	// packageSizes stores available package sizes in descending order for efficiency
+	packageSizes []int
+	// catalogVersion identifies the package sizes; computed once at construction
+	catalogVersion string
+	// strategy is the objective the optimizer minimizes
+	strategy Strategy
+	// observer receives statistics about every solve; nil disables instrumentation
+	observer SolveObserver
+	// limits bounds the quantities and solve memory accepted
+	limits Limits
+	// stock is the number of packages available per size; nil means unlimited
+	stock map[int]int
+	// costs is the price of one package per size; nil means packings are not priced
+	costs map[int]float64
+	// optionsVersion identifies the stock and costs; computed once at construction
+	optionsVersion string
+}
+
+// Option configures an Optimizer created by New.
+type Option func(*Optimizer)
+
+// WithStrategy selects the optimization strategy (default StrategyMinOverDelivery).
+// New fails with ErrUnknownStrategy for a strategy not listed by Strategies.
+func WithStrategy(strategy Strategy) Option {
+	return func(o *Optimizer) {
+		o.strategy = strategy
+	}
+}
+
+// WithLimits makes the optimizer reject solves exceeding the given limits (default: none).
+func WithLimits(limits Limits) Option {
+	return func(o *Optimizer) {
+		o.limits = limits
+	}
+}
+
+// WithObserver makes the optimizer report statistics about every solve to observer,
+// e.g., to record metrics.
+func WithObserver(observer SolveObserver) Option {
+	return func(o *Optimizer) {
+		o.observer = observer
+	}
+}
+
+// WithStock limits how many packages of each size a packing may use (default:
+// unlimited). Sizes missing from stock are unlimited, and a count of 0 makes a size
+// unavailable. Quantities the stock can't cover fail with ErrInsufficientStock.
+// New fails for a negative count or a size that isn't one of the package sizes.
+func WithStock(stock map[int]int) Option {
+	return func(o *Optimizer) {
+		o.stock = maps.Clone(stock)
+	}
+}
+
+// WithCosts prices each package size. Among the packings with the least
+// over-delivery the optimizer then picks the cheapest, and the fewest packages only
+// among equally cheap ones; results report their Cost. New fails if a package size
+// has no cost, or a cost is negative or not a number.
+func WithCosts(costs map[int]float64) Option {
+	return func(o *Optimizer) {
+		o.costs = maps.Clone(costs)
+	}
+}
+
+// New creates an optimizer for the given package sizes.
+//
+// Args:
+//   - packageSizes: the available package sizes, in any order; at least one, all positive
+//   - options: the strategy, limits, stock, costs and observer, if the defaults don't fit
+//
+// Returns:
+//   - *Optimizer: optimizer ready for use
+//   - error: if the package sizes are empty or not positive, the strategy is unknown,
+//     or the stock or costs don't match the package sizes
+//
+// Example:
+//
+//	o, err := optimizer.New([]int{250, 500, 1000, 2000},
+//	    optimizer.WithLimits(optimizer.Limits{MaxQuantity: 10_000_000}))
+func New(packageSizes []int, options ...Option) (*Optimizer, error) {
+	// Validate that package sizes list is not empty
+	if len(packageSizes) == 0 {
+		return nil, errors.New("package sizes cannot be empty")
+	}
+
+	// Validate that all package sizes are positive integers
+	for _, size := range packageSizes {
+		if size <= 0 {
+			return nil, fmt.Errorf("package sizes must be positive, got %d", size)
+		}
+	}
+
+	// Sort package sizes in descending order for efficiency in dynamic programming
+	// This allows us to try larger packages first, which often leads to better solutions
+	sizes := make([]int, len(packageSizes))
+	copy(sizes, packageSizes)
+	sort.Sort(sort.Reverse(sort.IntSlice(sizes)))
+
+	o := &Optimizer{
+		packageSizes:   sizes,
+		catalogVersion: CatalogVersion(sizes),
+		strategy:       StrategyMinOverDelivery,
+	}
+	for _, option := range options {
+		option(o)
+	}
+	if !slices.Contains(Strategies(), o.strategy) {
+		return nil, fmt.Errorf("%w %q", ErrUnknownStrategy, o.strategy)
+	}
+	if err := o.validateStockAndCosts(); err != nil {
+		return nil, err
+	}
+	o.optionsVersion = optionsVersion(o.stock, o.costs)
+	return o, nil
+}
+
+// validateStockAndCosts checks that the stock and costs refer to the package sizes.
+func (o *Optimizer) validateStockAndCosts() error {
+	// Check the stock in size order so errors are deterministic
+	for _, size := range sortedKeys(o.stock) {
+		if !slices.Contains(o.packageSizes, size) {
+			return fmt.Errorf("stock for unknown package size %d", size)
+		}
+		if o.stock[size] < 0 {
+			return fmt.Errorf("stock must not be negative, got %d for package size %d", o.stock[size], size)
+		}
+	}
+
+	if o.costs == nil {
+		return nil
+	}
+	for _, size := range sortedKeys(o.costs) {
+		if !slices.Contains(o.packageSizes, size) {
+			return fmt.Errorf("cost for unknown package size %d", size)
+		}
+		if cost := o.costs[size]; cost < 0 || math.IsNaN(cost) || math.IsInf(cost, 0) {
+			return fmt.Errorf("cost must be a non-negative number, got %v for package size %d", cost, size)
+		}
+	}
+	for _, size := range o.packageSizes {
+		if _, ok := o.costs[size]; !ok {
+			return fmt.Errorf("no cost for package size %d", size)
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of a map keyed by package size, in ascending order.
+func sortedKeys[V any](m map[int]V) []int {
+	keys := make([]int, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// MustNew is like New but panics if the optimizer can't be created. It is meant for
+// package sizes that are known to be valid, such as constants.
+func MustNew(packageSizes []int, options ...Option) *Optimizer {
+	o, err := New(packageSizes, options...)
+	if err != nil {
+		panic(err)
+	}
+	return o
+}
+
+// WithObserver returns a copy of the optimizer that reports statistics about every
+// solve to the given observer. The original optimizer is left unchanged, so an
+// instrumented optimizer can be shared safely between goroutines.
+func (o *Optimizer) WithObserver(observer SolveObserver) *Optimizer {
+	observed := *o
+	observed.observer = observer
+	return &observed
+}
+
+// WithLimits returns a copy of the optimizer that rejects solves exceeding the given
+// limits. Like WithObserver, the original optimizer is left unchanged.
+func (o *Optimizer) WithLimits(limits Limits) *Optimizer {
+	limited := *o
+	limited.limits = limits
+	return &limited
+}
+
+// Limits returns the limits the optimizer enforces.
+func (o *Optimizer) Limits() Limits {
+	return o.limits
+}
+
+// PackageSizes returns a copy of the optimizer's package sizes in descending order.
+// A copy is returned so callers cannot mutate the optimizer's internal state.
+func (o *Optimizer) PackageSizes() []int {
+	sizes := make([]int, len(o.packageSizes))
+	copy(sizes, o.packageSizes)
+	return sizes
+}
+
+// CatalogVersion returns a short, stable identifier for the optimizer's package catalog.
+// Two optimizers configured with the same set of package sizes (in any order) report
+// the same version, which makes it possible to tell which catalog produced a result.
+// The version covers the package sizes only, not the stock or costs.
+func (o *Optimizer) CatalogVersion() string {
+	return o.catalogVersion
+}
+
+// Stock returns a copy of the number of packages available per size, or nil if the
+// stock is unlimited (see WithStock).
+func (o *Optimizer) Stock() map[int]int {
+	return maps.Clone(o.stock)
+}
+
+// Costs returns a copy of the price of each package size, or nil if packings are not
+// priced (see WithCosts).
+func (o *Optimizer) Costs() map[int]float64 {
+	return maps.Clone(o.costs)
+}
+
+// OptionsVersion identifies the stock and costs the optimizer was created with, the
+// options that change results besides the package sizes and the strategy. It is
+// empty when neither is set, and otherwise a short hash like CatalogVersion, so
+// results with equal catalog versions, strategies, options versions and quantities
+// are equal.
+//
+// Example:
+//
+//	o, _ := optimizer.New([]int{250, 500}, optimizer.WithCosts(map[int]float64{250: 1, 500: 1.8}))
+//	key := o.CatalogVersion() + "-" + o.OptionsVersion() // e.g., for a result cache
+func (o *Optimizer) OptionsVersion() string {
+	return o.optionsVersion
+}
+
+// Strategy returns the optimization strategy used by the optimizer.
+func (o *Optimizer) Strategy() Strategy {
+	return o.strategy
+}
+
+// optionsVersion computes the options version for the given stock and costs (see
+// Optimizer.OptionsVersion). Entries are hashed in size order, and an empty stock
+// differs from none only in being set.
+func optionsVersion(stock map[int]int, costs map[int]float64) string {
+	if stock == nil && costs == nil {
+		return ""
+	}
+
+	// Build a canonical string representation (e.g., "stock=2000:4;costs=250:1,500:1.8")
+	var parts []string
+	if stock != nil {
+		items := make([]string, 0, len(stock))
+		for _, size := range sortedKeys(stock) {
+			items = append(items, strconv.Itoa(size)+":"+strconv.Itoa(stock[size]))
+		}
+		parts = append(parts, "stock="+strings.Join(items, ","))
+	}
+	if costs != nil {
+		items := make([]string, 0, len(costs))
+		for _, size := range sortedKeys(costs) {
+			items = append(items, strconv.Itoa(size)+":"+strconv.FormatFloat(costs[size], 'g', -1, 64))
+		}
+		parts = append(parts, "costs="+strings.Join(items, ","))
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(parts, ";")))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// CatalogVersion computes the catalog version for the given package sizes.
+// The version is the first 12 hex characters of a SHA-256 hash over the sorted sizes.
+//
+// Example:
+//
+//	CatalogVersion([]int{250, 500, 1000, 2000}) // Same as CatalogVersion([]int{2000, 1000, 500, 250})
+func CatalogVersion(packageSizes []int) string {
+	// Sort a copy so the version does not depend on the configured order
+	sizes := make([]int, len(packageSizes))
+	copy(sizes, packageSizes)
+	sort.Ints(sizes)
+
+	// Build a canonical string representation (e.g., "250,500,1000,2000")
+	parts := make([]string, len(sizes))
+	for i, size := range sizes {
+		parts[i] = strconv.Itoa(size)
+	}
+
+	// Hash the canonical representation and keep a short prefix
+	sum := sha256.Sum256([]byte(strings.Join(parts, ",")))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// cancelCheckInterval is how many DP rows are filled between context checks.
+// Checking every row would slow down the hot loop for no practical benefit.
+const cancelCheckInterval = 4096
+
+// progressSteps is the approximate number of progress reports per solve.
+const progressSteps = 100
+
+// Optimize calculates the optimal package combination for the given quantity.
+// It uses dynamic programming to find the solution that:
+// 1. Minimizes over-delivery (total_delivered - requested)
+// 2. Minimizes the number of packages used (when over-delivery is tied)
+func (o *Optimizer) Optimize(quantity int) (*Result, error) {
+	return o.OptimizeContext(context.Background(), quantity)
+}
+
+// OptimizeContext is like Optimize but stops early when ctx is cancelled.
+// Large quantities fill a large DP table, so long-running callers (such as
+// background jobs) use this to abandon work that is no longer needed.
+// It returns ctx.Err() if the context is cancelled before the solve completes.
+func (o *Optimizer) OptimizeContext(ctx context.Context, quantity int) (*Result, error) {
+	return o.OptimizeWithProgress(ctx, quantity, nil)
+}
+
+// OptimizeWithProgress is like OptimizeContext but also reports progress while
+// the DP table is being filled. The progress function is called roughly every
+// percent of the table (and once when it is complete) from the calling goroutine,
+// so it should return quickly. A nil progress function disables reporting.
+func (o *Optimizer) OptimizeWithProgress(ctx context.Context, quantity int, progress ProgressFunc) (*Result, error) {
+	// Report solve statistics to the observer, if one is installed
+	if o.observer != nil {
+		start := time.Now()
+		result, err := o.optimize(ctx, quantity, progress)
+		o.observer(SolveStats{
+			Quantity:  quantity,
+			TableRows: o.tableRows(quantity),
+			Duration:  time.Since(start),
+			Result:    result,
+			Err:       err,
+		})
+		return result, err
+	}
+
+	return o.optimize(ctx, quantity, progress)
+}
+
+// optimize validates the quantity and runs the solve inside an "optimizer.solve" span.
+func (o *Optimizer) optimize(ctx context.Context, quantity int, progress ProgressFunc) (*Result, error) {
+	// Trace the whole solve; the phases below are recorded as child spans
+	ctx, span := tracer.Start(ctx, "optimizer.solve", trace.WithAttributes(
+		attribute.Int("optimizer.quantity", quantity),
+		attribute.String("optimizer.catalog_version", o.catalogVersion),
+		attribute.IntSlice("optimizer.package_sizes", o.packageSizes),
+		attribute.String("optimizer.strategy", o.strategy.String()),
+	))
+	defer span.End()
+
+	// Validate the quantity against the limits before allocating anything
+	if err := o.Check(quantity); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	// Handle edge case: zero quantity requires no packages
+	if quantity == 0 {
+		return o.newResult(0, &solution{}), nil
+	}
+
+	// Use dynamic programming algorithm to find the optimal solution; stock and
+	// costs need the slower constrained search
+	find := o.findOptimalSolution
+	if o.constrained() {
+		find = o.findConstrainedSolution
+	}
+	solution, err := find(ctx, quantity, progress)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	// Convert the internal solution format to the public result format
+	_, formatSpan := tracer.Start(ctx, "optimizer.format_result")
+	result := o.newResult(quantity, solution)
+	formatSpan.End()
+
+	span.SetAttributes(
+		attribute.Int("optimizer.total_delivered", result.TotalDelivered),
+		attribute.Int("optimizer.over_delivery", result.OverDelivery),
+	)
+	return result, nil
+}
+
+// Check reports whether the optimizer would accept a solve for quantity, without
+// running it. Every Optimize method performs the same check, so callers only need
+// Check to reject requests early (e.g., before queueing them or waiting for the solver).
+//
+// Args:
+//   - quantity: the requested quantity
+//
+// Returns:
+//   - error: nil if the quantity can be solved; an error wrapping ErrQuantityTooLarge or
+//     ErrMemoryLimitExceeded if it exceeds the limits; another error if it is negative
+//
+// Example:
+//
+//	if err := optimizer.Check(quantity); errors.Is(err, optimizer.ErrMemoryLimitExceeded) {
+//	    // reject the request
+//	}
+func (o *Optimizer) Check(quantity int) error {
+	// Validate that quantity is non-negative
+	if quantity < 0 {
+		return fmt.Errorf("quantity must be non-negative, got %d", quantity)
+	}
+
+	// The DP table has quantity + largest package size rows, which must fit in an int
+	if quantity > math.MaxInt-o.packageSizes[0] {
+		return fmt.Errorf("%w: %d overflows the DP table size", ErrQuantityTooLarge, quantity)
+	}
+	if o.limits.MaxQuantity > 0 && quantity > o.limits.MaxQuantity {
+		return fmt.Errorf("%w: %d exceeds the maximum of %d", ErrQuantityTooLarge, quantity, o.limits.MaxQuantity)
+	}
+
+	// Reject solves whose table would not fit in the memory budget
+	if estimate := o.EstimateMemory(quantity); o.limits.MaxMemory > 0 && estimate > o.limits.MaxMemory {
+		return fmt.Errorf("%w: quantity %d needs about %d MiB, the limit is %d MiB",
+			ErrMemoryLimitExceeded, quantity, estimate>>20, o.limits.MaxMemory>>20)
+	}
+	return nil
+}
+
+// tableRows returns the number of DP table rows a solve for quantity fills.
+// Invalid, zero and overflowing quantities don't build a table.
+func (o *Optimizer) tableRows(quantity int) int {
+	if quantity <= 0 || quantity > math.MaxInt-o.packageSizes[0] {
+		return 0
+	}
+	return quantity + o.packageSizes[0]
+}
+
+// EstimateMemory returns a rough upper bound, in bytes, of the memory a solve for
+// quantity allocates. Each DP table row holds an int, a slice header and, in the
+// worst case, one PackageCount per package size, plus the packing's cost when the
+// optimizer has stock or costs. Callers use it to weigh solves against each other
+// before running them.
+//
+// Args:
+//   - quantity: the requested quantity
+//
+// Returns:
+//   - int64: estimated bytes (0 for quantities that don't build a table, math.MaxInt64
+//     for quantities whose table size would overflow)
+func (o *Optimizer) EstimateMemory(quantity int) int64 {
+	const intSize, floatSize, sliceHeaderSize, packageCountSize = 8, 8, 24, 16
+	if quantity <= 0 {
+		return 0
+	}
+
+	// Saturate instead of overflowing for absurd quantities
+	rowSize := int64(intSize + sliceHeaderSize + packageCountSize*len(o.packageSizes))
+	if o.constrained() {
+		rowSize += floatSize
+	}
+	rows := int64(o.tableRows(quantity))
+	if rows == 0 || rows > math.MaxInt64/rowSize {
+		return math.MaxInt64
+	}
+	return rows * rowSize
+}
+
+// newResult converts an internal solution into the public result format, priced
+// when the optimizer has costs.
+func (o *Optimizer) newResult(quantity int, solution *solution) *Result {
+	result := &Result{
+		Requested:      quantity,
+		TotalDelivered: solution.totalDelivered,
+		OverDelivery:   solution.totalDelivered - quantity,
+		Packages:       make(map[string]int),
+	}
+
+	// Convert package counts from internal format to string map for JSON response
+	for _, pkg := range solution.packages {
+		if pkg.Count > 0 {
+			result.Packages[fmt.Sprintf("%d", pkg.Size)] = pkg.Count
+			result.Cost += float64(pkg.Count) * o.costs[pkg.Size]
+		}
+	}
+
+	return result
+}
+
+// solution represents a complete solution with package counts.
+// This is an internal structure used by the dynamic programming algorithm.
+type solution struct {
+	totalDelivered int            // Total quantity delivered
+	packages       []PackageCount // List of packages used with their counts
+}
+
+// preferred reports whether a packing is preferred over another packing of the same
+// total. Both solvers choose between packings that over-deliver equally with this
+// rule, so they agree whenever stock and costs don't constrain the choice:
+//  1. the cheaper packing (all packings cost 0 without costs)
+//  2. then the one with fewer packages
+//  3. then the one with more of the larger sizes, comparing the count of the largest
+//     size first, so the choice never depends on the order the table is filled in
+//
+// Each rule is unchanged by adding the same packages to both packings, which keeps the
+// DP exact. The packings themselves are only needed for the last rule, so they are
+// passed as a function that builds the candidate on demand.
+//
+// Args:
+//   - cost, count, packages: the candidate packing's price, number of packages and packing
+//   - currentCost, currentCount, current: the same for the packing it would replace
+func (o *Optimizer) preferred(cost float64, count int, packages func() []PackageCount, currentCost float64, currentCount int, current []PackageCount) bool {
+	if cost != currentCost {
+		return cost < currentCost
+	}
+	if count != currentCount {
+		return count < currentCount
+	}
+	candidate := packages()
+	for _, size := range o.packageSizes {
+		if n, m := packageCount(candidate, size), packageCount(current, size); n != m {
+			return n > m
+		}
+	}
+	return false
+}
+
+// packageCount returns how many packages of the given size a packing uses.
+func packageCount(packages []PackageCount, size int) int {
+	for _, p := range packages {
+		if p.Size == size {
+			return p.Count
+		}
+	}
+	return 0
+}
+
+// findOptimalSolution uses dynamic programming to find the optimal package combination.
+//
+// Algorithm Overview:
+// 1. Create a DP table where dp[i] represents the minimum over-delivery for quantity i
+// 2. For each quantity i, try using each available package size
+// 3. Update the solution if we find a better combination: less over-delivery, or a
+// preferred packing (see preferred), the same rule findConstrainedSolution uses
+// 4. Track package combinations for each quantity
+//
+// Time Complexity: O(n × m) where n is the requested quantity and m is the number of package sizes
+// Space Complexity: O(n) for the DP arrays
+//
+// Args:
+//   - ctx: context checked periodically so long solves can be cancelled
+//   - quantity: the requested quantity
+//   - progress: optional callback reporting filled rows and the best solution so far
+//
+// Returns:
+//   - *solution: the optimal solution found
+//   - error: ctx.Err() if the context was cancelled during the solve
+func (o *Optimizer) findOptimalSolution(ctx context.Context, quantity int, progress ProgressFunc) (*solution, error) {
+	// Calculate the maximum quantity we need to consider
+	// We need to handle quantities up to quantity + maxPackageSize to find optimal solutions
+	maxPackageSize := o.packageSizes[0] // Largest package size (first after sorting)
+	maxQuantity := quantity + maxPackageSize
+
+	// Trace the table build separately from the backtrack that follows it
+	_, buildSpan := tracer.Start(ctx, "optimizer.table_build", trace.WithAttributes(
+		attribute.Int("optimizer.table_rows", maxQuantity),
+	))
+
+	// Initialize DP arrays
+	// dp[i] represents the minimum over-delivery for quantity i
+	dp := make([]int, maxQuantity+1)
+	// packageCounts[i] stores the package combination for quantity i
+	packageCounts := make([][]PackageCount, maxQuantity+1)
+	// counts[i] is the total number of packages in packageCounts[i]
+	counts := make([]int, maxQuantity+1)
+
+	// Initialize DP table with "infinity" (large number) to represent unreachable states
+	for i := range dp {
+		dp[i] = maxQuantity + 1
+	}
+	// Base case: quantity 0 requires 0 packages and has 0 over-delivery
+	dp[0] = 0
+	packageCounts[0] = []PackageCount{}
+
+	// Track the best solution among quantities >= requested as rows are filled
+	// -1 means no reachable quantity >= requested has been seen yet
+	bestQuantity := -1
+
+	// Report progress roughly once per percent of the table
+	progressInterval := max(maxQuantity/progressSteps, 1)
+
+	// Fill the DP table using bottom-up approach
+	for i := 1; i <= maxQuantity; i++ {
+		// Periodically check whether the caller has given up on this solve
+		if i%cancelCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				buildSpan.RecordError(err)
+				buildSpan.SetStatus(codes.Error, err.Error())
+				buildSpan.End()
+				return nil, err
+			}
+		}
+
+		// Try each available package size
+		for _, packageSize := range o.packageSizes {
+			// Only consider packages that can fit in the current quantity
+			if packageSize <= i {
+				// Calculate remaining quantity after using this package
+				remaining := i - packageSize
+				// Calculate new over-delivery for this quantity
+				newOverDelivery := max(0, i-quantity)
+
+				// Check if we can reach the remaining quantity
+				if dp[remaining] != maxQuantity+1 {
+					// Calculate total over-delivery for this combination
+					totalOverDelivery := dp[remaining] + newOverDelivery
+					count := counts[remaining] + 1
+
+					// Update if this is a better solution:
+					// 1. Less over-delivery, OR
+					// 2. Same over-delivery and a preferred packing (packages cost nothing here)
+					better := totalOverDelivery < dp[i]
+					if !better && totalOverDelivery == dp[i] {
+						better = o.preferred(0, count, func() []PackageCount {
+							return addPackages(packageCounts[remaining], packageSize, 1)
+						}, 0, counts[i], packageCounts[i])
+					}
+					if better {
+						// Update the minimum over-delivery and store the package combination
+						dp[i] = totalOverDelivery
+						counts[i] = count
+						packageCounts[i] = addPackages(packageCounts[remaining], packageSize, 1)
+					}
+				}
+			}
+		}
+
+		// Update the best solution if this row is a better way to satisfy the request.
+		// Every reachable row past the first one at or above quantity over-delivers more,
+		// so the best row is the smallest reachable total, as in findConstrainedSolution.
+		if i >= quantity && dp[i] != maxQuantity+1 && bestQuantity < 0 {
+			bestQuantity = i
+		}
+
+		// Report progress to the caller if requested
+		if progress != nil && (i%progressInterval == 0 || i == maxQuantity) {
+			report := Progress{RowsFilled: i, TotalRows: maxQuantity}
+			if bestQuantity >= 0 {
+				report.Best = o.newResult(quantity, &solution{
+					totalDelivered: bestQuantity,
+					packages:       packageCounts[bestQuantity],
+				})
+			}
+			progress(report)
+		}
+	}
+	buildSpan.End()
+
+	// Backtrack: recover the package combination for the best quantity,
+	// largest package size first
+	_, backtrackSpan := tracer.Start(ctx, "optimizer.backtrack")
+	defer backtrackSpan.End()
+
+	packages := make([]PackageCount, len(packageCounts[bestQuantity]))
+	copy(packages, packageCounts[bestQuantity])
+	sort.Slice(packages, func(i, j int) bool {
+		return packages[i].Size > packages[j].Size
+	})
+
+	// Return the optimal solution found
+	return &solution{
+		totalDelivered: bestQuantity,
+		packages:       packages,
+	}, nil
+}
+
+// max returns the maximum of two integers.
+// This is a utility function used in the dynamic programming algorithm.
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
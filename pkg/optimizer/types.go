@@ -0,0 +1,138 @@
+package optimizer
+
+import (
+	"sort"
+	"time"
+)
+
+// Result represents the result of a package optimization calculation.
+// This structure is returned by the optimizer and contains all the information
+// about the optimal package combination for a given quantity. Its JSON form is the
+// one the package optimizer API returns.
+type Result struct {
+	// Requested is the original quantity that was requested
+	Requested int `json:"requested"`
+
+	// TotalDelivered is the total quantity that will be delivered
+	// This may be greater than or equal to the requested quantity
+	TotalDelivered int `json:"total_delivered"`
+
+	// OverDelivery is the excess quantity delivered beyond what was requested
+	// Calculated as: TotalDelivered - Requested
+	OverDelivery int `json:"over_delivery"`
+
+	// Packages is a map of package sizes to their counts
+	// Key: package size as string (e.g., "250", "500", "1000")
+	// Value: number of packages of that size to use
+	Packages map[string]int `json:"packages"`
+
+	// Cost is the total price of the packages, set only when the optimizer has
+	// costs (see WithCosts)
+	Cost float64 `json:"cost,omitempty"`
+}
+
+// PackageCount represents a package size and its count in a solution.
+type PackageCount struct {
+	// Size is the package size (e.g., 250, 500, 1000)
+	Size int
+	// Count is the number of packages of this size to use
+	Count int
+}
+
+// Progress reports how far an optimization has advanced.
+// It is passed to a ProgressFunc while the DP table is being filled.
+type Progress struct {
+	// RowsFilled is the number of DP table rows computed so far
+	RowsFilled int `json:"rows_filled"`
+
+	// TotalRows is the number of DP table rows the solve will compute
+	TotalRows int `json:"total_rows"`
+
+	// Best is the best solution found so far, nil until the requested quantity is reachable
+	Best *Result `json:"best,omitempty"`
+}
+
+// ProgressFunc receives progress reports during an optimization.
+type ProgressFunc func(Progress)
+
+// SolveStats describes a completed (or failed) solve.
+// It is passed to a SolveObserver for instrumentation such as metrics.
+type SolveStats struct {
+	// Quantity is the requested quantity
+	Quantity int
+
+	// TableRows is the number of DP table rows the solve filled (0 if no table was built)
+	TableRows int
+
+	// Duration is how long the solve took
+	Duration time.Duration
+
+	// Result is the optimization result, nil if the solve failed
+	Result *Result
+
+	// Err is the error returned by the solve, if any
+	Err error
+}
+
+// SolveObserver receives statistics about every solve performed by an Optimizer.
+type SolveObserver func(SolveStats)
+
+// Limits bounds the solves an Optimizer accepts, so oversized requests are rejected
+// before the DP table is allocated. A zero value means no limit.
+type Limits struct {
+	// MaxQuantity is the largest quantity accepted
+	MaxQuantity int
+
+	// MaxMemory is the largest estimated solve memory accepted, in bytes (see Optimizer.EstimateMemory)
+	MaxMemory int64
+}
+
+// Gap is a range of quantities that can't be delivered exactly with a catalog:
+// every quantity in it is over-delivered.
+type Gap struct {
+	// From and To are the first and last quantities of the range
+	From int `json:"from"`
+	To   int `json:"to"`
+
+	// Delivered is the smallest total that covers the range: the next quantity that
+	// can be delivered exactly
+	Delivered int `json:"delivered"`
+}
+
+// MaxOverDelivery returns the over-delivery of the range's first quantity, the worst in the range.
+func (g Gap) MaxOverDelivery() int {
+	return g.Delivered - g.From
+}
+
+// GapAnalysis describes how well a catalog covers the quantities from 1 up to a limit.
+type GapAnalysis struct {
+	// UpTo is the largest quantity analyzed
+	UpTo int `json:"up_to"`
+
+	// Exact is the number of quantities that can be delivered without over-delivery
+	Exact int `json:"exact"`
+
+	// AverageOverDelivery is the mean over-delivery across all quantities analyzed
+	AverageOverDelivery float64 `json:"average_over_delivery"`
+
+	// MaxOverDelivery is the worst over-delivery of any quantity analyzed
+	MaxOverDelivery int `json:"max_over_delivery"`
+
+	// Gaps lists the ranges of quantities that are over-delivered, in ascending order
+	Gaps []Gap `json:"gaps"`
+}
+
+// OverDelivery returns the over-delivery of the best packing for quantity, which
+// must be between 1 and UpTo.
+//
+// Example:
+//
+//	analysis.OverDelivery(251) // 249 with package sizes 250 and 500
+func (a *GapAnalysis) OverDelivery(quantity int) int {
+	// Find the first gap that ends at or after quantity
+	i := sort.Search(len(a.Gaps), func(i int) bool { return a.Gaps[i].To >= quantity })
+	if i < len(a.Gaps) && a.Gaps[i].From <= quantity {
+		return a.Gaps[i].Delivered - quantity
+	}
+	return 0
+}
@@ -0,0 +1,382 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sinaw369/Package-Optimizer/internal/api"
+	"github.com/sinaw369/Package-Optimizer/internal/auth"
+	"github.com/sinaw369/Package-Optimizer/internal/jobs"
+	"github.com/sinaw369/Package-Optimizer/internal/limits"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Raw API keys used by the tests; the keyring only stores their hashes.
+const (
+	partnerKey   = "partner-secret"
+	adminKey     = "admin-secret"
+	adminOnlyKey = "admin-only-secret"
+	limitedKey   = "limited-secret"
+)
+
+// newTestKeyring creates a keyring with a partner key, an admin key, an
+// admin-only key and a key limited to two requests per hour.
+func newTestKeyring(t *testing.T) *auth.Keyring {
+	t.Helper()
+	keyring, err := auth.NewKeyring([]auth.KeyConfig{
+		{ID: "acme-prod", Client: "acme", KeySHA256: auth.HashKey(partnerKey), Scopes: []string{auth.ScopeCalculate}},
+		{ID: "ops", Client: "internal", KeySHA256: auth.HashKey(adminKey), Scopes: []string{auth.ScopeCalculate, auth.ScopeCatalogAdmin}},
+		{ID: "ops-catalog", Client: "internal", KeySHA256: auth.HashKey(adminOnlyKey), Scopes: []string{auth.ScopeCatalogAdmin}},
+		{ID: "trial", Client: "trial", KeySHA256: auth.HashKey(limitedKey), Scopes: []string{auth.ScopeCalculate},
+			Quota: auth.Quota{Requests: 2, Period: auth.Duration(time.Hour)}},
+	})
+	if err != nil {
+		t.Fatalf("NewKeyring() error: %v", err)
+	}
+	return keyring
+}
+
+// newAuthServer creates a test server that requires API keys.
+func newAuthServer(t *testing.T) *echo.Echo {
+	t.Helper()
+	return newServer(newCatalog(t, []int{250, 500, 1000, 2000}), api.Options{Keyring: newTestKeyring(t)})
+}
+
+// doRequest sends a request with an optional API key and JSON body.
+func doRequest(e *echo.Echo, method, target, apiKey, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	if apiKey != "" {
+		req.Header.Set(api.HeaderAPIKey, apiKey)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestKeyring_LoadFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	content := `{"keys":[{"id":"acme-prod","client":"acme","key_sha256":"` + strings.ToUpper(auth.HashKey(partnerKey)) +
+		`","scopes":["calculate"],"quota":{"requests":100,"period":"24h"}}]}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	keyring, err := auth.LoadKeyring(path)
+	if err != nil {
+		t.Fatalf("LoadKeyring() error: %v", err)
+	}
+
+	client, usage, err := keyring.Authorize(partnerKey, auth.ScopeCalculate)
+	if err != nil {
+		t.Fatalf("Authorize() error: %v", err)
+	}
+	if client.ID != "acme" || usage.QuotaLimit != 100 || usage.QuotaRemaining != 99 {
+		t.Errorf("client = %+v, usage = %+v", client, usage)
+	}
+}
+
+func TestKeyring_RejectsInvalidKeys(t *testing.T) {
+	hash := auth.HashKey(partnerKey)
+	tests := []struct {
+		name string
+		keys []auth.KeyConfig
+	}{
+		{"no keys", nil},
+		{"missing id", []auth.KeyConfig{{Client: "a", KeySHA256: hash, Scopes: []string{"calculate"}}}},
+		{"missing client", []auth.KeyConfig{{ID: "a", KeySHA256: hash, Scopes: []string{"calculate"}}}},
+		{"raw key instead of hash", []auth.KeyConfig{{ID: "a", Client: "a", KeySHA256: partnerKey, Scopes: []string{"calculate"}}}},
+		{"unknown scope", []auth.KeyConfig{{ID: "a", Client: "a", KeySHA256: hash, Scopes: []string{"root"}}}},
+		{"quota without period", []auth.KeyConfig{{ID: "a", Client: "a", KeySHA256: hash, Scopes: []string{"calculate"}, Quota: auth.Quota{Requests: 5}}}},
+		{"duplicate hash", []auth.KeyConfig{
+			{ID: "a", Client: "a", KeySHA256: hash, Scopes: []string{"calculate"}},
+			{ID: "b", Client: "b", KeySHA256: hash, Scopes: []string{"calculate"}},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := auth.NewKeyring(tt.keys); err == nil {
+				t.Error("NewKeyring() succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestKeyring_QuotaResetsAfterPeriod(t *testing.T) {
+	keyring, err := auth.NewKeyring([]auth.KeyConfig{{
+		ID: "burst", Client: "burst", KeySHA256: auth.HashKey(limitedKey), Scopes: []string{auth.ScopeCalculate},
+		Quota: auth.Quota{Requests: 1, Period: auth.Duration(50 * time.Millisecond)},
+	}})
+	if err != nil {
+		t.Fatalf("NewKeyring() error: %v", err)
+	}
+
+	if _, _, err := keyring.Authorize(limitedKey, auth.ScopeCalculate); err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+	if _, _, err := keyring.Authorize(limitedKey, auth.ScopeCalculate); !errors.Is(err, auth.ErrQuotaExceeded) {
+		t.Fatalf("second request: err = %v, want ErrQuotaExceeded", err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if _, _, err := keyring.Authorize(limitedKey, auth.ScopeCalculate); err != nil {
+		t.Fatalf("request after the period: %v", err)
+	}
+
+	usage := keyring.Usage()[0]
+	if usage.Requests != 2 || usage.Rejected != 1 {
+		t.Errorf("usage = %+v, want 2 requests and 1 rejected", usage)
+	}
+}
+
+func TestAuth_RequiresKey(t *testing.T) {
+	e := newAuthServer(t)
+
+	rec := doRequest(e, http.MethodGet, "/api/calculate?qty=1201", "", "")
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if rec.Header().Get(echo.HeaderWWWAuthenticate) == "" {
+		t.Error("missing WWW-Authenticate header")
+	}
+
+	// Public routes stay open
+	for _, target := range []string{"/api/health", "/api/package-sizes", "/api/catalog", "/api/openapi.json"} {
+		if rec := doRequest(e, http.MethodGet, target, "", ""); rec.Code != http.StatusOK {
+			t.Errorf("GET %s: status = %d, want %d", target, rec.Code, http.StatusOK)
+		}
+	}
+}
+
+func TestAuth_AcceptsHeaderAndBearerToken(t *testing.T) {
+	e := newAuthServer(t)
+
+	if rec := doRequest(e, http.MethodGet, "/api/calculate?qty=1201", partnerKey, ""); rec.Code != http.StatusOK {
+		t.Errorf("X-API-Key: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/calculate?qty=1201", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+partnerKey)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("Bearer token: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestAuth_CatalogUpdateRequiresAdminScope(t *testing.T) {
+	e := newAuthServer(t)
+	body := `{"package_sizes":[23,31,53]}`
+
+	if rec := doRequest(e, http.MethodPut, "/api/catalog", partnerKey, body); rec.Code != http.StatusForbidden {
+		t.Fatalf("partner key: status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	if rec := doRequest(e, http.MethodPut, "/api/catalog", adminOnlyKey, `{"package_sizes":[0]}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid catalog: status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	rec := doRequest(e, http.MethodPut, "/api/catalog", adminOnlyKey, body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("admin key: status = %d, want %d (%s)", rec.Code, http.StatusOK, rec.Body)
+	}
+
+	// Calculations use the new catalog straight away
+	rec = doRequest(e, http.MethodGet, "/api/calculate?qty=500000", partnerKey, "")
+	var result struct {
+		OverDelivery int `json:"over_delivery"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil || result.OverDelivery != 0 {
+		t.Errorf("calculation after update = %s, want exact delivery with the new catalog", rec.Body)
+	}
+}
+
+func TestAuth_QuotaExceeded(t *testing.T) {
+	e := newAuthServer(t)
+
+	for i := 0; i < 2; i++ {
+		rec := doRequest(e, http.MethodGet, "/api/calculate?qty=1", limitedKey, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d, want %d", i+1, rec.Code, http.StatusOK)
+		}
+	}
+
+	rec := doRequest(e, http.MethodGet, "/api/calculate?qty=1", limitedKey, "")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+	if rec.Header().Get("Retry-After") == "" || rec.Header().Get(api.HeaderQuotaRemaining) != "0" {
+		t.Errorf("headers = %v, want Retry-After and X-Quota-Remaining: 0", rec.Header())
+	}
+}
+
+func TestAuth_UsageReport(t *testing.T) {
+	e := newAuthServer(t)
+	doRequest(e, http.MethodGet, "/api/calculate?qty=1", partnerKey, "")
+
+	decode := func(rec *httptest.ResponseRecorder) []auth.Usage {
+		t.Helper()
+		var body struct {
+			Usage []auth.Usage `json:"usage"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode usage: %v (%s)", err, rec.Body)
+		}
+		return body.Usage
+	}
+
+	// Partners see only their own keys; reading usage is not itself counted
+	usage := decode(doRequest(e, http.MethodGet, "/api/usage", partnerKey, ""))
+	if len(usage) != 1 || usage[0].KeyID != "acme-prod" || usage[0].Requests != 1 {
+		t.Errorf("partner usage = %+v, want acme-prod with 1 request", usage)
+	}
+
+	// Admins see every key, even with a key that may not calculate
+	usage = decode(doRequest(e, http.MethodGet, "/api/usage", adminOnlyKey, ""))
+	if len(usage) != 4 {
+		t.Errorf("admin usage lists %d keys, want 4", len(usage))
+	}
+
+	// A key that has used up its quota can still check it
+	for i := 0; i < 3; i++ {
+		doRequest(e, http.MethodGet, "/api/calculate?qty=1", limitedKey, "")
+	}
+	rec := doRequest(e, http.MethodGet, "/api/usage", limitedKey, "")
+	if usage := decode(rec); rec.Code != http.StatusOK || len(usage) != 1 || usage[0].QuotaRemaining != 0 {
+		t.Errorf("exhausted key: status = %d, usage = %+v; want its usage with no quota left", rec.Code, usage)
+	}
+
+	// Unknown keys are still refused
+	if rec := doRequest(e, http.MethodGet, "/api/usage", "unknown", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("unknown key: status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestAuth_JobsAreChargedPerQuantity(t *testing.T) {
+	m, err := jobs.NewManager(newCatalog(t, []int{250, 500, 1000, 2000}), jobs.Options{Workers: 1, QueueSize: 4, ResultTTL: time.Minute})
+	if err != nil {
+		t.Fatalf("NewManager() error: %v", err)
+	}
+	defer m.Shutdown(context.Background())
+	keyring := newTestKeyring(t)
+	e := newServer(newCatalog(t, []int{250, 500, 1000, 2000}), api.Options{Keyring: keyring, Jobs: m})
+
+	// Too many quantities are refused before anything is queued or charged
+	tooMany := `{"quantities":[` + strings.Repeat("1,", api.MaxBatchQuantities) + `1]}`
+	if rec := doRequest(e, http.MethodPost, "/api/jobs", limitedKey, tooMany); rec.Code != http.StatusBadRequest {
+		t.Errorf("too many quantities: status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	// Three quantities don't fit in a quota of two, and nothing is charged
+	rec := doRequest(e, http.MethodPost, "/api/jobs", limitedKey, `{"quantities":[1201,5000,12001]}`)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get(api.HeaderQuotaRemaining) != "2" {
+		t.Errorf("status = %d, X-Quota-Remaining = %q; want 429 with 2 left", rec.Code, rec.Header().Get(api.HeaderQuotaRemaining))
+	}
+
+	// Two quantities use up the quota
+	rec = doRequest(e, http.MethodPost, "/api/jobs", limitedKey, `{"quantities":[1201,5000]}`)
+	if rec.Code != http.StatusAccepted || rec.Header().Get(api.HeaderQuotaRemaining) != "0" {
+		t.Errorf("status = %d, X-Quota-Remaining = %q; want 202 with 0 left", rec.Code, rec.Header().Get(api.HeaderQuotaRemaining))
+	}
+
+	usage := keyring.Usage()[3]
+	if usage.Requests != 2 || usage.Rejected != 1 || usage.QuotaRemaining != 0 {
+		t.Errorf("usage = %+v, want 2 requests and 1 rejected", usage)
+	}
+}
+
+func TestAuth_JobsBelongToTheirClient(t *testing.T) {
+	m, err := jobs.NewManager(newCatalog(t, []int{250, 500, 1000, 2000}), jobs.Options{Workers: 1, QueueSize: 4, ResultTTL: time.Minute})
+	if err != nil {
+		t.Fatalf("NewManager() error: %v", err)
+	}
+	defer m.Shutdown(context.Background())
+	e := newServer(newCatalog(t, []int{250, 500, 1000, 2000}), api.Options{Keyring: newTestKeyring(t), Jobs: m})
+
+	rec := doRequest(e, http.MethodPost, "/api/jobs", limitedKey, `{"quantity":1201}`)
+	var job jobs.Job
+	if err := json.Unmarshal(rec.Body.Bytes(), &job); rec.Code != http.StatusAccepted || err != nil {
+		t.Fatalf("submit: status = %d, body = %s; want 202 with the job", rec.Code, rec.Body)
+	}
+	if job.Request.Client != "trial" {
+		t.Errorf("Request.Client = %q, want trial", job.Request.Client)
+	}
+	waitForJob(t, m, job.ID)
+
+	// Other clients can't tell the job exists
+	for _, r := range []struct{ method, target string }{
+		{http.MethodGet, "/api/jobs/" + job.ID},
+		{http.MethodGet, "/api/jobs/" + job.ID + "/events"},
+		{http.MethodDelete, "/api/jobs/" + job.ID},
+	} {
+		if rec := doRequest(e, r.method, r.target, partnerKey, ""); rec.Code != http.StatusNotFound {
+			t.Errorf("other client: %s %s: status = %d, want %d", r.method, r.target, rec.Code, http.StatusNotFound)
+		}
+	}
+
+	// The owner and catalog admins can
+	for _, key := range []string{limitedKey, adminKey} {
+		if rec := doRequest(e, http.MethodGet, "/api/jobs/"+job.ID, key, ""); rec.Code != http.StatusOK {
+			t.Errorf("key %q: status = %d, want %d", key, rec.Code, http.StatusOK)
+		}
+	}
+}
+
+func TestAuth_RejectedRequestsAreRefunded(t *testing.T) {
+	keyring := newTestKeyring(t)
+	limiter := limits.New(limits.Config{Rate: 0.01, Burst: 4})
+	e := newServer(newCatalog(t, []int{250, 500, 1000, 2000}), api.Options{Keyring: keyring, Limiter: limiter})
+
+	// Invalid requests don't use up the quota, and the headers say so
+	for _, target := range []string{"/api/calculate?qty=abc", "/api/calculate?qty=-5", "/api/calculate"} {
+		rec := doRequest(e, http.MethodGet, target, limitedKey, "")
+		if rec.Code != http.StatusBadRequest || rec.Header().Get(api.HeaderQuotaRemaining) != "2" {
+			t.Errorf("%s: status = %d, X-Quota-Remaining = %q; want 400 with 2 left", target, rec.Code, rec.Header().Get(api.HeaderQuotaRemaining))
+		}
+	}
+
+	// A served request is charged; the fifth request is over the rate and is not
+	if rec := doRequest(e, http.MethodGet, "/api/calculate?qty=1201", limitedKey, ""); rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if rec := doRequest(e, http.MethodGet, "/api/calculate?qty=1201", limitedKey, ""); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want the rate limit", rec.Code)
+	}
+	usage := keyring.Usage()[3]
+	if usage.Requests != 1 || usage.QuotaRemaining != 1 {
+		t.Errorf("usage = %+v, want only the served request counted", usage)
+	}
+}
+
+func TestAuth_BatchesAreChargedPerQuantity(t *testing.T) {
+	keyring := newTestKeyring(t)
+	e := newServer(newCatalog(t, []int{250, 500, 1000, 2000}), api.Options{Keyring: keyring})
+
+	// Three quantities don't fit in a quota of two, and nothing is charged
+	rec := doRequest(e, http.MethodPost, "/api/calculate/batch", limitedKey, `{"quantities":[1201,5000,12001]}`)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get(api.HeaderQuotaRemaining) != "2" {
+		t.Errorf("status = %d, X-Quota-Remaining = %q; want 429 with 2 left", rec.Code, rec.Header().Get(api.HeaderQuotaRemaining))
+	}
+
+	// Two quantities use up the quota
+	rec = doRequest(e, http.MethodPost, "/api/calculate/batch", limitedKey, `{"quantities":[1201,5000]}`)
+	if rec.Code != http.StatusOK || rec.Header().Get(api.HeaderQuotaRemaining) != "0" {
+		t.Errorf("status = %d, X-Quota-Remaining = %q; want 200 with 0 left", rec.Code, rec.Header().Get(api.HeaderQuotaRemaining))
+	}
+
+	usage := keyring.Usage()[3]
+	if usage.Requests != 2 || usage.Rejected != 1 || usage.QuotaRemaining != 0 {
+		t.Errorf("usage = %+v, want 2 requests and 1 rejected", usage)
+	}
+}
@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/sinaw369/Package-Optimizer/internal/cli"
+)
+
+func TestBulk_CSVKeepsInputOrderInParallel(t *testing.T) {
+	// Large and small quantities interleaved, so later orders finish first
+	var input strings.Builder
+	input.WriteString("order_id,quantity\n")
+	for i := 0; i < 200; i++ {
+		quantity := 1 + i
+		if i%10 == 0 {
+			quantity = 300_000 + i
+		}
+		fmt.Fprintf(&input, "order-%d,%d\n", i, quantity)
+	}
+
+	code, stdout, stderr, _ := runCLIWithInput(t, input.String(), "bulk", "--workers", "8")
+	if code != cli.ExitOK {
+		t.Fatalf("code = %d, stderr = %s", code, stderr)
+	}
+	rows, err := csv.NewReader(strings.NewReader(stdout)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 201 || strings.Join(rows[0], ",") != "order_id,quantity,total_delivered,over_delivery,packages,error" {
+		t.Fatalf("got %d rows, header %v", len(rows), rows[0])
+	}
+	for i, row := range rows[1:] {
+		if row[0] != "order-"+strconv.Itoa(i) || row[5] != "" {
+			t.Fatalf("row %d = %v; want order-%d without an error", i+1, row, i)
+		}
+	}
+	if !strings.Contains(stderr, "200 orders: 200 solved, 0 failed") {
+		t.Errorf("summary missing: %s", stderr)
+	}
+}
+
+func TestBulk_ColumnMappingAndRowErrors(t *testing.T) {
+	input := "Customer,Order No,Qty\nacme,A-1,1201\nacme,A-2,abc\nacme,A-3\nacme,A-4,-5\nacme,A-5,500\n"
+	code, stdout, stderr, _ := runCLIWithInput(t, input, "bulk",
+		"--id-column", "order no", "--quantity-column", "QTY", "--output-format", "jsonl")
+	if code != cli.ExitFailure {
+		t.Errorf("code = %d; want %d because rows failed", code, cli.ExitFailure)
+	}
+
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(stdout), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 5 {
+		t.Fatalf("got %d records; want one per order:\n%s", len(records), stdout)
+	}
+	for i, wantErr := range []bool{false, true, true, true, false} {
+		record := records[i]
+		if record["order_id"] != fmt.Sprintf("A-%d", i+1) || (record["error"] != nil) != wantErr {
+			t.Errorf("record %d = %v; want error %v", i, record, wantErr)
+		}
+	}
+	if records[0]["result"].(map[string]any)["total_delivered"] != 1250.0 || records[0]["line"] != 2.0 {
+		t.Errorf("first record = %v", records[0])
+	}
+	if !strings.Contains(stderr, "line 3 (order A-2)") || !strings.Contains(stderr, "5 orders: 2 solved, 3 failed") {
+		t.Errorf("stderr = %s", stderr)
+	}
+}
+
+func TestBulk_JSONLFileToCSVFile(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "orders.jsonl")
+	outputPath := filepath.Join(dir, "results.csv")
+	input := `{"order_id": 17, "quantity": 251}` + "\n\n" + `{"order_id": "B", "quantity": "1000"}` + "\nnot json\n"
+	if err := os.WriteFile(inputPath, []byte(input), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	code, stdout, _, _ := runCLI(t, "bulk", "--input", inputPath, "--output", outputPath)
+	if code != cli.ExitFailure || stdout != "" {
+		t.Errorf("code = %d, stdout = %q; want %d and output only in the file", code, stdout, cli.ExitFailure)
+	}
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 || rows[1][0] != "17" || rows[1][4] != "1 x 500" || rows[2][0] != "B" || rows[3][0] != "4" || rows[3][5] == "" {
+		t.Errorf("rows = %v", rows)
+	}
+}
+
+func TestBulk_UsageErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		args  []string
+	}{
+		{"missing quantity column", "order_id,amount\nA,1\n", []string{"bulk"}},
+		{"missing explicit ID column", "quantity\n1\n", []string{"bulk", "--id-column", "sku"}},
+		{"empty input", "", []string{"bulk"}},
+		{"unknown output format", "quantity\n1\n", []string{"bulk", "--output-format", "xml"}},
+		{"no workers", "quantity\n1\n", []string{"bulk", "--workers", "0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr, _ := runCLIWithInput(t, tt.input, tt.args...)
+			if code != cli.ExitUsage || stdout != "" || stderr == "" {
+				t.Errorf("code = %d, stdout = %q, stderr = %q; want %d and an error", code, stdout, stderr, cli.ExitUsage)
+			}
+		})
+	}
+}
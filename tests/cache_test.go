@@ -0,0 +1,248 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sinaw369/Package-Optimizer/internal/api"
+	"github.com/sinaw369/Package-Optimizer/internal/cache"
+	"github.com/sinaw369/Package-Optimizer/internal/catalog"
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+	"github.com/sinaw369/Package-Optimizer/internal/history"
+
+	"github.com/labstack/echo/v4"
+)
+
+// resultKey builds a cache key for the default catalog.
+func resultKey(quantity int) cache.Key {
+	return cache.Key{CatalogVersion: "c2f56da27d65", Strategy: domain.StrategyMinOverDelivery, Quantity: quantity}
+}
+
+func TestCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	results := cache.New(2)
+	results.Add(resultKey(1), &domain.OptimizationResult{Requested: 1})
+	results.Add(resultKey(2), &domain.OptimizationResult{Requested: 2})
+
+	// Using 1 makes 2 the least recently used entry
+	if _, ok := results.Get(resultKey(1)); !ok {
+		t.Fatal("Get(1) missed")
+	}
+	results.Add(resultKey(3), &domain.OptimizationResult{Requested: 3})
+
+	if _, ok := results.Get(resultKey(2)); ok {
+		t.Error("Get(2) hit, want it evicted")
+	}
+	for _, quantity := range []int{1, 3} {
+		if result, ok := results.Get(resultKey(quantity)); !ok || result.Requested != quantity {
+			t.Errorf("Get(%d) = %v, %v; want the cached result", quantity, result, ok)
+		}
+	}
+	if results.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", results.Len())
+	}
+}
+
+func TestCache_KeysIncludeCatalogVersion(t *testing.T) {
+	results := cache.New(10)
+	results.Add(resultKey(1201), &domain.OptimizationResult{Requested: 1201})
+
+	other := resultKey(1201)
+	other.CatalogVersion = "8a0d3c1f9b2e"
+	if _, ok := results.Get(other); ok {
+		t.Error("result for another catalog version was returned")
+	}
+
+	results.Purge()
+	if _, ok := results.Get(resultKey(1201)); ok || results.Len() != 0 {
+		t.Error("Purge() left results in the cache")
+	}
+}
+
+func TestCache_ConcurrentUse(t *testing.T) {
+	results := cache.New(50)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				quantity := (g*31 + i) % 100
+				if _, ok := results.Get(resultKey(quantity)); !ok {
+					results.Add(resultKey(quantity), &domain.OptimizationResult{Requested: quantity})
+				}
+				if i%100 == 0 {
+					results.Purge()
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if results.Len() > 50 {
+		t.Errorf("Len() = %d, want at most the capacity", results.Len())
+	}
+}
+
+func TestCache_ETagAndConditionalRequests(t *testing.T) {
+	packageCatalog := newCatalog(t, []int{250, 500, 1000, 2000})
+	results := cache.New(100)
+	packageCatalog.OnChange(func(previous, current *domain.Optimizer) { results.Purge() })
+
+	e := newServer(packageCatalog, api.Options{Cache: results})
+
+	conditional := func(etag string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/calculate?qty=1201", nil)
+		req.Header.Set(api.HeaderIfNoneMatch, etag)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// The first request solves and caches the result
+	first := doRequest(e, http.MethodGet, "/api/calculate?qty=1201", "", "")
+	etag := first.Header().Get(api.HeaderETag)
+	if first.Code != http.StatusOK || etag == "" || first.Header().Get(echo.HeaderCacheControl) == "" {
+		t.Fatalf("status = %d, headers = %v; want 200 with ETag and Cache-Control", first.Code, first.Header())
+	}
+	if results.Len() != 1 {
+		t.Fatalf("cache holds %d results, want 1", results.Len())
+	}
+
+	// The second request is served from the cache with the same body and ETag
+	second := doRequest(e, http.MethodGet, "/api/calculate?qty=1201", "", "")
+	if second.Body.String() != first.Body.String() || second.Header().Get(api.HeaderETag) != etag {
+		t.Errorf("cached response differs: %s (ETag %s)", second.Body, second.Header().Get(api.HeaderETag))
+	}
+
+	// Matching ETags are answered with 304, in any of the forms clients send
+	for _, ifNoneMatch := range []string{etag, "W/" + etag, `"stale", ` + etag, "*"} {
+		if code := conditional(ifNoneMatch); code != http.StatusNotModified {
+			t.Errorf("If-None-Match %s: status = %d, want %d", ifNoneMatch, code, http.StatusNotModified)
+		}
+	}
+	if code := conditional(`"stale"`); code != http.StatusOK {
+		t.Errorf("stale If-None-Match: status = %d, want %d", code, http.StatusOK)
+	}
+
+	// A catalog change empties the cache and changes the ETag
+	if _, err := packageCatalog.Update([]int{23, 31, 53}); err != nil {
+		t.Fatal(err)
+	}
+	if results.Len() != 0 {
+		t.Errorf("cache holds %d results after a catalog change, want 0", results.Len())
+	}
+	if code := conditional(etag); code != http.StatusOK {
+		t.Errorf("old ETag after a catalog change: status = %d, want %d", code, http.StatusOK)
+	}
+
+	// Different quantities have different ETags
+	other := doRequest(e, http.MethodGet, "/api/calculate?qty=1202", "", "")
+	if other.Header().Get(api.HeaderETag) == etag {
+		t.Error("different quantities share an ETag")
+	}
+}
+
+func TestCache_KeysIncludeStockAndCosts(t *testing.T) {
+	// Two 500s are the cheapest way to deliver 1000 at first, one 1000 after the change
+	sizes := []int{250, 500, 1000, 2000}
+	settings := catalog.Settings{Costs: map[int]float64{250: 1, 500: 1, 1000: 4, 2000: 8}}
+	packageCatalog, err := catalog.New(sizes, nil, settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// No purge on change: a stale entry must not match the new key
+	results := cache.New(10)
+	e := newServer(packageCatalog, api.Options{Cache: results})
+
+	version := packageCatalog.Version()
+	first := doRequest(e, http.MethodGet, "/api/calculate?qty=1000", "", "")
+	etag := first.Header().Get(api.HeaderETag)
+	if first.Code != http.StatusOK || !strings.Contains(first.Body.String(), `"500":2`) {
+		t.Fatalf("status = %d, body = %s; want two 500s", first.Code, first.Body)
+	}
+
+	settings.Costs = map[int]float64{250: 1, 500: 3, 1000: 2, 2000: 8}
+	if _, err := packageCatalog.Reconfigure(sizes, settings); err != nil {
+		t.Fatal(err)
+	}
+	if packageCatalog.Version() != version {
+		t.Fatalf("catalog version changed; the test needs equal sizes")
+	}
+
+	// The new prices miss the cache, solve again and get a new ETag
+	second := doRequest(e, http.MethodGet, "/api/calculate?qty=1000", "", "")
+	if !strings.Contains(second.Body.String(), `"1000":1`) || results.Len() != 2 {
+		t.Errorf("body = %s, cache holds %d results; want one 1000 solved anew", second.Body, results.Len())
+	}
+	if second.Header().Get(api.HeaderETag) == etag {
+		t.Errorf("ETag %s unchanged after a change of costs", etag)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/api/calculate?qty=1000", nil)
+	req.Header.Set(api.HeaderIfNoneMatch, etag)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("old ETag after a change of costs: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	// Stock is part of the key too
+	unlimited := cache.KeyFor(packageCatalog.Optimizer(), 1000)
+	settings.Stock = map[int]int{1000: 0}
+	if _, err := packageCatalog.Reconfigure(sizes, settings); err != nil {
+		t.Fatal(err)
+	}
+	if cache.KeyFor(packageCatalog.Optimizer(), 1000) == unlimited {
+		t.Error("keys with and without stock are equal")
+	}
+}
+
+func TestCache_RevalidationsAreRecordedInHistory(t *testing.T) {
+	store, err := history.Open(filepath.Join(t.TempDir(), "history.jsonl"), history.Options{})
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer store.Close()
+	e := newServer(newCatalog(t, []int{250, 500, 1000, 2000}), api.Options{History: store, Cache: cache.New(10)})
+
+	etag := doRequest(e, http.MethodGet, "/api/calculate?qty=1201", "", "").Header().Get(api.HeaderETag)
+	req := httptest.NewRequest(http.MethodGet, "/api/calculate?qty=1201", nil)
+	req.Header.Set(api.HeaderIfNoneMatch, etag)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNotModified)
+	}
+
+	// Both calls are in the history, newest first; the revalidation is marked as such
+	records, total, err := store.Query(history.Filter{})
+	if err != nil || total != 2 {
+		t.Fatalf("Query() = %d records, %v; want 2", total, err)
+	}
+	if !records[0].NotModified || records[0].Result == nil || records[0].Result.TotalDelivered != 1250 {
+		t.Errorf("revalidation record = %+v, want not_modified with the cached result", records[0])
+	}
+	if records[1].NotModified || records[1].Result == nil {
+		t.Errorf("calculation record = %+v, want a plain calculation", records[1])
+	}
+}
+
+func TestCache_MetricsCountHitsAndMisses(t *testing.T) {
+	e := newServer(newCatalog(t, []int{250, 500, 1000, 2000}), api.Options{Cache: cache.New(10)})
+
+	for i := 0; i < 2; i++ {
+		doRequest(e, http.MethodGet, "/api/calculate?qty=777", "", "")
+	}
+
+	body := doRequest(e, http.MethodGet, "/metrics", "", "").Body.String()
+	for _, result := range []string{"hit", "miss"} {
+		if !strings.Contains(body, fmt.Sprintf(`package_optimizer_cache_lookups_total{result=%q}`, result)) {
+			t.Errorf("metrics missing cache %s counter", result)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sinaw369/Package-Optimizer/internal/catalog"
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+)
+
+// newCatalog creates a package catalog or fails the test.
+func newCatalog(t *testing.T, packageSizes []int) *catalog.Catalog {
+	t.Helper()
+	c, err := catalog.New(packageSizes, nil, catalog.Settings{})
+	if err != nil {
+		t.Fatalf("catalog.New(%v) error: %v", packageSizes, err)
+	}
+	return c
+}
+
+func TestCatalog_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		sizes   []int
+		wantErr bool
+	}{
+		{"valid", []int{250, 500, 1000}, false},
+		{"empty", []int{}, true},
+		{"zero", []int{0, 250}, true},
+		{"negative", []int{-250}, true},
+		{"duplicate", []int{250, 500, 250}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := catalog.Validate(tt.sizes); (err != nil) != tt.wantErr {
+				t.Errorf("Validate(%v) error = %v, wantErr %v", tt.sizes, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCatalog_UpdateSwapsOptimizer(t *testing.T) {
+	c := newCatalog(t, []int{250, 500, 1000, 2000})
+	before := c.Version()
+
+	var notified []string
+	c.OnChange(func(previous, current *domain.Optimizer) {
+		notified = append(notified, previous.CatalogVersion()+"->"+current.CatalogVersion())
+	})
+
+	optimizer, err := c.Update([]int{23, 31, 53})
+	if err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+	if c.Optimizer() != optimizer || c.Version() == before {
+		t.Fatalf("catalog was not swapped")
+	}
+
+	sizes, version := c.State()
+	if len(sizes) != 3 || sizes[0] != 23 || version != optimizer.CatalogVersion() {
+		t.Errorf("State() = %v, %q; want configured order and matching version", sizes, version)
+	}
+
+	result, err := c.Optimizer().Optimize(500000)
+	if err != nil || result.OverDelivery != 0 {
+		t.Errorf("Optimize(500000) with new catalog = %+v, %v; want exact delivery", result, err)
+	}
+	if len(notified) != 1 {
+		t.Errorf("listener called %d times, want 1", len(notified))
+	}
+
+	// Publishing the same sizes again is not a change
+	if _, err := c.Update([]int{53, 31, 23}); err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+	if len(notified) != 1 {
+		t.Errorf("listener called for an unchanged catalog")
+	}
+}
+
+func TestCatalog_InvalidUpdateKeepsCurrent(t *testing.T) {
+	c := newCatalog(t, []int{250, 500})
+	before := c.Optimizer()
+
+	if _, err := c.Update([]int{250, -1}); err == nil {
+		t.Fatal("Update() with a negative size succeeded")
+	}
+	if c.Optimizer() != before {
+		t.Error("invalid update replaced the catalog")
+	}
+}
+
+func TestCatalog_ConcurrentReadsDuringUpdates(t *testing.T) {
+	c := newCatalog(t, []int{250, 500, 1000, 2000})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				if _, err := c.Optimizer().Optimize(1201); err != nil {
+					t.Errorf("Optimize() error: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	for j := 0; j < 50; j++ {
+		sizes := []int{250, 500, 1000, 2000}
+		if j%2 == 1 {
+			sizes = []int{23, 31, 53}
+		}
+		if _, err := c.Update(sizes); err != nil {
+			t.Fatalf("Update() error: %v", err)
+		}
+	}
+	wg.Wait()
+}
+
+func TestCatalog_CompareDraft(t *testing.T) {
+	e := newAuthServer(t)
+
+	rec := doRequest(e, http.MethodPost, "/api/catalog/compare", partnerKey,
+		`{"package_sizes":[100,250],"from":50,"to":1000,"step":50}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var comparison struct {
+		Current struct {
+			Version         string `json:"version"`
+			Exact           int    `json:"exact"`
+			MaxOverDelivery int    `json:"max_over_delivery"`
+		} `json:"current"`
+		Draft struct {
+			PackageSizes    []int `json:"package_sizes"`
+			Exact           int   `json:"exact"`
+			MaxOverDelivery int   `json:"max_over_delivery"`
+		} `json:"draft"`
+		Points []struct {
+			Quantity int `json:"quantity"`
+			Current  int `json:"current"`
+			Draft    int `json:"draft"`
+		} `json:"points"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &comparison); err != nil {
+		t.Fatal(err)
+	}
+
+	// 50, 100, ..., 1000: the current catalog delivers multiples of 250 exactly,
+	// the draft 100 and every multiple of 50 from 200 on
+	if len(comparison.Points) != 20 || comparison.Points[0].Quantity != 50 || comparison.Points[19].Quantity != 1000 {
+		t.Fatalf("points = %+v, want 20 from 50 to 1000", comparison.Points)
+	}
+	if comparison.Current.Version != domain.CatalogVersion([]int{250, 500, 1000, 2000}) || comparison.Current.Exact != 4 {
+		t.Errorf("current = %+v, want the catalog in use with 4 exact quantities", comparison.Current)
+	}
+	if comparison.Draft.Exact != 18 || comparison.Draft.MaxOverDelivery != 50 {
+		t.Errorf("draft = %+v, want 18 exact quantities and a maximum over-delivery of 50", comparison.Draft)
+	}
+	if p := comparison.Points[1]; p.Quantity != 100 || p.Current != 150 || p.Draft != 0 {
+		t.Errorf("point for 100 = %+v, want current 150, draft 0", p)
+	}
+
+	// Comparing doesn't change the catalog in use
+	if rec := doRequest(e, http.MethodGet, "/api/catalog", "", ""); !strings.Contains(rec.Body.String(), `"package_sizes":[250,500,1000,2000]`) {
+		t.Errorf("catalog after compare = %s, want it unchanged", rec.Body.String())
+	}
+}
+
+func TestCatalog_CompareRejectsInvalidDrafts(t *testing.T) {
+	e := newAuthServer(t)
+
+	tests := []struct {
+		name   string
+		apiKey string
+		body   string
+		want   int
+	}{
+		{"no key", "", `{"package_sizes":[250],"to":1000}`, http.StatusUnauthorized},
+		{"invalid sizes", partnerKey, `{"package_sizes":[0],"to":1000}`, http.StatusBadRequest},
+		{"missing range", partnerKey, `{"package_sizes":[250]}`, http.StatusBadRequest},
+		{"reversed range", partnerKey, `{"package_sizes":[250],"from":500,"to":100}`, http.StatusBadRequest},
+		{"too many points", partnerKey, `{"package_sizes":[250],"to":5000,"step":1}`, http.StatusBadRequest},
+		{"over the limits", partnerKey, `{"package_sizes":[250],"to":9223372036854775000}`, http.StatusUnprocessableEntity},
+		{"default step", partnerKey, `{"package_sizes":[250],"to":5000}`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(e, http.MethodPost, "/api/catalog/compare", tt.apiKey, tt.body)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
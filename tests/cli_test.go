@@ -0,0 +1,186 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sinaw369/Package-Optimizer/internal/cli"
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+)
+
+// runCLI runs the command line with a serve command that records its arguments.
+func runCLI(t *testing.T, args ...string) (code int, stdout, stderr string, served []string) {
+	t.Helper()
+	return runCLIWithInput(t, "", args...)
+}
+
+// runCLIWithInput is like runCLI, with input as the standard input.
+func runCLIWithInput(t *testing.T, input string, args ...string) (code int, stdout, stderr string, served []string) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	serve := func(args []string) int {
+		served = append([]string{"serve"}, args...)
+		return cli.ExitOK
+	}
+	code = cli.Main(args, strings.NewReader(input), &out, &errOut, serve)
+	return code, out.String(), errOut.String(), served
+}
+
+func TestCLI_Dispatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		code  int
+		serve []string
+	}{
+		{"no command serves", nil, cli.ExitOK, []string{"serve"}},
+		{"flags only serve", []string{"--port", "9000"}, cli.ExitOK, []string{"serve", "--port", "9000"}},
+		{"serve command", []string{"serve", "--port", "9000"}, cli.ExitOK, []string{"serve", "--port", "9000"}},
+		{"help", []string{"help"}, cli.ExitOK, nil},
+		{"unknown command", []string{"bogus"}, cli.ExitUsage, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, _, served := runCLI(t, tt.args...)
+			if code != tt.code || strings.Join(served, " ") != strings.Join(tt.serve, " ") {
+				t.Errorf("code = %d, served %v; want %d, %v", code, served, tt.code, tt.serve)
+			}
+		})
+	}
+}
+
+func TestCLI_CalcText(t *testing.T) {
+	code, stdout, stderr, _ := runCLI(t, "calc", "1201")
+	if code != cli.ExitOK {
+		t.Fatalf("code = %d, stderr = %s", code, stderr)
+	}
+	for _, want := range []string{"Quantity:   1201", "Delivered:  1250 (49 over)", "1 x 1000, 1 x 250 (2 packages)"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("output missing %q:\n%s", want, stdout)
+		}
+	}
+}
+
+func TestCLI_CalcJSONWithFlagsAfterQuantity(t *testing.T) {
+	code, stdout, stderr, _ := runCLI(t, "calc", "500000", "--package-sizes", "23,31,53", "--format", "json")
+	if code != cli.ExitOK {
+		t.Fatalf("code = %d, stderr = %s", code, stderr)
+	}
+	var result domain.OptimizationResult
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON %q: %v", stdout, err)
+	}
+	if result.TotalDelivered != 500000 || result.Packages["53"] != 9429 {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestCLI_CalcWithCostsAndNamedCatalog(t *testing.T) {
+	code, stdout, stderr, _ := runCLI(t, "calc", "1000",
+		"--catalogs", "retail=250 500 1000, bulk=1000 5000", "--catalog", "retail",
+		"--package-costs", "250=1, 500=1.5, 1000=4, 5000=16", "--package-stock", "5000=0")
+	if code != cli.ExitOK {
+		t.Fatalf("code = %d, stderr = %s", code, stderr)
+	}
+	// Two 500s are cheaper than one 1000
+	for _, want := range []string{"Delivered:  1000 (0 over)", "2 x 500 (2 packages)", "Cost:       3"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("output missing %q:\n%s", want, stdout)
+		}
+	}
+}
+
+func TestCLI_CalcErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"missing quantity", []string{"calc"}, cli.ExitUsage},
+		{"not a number", []string{"calc", "abc"}, cli.ExitUsage},
+		{"unknown format", []string{"calc", "--format", "xml", "10"}, cli.ExitUsage},
+		{"invalid sizes", []string{"calc", "--package-sizes", "0", "10"}, cli.ExitUsage},
+		{"over the limit", []string{"calc", "--max-quantity", "100", "1000"}, cli.ExitFailure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr, _ := runCLI(t, tt.args...)
+			if code != tt.code || stdout != "" || stderr == "" {
+				t.Errorf("code = %d, stdout = %q, stderr = %q; want %d and an error", code, stdout, stderr, tt.code)
+			}
+		})
+	}
+}
+
+func TestCLI_ValidateConfig(t *testing.T) {
+	valid := writeConfig(t, "valid.yaml", "catalog:\n  package_sizes: [23, 31, 53]\n")
+	code, stdout, _, _ := runCLI(t, "validate-config", "--config", valid)
+	if code != cli.ExitOK || !strings.Contains(stdout, "configuration is valid") {
+		t.Errorf("valid file: code = %d, stdout = %q", code, stdout)
+	}
+
+	invalid := writeConfig(t, "invalid.yaml", "server:\n  port: 0\ncatalog:\n  package_sizes: [10, -1]\n")
+	code, _, stderr, _ := runCLI(t, "validate-config", "--config", invalid)
+	if code != cli.ExitFailure || !strings.Contains(stderr, "server.port") || !strings.Contains(stderr, "catalog.package_sizes") {
+		t.Errorf("invalid file: code = %d, stderr = %q", code, stderr)
+	}
+
+	code, _, stderr, _ = runCLI(t, "validate-config", "--auth-keys-file", "/nonexistent/keys.json")
+	if code != cli.ExitFailure || !strings.Contains(stderr, "auth.keys_file") {
+		t.Errorf("missing key file: code = %d, stderr = %q", code, stderr)
+	}
+}
+
+func TestCLI_Version(t *testing.T) {
+	code, stdout, _, _ := runCLI(t, "version")
+	if code != cli.ExitOK || !strings.HasPrefix(stdout, "package-optimizer "+cli.Version) {
+		t.Errorf("code = %d, stdout = %q", code, stdout)
+	}
+
+	code, stdout, _, _ = runCLI(t, "version", "--format", "json")
+	var info cli.BuildInfo
+	if code != cli.ExitOK || json.Unmarshal([]byte(stdout), &info) != nil || info.Version != cli.Version || info.GoVersion == "" {
+		t.Errorf("code = %d, stdout = %q", code, stdout)
+	}
+}
+
+func TestCLI_REPL(t *testing.T) {
+	script := strings.Join([]string{
+		"1201",
+		"alt 1201 2",
+		"sizes 23,31,53",
+		"strategy nope",
+		"strategy min-over-delivery",
+		"compare 250,500,1000,2000 263",
+		"gaps 100",
+		"bogus",
+		"quit",
+		"calc 1", // not reached
+	}, "\n")
+	code, stdout, stderr, _ := runCLIWithInput(t, script, "repl")
+	if code != cli.ExitOK {
+		t.Fatalf("code = %d, stderr = %s", code, stderr)
+	}
+	for _, want := range []string{
+		"Delivered:  1250 (49 over)",
+		"2  1500       299   1 x 1000, 1 x 500 (2 packages)",
+		"package sizes [53 31 23]",
+		"strategy min-over-delivery (the only strategy available)",
+		// Selecting the strategy rebuilds the optimizer with the current sizes
+		"263 (+0) 7 x 31, 2 x 23 (9 packages)",
+		"500 (+237) 1 x 500 (1 package)",
+		"1-22 delivered as 23 (up to +22)",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("output missing %q:\n%s", want, stdout)
+		}
+	}
+	if strings.Contains(stdout, "Quantity:   1\n") {
+		t.Error("commands after quit ran")
+	}
+	if !strings.Contains(stderr, `unknown strategy "nope"`) || !strings.Contains(stderr, `unknown command "bogus"`) {
+		t.Errorf("stderr = %s", stderr)
+	}
+}
@@ -0,0 +1,246 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sinaw369/Package-Optimizer/internal/api"
+	"github.com/sinaw369/Package-Optimizer/internal/catalog"
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+	"github.com/sinaw369/Package-Optimizer/pkg/client"
+)
+
+// newClientServer starts an HTTP server running the real handler with API keys
+// required and limits on the quantities it solves.
+func newClientServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	optimizer := domain.NewOptimizer([]int{250, 500, 1000, 2000})
+	packageCatalog, err := catalog.New([]int{250, 500, 1000, 2000}, nil, catalog.Settings{Limits: domain.Limits{
+		MaxQuantity: 1_000_000,
+		MaxMemory:   optimizer.EstimateMemory(100_000),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(newServer(packageCatalog, api.Options{Keyring: newTestKeyring(t)}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newClient creates a client for the server with the given API key and fast retries.
+func newClient(t *testing.T, baseURL, apiKey string) *client.Client {
+	t.Helper()
+	c, err := client.New(baseURL, client.Options{
+		APIKey: apiKey,
+		Retry:  client.RetryPolicy{InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("client.New() error: %v", err)
+	}
+	return c
+}
+
+func TestClient_New(t *testing.T) {
+	for _, baseURL := range []string{"", "localhost:8080", "ftp://example.com", "http://"} {
+		if _, err := client.New(baseURL, client.Options{}); err == nil {
+			t.Errorf("New(%q) succeeded, want an error", baseURL)
+		}
+	}
+	if _, err := client.New("http://localhost:8080/optimizer/", client.Options{}); err != nil {
+		t.Errorf("New() with a path prefix error: %v", err)
+	}
+}
+
+func TestClient_Calculate(t *testing.T) {
+	c := newClient(t, newClientServer(t).URL, partnerKey)
+
+	result, err := c.Calculate(context.Background(), 1201)
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+	want := map[string]int{"1000": 1, "250": 1}
+	if result.Requested != 1201 || result.TotalDelivered != 1250 || !reflect.DeepEqual(result.Packages, want) {
+		t.Errorf("Calculate(1201) = %+v, want 1250 delivered as %v", result, want)
+	}
+	if result.PackageCount() != 2 {
+		t.Errorf("PackageCount() = %d, want 2", result.PackageCount())
+	}
+}
+
+func TestClient_CalculateBatch(t *testing.T) {
+	c := newClient(t, newClientServer(t).URL, partnerKey)
+
+	batch, err := c.CalculateBatch(context.Background(), []int{1201, -1, 2_000_000, 251})
+	if err != nil {
+		t.Fatalf("CalculateBatch() error: %v", err)
+	}
+	if batch.CatalogVersion == "" || len(batch.Items) != 4 {
+		t.Fatalf("CalculateBatch() = %+v, want a catalog version and 4 items", batch)
+	}
+	if item := batch.Items[0]; item.Err() != nil || item.Result.TotalDelivered != 1250 {
+		t.Errorf("item 0 = %+v, want 1250 delivered", item)
+	}
+	if err := batch.Items[1].Err(); !errors.Is(err, client.ErrInvalidRequest) {
+		t.Errorf("item 1 error = %v, want ErrInvalidRequest", err)
+	}
+	if err := batch.Items[2].Err(); !errors.Is(err, client.ErrQuantityTooLarge) {
+		t.Errorf("item 2 error = %v, want ErrQuantityTooLarge", err)
+	}
+	if item := batch.Items[3]; item.Err() != nil || item.Result.TotalDelivered != 500 {
+		t.Errorf("item 3 = %+v, want 500 delivered", item)
+	}
+
+	// The request as a whole is rejected when it is empty or too large
+	if _, err := c.CalculateBatch(context.Background(), nil); !errors.Is(err, client.ErrInvalidRequest) {
+		t.Errorf("CalculateBatch(nil) error = %v, want ErrInvalidRequest", err)
+	}
+	if _, err := c.CalculateBatch(context.Background(), make([]int, client.MaxBatchQuantities+1)); !errors.Is(err, client.ErrInvalidRequest) {
+		t.Errorf("CalculateBatch() over the maximum error = %v, want ErrInvalidRequest", err)
+	}
+}
+
+func TestClient_Catalog(t *testing.T) {
+	server := newClientServer(t)
+	ctx := context.Background()
+
+	sizes, err := newClient(t, server.URL, partnerKey).PackageSizes(ctx)
+	if err != nil || !reflect.DeepEqual(sizes, []int{250, 500, 1000, 2000}) {
+		t.Errorf("PackageSizes() = %v, %v; want [250 500 1000 2000]", sizes, err)
+	}
+
+	admin := newClient(t, server.URL, adminKey)
+	before, err := admin.Catalog(ctx)
+	if err != nil {
+		t.Fatalf("Catalog() error: %v", err)
+	}
+	updated, err := admin.UpdateCatalog(ctx, []int{23, 31, 53})
+	if err != nil {
+		t.Fatalf("UpdateCatalog() error: %v", err)
+	}
+	if updated.Version == before.Version || !reflect.DeepEqual(updated.PackageSizes, []int{23, 31, 53}) {
+		t.Errorf("UpdateCatalog() = %+v, want a new version with sizes [23 31 53]", updated)
+	}
+	if _, err := admin.UpdateCatalog(ctx, []int{0}); !errors.Is(err, client.ErrInvalidRequest) {
+		t.Errorf("UpdateCatalog([0]) error = %v, want ErrInvalidRequest", err)
+	}
+}
+
+func TestClient_TypedErrors(t *testing.T) {
+	server := newClientServer(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func() error
+		want error
+	}{
+		{"too large", func() error {
+			_, err := newClient(t, server.URL, partnerKey).Calculate(ctx, 2_000_000)
+			return err
+		}, client.ErrQuantityTooLarge},
+		{"memory limit", func() error {
+			_, err := newClient(t, server.URL, partnerKey).Calculate(ctx, 500_000)
+			return err
+		}, client.ErrMemoryLimitExceeded},
+		{"negative", func() error {
+			_, err := newClient(t, server.URL, partnerKey).Calculate(ctx, -1)
+			return err
+		}, client.ErrInvalidRequest},
+		{"missing key", func() error {
+			_, err := newClient(t, server.URL, "").Calculate(ctx, 1)
+			return err
+		}, client.ErrUnauthorized},
+		{"missing scope", func() error {
+			_, err := newClient(t, server.URL, partnerKey).UpdateCatalog(ctx, []int{100})
+			return err
+		}, client.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("error = %v, want %v", err, tt.want)
+			}
+			var apiErr *client.APIError
+			if !errors.As(err, &apiErr) || apiErr.Message == "" || apiErr.RequestID == "" {
+				t.Errorf("error = %#v, want an *APIError with a message and request ID", err)
+			}
+		})
+	}
+}
+
+func TestClient_RetriesTransientFailures(t *testing.T) {
+	server := newClientServer(t)
+
+	// Fail the first two attempts as an overloaded proxy would
+	var attempts atomic.Int32
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) <= 2 {
+			http.Error(w, "upstream unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		r.URL.Scheme, r.URL.Host = "http", server.Listener.Addr().String()
+		resp, err := http.DefaultTransport.RoundTrip(r.Clone(r.Context()))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		w.WriteHeader(resp.StatusCode)
+		_, _ = io.Copy(w, resp.Body)
+	}))
+	defer flaky.Close()
+
+	result, err := newClient(t, flaky.URL, partnerKey).Calculate(context.Background(), 1201)
+	if err != nil || result.TotalDelivered != 1250 {
+		t.Fatalf("Calculate() = %+v, %v; want success after retries", result, err)
+	}
+	if got := attempts.Load(); got != 3 {
+		t.Errorf("attempts = %d, want 3", got)
+	}
+
+	// Without retries the failure is returned as is
+	attempts.Store(0)
+	c, _ := client.New(flaky.URL, client.Options{APIKey: partnerKey, Retry: client.RetryPolicy{MaxAttempts: 1}})
+	_, err = c.Calculate(context.Background(), 1201)
+	var apiErr *client.APIError
+	if !errors.Is(err, client.ErrUnavailable) || !errors.As(err, &apiErr) || apiErr.Message != "upstream unavailable" {
+		t.Errorf("Calculate() without retries error = %v, want ErrUnavailable", err)
+	}
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("attempts without retries = %d, want 1", got)
+	}
+}
+
+func TestClient_HonoursContext(t *testing.T) {
+	// The server always asks the client to come back later
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, `{"error":"busy"}`, http.StatusTooManyRequests)
+	}))
+	defer unavailable.Close()
+
+	c, err := client.New(unavailable.URL, client.Options{Retry: client.RetryPolicy{MaxAttempts: 10, MaxBackoff: time.Minute}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = c.Calculate(ctx, 1201)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Calculate() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Calculate() returned after %v, want it to stop at the deadline", elapsed)
+	}
+}
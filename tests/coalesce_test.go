@@ -0,0 +1,238 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sinaw369/Package-Optimizer/internal/api"
+	"github.com/sinaw369/Package-Optimizer/internal/coalesce"
+)
+
+// startWaiters calls group.Do for key from n goroutines and returns a channel with
+// each caller's error. It returns once every caller has joined the computation.
+func startWaiters(t *testing.T, group *coalesce.Group[string, int], ctxs []context.Context, key string, fn func(context.Context) (int, error)) <-chan error {
+	t.Helper()
+	errs := make(chan error, len(ctxs))
+	for _, ctx := range ctxs {
+		go func(ctx context.Context) {
+			_, _, err := group.Do(ctx, key, fn)
+			errs <- err
+		}(ctx)
+	}
+	return errs
+}
+
+func TestCoalesce_SharesOneExecution(t *testing.T) {
+	var group coalesce.Group[string, int]
+	var calls atomic.Int32
+	release := make(chan struct{})
+
+	const callers = 20
+	var wg sync.WaitGroup
+	var sharedCount atomic.Int32
+	results := make([]int, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			result, shared, err := group.Do(context.Background(), "1201", func(ctx context.Context) (int, error) {
+				calls.Add(1)
+				<-release
+				return 1250, nil
+			})
+			if err != nil {
+				t.Errorf("Do() error = %v", err)
+			}
+			if shared {
+				sharedCount.Add(1)
+			}
+			results[i] = result
+		}(i)
+	}
+
+	// Let every caller join before the computation finishes
+	waitFor(t, func() bool { return group.InFlight() == 1 })
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("fn ran %d times, want 1", calls.Load())
+	}
+	if sharedCount.Load() != callers-1 {
+		t.Errorf("%d callers were coalesced, want %d", sharedCount.Load(), callers-1)
+	}
+	for i, result := range results {
+		if result != 1250 {
+			t.Errorf("caller %d got %d, want 1250", i, result)
+		}
+	}
+	if group.InFlight() != 0 {
+		t.Errorf("InFlight() = %d after completion, want 0", group.InFlight())
+	}
+
+	// A finished computation is not reused
+	if _, shared, _ := group.Do(context.Background(), "1201", func(ctx context.Context) (int, error) { return 1250, nil }); shared {
+		t.Error("call after completion was coalesced with a finished computation")
+	}
+}
+
+func TestCoalesce_PropagatesErrorsToAllCallers(t *testing.T) {
+	var group coalesce.Group[string, int]
+	failure := errors.New("solver failed")
+	release := make(chan struct{})
+
+	ctxs := []context.Context{context.Background(), context.Background(), context.Background()}
+	errs := startWaiters(t, &group, ctxs, "k", func(ctx context.Context) (int, error) {
+		<-release
+		return 0, failure
+	})
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	for range ctxs {
+		if err := <-errs; !errors.Is(err, failure) {
+			t.Errorf("Do() error = %v, want %v", err, failure)
+		}
+	}
+}
+
+func TestCoalesce_CancellationIsPerCaller(t *testing.T) {
+	var group coalesce.Group[string, int]
+	release := make(chan struct{})
+	var computationCancelled atomic.Bool
+
+	leaving, leave := context.WithCancel(context.Background())
+	defer leave()
+	ctxs := []context.Context{leaving, context.Background()}
+	errs := startWaiters(t, &group, ctxs, "k", func(ctx context.Context) (int, error) {
+		select {
+		case <-release:
+			return 1, nil
+		case <-ctx.Done():
+			computationCancelled.Store(true)
+			return 0, ctx.Err()
+		}
+	})
+	time.Sleep(20 * time.Millisecond)
+
+	// The caller that leaves gets its own context error
+	leave()
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled caller error = %v, want %v", err, context.Canceled)
+	}
+
+	// The computation continues for the remaining caller
+	close(release)
+	if err := <-errs; err != nil {
+		t.Errorf("remaining caller error = %v, want nil", err)
+	}
+	if computationCancelled.Load() {
+		t.Error("computation was cancelled while a caller was still waiting")
+	}
+}
+
+func TestCoalesce_CancelsWhenAllCallersLeave(t *testing.T) {
+	var group coalesce.Group[string, int]
+	stopped := make(chan error, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := startWaiters(t, &group, []context.Context{ctx, ctx}, "k", func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		stopped <- ctx.Err()
+		return 0, ctx.Err()
+	})
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	for i := 0; i < 2; i++ {
+		if err := <-errs; !errors.Is(err, context.Canceled) {
+			t.Errorf("Do() error = %v, want %v", err, context.Canceled)
+		}
+	}
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("computation was not cancelled after every caller left")
+	}
+	if group.InFlight() != 0 {
+		t.Errorf("InFlight() = %d, want 0", group.InFlight())
+	}
+}
+
+func TestCoalesce_RecoversPanics(t *testing.T) {
+	var group coalesce.Group[string, int]
+	_, _, err := group.Do(context.Background(), "k", func(ctx context.Context) (int, error) {
+		panic("boom")
+	})
+	if err == nil {
+		t.Fatal("Do() error = nil, want the panic reported as an error")
+	}
+}
+
+func TestCoalesce_DifferentKeysRunSeparately(t *testing.T) {
+	var group coalesce.Group[string, int]
+	var calls atomic.Int32
+	release := make(chan struct{})
+
+	fn := func(ctx context.Context) (int, error) {
+		calls.Add(1)
+		<-release
+		return 0, nil
+	}
+	errsA := startWaiters(t, &group, []context.Context{context.Background()}, "a", fn)
+	errsB := startWaiters(t, &group, []context.Context{context.Background()}, "b", fn)
+	waitFor(t, func() bool { return group.InFlight() == 2 })
+	close(release)
+	<-errsA
+	<-errsB
+
+	if calls.Load() != 2 {
+		t.Errorf("fn ran %d times, want 2", calls.Load())
+	}
+}
+
+func TestCoalesce_ConcurrentIdenticalRequests(t *testing.T) {
+	e := newServer(newCatalog(t, []int{23, 31, 53}), api.Options{})
+
+	// Without a cache, identical requests either share a solve or solve on their own;
+	// either way every client gets the same answer
+	const clients = 16
+	bodies := make([]string, clients)
+	var wg sync.WaitGroup
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rec := doRequest(e, http.MethodGet, "/api/calculate?qty=500000", "", "")
+			if rec.Code != http.StatusOK {
+				t.Errorf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+			}
+			bodies[i] = rec.Body.String()
+		}(i)
+	}
+	wg.Wait()
+
+	for i, body := range bodies {
+		if body != bodies[0] {
+			t.Errorf("client %d got %s, want %s", i, body, bodies[0])
+		}
+	}
+}
+
+// waitFor polls cond until it holds, failing the test after a second.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within a second")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,352 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sinaw369/Package-Optimizer/internal/config"
+)
+
+// writeConfig writes a configuration file with the given name into a temporary directory.
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConfig_Defaults(t *testing.T) {
+	cfg, err := config.Load(nil)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Port != "8080" || !reflect.DeepEqual(cfg.PackageSizes, []int{250, 500, 1000, 2000}) {
+		t.Errorf("Port = %s, PackageSizes = %v; want the defaults", cfg.Port, cfg.PackageSizes)
+	}
+	if cfg.MaxSolveMemory != 1024<<20 || cfg.JobResultTTL != 15*time.Minute || !cfg.HistoryEnabled {
+		t.Errorf("unexpected defaults: %+v", cfg)
+	}
+	if cfg.File != "" || cfg.PrintConfig {
+		t.Errorf("File = %q, PrintConfig = %v; want neither", cfg.File, cfg.PrintConfig)
+	}
+}
+
+func TestConfig_FileFormats(t *testing.T) {
+	files := map[string]string{
+		"config.yaml": `
+server:
+  port: 3000
+catalog:
+  package_sizes: [23, 31, 53]
+logging:
+  level: DEBUG
+cors:
+  allowed_origins: ["https://shop.example.com"]
+  allow_credentials: true
+tracing:
+  otlp_headers:
+    x-api-key: secret
+limits:
+  max_quantity: 500000
+  solver_queue_timeout: 5s
+`,
+		"config.json": `{
+  "server": {"port": 3000},
+  "catalog": {"package_sizes": [23, 31, 53]},
+  "logging": {"level": "DEBUG"},
+  "cors": {"allowed_origins": ["https://shop.example.com"], "allow_credentials": true},
+  "tracing": {"otlp_headers": {"x-api-key": "secret"}},
+  "limits": {"max_quantity": 500000, "solver_queue_timeout": "5s"}
+}`,
+		"config.toml": `
+[server]
+port = 3000
+
+[catalog]
+package_sizes = [23, 31, 53]
+
+[logging]
+level = "DEBUG"
+
+[cors]
+allowed_origins = ["https://shop.example.com"]
+allow_credentials = true
+
+[tracing.otlp_headers]
+x-api-key = "secret"
+
+[limits]
+max_quantity = 500000
+solver_queue_timeout = "5s"
+`,
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			path := writeConfig(t, name, content)
+			cfg, err := config.Load([]string{"--config", path})
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			if cfg.File != path || cfg.Port != "3000" || !reflect.DeepEqual(cfg.PackageSizes, []int{23, 31, 53}) {
+				t.Errorf("File = %q, Port = %s, PackageSizes = %v", cfg.File, cfg.Port, cfg.PackageSizes)
+			}
+			if cfg.LogLevel != "debug" || !cfg.CORSAllowCredentials || cfg.CORSAllowedOrigins[0] != "https://shop.example.com" {
+				t.Errorf("LogLevel = %s, CORS = %v %v", cfg.LogLevel, cfg.CORSAllowCredentials, cfg.CORSAllowedOrigins)
+			}
+			if cfg.TracingOTLPHeaders["x-api-key"] != "secret" {
+				t.Errorf("TracingOTLPHeaders = %v", cfg.TracingOTLPHeaders)
+			}
+			if cfg.MaxQuantity != 500000 || cfg.SolverQueueTimeout != 5*time.Second {
+				t.Errorf("MaxQuantity = %d, SolverQueueTimeout = %s", cfg.MaxQuantity, cfg.SolverQueueTimeout)
+			}
+			// Settings missing from the file keep their defaults
+			if cfg.GRPCPort != "9090" || cfg.CacheSize != 10000 {
+				t.Errorf("GRPCPort = %s, CacheSize = %d; want the defaults", cfg.GRPCPort, cfg.CacheSize)
+			}
+		})
+	}
+}
+
+func TestConfig_Precedence(t *testing.T) {
+	path := writeConfig(t, "config.yaml", `
+server:
+  port: 3000
+  grpc_port: 3001
+cache:
+  size: 30
+`)
+	// Environment variables override the file, flags override both
+	t.Setenv("PORT", "4000")
+	t.Setenv("GRPC_PORT", "4001")
+	t.Setenv("CONFIG_FILE", path)
+
+	cfg, err := config.Load([]string{"--port", "5000"})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Port != "5000" || cfg.GRPCPort != "4001" || cfg.CacheSize != 30 || cfg.RateLimitBurst != 20 {
+		t.Errorf("Port = %s, GRPCPort = %s, CacheSize = %d, RateLimitBurst = %d; want flag, env, file and default",
+			cfg.Port, cfg.GRPCPort, cfg.CacheSize, cfg.RateLimitBurst)
+	}
+
+	// Boolean flags may be given without a value
+	cfg, err = config.Load([]string{"--cors-allow-credentials", "--cors-allowed-origins", "https://a.example.com"})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !cfg.CORSAllowCredentials {
+		t.Error("--cors-allow-credentials did not enable credentials")
+	}
+}
+
+func TestConfig_ReportsEveryProblem(t *testing.T) {
+	path := writeConfig(t, "config.yaml", `
+catalog:
+  package_sizes: [250, -1]
+limits:
+  max_quantity: lots
+  unknown_limit: 5
+jobs: 3
+`)
+	t.Setenv("LOG_FORMAT", "xml")
+
+	_, err := config.Load([]string{"--config", path, "--cors-allow-credentials"})
+	var validationErr *config.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Load() error = %v, want a *config.ValidationError", err)
+	}
+
+	want := map[string]string{
+		"catalog.package_sizes":  "file " + path,
+		"limits.max_quantity":    "file " + path,
+		"limits.unknown_limit":   "file " + path,
+		"jobs":                   "file " + path,
+		"logging.format":         "env LOG_FORMAT",
+		"cors.allow_credentials": "flag --cors-allow-credentials",
+	}
+	got := make(map[string]string)
+	for _, fieldErr := range validationErr.Errors {
+		got[fieldErr.Field] = fieldErr.Source
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("problems = %v, want %v", got, want)
+	}
+
+	// The message lists one problem per line, with its field path
+	if lines := strings.Split(err.Error(), "\n"); len(lines) != len(want)+1 || !strings.Contains(err.Error(), "limits.max_quantity: ") {
+		t.Errorf("error message = %q", err)
+	}
+}
+
+func TestConfig_RejectsDuplicateSizesAndMisplacedSettings(t *testing.T) {
+	path := writeConfig(t, "config.yaml", `
+catalog:
+  package_sizes: [250, 500, 250]
+costs:
+  "250": 1.5
+`)
+
+	_, err := config.Load([]string{"--config", path})
+	var validationErr *config.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Load() error = %v, want a *config.ValidationError", err)
+	}
+	got := make(map[string]string)
+	for _, fieldErr := range validationErr.Errors {
+		got[fieldErr.Field] = fieldErr.Message
+	}
+	if !strings.Contains(got["catalog.package_sizes"], "duplicate package size 250") {
+		t.Errorf("catalog.package_sizes problem = %q, want the duplicate size", got["catalog.package_sizes"])
+	}
+	if got["costs"] != "unknown setting" || len(got) != 2 {
+		t.Errorf("problems = %v, want the duplicate size and costs outside the catalog section", got)
+	}
+
+	// The same check applies to every source
+	t.Setenv("PACKAGE_SIZES", "23,31,23")
+	if _, err := config.Load(nil); err == nil || !strings.Contains(err.Error(), "catalog.package_sizes: duplicate package size 23") {
+		t.Errorf("Load() error = %v, want the duplicate size", err)
+	}
+}
+
+func TestConfig_CatalogSettings(t *testing.T) {
+	path := writeConfig(t, "config.yaml", `
+catalog:
+  name: bulk
+  costs: {250: 1, 1000: 3.5, 5000: 16}
+  stock: {5000: 2}
+catalogs:
+  retail: [250, 500, 1000]
+  bulk: [1000, 5000]
+`)
+	cfg, err := config.Load([]string{"--config", path})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(cfg.PackageSizes, []int{1000, 5000}) || len(cfg.Catalogs) != 2 {
+		t.Errorf("PackageSizes = %v, Catalogs = %v; want the bulk catalog selected", cfg.PackageSizes, cfg.Catalogs)
+	}
+	if !reflect.DeepEqual(cfg.PackageCosts, map[int]float64{250: 1, 1000: 3.5, 5000: 16}) ||
+		!reflect.DeepEqual(cfg.PackageStock, map[int]int{5000: 2}) {
+		t.Errorf("PackageCosts = %v, PackageStock = %v", cfg.PackageCosts, cfg.PackageStock)
+	}
+
+	// The printed configuration reads back the same
+	var out bytes.Buffer
+	if err := cfg.Print(&out); err != nil {
+		t.Fatal(err)
+	}
+	printed, err := config.Load([]string{"--config", writeConfig(t, "printed.yaml", out.String())})
+	if err != nil {
+		t.Fatalf("Load(printed) error = %v\n%s", err, out.String())
+	}
+	if changes := config.Diff(cfg, printed); len(changes) != 0 {
+		t.Errorf("printed configuration differs: %+v", changes)
+	}
+
+	// The environment uses the flat forms
+	t.Setenv("CATALOGS", "retail=250 500 1000, bulk=1000 5000")
+	t.Setenv("CATALOG", "retail")
+	t.Setenv("PACKAGE_COSTS", "250=1, 500=1.8, 1000=3.5")
+	cfg, err = config.Load(nil)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(cfg.PackageSizes, []int{250, 500, 1000}) || cfg.PackageCosts[500] != 1.8 {
+		t.Errorf("PackageSizes = %v, PackageCosts = %v", cfg.PackageSizes, cfg.PackageCosts)
+	}
+
+	// A named catalog takes precedence over explicit sizes
+	t.Setenv("PACKAGE_SIZES", "250,500")
+	if cfg, err = config.Load(nil); err != nil || len(cfg.PackageSizes) != 3 {
+		t.Fatalf("Load() error = %v, want the retail catalog", err)
+	}
+
+	// Every size in effect needs a price, and a named catalog must exist
+	tests := map[string]struct {
+		env  map[string]string
+		want string
+	}{
+		"missing cost":   {map[string]string{"PACKAGE_COSTS": "250=1, 500=1.8"}, "catalog.costs: no cost for package size 1000"},
+		"unknown name":   {map[string]string{"CATALOG": "wholesale"}, `catalog.name: no catalog named "wholesale" in catalogs`},
+		"bad cost":       {map[string]string{"PACKAGE_COSTS": "250=-1"}, "catalog.costs: cost of package size 250 must be a non-negative number"},
+		"bad stock size": {map[string]string{"PACKAGE_STOCK": "large=3"}, `catalog.stock: "large" is not a package size`},
+		"bad catalog":    {map[string]string{"CATALOGS": "retail=250 0"}, `catalogs: catalog "retail": package size must be positive`},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			if _, err := config.Load(nil); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_FileErrors(t *testing.T) {
+	tests := map[string]string{
+		"config.ini":  "port = 3000",
+		"config.yaml": "server: [unclosed",
+		"config.json": `{"server": `,
+	}
+	for name, content := range tests {
+		if _, err := config.Load([]string{"--config", writeConfig(t, name, content)}); err == nil {
+			t.Errorf("%s: Load() error = nil, want an error", name)
+		}
+	}
+
+	if _, err := config.Load([]string{"--config", filepath.Join(t.TempDir(), "missing.yaml")}); err == nil {
+		t.Error("missing file: Load() error = nil, want an error")
+	}
+	if _, err := config.Load([]string{"serve-everything"}); err == nil {
+		t.Error("stray argument: Load() error = nil, want an error")
+	}
+}
+
+func TestConfig_PrintRedactsSecretsAndRoundTrips(t *testing.T) {
+	t.Setenv("TRACING_OTLP_HEADERS", "authorization=Bearer s3cret")
+	cfg, err := config.Load([]string{"--print-config", "--package-sizes", "23,31,53", "--tracing-sample-ratio", "0.5"})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !cfg.PrintConfig {
+		t.Error("PrintConfig = false with --print-config")
+	}
+
+	var out bytes.Buffer
+	if err := cfg.Print(&out); err != nil {
+		t.Fatal(err)
+	}
+	printed := out.String()
+	if strings.Contains(printed, "s3cret") || !strings.Contains(printed, "authorization: '[REDACTED]'") {
+		t.Errorf("secret not redacted:\n%s", printed)
+	}
+	for _, annotation := range []string{"# flag --package-sizes", "# env TRACING_OTLP_HEADERS", "# default"} {
+		if !strings.Contains(printed, annotation) {
+			t.Errorf("printed configuration missing %q:\n%s", annotation, printed)
+		}
+	}
+
+	// Apart from the redacted secret, the output loads back into the same configuration
+	t.Setenv("TRACING_OTLP_HEADERS", "")
+	reloaded, err := config.Load([]string{"--config", writeConfig(t, "printed.yaml", printed)})
+	if err != nil {
+		t.Fatalf("loading the printed configuration: %v", err)
+	}
+	if !reflect.DeepEqual(reloaded.PackageSizes, cfg.PackageSizes) || reloaded.TracingSampleRatio != 0.5 ||
+		reloaded.CORSMaxAge != cfg.CORSMaxAge || reloaded.Port != cfg.Port {
+		t.Errorf("reloaded configuration differs: %+v", reloaded)
+	}
+}
@@ -0,0 +1,208 @@
+package tests
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sinaw369/Package-Optimizer/internal/api"
+	"github.com/sinaw369/Package-Optimizer/internal/config"
+)
+
+// newCORSServer creates a test server enforcing the given CORS policy.
+func newCORSServer(cfg api.CORSConfig) http.Handler {
+	e := newTestServer()
+	e.Use(api.CORSMiddleware(cfg))
+	return e
+}
+
+// restrictedCORS allows a single origin with credentials.
+func restrictedCORS() api.CORSConfig {
+	return api.CORSConfig{
+		AllowOrigins:     []string{"https://shop.example.com"},
+		AllowMethods:     []string{http.MethodGet, http.MethodPost},
+		AllowHeaders:     []string{"Content-Type", "X-Request-ID"},
+		ExposeHeaders:    []string{"X-Request-ID"},
+		AllowCredentials: true,
+		MaxAge:           5 * time.Minute,
+	}
+}
+
+// preflight builds a CORS preflight request.
+func preflight(origin, method, headers string) *http.Request {
+	req := httptest.NewRequest(http.MethodOptions, "/api/jobs", nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", method)
+	if headers != "" {
+		req.Header.Set("Access-Control-Request-Headers", headers)
+	}
+	return req
+}
+
+func TestCORS_PreflightAllowed(t *testing.T) {
+	server := newCORSServer(restrictedCORS())
+
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, preflight("https://shop.example.com", "POST", "content-type, x-request-id"))
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+
+	want := map[string]string{
+		"Access-Control-Allow-Origin":      "https://shop.example.com",
+		"Access-Control-Allow-Methods":     "GET, POST",
+		"Access-Control-Allow-Headers":     "Content-Type, X-Request-ID",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Max-Age":           "300",
+	}
+	for header, value := range want {
+		if got := rec.Header().Get(header); got != value {
+			t.Errorf("%s = %q, want %q", header, got, value)
+		}
+	}
+	if !hasVary(rec, "Origin") {
+		t.Errorf("Vary = %v, want it to include Origin", rec.Header().Values("Vary"))
+	}
+}
+
+func TestCORS_PreflightRejected(t *testing.T) {
+	server := newCORSServer(restrictedCORS())
+
+	tests := []struct {
+		name    string
+		origin  string
+		method  string
+		headers string
+	}{
+		{"unknown origin", "https://evil.example.com", "POST", ""},
+		{"method not allowed", "https://shop.example.com", "DELETE", ""},
+		{"header not allowed", "https://shop.example.com", "POST", "Authorization"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, preflight(tt.origin, tt.method, tt.headers))
+
+			if rec.Code != http.StatusForbidden {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusForbidden)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Methods"); got != "" {
+				t.Errorf("Access-Control-Allow-Methods = %q, want none", got)
+			}
+		})
+	}
+}
+
+func TestCORS_SimpleRequestAllowed(t *testing.T) {
+	server := newCORSServer(restrictedCORS())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/calculate?qty=1201", nil)
+	req.Header.Set("Origin", "https://shop.example.com")
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://shop.example.com" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want the request origin", got)
+	}
+	if got := rec.Header().Get("Access-Control-Expose-Headers"); got != "X-Request-ID" {
+		t.Errorf("Access-Control-Expose-Headers = %q, want X-Request-ID", got)
+	}
+	if !hasVary(rec, "Origin") {
+		t.Errorf("Vary = %v, want it to include Origin", rec.Header().Values("Vary"))
+	}
+}
+
+func TestCORS_SimpleRequestRejected(t *testing.T) {
+	server := newCORSServer(restrictedCORS())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/calculate?qty=1201", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want none", got)
+	}
+}
+
+func TestCORS_NonCORSRequestsPassThrough(t *testing.T) {
+	server := newCORSServer(restrictedCORS())
+
+	// No Origin header at all (e.g., curl or server-to-server)
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/health", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("request without Origin: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	// Same-origin request from the bundled web UI
+	req := httptest.NewRequest(http.MethodPost, "/api/jobs", nil)
+	req.Host = "optimizer.internal:8080"
+	req.Header.Set("Origin", "http://optimizer.internal:8080")
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	if rec.Code == http.StatusForbidden {
+		t.Errorf("same-origin request was rejected")
+	}
+}
+
+func TestCORS_WildcardOrigin(t *testing.T) {
+	server := newCORSServer(api.DefaultCORSConfig())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+	req.Header.Set("Origin", "https://anywhere.example.com")
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want *", got)
+	}
+	if hasVary(rec, "Origin") {
+		t.Errorf("Vary includes Origin for a wildcard policy without credentials")
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("Access-Control-Allow-Credentials = %q, want none", got)
+	}
+}
+
+func TestCORS_WildcardWithCredentialsRejected(t *testing.T) {
+	// A "*" among explicit origins is still a wildcard
+	_, err := config.Load([]string{
+		"--cors-allow-credentials",
+		"--cors-allowed-origins", "https://shop.example.com,*",
+	})
+	var validationErr *config.ValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Errors) != 1 || validationErr.Errors[0].Field != "cors.allow_credentials" {
+		t.Errorf("Load() error = %v, want a cors.allow_credentials problem", err)
+	}
+
+	// The middleware refuses the combination too, for policies built in code
+	defer func() {
+		if recover() == nil {
+			t.Error("CORSMiddleware() accepted credentials with a wildcard origin")
+		}
+	}()
+	cfg := restrictedCORS()
+	cfg.AllowOrigins = append(cfg.AllowOrigins, "*")
+	api.CORSMiddleware(cfg)
+}
+
+// hasVary reports whether the response's Vary headers include the given value.
+func hasVary(rec *httptest.ResponseRecorder, value string) bool {
+	for _, v := range rec.Header().Values("Vary") {
+		if http.CanonicalHeaderKey(v) == http.CanonicalHeaderKey(value) {
+			return true
+		}
+	}
+	return false
+}
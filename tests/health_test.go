@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sinaw369/Package-Optimizer/internal/api"
+	"github.com/sinaw369/Package-Optimizer/internal/catalog"
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+	"github.com/sinaw369/Package-Optimizer/internal/history"
+	"github.com/sinaw369/Package-Optimizer/internal/jobs"
+
+	"github.com/labstack/echo/v4"
+)
+
+// readiness requests the readiness probe and decodes its report.
+func readiness(t *testing.T, e *echo.Echo) (int, api.HealthReport) {
+	t.Helper()
+	rec := doRequest(e, http.MethodGet, "/api/health/ready", "", "")
+	var report api.HealthReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decode readiness report: %v (%s)", err, rec.Body)
+	}
+	return rec.Code, report
+}
+
+func TestHealth_ReadyWithEveryComponent(t *testing.T) {
+	packageCatalog := newCatalog(t, []int{23, 31, 53})
+	historyPath := filepath.Join(t.TempDir(), "history.jsonl")
+	store, err := history.Open(historyPath, history.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	manager, err := jobs.NewManager(packageCatalog, jobs.Options{Workers: 1, QueueSize: 1, ResultTTL: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := api.NewHandler(packageCatalog, api.Options{History: store, Jobs: manager})
+	e := serveHandler(handler)
+
+	code, report := readiness(t, e)
+	if code != http.StatusOK || report.Status != api.HealthStatusReady {
+		t.Fatalf("status = %d %+v, want %d ready", code, report, http.StatusOK)
+	}
+	for _, check := range []string{"routes", "shutdown", "self_test", "catalog", "history", "jobs"} {
+		if report.Checks[check] != "ok" {
+			t.Errorf("check %s = %q, want ok", check, report.Checks[check])
+		}
+	}
+
+	// A removed history file makes the service unready
+	if err := os.Remove(historyPath); err != nil {
+		t.Fatal(err)
+	}
+	code, report = readiness(t, e)
+	if code != http.StatusServiceUnavailable || report.Checks["history"] == "ok" {
+		t.Errorf("status = %d %+v after removing the history file, want %d with a failing history check",
+			code, report, http.StatusServiceUnavailable)
+	}
+
+	// So does a job manager that has stopped accepting jobs
+	if err := manager.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, report = readiness(t, e); report.Checks["jobs"] == "ok" {
+		t.Errorf("jobs check = ok after the job manager shut down")
+	}
+}
+
+func TestHealth_CatalogCheckProbesTheLiveOptimizer(t *testing.T) {
+	// A catalog whose limits reject its own smallest package can't serve requests;
+	// the check must see the limits of the optimizer in use, not a fresh one
+	solves := 0
+	packageCatalog, err := catalog.New([]int{250, 500}, func(domain.SolveStats) { solves++ }, catalog.Settings{Limits: domain.Limits{MaxQuantity: 100}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := newServer(packageCatalog, api.Options{})
+
+	code, report := readiness(t, e)
+	if code != http.StatusServiceUnavailable || report.Checks["catalog"] == "ok" {
+		t.Errorf("status = %d %+v, want %d with a failing catalog check", code, report, http.StatusServiceUnavailable)
+	}
+
+	// Once the catalog serves the smallest package, the probe passes
+	if _, err := packageCatalog.Update([]int{50, 100}); err != nil {
+		t.Fatal(err)
+	}
+	if code, report := readiness(t, e); code != http.StatusOK {
+		t.Errorf("status = %d %+v after the update, want %d", code, report, http.StatusOK)
+	}
+
+	// Probes are not reported as solves
+	if solves != 0 {
+		t.Errorf("observer saw %d solves from the probes, want 0", solves)
+	}
+}
+
+func TestHealth_UnreadyOnceShutdownStarts(t *testing.T) {
+	handler := api.NewHandler(newCatalog(t, []int{250, 500, 1000, 2000}), api.Options{})
+	e := serveHandler(handler)
+
+	if code, _ := readiness(t, e); code != http.StatusOK {
+		t.Fatalf("status = %d before shutdown, want %d", code, http.StatusOK)
+	}
+
+	handler.StartShutdown()
+
+	code, report := readiness(t, e)
+	if code != http.StatusServiceUnavailable || report.Status != api.HealthStatusUnready {
+		t.Errorf("status = %d %q during shutdown, want %d unready", code, report.Status, http.StatusServiceUnavailable)
+	}
+	if report.Checks["shutdown"] == "ok" {
+		t.Error("shutdown check = ok during shutdown")
+	}
+	if _, ok := report.Checks["history"]; ok {
+		t.Error("history check reported although history is disabled")
+	}
+
+	// The process is still alive while it drains
+	for _, target := range []string{"/api/health/live", "/api/health"} {
+		rec := doRequest(e, http.MethodGet, target, "", "")
+		if rec.Code != http.StatusOK {
+			t.Errorf("GET %s = %d during shutdown, want %d", target, rec.Code, http.StatusOK)
+		}
+	}
+}
+
+func TestHealth_UnreadyBeforeRoutesAreRegistered(t *testing.T) {
+	handler := api.NewHandler(newCatalog(t, []int{250, 500, 1000, 2000}), api.Options{})
+
+	// Serve only the probe, as a server would while still starting up
+	e := echo.New()
+	e.GET("/api/health/ready", handler.ReadyHandler)
+
+	code, report := readiness(t, e)
+	if code != http.StatusServiceUnavailable || report.Checks["routes"] == "ok" {
+		t.Errorf("status = %d %+v before RegisterRoutes, want %d with a failing routes check",
+			code, report, http.StatusServiceUnavailable)
+	}
+}
@@ -0,0 +1,350 @@
+package tests
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sinaw369/Package-Optimizer/internal/api"
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+	"github.com/sinaw369/Package-Optimizer/internal/history"
+
+	"github.com/labstack/echo/v4"
+)
+
+// newHistoryServer creates a test server backed by a history store holding five
+// calculations, one hour apart from 2025-08-05 12:00 UTC: quantities 100, 1201 and
+// 5000 from 10.0.0.1, then 250 and -1 (which failed) from 10.0.0.2.
+func newHistoryServer(t *testing.T) *echo.Echo {
+	t.Helper()
+	store, err := history.Open(filepath.Join(t.TempDir(), "history.jsonl"), history.Options{})
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	optimizer := domain.NewOptimizer([]int{250, 500, 1000, 2000})
+	base := time.Date(2025, 8, 5, 12, 0, 0, 0, time.UTC)
+	calls := []struct {
+		client   string
+		quantity int
+	}{{"10.0.0.1", 100}, {"10.0.0.1", 1201}, {"10.0.0.1", 5000}, {"10.0.0.2", 250}, {"10.0.0.2", -1}}
+	for i, call := range calls {
+		rec := history.Record{
+			Timestamp:      base.Add(time.Duration(i) * time.Hour),
+			Client:         call.client,
+			CatalogVersion: optimizer.CatalogVersion(),
+			Request:        domain.OptimizationRequest{Quantity: call.quantity},
+		}
+		if result, err := optimizer.Optimize(call.quantity); err != nil {
+			rec.Error = err.Error()
+		} else {
+			rec.Result = result
+		}
+		if _, err := store.Append(rec); err != nil {
+			t.Fatalf("Append() error: %v", err)
+		}
+	}
+
+	return newServer(newCatalog(t, []int{250, 500, 1000, 2000}), api.Options{History: store})
+}
+
+// historyPage is the JSON response of GET /api/history.
+type historyPage struct {
+	Total   int              `json:"total"`
+	Offset  int              `json:"offset"`
+	Limit   int              `json:"limit"`
+	Records []history.Record `json:"records"`
+}
+
+// quantities returns the requested quantity of every record, in order.
+func (p historyPage) quantities() []int {
+	quantities := make([]int, len(p.Records))
+	for i, rec := range p.Records {
+		quantities[i] = rec.Request.Quantity
+	}
+	return quantities
+}
+
+func TestHistoryStore_AppendAndQuery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	store, err := history.Open(path, history.Options{})
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+
+	optimizer := domain.NewOptimizer([]int{250, 500, 1000, 2000})
+	base := time.Date(2025, 8, 5, 12, 0, 0, 0, time.UTC)
+	for i, qty := range []int{100, 1201, 5000} {
+		result, _ := optimizer.Optimize(qty)
+		_, err := store.Append(history.Record{
+			Timestamp:      base.Add(time.Duration(i) * time.Hour),
+			Client:         "10.0.0.1",
+			CatalogVersion: optimizer.CatalogVersion(),
+			Request:        domain.OptimizationRequest{Quantity: qty},
+			Result:         result,
+		})
+		if err != nil {
+			t.Fatalf("Append() error: %v", err)
+		}
+	}
+
+	t.Run("Newest first", func(t *testing.T) {
+		records, total, err := store.Query(history.Filter{})
+		if err != nil {
+			t.Fatalf("Query() error: %v", err)
+		}
+		if total != 3 || len(records) != 3 {
+			t.Fatalf("total = %d, len = %d, want 3, 3", total, len(records))
+		}
+		if records[0].Request.Quantity != 5000 || records[0].ID != 3 {
+			t.Errorf("first record = %+v, want quantity 5000 with ID 3", records[0])
+		}
+	})
+
+	t.Run("Filter and page", func(t *testing.T) {
+		minQty := 1000
+		records, total, err := store.Query(history.Filter{MinQuantity: &minQty, Limit: 1, Offset: 1})
+		if err != nil {
+			t.Fatalf("Query() error: %v", err)
+		}
+		if total != 2 {
+			t.Errorf("total = %d, want 2", total)
+		}
+		if len(records) != 1 || records[0].Request.Quantity != 1201 {
+			t.Errorf("records = %+v, want only quantity 1201", records)
+		}
+	})
+
+	t.Run("Time range", func(t *testing.T) {
+		records, _, err := store.Query(history.Filter{From: base.Add(30 * time.Minute), To: base.Add(90 * time.Minute)})
+		if err != nil {
+			t.Fatalf("Query() error: %v", err)
+		}
+		if len(records) != 1 || records[0].Result.TotalDelivered != 1250 {
+			t.Errorf("records = %+v, want only the 1201 calculation", records)
+		}
+	})
+
+	t.Run("Reopen continues IDs", func(t *testing.T) {
+		store.Close()
+		reopened, err := history.Open(path, history.Options{})
+		if err != nil {
+			t.Fatalf("Open() error: %v", err)
+		}
+		defer reopened.Close()
+
+		rec, err := reopened.Append(history.Record{Request: domain.OptimizationRequest{Quantity: 1}})
+		if err != nil {
+			t.Fatalf("Append() error: %v", err)
+		}
+		if rec.ID != 4 {
+			t.Errorf("ID = %d, want 4", rec.ID)
+		}
+	})
+}
+
+func TestHistoryStore_RotatesAndKeepsMaxFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	// Every record is about 150 bytes, so each file holds two records
+	store, err := history.Open(path, history.Options{MaxFileSize: 350, MaxFiles: 2})
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	for qty := 1; qty <= 9; qty++ {
+		if _, err := store.Append(history.Record{Client: "10.0.0.1", Request: domain.OptimizationRequest{Quantity: qty}}); err != nil {
+			t.Fatalf("Append() error: %v", err)
+		}
+	}
+
+	// The active file and two rotated files remain; the oldest were deleted
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		if info, err := os.Stat(name); err != nil || info.Size() > 350 {
+			t.Errorf("%s: %v, want a file of at most 350 bytes", name, err)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("%s.3 exists, want only two rotated files", path)
+	}
+
+	// Queries read every kept file, newest first
+	records, total, err := store.Query(history.Filter{})
+	if err != nil {
+		t.Fatalf("Query() error: %v", err)
+	}
+	if total != 5 || records[0].Request.Quantity != 9 || records[4].Request.Quantity != 5 {
+		t.Errorf("total = %d, records = %+v; want quantities 9 down to 5", total, records)
+	}
+
+	// IDs continue from the newest file after a restart
+	store.Close()
+	reopened, err := history.Open(path, history.Options{MaxFileSize: 350, MaxFiles: 2})
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer reopened.Close()
+	rec, err := reopened.Append(history.Record{Request: domain.OptimizationRequest{Quantity: 10}})
+	if err != nil || rec.ID != 10 {
+		t.Errorf("Append() = ID %d, %v; want ID 10", rec.ID, err)
+	}
+}
+
+func TestHistoryStore_RotationWithoutRotatedFilesDropsTheFullFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	store, err := history.Open(path, history.Options{MaxFileSize: 200, MaxFiles: 0})
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer store.Close()
+
+	// Every append after the first rotates, and writing must keep working
+	for qty := 1; qty <= 5; qty++ {
+		if _, err := store.Append(history.Record{Client: "10.0.0.1", Request: domain.OptimizationRequest{Quantity: qty}}); err != nil {
+			t.Fatalf("Append(%d) error: %v", qty, err)
+		}
+	}
+
+	if _, err := os.Stat(path + ".1"); !os.IsNotExist(err) {
+		t.Errorf("%s.1 exists, want no rotated files", path)
+	}
+	records, total, err := store.Query(history.Filter{})
+	if err != nil {
+		t.Fatalf("Query() error: %v", err)
+	}
+	if total != 1 || records[0].Request.Quantity != 5 {
+		t.Errorf("total = %d, records = %+v; want only the newest record", total, records)
+	}
+}
+
+func TestHistoryHandler_FiltersAndPages(t *testing.T) {
+	e := newHistoryServer(t)
+
+	tests := []struct {
+		name       string
+		query      string
+		total      int
+		quantities []int
+	}{
+		{"Newest first", "", 5, []int{-1, 250, 5000, 1201, 100}},
+		{"Client", "client=10.0.0.2", 2, []int{-1, 250}},
+		{"Time range", "from=2025-08-05T13:00:00Z&to=2025-08-05T15:00:00Z", 2, []int{5000, 1201}},
+		{"Date range", "from=2025-08-05&to=2025-08-06", 5, []int{-1, 250, 5000, 1201, 100}},
+		{"Quantity range", "min_qty=250&max_qty=1201", 2, []int{250, 1201}},
+		{"Limit and offset", "limit=2&offset=1", 5, []int{250, 5000}},
+		{"Offset past the end", "offset=10", 5, []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(e, http.MethodGet, "/api/history?"+tt.query, "", "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+			}
+			var page historyPage
+			if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if page.Total != tt.total {
+				t.Errorf("total = %d, want %d", page.Total, tt.total)
+			}
+			if got := page.quantities(); !reflect.DeepEqual(got, tt.quantities) {
+				t.Errorf("quantities = %v, want %v", got, tt.quantities)
+			}
+		})
+	}
+
+	// JSON pages default to 50 records and are capped at 1000
+	rec := doRequest(e, http.MethodGet, "/api/history?limit=5000", "", "")
+	var page historyPage
+	json.Unmarshal(rec.Body.Bytes(), &page)
+	if page.Limit != 1000 {
+		t.Errorf("limit = %d, want the 1000 cap", page.Limit)
+	}
+}
+
+func TestHistoryHandler_RejectsInvalidParameters(t *testing.T) {
+	e := newHistoryServer(t)
+
+	tests := map[string]string{
+		"from=yesterday": "invalid 'from' parameter",
+		"to=2025-13-01":  "invalid 'to' parameter",
+		"min_qty=lots":   "invalid 'min_qty' parameter",
+		"max_qty=1.5":    "invalid 'max_qty' parameter",
+		"limit=0":        "invalid 'limit' parameter",
+		"limit=ten":      "invalid 'limit' parameter",
+		"offset=-1":      "invalid 'offset' parameter",
+		"format=xml":     "invalid 'format' parameter",
+	}
+	for query, want := range tests {
+		rec := doRequest(e, http.MethodGet, "/api/history?"+query, "", "")
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("GET /api/history?%s: %d %s, want 400 with %q", query, rec.Code, rec.Body.String(), want)
+		}
+	}
+}
+
+func TestHistoryHandler_Exports(t *testing.T) {
+	e := newHistoryServer(t)
+
+	t.Run("CSV", func(t *testing.T) {
+		rec := doRequest(e, http.MethodGet, "/api/history?format=csv&client=10.0.0.2", "", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+		}
+		if got := rec.Header().Get(echo.HeaderContentType); got != "text/csv; charset=utf-8" {
+			t.Errorf("Content-Type = %q", got)
+		}
+		if got := rec.Header().Get(echo.HeaderContentDisposition); got != `attachment; filename="history.csv"` {
+			t.Errorf("Content-Disposition = %q", got)
+		}
+
+		rows, err := csv.NewReader(rec.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("invalid CSV: %v", err)
+		}
+		want := [][]string{
+			{"id", "timestamp", "client", "catalog_version", "requested", "total_delivered", "over_delivery", "packages", "error", "not_modified"},
+			{"5", "2025-08-05T16:00:00Z", "10.0.0.2", "c2f56da27d65", "-1", "", "", "", "quantity must be non-negative, got -1", "false"},
+			{"4", "2025-08-05T15:00:00Z", "10.0.0.2", "c2f56da27d65", "250", "250", "0", "250:1", "", "false"},
+		}
+		if len(rows) != len(want) {
+			t.Fatalf("rows = %q, want %q", rows, want)
+		}
+		for i := range want {
+			if strings.Join(rows[i], ",") != strings.Join(want[i], ",") {
+				t.Errorf("row %d = %q, want %q", i, rows[i], want[i])
+			}
+		}
+	})
+
+	t.Run("JSONL without a limit", func(t *testing.T) {
+		rec := doRequest(e, http.MethodGet, "/api/history?format=jsonl", "", "")
+		if got := rec.Header().Get(echo.HeaderContentType); got != "application/x-ndjson" {
+			t.Errorf("Content-Type = %q", got)
+		}
+		lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+		if len(lines) != 5 {
+			t.Fatalf("lines = %d, want every record", len(lines))
+		}
+		var first history.Record
+		if err := json.Unmarshal([]byte(lines[0]), &first); err != nil || first.ID != 5 {
+			t.Errorf("first line = %s, want record 5", lines[0])
+		}
+	})
+
+	t.Run("Limit and empty export", func(t *testing.T) {
+		rec := doRequest(e, http.MethodGet, "/api/history?format=jsonl&limit=2&offset=1", "", "")
+		if lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n"); len(lines) != 2 {
+			t.Errorf("lines = %d, want 2", len(lines))
+		}
+		rec = doRequest(e, http.MethodGet, "/api/history?format=csv&client=nobody", "", "")
+		if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != "id,timestamp,client,catalog_version,requested,total_delivered,over_delivery,packages,error,not_modified" {
+			t.Errorf("empty export = %d %q, want only the header row", rec.Code, rec.Body.String())
+		}
+	})
+}
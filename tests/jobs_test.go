@@ -0,0 +1,163 @@
+package tests
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sinaw369/Package-Optimizer/internal/jobs"
+	"github.com/sinaw369/Package-Optimizer/internal/limits"
+)
+
+// longQuantity keeps a worker busy long enough for the tests to observe it,
+// with package sizes {1, 2}, without allocating an excessive DP table.
+const longQuantity = 3_000_000
+
+// waitForJob polls the manager until the job reaches a terminal state.
+func waitForJob(t *testing.T, m *jobs.Manager, id string) jobs.Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := m.Get(id)
+		if err != nil {
+			t.Fatalf("Get() error: %v", err)
+		}
+		if job.Status != jobs.StatusQueued && job.Status != jobs.StatusRunning {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish in time", id)
+	return jobs.Job{}
+}
+
+func TestJobManager_RunsJobs(t *testing.T) {
+	m, err := jobs.NewManager(newCatalog(t, []int{250, 500, 1000, 2000}), jobs.Options{Workers: 2, QueueSize: 4, ResultTTL: time.Minute})
+	if err != nil {
+		t.Fatalf("NewManager() error: %v", err)
+	}
+	defer m.Shutdown(context.Background())
+
+	job, err := m.Submit(jobs.Request{Quantities: []int{1201, 5000}})
+	if err != nil {
+		t.Fatalf("Submit() error: %v", err)
+	}
+
+	job = waitForJob(t, m, job.ID)
+	if job.Status != jobs.StatusSucceeded {
+		t.Fatalf("Status = %v, want %v (error: %s)", job.Status, jobs.StatusSucceeded, job.Error)
+	}
+	if len(job.Results) != 2 || job.Results[0].TotalDelivered != 1250 || job.Results[1].TotalDelivered != 5000 {
+		t.Errorf("Results = %+v, want deliveries 1250 and 5000", job.Results)
+	}
+	if job.Progress.Completed != 2 || job.ExpiresAt == nil {
+		t.Errorf("Progress = %+v, ExpiresAt = %v, want 2 completed and an expiry", job.Progress, job.ExpiresAt)
+	}
+}
+
+func TestJobManager_Cancel(t *testing.T) {
+	m, err := jobs.NewManager(newCatalog(t, []int{1, 2}), jobs.Options{Workers: 1, QueueSize: 4, ResultTTL: time.Minute})
+	if err != nil {
+		t.Fatalf("NewManager() error: %v", err)
+	}
+	defer m.Shutdown(context.Background())
+
+	// A long job occupies the only worker so the second one stays queued
+	long, err := m.Submit(jobs.Request{Quantities: []int{longQuantity}})
+	if err != nil {
+		t.Fatalf("Submit() error: %v", err)
+	}
+	queued, err := m.Submit(jobs.Request{Quantities: []int{10}})
+	if err != nil {
+		t.Fatalf("Submit() error: %v", err)
+	}
+
+	for _, id := range []string{queued.ID, long.ID} {
+		if _, err := m.Cancel(id); err != nil {
+			t.Fatalf("Cancel() error: %v", err)
+		}
+		if job := waitForJob(t, m, id); job.Status != jobs.StatusCanceled {
+			t.Errorf("job %s Status = %v, want %v", id, job.Status, jobs.StatusCanceled)
+		}
+	}
+
+	if _, err := m.Get("missing"); err != jobs.ErrNotFound {
+		t.Errorf("Get(missing) error = %v, want %v", err, jobs.ErrNotFound)
+	}
+}
+
+func TestJobManager_PersistsQueuedJobsOnShutdown(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "jobs.json")
+	packageCatalog := newCatalog(t, []int{1, 2})
+	opts := jobs.Options{Workers: 1, QueueSize: 4, ResultTTL: time.Minute, StatePath: statePath}
+
+	m, err := jobs.NewManager(packageCatalog, opts)
+	if err != nil {
+		t.Fatalf("NewManager() error: %v", err)
+	}
+	if _, err := m.Submit(jobs.Request{Quantities: []int{longQuantity}}); err != nil {
+		t.Fatalf("Submit() error: %v", err)
+	}
+	queued, err := m.Submit(jobs.Request{Quantities: []int{10}})
+	if err != nil {
+		t.Fatalf("Submit() error: %v", err)
+	}
+
+	// An already-expired context forces persistence instead of draining
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := m.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error: %v", err)
+	}
+	if _, err := m.Submit(jobs.Request{Quantities: []int{1}}); err != jobs.ErrShuttingDown {
+		t.Errorf("Submit() after shutdown error = %v, want %v", err, jobs.ErrShuttingDown)
+	}
+
+	// The next manager resumes the persisted jobs; a second worker keeps the
+	// restored long job from delaying the short one
+	opts.Workers = 2
+	restored, err := jobs.NewManager(packageCatalog, opts)
+	if err != nil {
+		t.Fatalf("NewManager() error: %v", err)
+	}
+	defer restored.Shutdown(ctx)
+
+	if job := waitForJob(t, restored, queued.ID); job.Status != jobs.StatusSucceeded {
+		t.Errorf("restored job Status = %v, want %v", job.Status, jobs.StatusSucceeded)
+	}
+}
+
+func TestJobManager_WaitsForSolverMemory(t *testing.T) {
+	limiter := limits.New(limits.Config{SolveMemory: 1 << 20, SolveWait: 10 * time.Millisecond})
+	m, err := jobs.NewManager(newCatalog(t, []int{250, 500, 1000, 2000}),
+		jobs.Options{Workers: 1, QueueSize: 4, ResultTTL: time.Minute, Limiter: limiter})
+	if err != nil {
+		t.Fatalf("NewManager() error: %v", err)
+	}
+	defer m.Shutdown(context.Background())
+
+	// Occupy the whole budget, as a huge synchronous solve would
+	release, err := limiter.AcquireSolve(context.Background(), 1<<20)
+	if err != nil {
+		t.Fatalf("AcquireSolve() error: %v", err)
+	}
+	job, err := m.Submit(jobs.Request{Quantities: []int{1201}})
+	if err != nil {
+		t.Fatalf("Submit() error: %v", err)
+	}
+
+	// The job waits well past SolveWait instead of failing
+	time.Sleep(50 * time.Millisecond)
+	if job, _ = m.Get(job.ID); job.Status != jobs.StatusRunning || job.Progress.Completed != 0 {
+		t.Fatalf("job while the solver is busy = %+v, want it running and waiting", job)
+	}
+
+	release()
+	if job = waitForJob(t, m, job.ID); job.Status != jobs.StatusSucceeded {
+		t.Errorf("Status = %v, want %v (error: %s)", job.Status, jobs.StatusSucceeded, job.Error)
+	}
+	if got := limiter.SolveMemoryInUse(); got != 0 {
+		t.Errorf("SolveMemoryInUse() = %d, want the job's reservation released", got)
+	}
+}
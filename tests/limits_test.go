@@ -0,0 +1,318 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sinaw369/Package-Optimizer/internal/api"
+	"github.com/sinaw369/Package-Optimizer/internal/catalog"
+	"github.com/sinaw369/Package-Optimizer/internal/config"
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+	"github.com/sinaw369/Package-Optimizer/internal/limits"
+
+	"github.com/labstack/echo/v4"
+)
+
+// newLimitedServer creates a test server with the given limits and no authentication.
+func newLimitedServer(t *testing.T, limiter *limits.Limiter) *echo.Echo {
+	t.Helper()
+	return newServer(newCatalog(t, []int{250, 500, 1000, 2000}), api.Options{Limiter: limiter})
+}
+
+func TestLimiter_TokenBucket(t *testing.T) {
+	limiter := limits.New(limits.Config{Rate: 1, Burst: 2})
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := limiter.Allow("ip:192.0.2.1"); !ok {
+			t.Fatalf("request %d within the burst was rejected", i+1)
+		}
+	}
+	ok, wait := limiter.Allow("ip:192.0.2.1")
+	if ok || wait <= 0 || wait > time.Second {
+		t.Errorf("Allow() over the burst = %v, %v; want rejection with a wait of at most 1s", ok, wait)
+	}
+
+	// Clients have separate buckets
+	if ok, _ := limiter.Allow("ip:192.0.2.2"); !ok {
+		t.Error("another client was rejected")
+	}
+}
+
+func TestLimiter_TokensRefill(t *testing.T) {
+	limiter := limits.New(limits.Config{Rate: 50, Burst: 1})
+
+	if ok, _ := limiter.Allow("key:acme-prod"); !ok {
+		t.Fatal("first request rejected")
+	}
+	if ok, _ := limiter.Allow("key:acme-prod"); ok {
+		t.Fatal("second request allowed before a token was refilled")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if ok, _ := limiter.Allow("key:acme-prod"); !ok {
+		t.Error("request rejected after the bucket refilled")
+	}
+}
+
+func TestLimiter_SolveMemoryIsCapped(t *testing.T) {
+	limiter := limits.New(limits.Config{SolveMemory: 100, SolveWait: 20 * time.Millisecond})
+	ctx := context.Background()
+
+	release, err := limiter.AcquireSolve(ctx, 60)
+	if err != nil {
+		t.Fatalf("AcquireSolve(60) error: %v", err)
+	}
+
+	// A second solve that doesn't fit waits, then gives up
+	if _, err := limiter.AcquireSolve(ctx, 60); !errors.Is(err, limits.ErrBusy) {
+		t.Fatalf("AcquireSolve(60) while busy: err = %v, want ErrBusy", err)
+	}
+
+	// A small solve still fits next to the running one
+	releaseSmall, err := limiter.AcquireSolve(ctx, 40)
+	if err != nil {
+		t.Fatalf("AcquireSolve(40) error: %v", err)
+	}
+	if got := limiter.SolveMemoryInUse(); got != 100 {
+		t.Errorf("SolveMemoryInUse() = %d, want 100", got)
+	}
+
+	release()
+	releaseSmall()
+	release() // releasing twice is harmless
+	if got := limiter.SolveMemoryInUse(); got != 0 {
+		t.Errorf("SolveMemoryInUse() after release = %d, want 0", got)
+	}
+
+	// Solves larger than the whole budget run alone instead of never running
+	releaseHuge, err := limiter.AcquireSolve(ctx, 1<<40)
+	if err != nil {
+		t.Fatalf("AcquireSolve(huge) error: %v", err)
+	}
+	releaseHuge()
+}
+
+func TestLimiter_WaitingSolveRunsWhenMemoryFrees(t *testing.T) {
+	limiter := limits.New(limits.Config{SolveMemory: 100, SolveWait: time.Second})
+	ctx := context.Background()
+
+	release, err := limiter.AcquireSolve(ctx, 100)
+	if err != nil {
+		t.Fatalf("AcquireSolve() error: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			releaseWaiting, err := limiter.AcquireSolve(ctx, 30)
+			if err == nil {
+				defer releaseWaiting()
+			}
+			errs <- err
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	release()
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("waiting solve failed: %v", err)
+		}
+	}
+}
+
+func TestLimiter_WaitStopsWhenContextIsCanceled(t *testing.T) {
+	limiter := limits.New(limits.Config{SolveMemory: 100, SolveWait: time.Minute})
+
+	release, err := limiter.AcquireSolve(context.Background(), 100)
+	if err != nil {
+		t.Fatalf("AcquireSolve() error: %v", err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.AcquireSolve(ctx, 10); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("AcquireSolve() err = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestOptimizer_EstimateMemory(t *testing.T) {
+	optimizer := domain.NewOptimizer([]int{250, 500, 1000, 2000})
+
+	if got := optimizer.EstimateMemory(0); got != 0 {
+		t.Errorf("EstimateMemory(0) = %d, want 0", got)
+	}
+	small, large := optimizer.EstimateMemory(1000), optimizer.EstimateMemory(1000000)
+	if small <= 0 || large <= small*100 {
+		t.Errorf("EstimateMemory(1000) = %d, EstimateMemory(1000000) = %d; want growth proportional to quantity", small, large)
+	}
+}
+
+func TestLimits_RateLimitedRequestsGet429(t *testing.T) {
+	e := newLimitedServer(t, limits.New(limits.Config{Rate: 0.01, Burst: 2}))
+
+	for i := 0; i < 2; i++ {
+		if rec := doRequest(e, http.MethodGet, "/api/calculate?qty=1201", "", ""); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d, want %d", i+1, rec.Code, http.StatusOK)
+		}
+	}
+
+	rec := doRequest(e, http.MethodGet, "/api/calculate?qty=1201", "", "")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("missing Retry-After header")
+	}
+
+	// Routes that don't start solves are not limited
+	if rec := doRequest(e, http.MethodGet, "/api/package-sizes", "", ""); rec.Code != http.StatusOK {
+		t.Errorf("package sizes: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestLimits_ForwardingHeadersDontResetTheBucket(t *testing.T) {
+	// requestFrom sends a calculation from the test recorder's address (192.0.2.1)
+	// with the given forwarding headers
+	requestFrom := func(e *echo.Echo, forwardedFor, realIP string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/calculate?qty=1201", nil)
+		if forwardedFor != "" {
+			req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+		}
+		if realIP != "" {
+			req.Header.Set(echo.HeaderXRealIP, realIP)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Without trusted proxies the headers are ignored: every request is from 192.0.2.1
+	e := newLimitedServer(t, limits.New(limits.Config{Rate: 0.01, Burst: 1}))
+	e.IPExtractor = api.IPExtractor(nil)
+	if code := requestFrom(e, "", ""); code != http.StatusOK {
+		t.Fatalf("first request: status = %d, want %d", code, http.StatusOK)
+	}
+	if code := requestFrom(e, "203.0.113.7", "203.0.113.8"); code != http.StatusTooManyRequests {
+		t.Errorf("spoofed headers: status = %d, want %d", code, http.StatusTooManyRequests)
+	}
+
+	// Behind a trusted proxy, clients are told apart by the address the proxy appended
+	proxies, err := config.Load([]string{"--trusted-proxies", "192.0.2.0/24"})
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	e = newLimitedServer(t, limits.New(limits.Config{Rate: 0.01, Burst: 1}))
+	e.IPExtractor = api.IPExtractor(proxies.TrustedProxies)
+	if code := requestFrom(e, "198.51.100.1, 203.0.113.7", ""); code != http.StatusOK {
+		t.Fatalf("first client: status = %d, want %d", code, http.StatusOK)
+	}
+	if code := requestFrom(e, "203.0.113.8", ""); code != http.StatusOK {
+		t.Errorf("second client: status = %d, want %d", code, http.StatusOK)
+	}
+	// An entry the client wrote itself, ahead of the one the proxy added, changes nothing
+	if code := requestFrom(e, "198.51.100.2, 203.0.113.7", "198.51.100.3"); code != http.StatusTooManyRequests {
+		t.Errorf("spoofed entry: status = %d, want %d", code, http.StatusTooManyRequests)
+	}
+}
+
+func TestLimits_BusySolverGets429(t *testing.T) {
+	limiter := limits.New(limits.Config{SolveMemory: 1 << 20, SolveWait: 10 * time.Millisecond})
+	e := newLimitedServer(t, limiter)
+
+	// Occupy the whole budget, as a huge running solve would
+	release, err := limiter.AcquireSolve(context.Background(), 1<<20)
+	if err != nil {
+		t.Fatalf("AcquireSolve() error: %v", err)
+	}
+
+	for _, target := range []string{"/api/calculate?qty=1201", "/api/calculate/stream?qty=1201"} {
+		rec := doRequest(e, http.MethodGet, target, "", "")
+		if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "1" {
+			t.Errorf("GET %s: status = %d, Retry-After = %q; want 429 with Retry-After 1",
+				target, rec.Code, rec.Header().Get("Retry-After"))
+		}
+	}
+
+	release()
+	if rec := doRequest(e, http.MethodGet, "/api/calculate?qty=1201", "", ""); rec.Code != http.StatusOK {
+		t.Errorf("status after release = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestLimits_OversizedQuantitiesAreRejected(t *testing.T) {
+	optimizer := domain.NewOptimizer([]int{250, 500, 1000, 2000})
+	packageCatalog, err := catalog.New([]int{250, 500, 1000, 2000}, nil, catalog.Settings{Limits: domain.Limits{
+		MaxQuantity: 1_000_000,
+		MaxMemory:   optimizer.EstimateMemory(100_000),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := newServer(packageCatalog, api.Options{})
+
+	tests := []struct {
+		target string
+		want   int
+	}{
+		{"/api/calculate?qty=100000", http.StatusOK},
+		{"/api/calculate?qty=500000", http.StatusRequestEntityTooLarge},
+		{"/api/calculate?qty=2000000", http.StatusUnprocessableEntity},
+		{"/api/calculate?qty=9223372036854775807", http.StatusUnprocessableEntity},
+		{"/api/calculate/stream?qty=500000", http.StatusRequestEntityTooLarge},
+		{"/calculate?qty=2000000", http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		rec := doRequest(e, http.MethodGet, tt.target, "", "")
+		if rec.Code != tt.want {
+			t.Errorf("GET %s: status = %d, want %d (%s)", tt.target, rec.Code, tt.want, rec.Body)
+		}
+	}
+
+	// Jobs are checked on submission instead of failing later
+	rec := doRequest(e, http.MethodPost, "/api/jobs", "", `{"quantities":[1201,2000000]}`)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("POST /api/jobs: status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestLimiter_ChargeCanGoIntoDebt(t *testing.T) {
+	limiter := limits.New(limits.Config{Rate: 1, Burst: 2})
+
+	// A request admitted with one token is charged for three more, one over the burst
+	if ok, _ := limiter.Allow("ip:192.0.2.1"); !ok {
+		t.Fatal("first request was rejected")
+	}
+	limiter.Charge("ip:192.0.2.1", 3)
+
+	// The client now waits for the debt to be repaid, not just for the next token
+	ok, wait := limiter.Allow("ip:192.0.2.1")
+	if ok || wait <= 2*time.Second {
+		t.Errorf("Allow() after the charge = %v, %v; want rejection with a wait over 2s", ok, wait)
+	}
+}
+
+func TestLimits_BatchesTakeATokenPerQuantity(t *testing.T) {
+	e := newLimitedServer(t, limits.New(limits.Config{Rate: 0.01, Burst: 3}))
+
+	if rec := doRequest(e, http.MethodPost, "/api/calculate/batch", "", `{"quantities":[1201,5000,12001]}`); rec.Code != http.StatusOK {
+		t.Fatalf("batch within the burst: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	// The three quantities used up the burst
+	rec := doRequest(e, http.MethodGet, "/api/calculate?qty=1201", "", "")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("status = %d, Retry-After = %q; want 429 with Retry-After", rec.Code, rec.Header().Get("Retry-After"))
+	}
+}
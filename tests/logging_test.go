@@ -0,0 +1,274 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sinaw369/Package-Optimizer/internal/api"
+	"github.com/sinaw369/Package-Optimizer/internal/cache"
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+	"github.com/sinaw369/Package-Optimizer/internal/logging"
+
+	"github.com/labstack/echo/v4"
+)
+
+// captureLogs installs a JSON logger writing to a buffer as the default logger
+// for the duration of the test.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "json", "debug")
+	if err != nil {
+		t.Fatalf("logging.New: %v", err)
+	}
+	previous := slog.Default()
+	slog.SetDefault(logger)
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+// newLoggedServer creates a test server with the request ID and logging middleware.
+func newLoggedServer() *echo.Echo {
+	e := newTestServer()
+	e.Use(api.LoggingMiddleware())
+	return e
+}
+
+// logLines decodes every JSON log record in the buffer.
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("log line is not JSON: %q", line)
+		}
+		lines = append(lines, record)
+	}
+	return lines
+}
+
+func TestLogging_New(t *testing.T) {
+	tests := []struct {
+		format  string
+		level   string
+		wantErr bool
+	}{
+		{"json", "info", false},
+		{"text", "debug", false},
+		{"TEXT", "WARN", false},
+		{"", "", false},
+		{"xml", "info", true},
+		{"json", "verbose", true},
+	}
+
+	for _, tt := range tests {
+		_, err := logging.New(&bytes.Buffer{}, tt.format, tt.level)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("New(%q, %q) error = %v, wantErr %v", tt.format, tt.level, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLogging_LevelFiltersRecords(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "text", "warn")
+	if err != nil {
+		t.Fatalf("logging.New: %v", err)
+	}
+
+	logger.Info("hidden")
+	logger.Warn("shown")
+
+	if strings.Contains(buf.String(), "hidden") || !strings.Contains(buf.String(), "shown") {
+		t.Errorf("unexpected output for level warn: %q", buf.String())
+	}
+}
+
+func TestLogging_RequestIDFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "json", "info")
+	if err != nil {
+		t.Fatalf("logging.New: %v", err)
+	}
+
+	ctx := logging.WithRequestID(context.Background(), "abc123")
+	logger.With("component", "test").InfoContext(ctx, "hello")
+
+	record := logLines(t, &buf)[0]
+	if record["request_id"] != "abc123" || record["component"] != "test" {
+		t.Errorf("record = %v, want request_id and component fields", record)
+	}
+}
+
+func TestRequestID_GeneratedWhenMissing(t *testing.T) {
+	e := newTestServer()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/health", nil))
+
+	if id := rec.Header().Get(api.HeaderRequestID); len(id) != 16 {
+		t.Errorf("generated request ID = %q, want 16 hex characters", id)
+	}
+}
+
+func TestRequestID_ReusesClientHeader(t *testing.T) {
+	e := newTestServer()
+
+	tests := []struct {
+		name   string
+		header string
+		reused bool
+	}{
+		{"valid", "checkout-42", true},
+		{"with spaces", "not valid", false},
+		{"too long", strings.Repeat("a", 129), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+			req.Header.Set(api.HeaderRequestID, tt.header)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			got := rec.Header().Get(api.HeaderRequestID)
+			if (got == tt.header) != tt.reused {
+				t.Errorf("request ID = %q, reused = %v, want reused = %v", got, got == tt.header, tt.reused)
+			}
+		})
+	}
+}
+
+func TestRequestID_InErrorResponse(t *testing.T) {
+	e := newTestServer()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/calculate?qty=abc", nil)
+	req.Header.Set(api.HeaderRequestID, "req-1")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	var body domain.ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode error response: %v", err)
+	}
+	if body.RequestID != "req-1" || body.Error == "" {
+		t.Errorf("error response = %+v, want error message and request_id req-1", body)
+	}
+}
+
+func TestLoggingMiddleware_LogsRequestFields(t *testing.T) {
+	buf := captureLogs(t)
+	e := newLoggedServer()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/calculate?qty=1201", nil)
+	req.Header.Set(api.HeaderRequestID, "req-2")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := logLines(t, buf)
+	if len(lines) != 1 {
+		t.Fatalf("got %d log lines, want 1", len(lines))
+	}
+
+	record := lines[0]
+	want := map[string]interface{}{
+		"msg":             "request",
+		"level":           "INFO",
+		"route":           "/api/calculate",
+		"status":          float64(200),
+		"request_id":      "req-2",
+		"quantity":        float64(1201),
+		"total_delivered": float64(1250),
+		"over_delivery":   float64(49),
+		"packages":        float64(2),
+	}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("%s = %v, want %v", key, record[key], value)
+		}
+	}
+	if _, ok := record["latency_ms"].(float64); !ok {
+		t.Errorf("latency_ms missing from %v", record)
+	}
+}
+
+func TestLoggingMiddleware_ClientErrorsLogAtWarn(t *testing.T) {
+	buf := captureLogs(t)
+	e := newLoggedServer()
+
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/calculate?qty=-1", nil))
+
+	record := logLines(t, buf)[0]
+	if record["level"] != "WARN" || record["status"] != float64(400) || record["error"] == nil {
+		t.Errorf("record = %v, want a WARN line with status 400 and the error", record)
+	}
+	if record["request_id"] == nil {
+		t.Errorf("record = %v, want a request_id", record)
+	}
+}
+
+func TestLoggingMiddleware_BatchLogsCacheTotalsOnce(t *testing.T) {
+	buf := captureLogs(t)
+	e := newServer(newCatalog(t, []int{250, 500, 1000, 2000}), api.Options{Cache: cache.New(10)}, api.LoggingMiddleware())
+
+	// 1201 is solved once and then found in the cache; -1 never reaches the cache
+	doRequest(e, http.MethodPost, "/api/calculate/batch", "", `{"quantities":[1201,5000,1201,-1]}`)
+
+	record := logLines(t, buf)[0]
+	want := map[string]interface{}{
+		"quantities":   float64(4),
+		"failed":       float64(1),
+		"cache_hits":   float64(1),
+		"cache_misses": float64(2),
+		"coalesced":    float64(0),
+	}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("%s = %v, want %v", key, record[key], value)
+		}
+	}
+	if _, ok := record["cache"]; ok {
+		t.Errorf("record = %v, want no per-item cache field", record)
+	}
+}
+
+func TestLoggingMiddleware_ServerErrorsLogOnce(t *testing.T) {
+	buf := captureLogs(t)
+	e := newLoggedServer()
+	e.GET("/fail", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to read calculation history").
+			SetInternal(errors.New("disk full"))
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/fail", nil))
+
+	// The access log line is the only record, and it carries the cause
+	lines := logLines(t, buf)
+	if len(lines) != 1 {
+		t.Fatalf("got %d log lines, want 1: %v", len(lines), lines)
+	}
+	record := lines[0]
+	if record["msg"] != "request" || record["level"] != "ERROR" || record["status"] != float64(500) || record["error"] != "disk full" {
+		t.Errorf("record = %v, want an ERROR request line with the internal cause", record)
+	}
+
+	// The client only sees the message
+	if strings.Contains(rec.Body.String(), "disk full") {
+		t.Errorf("response = %s, want the internal cause hidden", rec.Body)
+	}
+}
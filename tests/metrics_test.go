@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"bufio"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/sinaw369/Package-Optimizer/internal/api"
+	"github.com/sinaw369/Package-Optimizer/internal/catalog"
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+	"github.com/sinaw369/Package-Optimizer/internal/metrics"
+
+	"github.com/labstack/echo/v4"
+)
+
+// scrapeMetrics reads /metrics and returns the value of every series, keyed by the
+// series name and labels as they appear in the exposition format.
+func scrapeMetrics(t *testing.T, e *echo.Echo) map[string]float64 {
+	t.Helper()
+	rec := doRequest(e, http.MethodGet, "/metrics", "", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /metrics: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	series := make(map[string]float64)
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndexByte(line, ' ')
+		value, err := strconv.ParseFloat(line[i+1:], 64)
+		if err != nil {
+			t.Fatalf("invalid metrics line %q: %v", line, err)
+		}
+		series[line[:i]] = value
+	}
+	return series
+}
+
+func TestMetrics_RecordRequestsSolvesAndErrors(t *testing.T) {
+	// The catalog reports solves to the metrics, as in the server
+	c, err := catalog.New([]int{250, 500, 1000, 2000}, metrics.ObserveSolve, catalog.Settings{Limits: domain.Limits{MaxQuantity: 100000}})
+	if err != nil {
+		t.Fatalf("catalog.New() error: %v", err)
+	}
+	e := newServer(c, api.Options{}, api.MetricsMiddleware())
+
+	// Metrics are process-wide, so compare against what other tests left behind
+	before := scrapeMetrics(t, e)
+
+	for _, target := range []string{
+		"/api/calculate?qty=1201",   // 200
+		"/api/calculate?qty=12001",  // 200
+		"/api/calculate?qty=abc",    // 400
+		"/api/calculate?qty=200001", // 422, above the maximum quantity
+	} {
+		doRequest(e, http.MethodGet, target, "", "")
+	}
+
+	after := scrapeMetrics(t, e)
+	wantIncreases := map[string]float64{
+		`package_optimizer_http_requests_total{method="GET",route="/api/calculate",status="200"}`:                 2,
+		`package_optimizer_http_requests_total{method="GET",route="/api/calculate",status="400"}`:                 1,
+		`package_optimizer_http_requests_total{method="GET",route="/api/calculate",status="422"}`:                 1,
+		`package_optimizer_http_request_duration_seconds_count{method="GET",route="/api/calculate",status="200"}`: 2,
+		`package_optimizer_solve_duration_seconds_count`:                                                          2,
+		`package_optimizer_dp_table_rows_count`:                                                                   2,
+		`package_optimizer_over_delivery_units_count`:                                                             2,
+		`package_optimizer_errors_total{type="bad_request"}`:                                                      1,
+		`package_optimizer_errors_total{type="unprocessable"}`:                                                    1,
+	}
+	for series, want := range wantIncreases {
+		if got := after[series] - before[series]; got != want {
+			t.Errorf("%s increased by %v, want %v", series, got, want)
+		}
+	}
+
+	// The DP table histogram sees the table sizes: 1201 and 12001 fill more than 1000 rows,
+	// and only the larger one more than 4000
+	for bucket, want := range map[string]float64{"1000": 0, "4000": 1, "16000": 2} {
+		series := `package_optimizer_dp_table_rows_bucket{le="` + bucket + `"}`
+		if got := after[series] - before[series]; got != want {
+			t.Errorf("%s increased by %v, want %v", series, got, want)
+		}
+	}
+}
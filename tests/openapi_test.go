@@ -0,0 +1,251 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/sinaw369/Package-Optimizer/internal/api"
+)
+
+// loadSpec decodes the embedded OpenAPI document.
+func loadSpec(t *testing.T) map[string]interface{} {
+	t.Helper()
+	var spec map[string]interface{}
+	if err := json.Unmarshal(api.OpenAPISpec(), &spec); err != nil {
+		t.Fatalf("OpenAPI document is not valid JSON: %v", err)
+	}
+	return spec
+}
+
+// echoPathParam matches Echo path parameters such as ":id".
+var echoPathParam = regexp.MustCompile(`:(\w+)`)
+
+func TestOpenAPI_CoversEveryRoute(t *testing.T) {
+	spec := loadSpec(t)
+	paths := spec["paths"].(map[string]interface{})
+
+	registered := make(map[string]bool)
+	for _, route := range newTestServer().Routes() {
+		path := echoPathParam.ReplaceAllString(route.Path, "{$1}")
+		method := strings.ToLower(route.Method)
+		registered[method+" "+path] = true
+
+		item, ok := paths[path].(map[string]interface{})
+		if !ok || item[method] == nil {
+			t.Errorf("route %s %s is not documented in the OpenAPI document", route.Method, path)
+		}
+	}
+
+	// The document must not describe routes that don't exist
+	for path, item := range paths {
+		for method := range item.(map[string]interface{}) {
+			if method == "parameters" {
+				continue
+			}
+			if !registered[method+" "+path] {
+				t.Errorf("documented operation %s %s is not registered", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+func TestOpenAPI_ExamplesMatchSchemas(t *testing.T) {
+	spec := loadSpec(t)
+	paths := spec["paths"].(map[string]interface{})
+
+	checked := 0
+	for path, item := range paths {
+		for method, op := range item.(map[string]interface{}) {
+			if method == "parameters" {
+				continue
+			}
+			opMap := op.(map[string]interface{})
+
+			// Request body examples
+			if body, ok := opMap["requestBody"].(map[string]interface{}); ok {
+				checked += checkContentExamples(t, spec, fmt.Sprintf("%s %s request", method, path), resolveRef(spec, body))
+			}
+
+			// Response examples
+			for code, resp := range opMap["responses"].(map[string]interface{}) {
+				where := fmt.Sprintf("%s %s response %s", method, path, code)
+				checked += checkContentExamples(t, spec, where, resolveRef(spec, resp.(map[string]interface{})))
+			}
+		}
+	}
+
+	if checked == 0 {
+		t.Fatal("no examples were checked")
+	}
+}
+
+func TestOpenAPI_LiveResponsesMatchSchemas(t *testing.T) {
+	spec := loadSpec(t)
+	e := newTestServer()
+
+	tests := []struct {
+		target string
+		status int
+		schema string
+	}{
+		{"/api/calculate?qty=1201", http.StatusOK, "OptimizationResult"},
+		{"/api/calculate?qty=abc", http.StatusBadRequest, "Error"},
+		{"/api/package-sizes", http.StatusOK, "PackageSizes"},
+		{"/api/health", http.StatusOK, "Health"},
+		{"/api/catalog", http.StatusOK, "Catalog"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+
+			var body interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+
+			schema := map[string]interface{}{"$ref": "#/components/schemas/" + tt.schema}
+			for _, problem := range validateSchema(spec, schema, body, "$") {
+				t.Error(problem)
+			}
+		})
+	}
+}
+
+// checkContentExamples validates every JSON example in a request body or response
+// against its schema and returns the number of examples checked.
+func checkContentExamples(t *testing.T, spec map[string]interface{}, where string, obj map[string]interface{}) int {
+	t.Helper()
+	content, ok := obj["content"].(map[string]interface{})
+	if !ok {
+		return 0
+	}
+
+	media, ok := content["application/json"].(map[string]interface{})
+	if !ok {
+		return 0
+	}
+	example, ok := media["example"]
+	if !ok {
+		return 0
+	}
+
+	for _, problem := range validateSchema(spec, media["schema"].(map[string]interface{}), example, "$") {
+		t.Errorf("%s: %s", where, problem)
+	}
+	return 1
+}
+
+// resolveRef follows a local "$ref" (e.g. "#/components/schemas/Job") if present.
+func resolveRef(spec map[string]interface{}, obj map[string]interface{}) map[string]interface{} {
+	ref, ok := obj["$ref"].(string)
+	if !ok {
+		return obj
+	}
+
+	var node interface{} = spec
+	for _, key := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		node = node.(map[string]interface{})[key]
+	}
+	return node.(map[string]interface{})
+}
+
+// validateSchema checks a decoded JSON value against the subset of JSON Schema
+// used by the OpenAPI document and returns a description of every mismatch.
+func validateSchema(spec, schema map[string]interface{}, value interface{}, path string) []string {
+	schema = resolveRef(spec, schema)
+	var problems []string
+
+	// Enumerations
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, allowed := range enum {
+			if allowed == value {
+				found = true
+			}
+		}
+		if !found {
+			problems = append(problems, fmt.Sprintf("%s: %v is not one of %v", path, value, enum))
+		}
+	}
+
+	switch schema["type"] {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return append(problems, fmt.Sprintf("%s: expected object, got %T", path, value))
+		}
+
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, name := range required {
+				if _, ok := obj[name.(string)]; !ok {
+					problems = append(problems, fmt.Sprintf("%s: missing required property %q", path, name))
+				}
+			}
+		}
+
+		props, _ := schema["properties"].(map[string]interface{})
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			childPath := path + "." + key
+			if prop, ok := props[key].(map[string]interface{}); ok {
+				problems = append(problems, validateSchema(spec, prop, obj[key], childPath)...)
+			} else if extra, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+				problems = append(problems, validateSchema(spec, extra, obj[key], childPath)...)
+			} else if props != nil {
+				problems = append(problems, fmt.Sprintf("%s: unexpected property", childPath))
+			}
+		}
+
+	case "array":
+		arr, ok := value.([]interface{})
+		if !ok {
+			return append(problems, fmt.Sprintf("%s: expected array, got %T", path, value))
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range arr {
+				problems = append(problems, validateSchema(spec, items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+
+	case "integer", "number":
+		n, ok := value.(float64)
+		if !ok {
+			return append(problems, fmt.Sprintf("%s: expected %s, got %T", path, schema["type"], value))
+		}
+		if schema["type"] == "integer" && n != math.Trunc(n) {
+			problems = append(problems, fmt.Sprintf("%s: expected integer, got %v", path, n))
+		}
+		if min, ok := schema["minimum"].(float64); ok && n < min {
+			problems = append(problems, fmt.Sprintf("%s: %v is below the minimum %v", path, n, min))
+		}
+
+	case "string":
+		if _, ok := value.(string); !ok {
+			problems = append(problems, fmt.Sprintf("%s: expected string, got %T", path, value))
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			problems = append(problems, fmt.Sprintf("%s: expected boolean, got %T", path, value))
+		}
+	}
+
+	return problems
+}
@@ -1,9 +1,13 @@
 package tests
 
 import (
+	"context"
+	"errors"
+	"math"
 	"testing"
 
-	"package-optimizer/internal/domain"
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+	"github.com/sinaw369/Package-Optimizer/pkg/optimizer"
 )
 
 func TestOptimizer_Optimize(t *testing.T) {
@@ -237,6 +241,377 @@ func TestOptimizer_Validation(t *testing.T) {
 	})
 }
 
+func TestOptimizer_New(t *testing.T) {
+	for _, sizes := range [][]int{nil, {}, {0, 250}, {-100, 200}} {
+		if _, err := optimizer.New(sizes); err == nil {
+			t.Errorf("New(%v) succeeded, want an error", sizes)
+		}
+	}
+	if _, err := optimizer.New([]int{250}, optimizer.WithStrategy("min-cost")); !errors.Is(err, optimizer.ErrUnknownStrategy) {
+		t.Errorf("New() with an unknown strategy error = %v, want ErrUnknownStrategy", err)
+	}
+
+	// Options are applied at construction
+	var solves []optimizer.SolveStats
+	limits := optimizer.Limits{MaxQuantity: 5000}
+	o, err := optimizer.New([]int{500, 250, 1000},
+		optimizer.WithLimits(limits),
+		optimizer.WithObserver(func(stats optimizer.SolveStats) { solves = append(solves, stats) }))
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if o.Strategy() != optimizer.StrategyMinOverDelivery || o.Limits() != limits {
+		t.Errorf("Strategy(), Limits() = %v, %v; want the default strategy and %v", o.Strategy(), o.Limits(), limits)
+	}
+	if _, err := o.Optimize(6000); !errors.Is(err, optimizer.ErrQuantityTooLarge) {
+		t.Errorf("Optimize(6000) error = %v, want ErrQuantityTooLarge", err)
+	}
+	if _, err := o.Optimize(1201); err != nil {
+		t.Fatalf("Optimize(1201) error: %v", err)
+	}
+	if len(solves) != 2 || solves[1].Result == nil || solves[1].Result.TotalDelivered != 1250 {
+		t.Errorf("observed solves = %+v, want 2 with the last delivering 1250", solves)
+	}
+
+	// The server's optimizer is the public one
+	var server *domain.Optimizer = domain.NewOptimizer([]int{250, 500})
+	if server.CatalogVersion() != optimizer.CatalogVersion([]int{500, 250}) {
+		t.Errorf("domain and public catalog versions differ")
+	}
+}
+
+func TestOptimizer_Progress(t *testing.T) {
+	optimizer := domain.NewOptimizer([]int{250, 500, 1000, 2000})
+
+	var reports []domain.Progress
+	result, err := optimizer.OptimizeWithProgress(context.Background(), 12001, func(p domain.Progress) {
+		reports = append(reports, p)
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(reports) == 0 {
+		t.Fatal("Expected progress reports")
+	}
+
+	last := reports[len(reports)-1]
+	if last.RowsFilled != last.TotalRows {
+		t.Errorf("Last report RowsFilled = %v, want %v", last.RowsFilled, last.TotalRows)
+	}
+	if last.Best == nil || last.Best.TotalDelivered != result.TotalDelivered {
+		t.Errorf("Last report Best = %+v, want total delivered %v", last.Best, result.TotalDelivered)
+	}
+
+	for i := 1; i < len(reports); i++ {
+		if reports[i].RowsFilled <= reports[i-1].RowsFilled {
+			t.Fatalf("RowsFilled not increasing: %v then %v", reports[i-1].RowsFilled, reports[i].RowsFilled)
+		}
+	}
+}
+
+func TestOptimizer_Cancellation(t *testing.T) {
+	optimizer := domain.NewOptimizer([]int{250, 500, 1000, 2000})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := optimizer.OptimizeContext(ctx, 1_000_000)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestOptimizer_Limits(t *testing.T) {
+	optimizer := domain.NewOptimizer([]int{250, 500, 1000, 2000})
+	limited := optimizer.WithLimits(domain.Limits{
+		MaxQuantity: 1_000_000,
+		MaxMemory:   optimizer.EstimateMemory(100_000),
+	})
+
+	tests := []struct {
+		name     string
+		quantity int
+		wantErr  error
+	}{
+		{"within limits", 100_000, nil},
+		{"over memory limit", 100_001, domain.ErrMemoryLimitExceeded},
+		{"over maximum quantity", 1_000_001, domain.ErrQuantityTooLarge},
+		{"table size overflows", math.MaxInt, domain.ErrQuantityTooLarge},
+		{"table size overflows by one", math.MaxInt - 1999, domain.ErrQuantityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := limited.Check(tt.quantity)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("Check(%d) = %v, want %v", tt.quantity, err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				// Optimize must reject the quantity too, without allocating the table
+				if _, err := limited.Optimize(tt.quantity); !errors.Is(err, tt.wantErr) {
+					t.Errorf("Optimize(%d) error = %v, want %v", tt.quantity, err, tt.wantErr)
+				}
+			}
+		})
+	}
+
+	// The overflow guard applies even without configured limits
+	if _, err := optimizer.Optimize(math.MaxInt); !errors.Is(err, domain.ErrQuantityTooLarge) {
+		t.Errorf("Optimize(math.MaxInt) without limits: error = %v, want %v", err, domain.ErrQuantityTooLarge)
+	}
+	if got := optimizer.EstimateMemory(math.MaxInt); got != math.MaxInt64 {
+		t.Errorf("EstimateMemory(math.MaxInt) = %d, want math.MaxInt64", got)
+	}
+}
+
+func TestOptimizer_Alternatives(t *testing.T) {
+	optimizer := domain.NewOptimizer([]int{250, 500, 1000, 2000})
+
+	alternatives, err := optimizer.Alternatives(context.Background(), 1201, 3)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	wantDelivered := []int{1250, 1500, 1750}
+	if len(alternatives) != len(wantDelivered) {
+		t.Fatalf("Got %d alternatives, want %d", len(alternatives), len(wantDelivered))
+	}
+	for i, result := range alternatives {
+		if result.Requested != 1201 || result.TotalDelivered != wantDelivered[i] || result.OverDelivery != wantDelivered[i]-1201 {
+			t.Errorf("Alternative %d = %+v, want %d delivered for 1201", i, result, wantDelivered[i])
+		}
+	}
+
+	// Alternatives past the limits are left out; the quantity itself must be solvable
+	limited := optimizer.WithLimits(domain.Limits{MaxQuantity: 1250})
+	if alternatives, err := limited.Alternatives(context.Background(), 1201, 3); err != nil || len(alternatives) != 1 {
+		t.Errorf("Limited alternatives = %d, %v; want only the optimal one", len(alternatives), err)
+	}
+	if _, err := limited.Alternatives(context.Background(), 1251, 3); !errors.Is(err, domain.ErrQuantityTooLarge) {
+		t.Errorf("Error = %v, want %v", err, domain.ErrQuantityTooLarge)
+	}
+}
+
+func TestOptimizer_AnalyzeGaps(t *testing.T) {
+	optimizer := domain.NewOptimizer([]int{23, 31, 53})
+	analysis, err := optimizer.AnalyzeGaps(context.Background(), 300)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if analysis.Gaps[0] != (domain.Gap{From: 1, To: 22, Delivered: 23}) || analysis.MaxOverDelivery != 22 {
+		t.Errorf("First gap = %+v, worst = %d; want 1-22 delivered as 23", analysis.Gaps[0], analysis.MaxOverDelivery)
+	}
+
+	// The analysis must agree with what the optimizer delivers for every quantity
+	exact, totalOver := 0, 0
+	gap := 0
+	for q := 1; q <= 300; q++ {
+		result, err := optimizer.Optimize(q)
+		if err != nil {
+			t.Fatalf("Optimize(%d) error: %v", q, err)
+		}
+		if result.OverDelivery == 0 {
+			exact++
+			continue
+		}
+		totalOver += result.OverDelivery
+		for analysis.Gaps[gap].To < q {
+			gap++
+		}
+		if g := analysis.Gaps[gap]; q < g.From || g.Delivered != result.TotalDelivered {
+			t.Fatalf("Quantity %d delivered as %d, but gap %+v", q, result.TotalDelivered, g)
+		}
+	}
+	if analysis.Exact != exact || analysis.AverageOverDelivery != float64(totalOver)/300 {
+		t.Errorf("Exact = %d, average = %v; want %d, %v", analysis.Exact, analysis.AverageOverDelivery, exact, float64(totalOver)/300)
+	}
+
+	if _, err := optimizer.WithLimits(domain.Limits{MaxQuantity: 100}).AnalyzeGaps(context.Background(), 101); !errors.Is(err, domain.ErrQuantityTooLarge) {
+		t.Errorf("Error = %v, want %v", err, domain.ErrQuantityTooLarge)
+	}
+}
+
+func TestOptimizer_Stock(t *testing.T) {
+	sizes := []int{250, 500, 1000, 2000}
+	tests := []struct {
+		name     string
+		stock    map[int]int
+		quantity int
+		want     map[string]int
+	}{
+		{"unlimited sizes fill in", map[int]int{2000: 1}, 5000, map[string]int{"2000": 1, "1000": 3}},
+		{"unavailable size", map[int]int{250: 0}, 1201, map[string]int{"1000": 1, "500": 1}},
+		{"stock beyond the need", map[int]int{2000: 100}, 12001, map[string]int{"2000": 6, "250": 1}},
+		{"small packages only", map[int]int{2000: 0, 1000: 0, 500: 1}, 1000, map[string]int{"500": 1, "250": 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := optimizer.MustNew(sizes, optimizer.WithStock(tt.stock)).Optimize(tt.quantity)
+			if err != nil {
+				t.Fatalf("Optimize(%d) error: %v", tt.quantity, err)
+			}
+			if !equalPackages(result.Packages, tt.want) {
+				t.Errorf("Packages = %v, want %v", result.Packages, tt.want)
+			}
+		})
+	}
+
+	// Stock that runs out is reported, and limits alternatives
+	limited := optimizer.MustNew([]int{250, 500}, optimizer.WithStock(map[int]int{250: 1, 500: 1}))
+	if _, err := limited.Optimize(751); !errors.Is(err, optimizer.ErrInsufficientStock) {
+		t.Errorf("Optimize(751) error = %v, want ErrInsufficientStock", err)
+	}
+	alternatives, err := limited.Alternatives(context.Background(), 300, 5)
+	if err != nil || len(alternatives) != 2 {
+		t.Errorf("Alternatives() = %d packings, %v; want 2 (500 and 750)", len(alternatives), err)
+	}
+}
+
+func TestOptimizer_Costs(t *testing.T) {
+	// The 500 package is dearer than two 250s, so it is only used to avoid over-delivery
+	o := optimizer.MustNew([]int{250, 500, 1000}, optimizer.WithCosts(map[int]float64{250: 1, 500: 2.5, 1000: 3}))
+
+	for quantity, want := range map[int]map[string]int{
+		500:  {"250": 2},
+		1000: {"1000": 1},
+		1201: {"1000": 1, "250": 1},
+	} {
+		result, err := o.Optimize(quantity)
+		if err != nil {
+			t.Fatalf("Optimize(%d) error: %v", quantity, err)
+		}
+		if !equalPackages(result.Packages, want) {
+			t.Errorf("Optimize(%d) packages = %v, want %v", quantity, result.Packages, want)
+		}
+	}
+
+	// Over-delivery still comes first, whatever the price
+	result, err := o.Optimize(251)
+	if err != nil {
+		t.Fatalf("Optimize(251) error: %v", err)
+	}
+	if result.TotalDelivered != 500 || result.Cost != 2 {
+		t.Errorf("Optimize(251) = %d delivered for %v, want 500 for 2", result.TotalDelivered, result.Cost)
+	}
+
+	// Results without costs are not priced
+	if result, _ := optimizer.MustNew([]int{250}).Optimize(251); result.Cost != 0 {
+		t.Errorf("Cost = %v without costs, want 0", result.Cost)
+	}
+}
+
+func TestOptimizer_StockAndCostsValidation(t *testing.T) {
+	sizes := []int{250, 500}
+	for name, option := range map[string]optimizer.Option{
+		"stock for an unknown size": optimizer.WithStock(map[int]int{300: 1}),
+		"negative stock":            optimizer.WithStock(map[int]int{250: -1}),
+		"missing cost":              optimizer.WithCosts(map[int]float64{250: 1}),
+		"cost for an unknown size":  optimizer.WithCosts(map[int]float64{250: 1, 500: 2, 300: 1}),
+		"negative cost":             optimizer.WithCosts(map[int]float64{250: 1, 500: -2}),
+		"NaN cost":                  optimizer.WithCosts(map[int]float64{250: 1, 500: math.NaN()}),
+	} {
+		if _, err := optimizer.New(sizes, option); err == nil {
+			t.Errorf("%s: New() succeeded, want an error", name)
+		}
+	}
+
+	// The optimizer keeps its own copies
+	stock := map[int]int{250: 3}
+	o := optimizer.MustNew(sizes, optimizer.WithStock(stock))
+	stock[250] = 0
+	if o.Stock()[250] != 3 || o.Costs() != nil {
+		t.Errorf("Stock(), Costs() = %v, %v; want map[250:3] and nil", o.Stock(), o.Costs())
+	}
+}
+
+func TestOptimizer_ConstrainedSolveMatchesUnconstrained(t *testing.T) {
+	// With equal costs and ample stock, the constrained search must find packings
+	// as good as the default one: the same over-delivery and number of packages
+	for _, sizes := range [][]int{{250, 500, 1000, 2000}, {23, 31, 53}, {3, 7}} {
+		plain := optimizer.MustNew(sizes)
+		costs, stock := make(map[int]float64), make(map[int]int)
+		for _, size := range sizes {
+			costs[size], stock[size] = 1, 1000
+		}
+		constrained := optimizer.MustNew(sizes, optimizer.WithCosts(costs), optimizer.WithStock(stock))
+
+		for quantity := 0; quantity <= 2500; quantity += 7 {
+			want, err := plain.Optimize(quantity)
+			if err != nil {
+				t.Fatalf("Optimize(%d) error: %v", quantity, err)
+			}
+			got, err := constrained.Optimize(quantity)
+			if err != nil {
+				t.Fatalf("constrained Optimize(%d) error: %v", quantity, err)
+			}
+			if got.OverDelivery != want.OverDelivery || packageCount(got.Packages) != packageCount(want.Packages) {
+				t.Errorf("sizes %v, quantity %d: got %v, want %v", sizes, quantity, got.Packages, want.Packages)
+			}
+		}
+	}
+}
+
+func TestOptimizer_ConstrainedSolveBreaksTiesLikeUnconstrained(t *testing.T) {
+	// Both searches choose between equally good packings with the same rule, so with
+	// stock that never runs out they return exactly the same packing, including for
+	// sizes where many packings tie (5 is both 4+1 and 3+2)
+	for _, sizes := range [][]int{{250, 500, 1000, 2000}, {23, 31, 53}, {1, 2, 3, 4}, {4, 6, 9}} {
+		plain := optimizer.MustNew(sizes)
+		ample := make(map[int]int)
+		for _, size := range sizes {
+			ample[size] = 1000
+		}
+
+		for name, o := range map[string]*optimizer.Optimizer{
+			"unlimited stock": optimizer.MustNew(sizes, optimizer.WithStock(map[int]int{})),
+			"ample stock":     optimizer.MustNew(sizes, optimizer.WithStock(ample)),
+		} {
+			for quantity := 0; quantity <= 2500; quantity += 7 {
+				want, err := plain.Optimize(quantity)
+				if err != nil {
+					t.Fatalf("Optimize(%d) error: %v", quantity, err)
+				}
+				got, err := o.Optimize(quantity)
+				if err != nil {
+					t.Fatalf("%s: Optimize(%d) error: %v", name, quantity, err)
+				}
+				if got.TotalDelivered != want.TotalDelivered || !equalPackages(got.Packages, want.Packages) {
+					t.Errorf("%s, sizes %v, quantity %d: got %v, want %v", name, sizes, quantity, got.Packages, want.Packages)
+				}
+			}
+		}
+	}
+
+	// Among packings with as few packages, the one with more of the larger sizes wins
+	result, err := optimizer.MustNew([]int{1, 2, 3, 4}).Optimize(5)
+	if err != nil || !equalPackages(result.Packages, map[string]int{"4": 1, "1": 1}) {
+		t.Errorf("Optimize(5) = %v, %v; want 4+1", result.Packages, err)
+	}
+}
+
+// equalPackages reports whether two package breakdowns are the same.
+func equalPackages(got, want map[string]int) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for size, count := range want {
+		if got[size] != count {
+			return false
+		}
+	}
+	return true
+}
+
+// packageCount returns the number of packages in a breakdown.
+func packageCount(packages map[string]int) int {
+	total := 0
+	for _, count := range packages {
+		total += count
+	}
+	return total
+}
+
 func BenchmarkOptimizer_Optimize(b *testing.B) {
 	optimizer := domain.NewOptimizer([]int{250, 500, 1000, 2000})
 
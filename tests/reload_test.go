@@ -0,0 +1,327 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sinaw369/Package-Optimizer/internal/auth"
+	"github.com/sinaw369/Package-Optimizer/internal/catalog"
+	"github.com/sinaw369/Package-Optimizer/internal/config"
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+	"github.com/sinaw369/Package-Optimizer/internal/reload"
+)
+
+// newReloader loads the configuration file at path and returns a reloader for a
+// catalog and log level built from it.
+func newReloader(t *testing.T, path string) (*reload.Reloader, *catalog.Catalog, *slog.LevelVar) {
+	t.Helper()
+	load := func() (*config.Config, error) { return config.Load([]string{"--config", path}) }
+	cfg, err := load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	packageCatalog, err := catalog.New(cfg.PackageSizes, nil, catalog.Settings{
+		Limits: domain.Limits{MaxQuantity: cfg.MaxQuantity, MaxMemory: cfg.MaxSolveMemory},
+		Stock:  cfg.PackageStock,
+		Costs:  cfg.PackageCosts,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var level slog.LevelVar
+	return reload.New(cfg, load, reload.Targets{Catalog: packageCatalog, LogLevel: &level}), packageCatalog, &level
+}
+
+// rewrite replaces the content of a configuration file.
+func rewrite(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReload_AppliesChangedSettings(t *testing.T) {
+	path := writeConfig(t, "config.yaml", "catalog:\n  package_sizes: [250, 500, 1000, 2000]\n")
+	reloader, packageCatalog, level := newReloader(t, path)
+	before := packageCatalog.Optimizer()
+
+	rewrite(t, path, `
+catalog:
+  package_sizes: [23, 31, 53]
+limits:
+  max_quantity: 5000
+logging:
+  level: debug
+server:
+  port: 9999
+`)
+	changes, err := reloader.Reload(reload.TriggerSignal)
+	if err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	want := []config.Change{
+		{Field: "server.port", Old: "8080", New: "9999"},
+		{Field: "catalog.package_sizes", Old: "250,500,1000,2000", New: "23,31,53"},
+		{Field: "logging.level", Old: "info", New: "debug"},
+		{Field: "limits.max_quantity", Old: "10000000", New: "5000"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("changes = %+v, want %+v", changes, want)
+	}
+
+	// The catalog is swapped; an optimizer taken before the reload still works on the old sizes
+	if got := packageCatalog.PackageSizes(); !reflect.DeepEqual(got, []int{23, 31, 53}) {
+		t.Errorf("PackageSizes() = %v, want [23 31 53]", got)
+	}
+	if packageCatalog.Optimizer().Limits().MaxQuantity != 5000 {
+		t.Errorf("MaxQuantity = %d, want 5000", packageCatalog.Optimizer().Limits().MaxQuantity)
+	}
+	if result, err := before.Optimize(1201); err != nil || result.TotalDelivered != 1250 {
+		t.Errorf("old optimizer: %+v, %v; want 1250", result, err)
+	}
+	if level.Level() != slog.LevelDebug {
+		t.Errorf("log level = %s, want DEBUG", level.Level())
+	}
+	// Current reports what is in effect: the port needs a restart, so it is unchanged
+	if cfg := reloader.Current(); cfg.Port != "8080" || cfg.LogLevel != "debug" || cfg.MaxQuantity != 5000 {
+		t.Errorf("Current() = port %s, level %s, max quantity %d; want 8080, debug, 5000", cfg.Port, cfg.LogLevel, cfg.MaxQuantity)
+	}
+
+	// Reloading the same file again only reports the change still pending
+	want = []config.Change{{Field: "server.port", Old: "8080", New: "9999"}}
+	if changes, err := reloader.Reload(reload.TriggerSignal); err != nil || !reflect.DeepEqual(changes, want) {
+		t.Errorf("second Reload() = %+v, %v; want %+v", changes, err, want)
+	}
+}
+
+func TestReload_ReloadsAPIKeys(t *testing.T) {
+	dir := t.TempDir()
+	keysPath := filepath.Join(dir, "keys.json")
+	writeKeys := func(keys ...string) {
+		t.Helper()
+		rewrite(t, keysPath, `{"keys":[`+strings.Join(keys, ",")+`]}`)
+	}
+	partner := `{"id":"acme-prod","client":"acme","key_sha256":"` + auth.HashKey(partnerKey) + `","scopes":["calculate"]}`
+	admin := `{"id":"ops","client":"internal","key_sha256":"` + auth.HashKey(adminKey) + `","scopes":["calculate","catalog-admin"]}`
+	writeKeys(partner)
+	path := writeConfig(t, "config.yaml", "auth:\n  keys_file: "+keysPath+"\n")
+
+	load := func() (*config.Config, error) { return config.Load([]string{"--config", path}) }
+	cfg, err := load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyring, err := auth.LoadKeyring(keysPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloader := reload.New(cfg, load, reload.Targets{Catalog: newCatalog(t, cfg.PackageSizes), Keyring: keyring})
+	if _, _, err := keyring.Authorize(partnerKey, auth.ScopeCalculate); err != nil {
+		t.Fatalf("Authorize() error: %v", err)
+	}
+
+	// The key file is read again even though the configuration itself is unchanged
+	writeKeys(partner, admin)
+	if _, err := reloader.Reload(reload.TriggerSignal); err != nil {
+		t.Fatalf("Reload() error: %v", err)
+	}
+	if _, _, err := keyring.Authorize(adminKey, auth.ScopeCatalogAdmin); err != nil {
+		t.Errorf("added key: Authorize() error = %v", err)
+	}
+	if usage := keyring.ClientUsage("acme"); len(usage) != 1 || usage[0].Requests != 1 {
+		t.Errorf("kept key usage = %+v, want its request count carried over", usage)
+	}
+
+	// An invalid key file fails the reload and keeps the current keys
+	rewrite(t, keysPath, `{"keys":[`)
+	if _, err := reloader.Reload(reload.TriggerSignal); err == nil {
+		t.Error("Reload() with an invalid key file: error = nil")
+	}
+	if _, _, err := keyring.Authorize(adminKey, auth.ScopeCalculate); err != nil {
+		t.Errorf("after a failed reload: Authorize() error = %v", err)
+	}
+
+	// Revoked keys stop working
+	writeKeys(admin)
+	if _, err := reloader.Reload(reload.TriggerSignal); err != nil {
+		t.Fatalf("Reload() error: %v", err)
+	}
+	if _, _, err := keyring.Authorize(partnerKey, auth.ScopeCalculate); !errors.Is(err, auth.ErrUnauthenticated) {
+		t.Errorf("revoked key: Authorize() error = %v, want %v", err, auth.ErrUnauthenticated)
+	}
+}
+
+func TestReload_KeepsCurrentConfigurationWhenInvalid(t *testing.T) {
+	path := writeConfig(t, "config.yaml", "catalog:\n  package_sizes: [250, 500]\n")
+	reloader, packageCatalog, _ := newReloader(t, path)
+	version := packageCatalog.Version()
+
+	for _, content := range []string{
+		// Invalid values are rejected as a whole, even alongside valid ones
+		"catalog:\n  package_sizes: [23, 31]\nlimits:\n  max_quantity: -1\n",
+		// Duplicate sizes
+		"catalog:\n  package_sizes: [23, 23]\n",
+		"catalog: [unclosed",
+	} {
+		rewrite(t, path, content)
+		if _, err := reloader.Reload(reload.TriggerFile); err == nil {
+			t.Errorf("Reload() with %q: error = nil, want an error", content)
+		}
+		if packageCatalog.Version() != version || reloader.Current().PackageSizes[0] != 250 {
+			t.Fatalf("configuration changed by an invalid reload with %q", content)
+		}
+	}
+}
+
+func TestReload_KeepsAdminCatalogWhenSizesAreUnchanged(t *testing.T) {
+	path := writeConfig(t, "config.yaml", "catalog:\n  package_sizes: [250, 500]\n")
+	reloader, packageCatalog, _ := newReloader(t, path)
+
+	// An administrator changes the catalog through the API
+	if _, err := packageCatalog.Update([]int{100, 300}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A reload that only changes the limits keeps the administrator's sizes
+	rewrite(t, path, "catalog:\n  package_sizes: [250, 500]\nlimits:\n  max_solve_memory_mb: 64\n")
+	if _, err := reloader.Reload(reload.TriggerSignal); err != nil {
+		t.Fatal(err)
+	}
+	if got := packageCatalog.PackageSizes(); !reflect.DeepEqual(got, []int{100, 300}) {
+		t.Errorf("PackageSizes() = %v, want the administrator's [100 300]", got)
+	}
+	if packageCatalog.Optimizer().Limits().MaxMemory != 64<<20 {
+		t.Errorf("MaxMemory = %d, want %d", packageCatalog.Optimizer().Limits().MaxMemory, 64<<20)
+	}
+}
+
+func TestReload_WatchesFileAndSignal(t *testing.T) {
+	path := writeConfig(t, "config.yaml", "config:\n  watch_interval: 10ms\ncatalog:\n  package_sizes: [250, 500]\n")
+	reloader, packageCatalog, _ := newReloader(t, path)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reloader.Watch(ctx)
+
+	waitForSizes := func(want []int) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for !reflect.DeepEqual(packageCatalog.PackageSizes(), want) {
+			if time.Now().After(deadline) {
+				t.Fatalf("PackageSizes() = %v, want %v", packageCatalog.PackageSizes(), want)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	// A changed file is picked up by polling
+	rewrite(t, path, "config:\n  watch_interval: 10ms\ncatalog:\n  package_sizes: [23, 31, 53]\n")
+	waitForSizes([]int{23, 31, 53})
+
+	// SIGHUP reloads at once, even with polling disabled
+	rewrite(t, path, "config:\n  watch_interval: 0s\ncatalog:\n  package_sizes: [7, 11]\n")
+	waitForSizes([]int{7, 11})
+	rewrite(t, path, "config:\n  watch_interval: 0s\ncatalog:\n  package_sizes: [6, 9, 20]\n")
+	time.Sleep(50 * time.Millisecond)
+	if got := packageCatalog.PackageSizes(); !reflect.DeepEqual(got, []int{7, 11}) {
+		t.Fatalf("PackageSizes() = %v with polling disabled, want [7 11]", got)
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	waitForSizes([]int{6, 9, 20})
+}
+
+func TestReload_AppliesCatalogSettings(t *testing.T) {
+	path := writeConfig(t, "config.yaml", `
+catalog:
+  name: retail
+catalogs:
+  retail: [250, 500, 1000]
+  bulk: [1000, 5000]
+`)
+	reloader, packageCatalog, _ := newReloader(t, path)
+	var purges int
+	packageCatalog.OnChange(func(previous, current *domain.Optimizer) { purges++ })
+
+	// Switching the named catalog and pricing the sizes reconfigures the catalog
+	rewrite(t, path, `
+catalog:
+  name: bulk
+  costs: {250: 1, 1000: 3.5, 5000: 16}
+  stock: {5000: 2}
+catalogs:
+  retail: [250, 500, 1000]
+  bulk: [1000, 5000]
+`)
+	if _, err := reloader.Reload(reload.TriggerSignal); err != nil {
+		t.Fatal(err)
+	}
+	optimizer := packageCatalog.Optimizer()
+	if got := packageCatalog.PackageSizes(); !reflect.DeepEqual(got, []int{1000, 5000}) {
+		t.Errorf("PackageSizes() = %v, want the bulk catalog", got)
+	}
+	// Prices and stock for sizes the catalog doesn't offer are left out
+	if !reflect.DeepEqual(optimizer.Costs(), map[int]float64{1000: 3.5, 5000: 16}) || !reflect.DeepEqual(optimizer.Stock(), map[int]int{5000: 2}) {
+		t.Errorf("Costs() = %v, Stock() = %v", optimizer.Costs(), optimizer.Stock())
+	}
+	if result, err := optimizer.Optimize(15000); err != nil || result.Cost != 49.5 {
+		t.Errorf("Optimize(15000) = %+v, %v; want 2 x 5000 and 5 x 1000 for 49.5", result, err)
+	}
+
+	// New prices alone are a change too, so cached results are dropped
+	purges = 0
+	rewrite(t, path, `
+catalog:
+  name: bulk
+  costs: {1000: 3, 5000: 16}
+catalogs:
+  bulk: [1000, 5000]
+`)
+	if _, err := reloader.Reload(reload.TriggerSignal); err != nil {
+		t.Fatal(err)
+	}
+	if purges != 1 || packageCatalog.Optimizer().Costs()[1000] != 3 {
+		t.Errorf("purges = %d, Costs() = %v; want one change to the new prices", purges, packageCatalog.Optimizer().Costs())
+	}
+
+	// Sizes set through the admin API need a price as well, also after a reload
+	if _, err := packageCatalog.Update([]int{1000, 2000}); err == nil || !strings.Contains(err.Error(), "no cost for package size 2000") {
+		t.Errorf("Update() error = %v, want the missing cost", err)
+	}
+	rewrite(t, path, `
+catalog:
+  name: bulk
+  costs: {1000: 3, 2000: 5.5, 5000: 16}
+catalogs:
+  bulk: [1000, 5000]
+`)
+	if _, err := reloader.Reload(reload.TriggerSignal); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := packageCatalog.Update([]int{1000, 2000}); err != nil {
+		t.Fatal(err)
+	}
+	rewrite(t, path, `
+catalog:
+  name: bulk
+  costs: {1000: 3, 5000: 16}
+catalogs:
+  bulk: [1000, 5000]
+`)
+	if _, err := reloader.Reload(reload.TriggerSignal); err == nil || !strings.Contains(err.Error(), "catalog.costs: no cost for package size 2000") {
+		t.Errorf("Reload() error = %v, want the missing cost of the administrator's size", err)
+	}
+	if got := packageCatalog.Optimizer().Costs(); got[2000] != 5.5 {
+		t.Errorf("Costs() = %v, want the prices still in effect", got)
+	}
+}
@@ -0,0 +1,269 @@
+package tests
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	optimizerv1 "github.com/sinaw369/Package-Optimizer/api/proto/optimizer/v1"
+	"github.com/sinaw369/Package-Optimizer/internal/cache"
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+	"github.com/sinaw369/Package-Optimizer/internal/limits"
+	"github.com/sinaw369/Package-Optimizer/internal/rpc"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newRPCClient starts an in-memory gRPC server and returns a client connected to it.
+func newRPCClient(t *testing.T, packageSizes []int, opts ...grpc.ServerOption) optimizerv1.OptimizerServiceClient {
+	t.Helper()
+	return newLimitedRPCClient(t, packageSizes, rpc.Options{}, opts...)
+}
+
+// newLimitedRPCClient starts an in-memory gRPC server with the given limiter and
+// cache and returns a client connected to it.
+func newLimitedRPCClient(t *testing.T, packageSizes []int, options rpc.Options, opts ...grpc.ServerOption) optimizerv1.OptimizerServiceClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	server := rpc.Register(newCatalog(t, packageSizes), options, opts...)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return optimizerv1.NewOptimizerServiceClient(conn)
+}
+
+func TestRPC_Calculate(t *testing.T) {
+	client := newRPCClient(t, []int{250, 500, 1000, 2000})
+
+	resp, err := client.Calculate(context.Background(), &optimizerv1.CalculateRequest{Quantity: 1201})
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+
+	result := resp.GetResult()
+	if result.GetTotalDelivered() != 1250 || result.GetOverDelivery() != 49 {
+		t.Errorf("result = %v, want 1250 delivered with 49 over-delivery", result)
+	}
+	if len(result.GetPackages()) != 2 || result.GetPackages()[0].GetSize() != 1000 {
+		t.Errorf("packages = %v, want 1000x1 then 250x1", result.GetPackages())
+	}
+
+	_, err = client.Calculate(context.Background(), &optimizerv1.CalculateRequest{Quantity: -1})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Calculate(-1) code = %v, want %v", status.Code(err), codes.InvalidArgument)
+	}
+}
+
+func TestRPC_BatchCalculate(t *testing.T) {
+	client := newRPCClient(t, []int{250, 500, 1000, 2000})
+
+	resp, err := client.BatchCalculate(context.Background(), &optimizerv1.BatchCalculateRequest{Quantities: []int64{1000, -5, 5000}})
+	if err != nil {
+		t.Fatalf("BatchCalculate() error: %v", err)
+	}
+
+	items := resp.GetItems()
+	if len(items) != 3 {
+		t.Fatalf("len(items) = %d, want 3", len(items))
+	}
+	if items[0].GetResult().GetTotalDelivered() != 1000 {
+		t.Errorf("items[0] = %v, want 1000 delivered", items[0])
+	}
+	if items[1].GetError() == "" {
+		t.Errorf("items[1] = %v, want an error", items[1])
+	}
+	if items[2].GetResult().GetTotalDelivered() != 5000 {
+		t.Errorf("items[2] = %v, want 5000 delivered", items[2])
+	}
+}
+
+func TestRPC_ListPackageSizes(t *testing.T) {
+	client := newRPCClient(t, []int{500, 250, 1000})
+
+	resp, err := client.ListPackageSizes(context.Background(), &optimizerv1.ListPackageSizesRequest{})
+	if err != nil {
+		t.Fatalf("ListPackageSizes() error: %v", err)
+	}
+
+	want := []int64{1000, 500, 250}
+	if len(resp.GetPackageSizes()) != len(want) {
+		t.Fatalf("PackageSizes = %v, want %v", resp.GetPackageSizes(), want)
+	}
+	for i := range want {
+		if resp.GetPackageSizes()[i] != want[i] {
+			t.Errorf("PackageSizes = %v, want %v", resp.GetPackageSizes(), want)
+			break
+		}
+	}
+	if resp.GetCatalogVersion() != domain.CatalogVersion([]int{250, 500, 1000}) {
+		t.Errorf("CatalogVersion = %q, want the version of the configured sizes", resp.GetCatalogVersion())
+	}
+}
+
+func TestRPC_AuthInterceptor(t *testing.T) {
+	keyring := newTestKeyring(t)
+	client := newRPCClient(t, []int{250, 500}, grpc.UnaryInterceptor(rpc.AuthInterceptor(keyring)))
+	req := &optimizerv1.CalculateRequest{Quantity: 251}
+
+	tests := []struct {
+		name string
+		md   metadata.MD
+		want codes.Code
+	}{
+		{"no key", metadata.MD{}, codes.Unauthenticated},
+		{"unknown key", metadata.Pairs("x-api-key", "nope"), codes.Unauthenticated},
+		{"admin-only key", metadata.Pairs("x-api-key", adminOnlyKey), codes.PermissionDenied},
+		{"api key header", metadata.Pairs("x-api-key", partnerKey), codes.OK},
+		{"bearer token", metadata.Pairs("authorization", "Bearer "+partnerKey), codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewOutgoingContext(context.Background(), tt.md)
+			_, err := client.Calculate(ctx, req)
+			if got := status.Code(err); got != tt.want {
+				t.Errorf("code = %v, want %v (err: %v)", got, tt.want, err)
+			}
+
+			// Package sizes are open to everyone, as over HTTP
+			if _, err := client.ListPackageSizes(ctx, &optimizerv1.ListPackageSizesRequest{}); err != nil {
+				t.Errorf("ListPackageSizes() error: %v", err)
+			}
+		})
+	}
+
+	// Listing package sizes isn't counted against the quota
+	trial := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("x-api-key", limitedKey))
+	for i := 0; i < 3; i++ {
+		if _, err := client.ListPackageSizes(trial, &optimizerv1.ListPackageSizesRequest{}); err != nil {
+			t.Fatalf("ListPackageSizes() error: %v", err)
+		}
+	}
+	if usage := keyring.Usage()[3]; usage.Requests != 0 {
+		t.Errorf("usage = %+v, want no requests counted", usage)
+	}
+}
+
+func TestRPC_BatchCalculateRejectsTooManyQuantities(t *testing.T) {
+	client := newRPCClient(t, []int{250, 500})
+
+	quantities := make([]int64, domain.MaxBatchQuantities+1)
+	_, err := client.BatchCalculate(context.Background(), &optimizerv1.BatchCalculateRequest{Quantities: quantities})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("code = %v, want %v (err: %v)", status.Code(err), codes.InvalidArgument, err)
+	}
+}
+
+func TestRPC_RateLimitInterceptor(t *testing.T) {
+	limiter := limits.New(limits.Config{Rate: 0.01, Burst: 2})
+	client := newLimitedRPCClient(t, []int{250, 500}, rpc.Options{Limiter: limiter},
+		grpc.ChainUnaryInterceptor(rpc.RateLimitInterceptor(limiter)))
+	ctx := context.Background()
+
+	// The burst is allowed, across both calculation RPCs
+	if _, err := client.Calculate(ctx, &optimizerv1.CalculateRequest{Quantity: 251}); err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+	if _, err := client.BatchCalculate(ctx, &optimizerv1.BatchCalculateRequest{Quantities: []int64{251}}); err != nil {
+		t.Fatalf("BatchCalculate() error: %v", err)
+	}
+
+	// The next call is over the rate and says when to retry
+	var header metadata.MD
+	_, err := client.Calculate(ctx, &optimizerv1.CalculateRequest{Quantity: 251}, grpc.Header(&header))
+	if status.Code(err) != codes.ResourceExhausted || len(header.Get("retry-after")) != 1 {
+		t.Errorf("code = %v, retry-after = %v; want ResourceExhausted with retry-after", status.Code(err), header.Get("retry-after"))
+	}
+
+	// Listing package sizes doesn't solve and isn't limited
+	if _, err := client.ListPackageSizes(ctx, &optimizerv1.ListPackageSizesRequest{}); err != nil {
+		t.Errorf("ListPackageSizes() error: %v", err)
+	}
+}
+
+func TestRPC_BusySolver(t *testing.T) {
+	limiter := limits.New(limits.Config{SolveMemory: 1 << 20, SolveWait: 10 * time.Millisecond})
+	client := newLimitedRPCClient(t, []int{250, 500}, rpc.Options{Limiter: limiter})
+	ctx := context.Background()
+
+	// Occupy the whole budget, as a huge running solve would
+	release, err := limiter.AcquireSolve(ctx, 1<<20)
+	if err != nil {
+		t.Fatalf("AcquireSolve() error: %v", err)
+	}
+
+	_, err = client.Calculate(ctx, &optimizerv1.CalculateRequest{Quantity: 1201})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Calculate(): code = %v, want %v", status.Code(err), codes.ResourceExhausted)
+	}
+	_, err = client.BatchCalculate(ctx, &optimizerv1.BatchCalculateRequest{Quantities: []int64{1201, -1}})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("BatchCalculate(): code = %v, want %v", status.Code(err), codes.ResourceExhausted)
+	}
+
+	release()
+	if _, err := client.Calculate(ctx, &optimizerv1.CalculateRequest{Quantity: 1201}); err != nil {
+		t.Errorf("Calculate() after release: %v", err)
+	}
+}
+
+func TestRPC_SharesTheResultCache(t *testing.T) {
+	results := cache.New(10)
+	client := newLimitedRPCClient(t, []int{250, 500}, rpc.Options{Cache: results})
+
+	if _, err := client.Calculate(context.Background(), &optimizerv1.CalculateRequest{Quantity: 1201}); err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+	optimizer := domain.NewOptimizer([]int{250, 500})
+	if result, ok := results.Get(cache.KeyFor(optimizer, 1201)); !ok || result.TotalDelivered != 1250 {
+		t.Errorf("cached result = %+v, %v; want the solve stored for the HTTP API", result, ok)
+	}
+}
+
+func TestRPC_BatchCalculateIsChargedPerQuantity(t *testing.T) {
+	keyring := newTestKeyring(t)
+	limiter := limits.New(limits.Config{Rate: 0.01, Burst: 3})
+	client := newLimitedRPCClient(t, []int{250, 500}, rpc.Options{Limiter: limiter},
+		grpc.ChainUnaryInterceptor(rpc.AuthInterceptor(keyring), rpc.RateLimitInterceptor(limiter)))
+
+	// The trial key's quota of two can't cover three quantities, and nothing is charged
+	trial := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("x-api-key", limitedKey))
+	_, err := client.BatchCalculate(trial, &optimizerv1.BatchCalculateRequest{Quantities: []int64{251, 501, 751}})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("code = %v, want %v (err: %v)", status.Code(err), codes.ResourceExhausted, err)
+	}
+	if _, err := client.BatchCalculate(trial, &optimizerv1.BatchCalculateRequest{Quantities: []int64{251, 501}}); err != nil {
+		t.Fatalf("BatchCalculate() within the quota error: %v", err)
+	}
+	if usage := keyring.Usage()[3]; usage.Requests != 2 || usage.QuotaRemaining != 0 {
+		t.Errorf("usage = %+v, want both quantities counted", usage)
+	}
+
+	// Three quantities use up another key's burst
+	partner := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("x-api-key", partnerKey))
+	if _, err := client.BatchCalculate(partner, &optimizerv1.BatchCalculateRequest{Quantities: []int64{251, 501, 751}}); err != nil {
+		t.Fatalf("BatchCalculate() within the burst error: %v", err)
+	}
+	_, err = client.Calculate(partner, &optimizerv1.CalculateRequest{Quantity: 251})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("code = %v, want %v (err: %v)", status.Code(err), codes.ResourceExhausted, err)
+	}
+}
@@ -0,0 +1,32 @@
+package tests
+
+import (
+	"github.com/sinaw369/Package-Optimizer/internal/api"
+	"github.com/sinaw369/Package-Optimizer/internal/catalog"
+
+	"github.com/labstack/echo/v4"
+)
+
+// newServer creates a test server for a catalog and the handler options, as the
+// server sets it up: the API error handler, request IDs, the given middleware (in
+// order) and the API routes.
+func newServer(packageCatalog *catalog.Catalog, options api.Options, middleware ...echo.MiddlewareFunc) *echo.Echo {
+	return serveHandler(api.NewHandler(packageCatalog, options), middleware...)
+}
+
+// newTestServer creates a test server with the default package sizes and no
+// optional features.
+func newTestServer() *echo.Echo {
+	packageCatalog, _ := catalog.New([]int{250, 500, 1000, 2000}, nil, catalog.Settings{})
+	return newServer(packageCatalog, api.Options{})
+}
+
+// serveHandler is like newServer for a handler the test has already configured.
+func serveHandler(handler *api.Handler, middleware ...echo.MiddlewareFunc) *echo.Echo {
+	e := echo.New()
+	e.HTTPErrorHandler = api.HTTPErrorHandler
+	e.Use(api.RequestIDMiddleware())
+	e.Use(middleware...)
+	api.RegisterRoutes(e, handler)
+	return e
+}
@@ -0,0 +1,238 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sinaw369/Package-Optimizer/internal/api"
+	"github.com/sinaw369/Package-Optimizer/internal/cache"
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+	"github.com/sinaw369/Package-Optimizer/internal/jobs"
+
+	"github.com/labstack/echo/v4"
+)
+
+// sseEvent is one Server-Sent Event read from a response body.
+type sseEvent struct {
+	name string
+	data string
+}
+
+// parseEvents splits a text/event-stream body into its events.
+func parseEvents(t *testing.T, body string) []sseEvent {
+	t.Helper()
+	var events []sseEvent
+	for _, block := range strings.Split(strings.TrimSpace(body), "\n\n") {
+		var event sseEvent
+		for _, line := range strings.Split(block, "\n") {
+			switch {
+			case strings.HasPrefix(line, "event: "):
+				event.name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				event.data = strings.TrimPrefix(line, "data: ")
+			default:
+				t.Fatalf("unexpected line %q in event stream", line)
+			}
+		}
+		if event.name == "" || event.data == "" {
+			t.Fatalf("incomplete event %q", block)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// decodeEvent decodes an event's JSON payload.
+func decodeEvent(t *testing.T, event sseEvent, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal([]byte(event.data), v); err != nil {
+		t.Fatalf("%s event: invalid JSON %q: %v", event.name, event.data, err)
+	}
+}
+
+// newStreamServer creates a test server with request IDs, a result cache and a job manager.
+func newStreamServer(t *testing.T, sizes []int, jobManager *jobs.Manager) *echo.Echo {
+	t.Helper()
+	return newServer(newCatalog(t, sizes), api.Options{Jobs: jobManager, Cache: cache.New(10)})
+}
+
+func TestCalculateStream_EmitsProgressThenResult(t *testing.T) {
+	e := newStreamServer(t, []int{250, 500, 1000, 2000}, nil)
+
+	rec := doRequest(e, http.MethodGet, "/api/calculate/stream?qty=200000", "", "")
+	if rec.Code != http.StatusOK || rec.Header().Get(echo.HeaderContentType) != "text/event-stream" {
+		t.Fatalf("status = %d, Content-Type = %q; want a 200 event stream", rec.Code, rec.Header().Get(echo.HeaderContentType))
+	}
+	events := parseEvents(t, rec.Body.String())
+	if len(events) < 2 {
+		t.Fatalf("events = %+v, want progress events and a result", events)
+	}
+
+	// Progress events report a growing number of filled rows out of a fixed total
+	lastRows, totalRows := 0, 0
+	for _, event := range events[:len(events)-1] {
+		if event.name != "progress" {
+			t.Fatalf("event %q before the result, want only progress events", event.name)
+		}
+		var progress domain.Progress
+		decodeEvent(t, event, &progress)
+		if progress.RowsFilled < lastRows || progress.RowsFilled > progress.TotalRows {
+			t.Errorf("progress = %+v after %d rows, want growing rows within the total", progress, lastRows)
+		}
+		if totalRows != 0 && progress.TotalRows != totalRows {
+			t.Errorf("total_rows changed from %d to %d", totalRows, progress.TotalRows)
+		}
+		lastRows, totalRows = progress.RowsFilled, progress.TotalRows
+	}
+
+	// The stream ends with the same result as a plain calculation
+	last := events[len(events)-1]
+	if last.name != "result" {
+		t.Fatalf("last event = %q, want result", last.name)
+	}
+	var result domain.OptimizationResult
+	decodeEvent(t, last, &result)
+	if result.Requested != 200000 || result.TotalDelivered != 200000 || result.OverDelivery != 0 {
+		t.Errorf("result = %+v, want 200000 delivered exactly", result)
+	}
+
+	// A cached result is sent straight away, without progress
+	rec = doRequest(e, http.MethodGet, "/api/calculate/stream?qty=200000", "", "")
+	if events := parseEvents(t, rec.Body.String()); len(events) != 1 || events[0].name != "result" {
+		t.Errorf("cached stream = %+v, want a single result event", events)
+	}
+}
+
+// cancelingRecorder cancels the request once the first progress event is written,
+// as a client closing the page would.
+type cancelingRecorder struct {
+	*httptest.ResponseRecorder
+	cancel context.CancelFunc
+}
+
+func (r *cancelingRecorder) Write(p []byte) (int, error) {
+	if strings.HasPrefix(string(p), "event: progress") {
+		r.cancel()
+	}
+	return r.ResponseRecorder.Write(p)
+}
+
+func TestCalculateStream_EmitsErrorEvent(t *testing.T) {
+	e := newStreamServer(t, []int{250, 500, 1000, 2000}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "/api/calculate/stream?qty=5000000", nil).WithContext(ctx)
+	rec := &cancelingRecorder{ResponseRecorder: httptest.NewRecorder(), cancel: cancel}
+	e.ServeHTTP(rec, req)
+
+	events := parseEvents(t, rec.Body.String())
+	last := events[len(events)-1]
+	if last.name != "error" {
+		t.Fatalf("last event = %q, want error", last.name)
+	}
+	var payload domain.ErrorResponse
+	decodeEvent(t, last, &payload)
+	if !strings.Contains(payload.Error, "canceled") || payload.RequestID == "" {
+		t.Errorf("error payload = %+v, want the cancellation and the request ID", payload)
+	}
+}
+
+func TestJobEvents_StreamsUntilTheJobFinishes(t *testing.T) {
+	m, err := jobs.NewManager(newCatalog(t, []int{1, 2}), jobs.Options{Workers: 1, QueueSize: 4, ResultTTL: time.Minute})
+	if err != nil {
+		t.Fatalf("NewManager() error: %v", err)
+	}
+	defer m.Shutdown(context.Background())
+	e := newStreamServer(t, []int{1, 2}, m)
+
+	// Occupy the only worker so the watched job is still queued when the stream starts
+	blocker, err := m.Submit(jobs.Request{Quantities: []int{longQuantity}})
+	if err != nil {
+		t.Fatalf("Submit() error: %v", err)
+	}
+	job, err := m.Submit(jobs.Request{Quantities: []int{1201, 5000}})
+	if err != nil {
+		t.Fatalf("Submit() error: %v", err)
+	}
+	time.AfterFunc(50*time.Millisecond, func() { m.Cancel(blocker.ID) })
+
+	// The handler returns once the job is done, ending the stream
+	rec := doRequest(e, http.MethodGet, "/api/jobs/"+job.ID+"/events", "", "")
+	events := parseEvents(t, rec.Body.String())
+	if len(events) < 2 {
+		t.Fatalf("events = %+v, want progress events and done", events)
+	}
+
+	var first jobs.Job
+	decodeEvent(t, events[0], &first)
+	if events[0].name != "progress" || first.ID != job.ID || first.Status != jobs.StatusQueued {
+		t.Errorf("first event = %s %+v, want progress for the queued job", events[0].name, first)
+	}
+	for _, event := range events[1 : len(events)-1] {
+		if event.name != "progress" {
+			t.Errorf("event %q before done, want only progress events", event.name)
+		}
+	}
+
+	var done jobs.Job
+	decodeEvent(t, events[len(events)-1], &done)
+	if events[len(events)-1].name != "done" || done.Status != jobs.StatusSucceeded || len(done.Results) != 2 {
+		t.Errorf("last event = %s %+v, want done with both results", events[len(events)-1].name, done)
+	}
+
+	// A finished job gets a single done event
+	rec = doRequest(e, http.MethodGet, "/api/jobs/"+blocker.ID+"/events", "", "")
+	events = parseEvents(t, rec.Body.String())
+	var canceled jobs.Job
+	if len(events) == 1 {
+		decodeEvent(t, events[0], &canceled)
+	}
+	if len(events) != 1 || events[0].name != "done" || canceled.Status != jobs.StatusCanceled {
+		t.Errorf("events for a canceled job = %+v, want a single done event", events)
+	}
+
+	// Unknown jobs are not found before any stream starts
+	if rec := doRequest(e, http.MethodGet, "/api/jobs/unknown/events", "", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown job: status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestStreams_EndWhenTheServerShutsDown(t *testing.T) {
+	m, err := jobs.NewManager(newCatalog(t, []int{1, 2}), jobs.Options{Workers: 1, QueueSize: 4, ResultTTL: time.Minute})
+	if err != nil {
+		t.Fatalf("NewManager() error: %v", err)
+	}
+	defer m.Shutdown(context.Background())
+	handler := api.NewHandler(newCatalog(t, []int{250, 500, 1000, 2000}), api.Options{Jobs: m})
+	e := serveHandler(handler)
+
+	// A job that never finishes in time keeps its event stream open until shutdown
+	job, err := m.Submit(jobs.Request{Quantities: []int{longQuantity}})
+	if err != nil {
+		t.Fatalf("Submit() error: %v", err)
+	}
+	time.AfterFunc(50*time.Millisecond, handler.CloseStreams)
+	rec := doRequest(e, http.MethodGet, "/api/jobs/"+job.ID+"/events", "", "")
+	events := parseEvents(t, rec.Body.String())
+	last := events[len(events)-1]
+	var payload domain.ErrorResponse
+	decodeEvent(t, last, &payload)
+	if last.name != "error" || !strings.Contains(payload.Error, "shutting down") {
+		t.Errorf("last job event = %s %+v, want a shutdown error", last.name, payload)
+	}
+
+	// Calculation streams started after shutdown stop their solve and say why
+	rec = doRequest(e, http.MethodGet, "/api/calculate/stream?qty=5000000", "", "")
+	events = parseEvents(t, rec.Body.String())
+	last = events[len(events)-1]
+	decodeEvent(t, last, &payload)
+	if last.name != "error" || !strings.Contains(payload.Error, "shutting down") {
+		t.Errorf("last calculation event = %s %+v, want a shutdown error", last.name, payload)
+	}
+}
@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sinaw369/Package-Optimizer/internal/api"
+	"github.com/sinaw369/Package-Optimizer/internal/domain"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing_RequestAndSolverSpans(t *testing.T) {
+	// Record spans in memory for the duration of the test
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	packageCatalog := newCatalog(t, []int{250, 500, 1000, 2000})
+	e := newServer(packageCatalog, api.Options{}, api.TracingMiddleware())
+
+	// Send a request that continues an existing W3C trace
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/api/calculate?qty=1201", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+		if got := span.SpanContext().TraceID().String(); got != traceID {
+			t.Errorf("span %q trace ID = %s, want %s", span.Name(), got, traceID)
+		}
+	}
+
+	// The solver span is a child of the request span, and the phases are children of the solve
+	parents := map[string]string{
+		"optimizer.solve":         "GET /api/calculate",
+		"optimizer.table_build":   "optimizer.solve",
+		"optimizer.backtrack":     "optimizer.solve",
+		"optimizer.format_result": "optimizer.solve",
+	}
+	for name, parentName := range parents {
+		span, ok := spans[name]
+		if !ok {
+			t.Errorf("missing span %q", name)
+			continue
+		}
+		parent, ok := spans[parentName]
+		if !ok {
+			t.Errorf("missing span %q", parentName)
+			continue
+		}
+		if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("span %q parent = %s, want %q", name, span.Parent().SpanID(), parentName)
+		}
+	}
+
+	// The solve span carries the quantity, catalog and strategy
+	attrs := make(map[string]string)
+	for _, kv := range spans["optimizer.solve"].Attributes() {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	want := map[string]string{
+		"optimizer.quantity":        "1201",
+		"optimizer.catalog_version": packageCatalog.Version(),
+		"optimizer.strategy":        domain.StrategyMinOverDelivery,
+	}
+	for key, value := range want {
+		if attrs[key] != value {
+			t.Errorf("attribute %s = %q, want %q", key, attrs[key], value)
+		}
+	}
+}
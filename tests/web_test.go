@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sinaw369/Package-Optimizer/internal/api"
+	"github.com/sinaw369/Package-Optimizer/internal/catalog"
+
+	"github.com/labstack/echo/v4"
+)
+
+// newWebServer creates a test server whose handler is configured by setup.
+func newWebServer(t *testing.T, setup func(h *api.Handler)) *echo.Echo {
+	t.Helper()
+	packageCatalog, err := catalog.New([]int{250, 500, 1000, 2000}, nil, catalog.Settings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := api.NewHandler(packageCatalog, api.Options{})
+	if setup != nil {
+		setup(handler)
+	}
+	return serveHandler(handler)
+}
+
+func TestWebUI_ServesEmbeddedFiles(t *testing.T) {
+	// Run from a directory without web/static: the files must come from the binary
+	wd, _ := os.Getwd()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	e := newWebServer(t, nil)
+	tests := []struct {
+		path        string
+		contentType string
+		contains    string
+	}{
+		{"/", "text/html; charset=UTF-8", "<html"},
+		{"/style.css", "text/css; charset=utf-8", "{"},
+		{"/script.js", "application/javascript; charset=UTF-8", "function"},
+	}
+
+	for _, tt := range tests {
+		rec := doRequest(e, http.MethodGet, tt.path, "", "")
+		if rec.Code != http.StatusOK {
+			t.Errorf("GET %s: status = %d, want %d", tt.path, rec.Code, http.StatusOK)
+			continue
+		}
+		if got := rec.Header().Get(echo.HeaderContentType); got != tt.contentType {
+			t.Errorf("GET %s: Content-Type = %q, want %q", tt.path, got, tt.contentType)
+		}
+		if got := rec.Header().Get(echo.HeaderCacheControl); got != "no-cache" {
+			t.Errorf("GET %s: Cache-Control = %q, want no-cache", tt.path, got)
+		}
+		if rec.Header().Get("X-Content-Type-Options") != "nosniff" {
+			t.Errorf("GET %s: X-Content-Type-Options not set", tt.path)
+		}
+		if !strings.Contains(rec.Body.String(), tt.contains) {
+			t.Errorf("GET %s: body does not contain %q", tt.path, tt.contains)
+		}
+
+		// Revalidation with the ETag answers without a body
+		etag := rec.Header().Get(api.HeaderETag)
+		if etag == "" {
+			t.Errorf("GET %s: no ETag", tt.path)
+			continue
+		}
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.Header.Set(api.HeaderIfNoneMatch, etag)
+		revalidated := httptest.NewRecorder()
+		e.ServeHTTP(revalidated, req)
+		if revalidated.Code != http.StatusNotModified || revalidated.Body.Len() != 0 {
+			t.Errorf("GET %s with If-None-Match: status = %d, body = %d bytes; want 304 without a body",
+				tt.path, revalidated.Code, revalidated.Body.Len())
+		}
+	}
+}
+
+func TestWebUI_ServesFromDiskInDevMode(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("index.html", "<html>first</html>")
+
+	e := newWebServer(t, func(h *api.Handler) {
+		if err := h.ServeWebUIFromDisk(dir); err != nil {
+			t.Fatalf("ServeWebUIFromDisk() error: %v", err)
+		}
+	})
+
+	rec := doRequest(e, http.MethodGet, "/", "", "")
+	if rec.Code != http.StatusOK || rec.Body.String() != "<html>first</html>" {
+		t.Fatalf("GET /: %d %q, want the file from disk", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get(echo.HeaderCacheControl); got != "no-store" || rec.Header().Get(api.HeaderETag) != "" {
+		t.Errorf("GET /: Cache-Control = %q, ETag = %q; want no-store without an ETag", got, rec.Header().Get(api.HeaderETag))
+	}
+
+	// Edits show up on the next request
+	writeFile("index.html", "<html>second</html>")
+	if rec := doRequest(e, http.MethodGet, "/", "", ""); rec.Body.String() != "<html>second</html>" {
+		t.Errorf("GET / after an edit = %q, want the edited file", rec.Body.String())
+	}
+
+	// Files missing from the directory are not found
+	if rec := doRequest(e, http.MethodGet, "/style.css", "", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET /style.css: status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	// The directory must exist
+	h := api.NewHandler(newCatalog(t, []int{250}), api.Options{})
+	if err := h.ServeWebUIFromDisk(filepath.Join(dir, "missing")); err == nil {
+		t.Error("ServeWebUIFromDisk() with a missing directory succeeded, want an error")
+	}
+}
@@ -1,16 +1,28 @@
 // Global variables
 let packageSizes = [];
 
+// localStorage key under which the API key is remembered
+const API_KEY_STORAGE = 'packageOptimizer.apiKey';
+
 // Initialize the application
 document.addEventListener('DOMContentLoaded', function() {
     loadPackageSizes();
     setupEventListeners();
+    setupBatchUpload();
+    setupCatalogEditor();
 });
 
 // Setup event listeners
 function setupEventListeners() {
     const quantityInput = document.getElementById('quantity');
     const calculateBtn = document.getElementById('calculate-btn');
+    const apiKeyInput = document.getElementById('api-key');
+    
+    // Remember the API key between visits
+    apiKeyInput.value = localStorage.getItem(API_KEY_STORAGE) || '';
+    apiKeyInput.addEventListener('change', function() {
+        localStorage.setItem(API_KEY_STORAGE, apiKeyInput.value.trim());
+    });
     
     // Calculate on Enter key
     quantityInput.addEventListener('keypress', function(e) {
@@ -48,6 +60,12 @@ function displayPackageSizes() {
     const container = document.getElementById('package-sizes-display');
     container.innerHTML = '';
     
+    // Start the draft catalog from the current one
+    const draftInput = document.getElementById('draft-sizes');
+    if (!draftInput.value.trim()) {
+        resetDraftSizes();
+    }
+    
     packageSizes.forEach(size => {
         const sizeElement = document.createElement('div');
         sizeElement.className = 'package-size';
@@ -73,14 +91,8 @@ async function calculate() {
     hideError();
     
     try {
-        const response = await fetch(`/api/calculate?qty=${quantity}`);
-        
-        if (!response.ok) {
-            const errorData = await response.json();
-            throw new Error(errorData.error || 'Failed to calculate');
-        }
-        
-        const result = await response.json();
+        // Stream progress while the server solves
+        const result = await calculateWithProgress(quantity);
         displayResults(result);
         
     } catch (error) {
@@ -91,6 +103,125 @@ async function calculate() {
     }
 }
 
+// Calculate with a single request and no progress reporting
+async function calculateWithFetch(quantity) {
+    const response = await fetch(`/api/calculate?qty=${quantity}`, { headers: apiHeaders() });
+    
+    if (!response.ok) {
+        const errorData = await response.json();
+        throw new Error(errorData.error || 'Failed to calculate');
+    }
+    
+    return response.json();
+}
+
+// Get the API key entered by the user, if any
+function getApiKey() {
+    return document.getElementById('api-key').value.trim();
+}
+
+// Build request headers carrying the API key, if one was entered
+function apiHeaders() {
+    const apiKey = getApiKey();
+    return apiKey ? { 'X-API-Key': apiKey } : {};
+}
+
+// Calculate via the SSE stream, rendering progress as it arrives. The stream is read
+// with fetch rather than EventSource, so the API key can be sent and a rejected
+// request (400, 413, 429, ...) is reported with the server's message instead of
+// being repeated.
+async function calculateWithProgress(quantity) {
+    let response;
+    try {
+        response = await fetch(`/api/calculate/stream?qty=${quantity}`, { headers: apiHeaders() });
+    } catch (error) {
+        // The stream never opened, so nothing was solved yet: try a plain request
+        return calculateWithFetch(quantity);
+    }
+    if (!response.ok) {
+        throw await responseError(response, 'Failed to calculate');
+    }
+    
+    // Browsers without streaming response bodies get every event at the end
+    if (!response.body || !window.TextDecoder) {
+        return handleStreamEvents(parseStreamEvents(await response.text()).events);
+    }
+    
+    // Events are separated by a blank line; keep the unfinished one for the next chunk
+    const reader = response.body.getReader();
+    const decoder = new TextDecoder();
+    let buffer = '';
+    for (;;) {
+        const { done, value } = await reader.read();
+        if (done) {
+            break;
+        }
+        const parsed = parseStreamEvents(buffer + decoder.decode(value, { stream: true }));
+        buffer = parsed.rest;
+        const result = handleStreamEvents(parsed.events);
+        if (result) {
+            reader.cancel();
+            return result;
+        }
+    }
+    
+    // The connection dropped mid-stream; repeating the solve could be just as slow
+    const result = handleStreamEvents(parseStreamEvents(buffer + '\n\n').events);
+    if (result) {
+        return result;
+    }
+    throw new Error('The connection to the server was lost before the calculation finished');
+}
+
+// Split Server-Sent Events text into complete events and the unfinished rest,
+// e.g. "event: progress\ndata: {...}\n\n"
+function parseStreamEvents(text) {
+    const blocks = text.replace(/\r\n/g, '\n').split('\n\n');
+    const rest = blocks.pop();
+    const events = blocks.map(block => {
+        const event = { type: 'message', data: '' };
+        block.split('\n').forEach(line => {
+            if (line.startsWith('event:')) {
+                event.type = line.slice(6).trim();
+            } else if (line.startsWith('data:')) {
+                event.data += line.slice(5).trim();
+            }
+        });
+        return event;
+    });
+    return { events: events, rest: rest };
+}
+
+// Render progress events and return the result once it arrives; an error event
+// throws with the server's message
+function handleStreamEvents(events) {
+    for (const event of events) {
+        if (event.type === 'progress') {
+            displayProgress(JSON.parse(event.data));
+        } else if (event.type === 'result') {
+            return JSON.parse(event.data);
+        } else if (event.type === 'error') {
+            throw new Error(JSON.parse(event.data).error || 'Failed to calculate');
+        }
+    }
+    return null;
+}
+
+// Display DP progress and the best solution found so far
+function displayProgress(progress) {
+    const percent = progress.total_rows > 0
+        ? Math.floor(progress.rows_filled * 100 / progress.total_rows)
+        : 0;
+    document.getElementById('progress-fill').style.width = `${percent}%`;
+    document.getElementById('progress-text').textContent =
+        `${formatNumber(progress.rows_filled)} / ${formatNumber(progress.total_rows)} rows (${percent}%)`;
+    
+    const best = progress.best;
+    document.getElementById('progress-best').textContent = best
+        ? `Best so far: ${formatNumber(best.total_delivered)} delivered (over-delivery ${formatNumber(best.over_delivery)})`
+        : '';
+}
+
 // Display calculation results
 function displayResults(result) {
     // Update result values
@@ -120,8 +251,572 @@ function displayResults(result) {
     showResults();
 }
 
+// Read the error message from a failed API response
+async function responseError(response, fallback) {
+    try {
+        const data = await response.json();
+        return new Error(data.error || fallback);
+    } catch (e) {
+        return new Error(fallback);
+    }
+}
+
+// Most quantities sent in one batch request (the server's limit)
+const BATCH_SIZE = 1000;
+
+// Attempts per batch request while the server answers 429 Too Many Requests
+const MAX_BATCH_ATTEMPTS = 5;
+
+// Most result rows shown on the page; the downloads always hold every row
+const MAX_BATCH_ROWS_SHOWN = 500;
+
+// Orders of the last uploaded file, in file order
+let batchRows = [];
+// Name of the last uploaded file without its extension, used for the downloads
+let batchName = 'orders';
+// True while a file is being calculated
+let batchRunning = false;
+
+// Setup the batch upload's event listeners
+function setupBatchUpload() {
+    const dropZone = document.getElementById('batch-drop-zone');
+    const fileInput = document.getElementById('batch-file');
+    
+    fileInput.addEventListener('change', function() {
+        if (fileInput.files.length > 0) {
+            runBatch(fileInput.files[0]);
+        }
+        // Allow choosing the same file again
+        fileInput.value = '';
+    });
+    
+    // Highlight the drop zone while a file is dragged over it
+    dropZone.addEventListener('dragover', function(e) {
+        e.preventDefault();
+        dropZone.classList.add('dragover');
+    });
+    dropZone.addEventListener('dragleave', function() {
+        dropZone.classList.remove('dragover');
+    });
+    dropZone.addEventListener('drop', function(e) {
+        e.preventDefault();
+        dropZone.classList.remove('dragover');
+        if (e.dataTransfer.files.length > 0) {
+            runBatch(e.dataTransfer.files[0]);
+        }
+    });
+    
+    document.getElementById('download-csv-btn').addEventListener('click', downloadBatchCSV);
+    document.getElementById('download-json-btn').addEventListener('click', downloadBatchJSON);
+}
+
+// Calculate every order of a CSV file, sending the quantities to the batch
+// endpoint in chunks and showing the progress after each one
+async function runBatch(file) {
+    if (batchRunning) {
+        showBatchMessage('Wait for the current file to finish before uploading another one', 'error');
+        return;
+    }
+    batchRunning = true;
+    hideBatchMessage();
+    document.getElementById('batch-result').style.display = 'none';
+    batchRows = [];
+    batchName = file.name.replace(/\.[^.]*$/, '') || 'orders';
+    
+    // Catalog versions the results were computed with; more than one means the
+    // catalog changed during the upload
+    const versions = new Set();
+    try {
+        batchRows = parseOrdersCSV(await file.text());
+        
+        // Rows that failed to parse already have their error and are not sent
+        const pending = batchRows.filter(row => !row.error);
+        showBatchProgress(0, pending.length);
+        for (let start = 0; start < pending.length; start += BATCH_SIZE) {
+            const chunk = pending.slice(start, start + BATCH_SIZE);
+            versions.add(await calculateBatchChunk(chunk, start, pending.length));
+            showBatchProgress(start + chunk.length, pending.length);
+        }
+        displayBatchResults(versions);
+        
+    } catch (error) {
+        console.error('Batch error:', error);
+        showBatchMessage(error.message, 'error');
+        
+        // Keep the results computed so far; the rest are reported as failed
+        batchRows.forEach(row => {
+            if (!row.result && !row.error) {
+                row.error = `not calculated: ${error.message}`;
+            }
+        });
+        if (batchRows.length > 0) {
+            displayBatchResults(versions);
+        }
+    } finally {
+        document.getElementById('batch-progress').style.display = 'none';
+        batchRunning = false;
+    }
+}
+
+// Parse orders from CSV text. The file has the same form the CLI's bulk command
+// reads: a header row naming a "quantity" column and optionally an "order_id"
+// column, matched case-insensitively. Orders without an ID are numbered by line.
+// Rows whose quantity isn't an integer get an error and are not sent.
+function parseOrdersCSV(text) {
+    // Spreadsheets often save CSV with a byte order mark
+    const records = parseCSV(text.replace(/^\uFEFF/, ''));
+    if (records.length === 0) {
+        throw new Error('The file is empty; a header row is required');
+    }
+    
+    const header = records[0].fields.map(name => name.trim().toLowerCase());
+    const quantityIndex = header.indexOf('quantity');
+    const idIndex = header.indexOf('order_id');
+    if (quantityIndex < 0) {
+        throw new Error(`No "quantity" column; the header has ${records[0].fields.join(', ')}`);
+    }
+    
+    return records.slice(1).map(record => {
+        const row = { line: record.line, id: String(record.line), raw: '', quantity: null, result: null, error: '' };
+        if (idIndex >= 0 && record.fields[idIndex] && record.fields[idIndex].trim()) {
+            row.id = record.fields[idIndex].trim();
+        }
+        row.raw = (record.fields[quantityIndex] || '').trim();
+        if (row.raw === '') {
+            row.error = 'missing quantity';
+        } else if (!/^[+-]?\d+$/.test(row.raw) || !Number.isSafeInteger(Number(row.raw))) {
+            row.error = `invalid quantity "${row.raw}": must be an integer`;
+        } else {
+            row.quantity = Number(row.raw);
+        }
+        return row;
+    });
+}
+
+// Split CSV text into records with the line each starts on. Fields may be quoted,
+// and quoted fields may hold commas, doubled quotes and line breaks. Blank lines
+// are skipped.
+function parseCSV(text) {
+    const records = [];
+    let fields = null;
+    let field = '';
+    let quoted = false;
+    let line = 1;
+    let recordLine = 1;
+    
+    // Finish the current record, skipping it if the line was blank
+    function endRecord() {
+        fields.push(field);
+        if (fields.length > 1 || fields[0].trim() !== '') {
+            records.push({ line: recordLine, fields: fields });
+        }
+        fields = null;
+        field = '';
+    }
+    
+    for (let i = 0; i < text.length; i++) {
+        const c = text[i];
+        if (quoted) {
+            if (c === '"' && text[i + 1] === '"') {
+                field += '"';
+                i++;
+            } else if (c === '"') {
+                quoted = false;
+            } else {
+                if (c === '\n') {
+                    line++;
+                }
+                field += c;
+            }
+            continue;
+        }
+        
+        if (fields === null) {
+            fields = [];
+            recordLine = line;
+        }
+        if (c === '"' && field.trim() === '') {
+            quoted = true;
+            field = '';
+        } else if (c === ',') {
+            fields.push(field);
+            field = '';
+        } else if (c === '\n' || c === '\r') {
+            if (c === '\r' && text[i + 1] === '\n') {
+                i++;
+            }
+            endRecord();
+            line++;
+        } else {
+            field += c;
+        }
+    }
+    if (fields !== null) {
+        endRecord();
+    }
+    return records;
+}
+
+// Calculate one chunk of orders with the batch endpoint, storing each order's
+// result or error. A rate-limited request is retried after the delay the server
+// asks for.
+//
+// Returns the version of the catalog the chunk was calculated with
+async function calculateBatchChunk(rows, done, total) {
+    for (let attempt = 1; ; attempt++) {
+        const response = await fetch('/api/calculate/batch', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json', ...apiHeaders() },
+            body: JSON.stringify({ quantities: rows.map(row => row.quantity) })
+        });
+        if (response.status === 429 && attempt < MAX_BATCH_ATTEMPTS) {
+            const seconds = parseInt(response.headers.get('Retry-After')) || 1;
+            showBatchProgress(done, total, `Rate limited; retrying in ${seconds}s...`);
+            await new Promise(resolve => setTimeout(resolve, seconds * 1000));
+            continue;
+        }
+        if (!response.ok) {
+            throw await responseError(response, 'Failed to calculate the batch');
+        }
+        
+        // Items come back in request order, one per quantity
+        const data = await response.json();
+        data.items.forEach((item, i) => {
+            rows[i].result = item.result || null;
+            rows[i].error = item.error || '';
+        });
+        return data.catalog_version;
+    }
+}
+
+// Display the batch progress, e.g. "2,000 of 5,400 orders calculated (37%)"
+function showBatchProgress(done, total, note) {
+    const percent = total > 0 ? Math.round(done * 100 / total) : 100;
+    document.getElementById('batch-progress-fill').style.width = `${percent}%`;
+    document.getElementById('batch-progress-text').textContent =
+        note || `${formatNumber(done)} of ${formatNumber(total)} orders calculated (${percent}%)`;
+    document.getElementById('batch-progress').style.display = 'block';
+}
+
+// Display the summary and result table of the last upload
+function displayBatchResults(versions) {
+    const failed = batchRows.filter(row => row.error).length;
+    let summary = `${formatNumber(batchRows.length)} orders: ${formatNumber(batchRows.length - failed)} calculated, ${formatNumber(failed)} failed`;
+    if (versions.size === 1) {
+        summary += ` (catalog ${[...versions][0]})`;
+    } else if (versions.size > 1) {
+        summary += ` (the catalog changed during the upload: ${[...versions].join(', ')})`;
+    }
+    document.getElementById('batch-summary').textContent = summary;
+    
+    const tbody = document.getElementById('batch-rows');
+    tbody.innerHTML = '';
+    batchRows.slice(0, MAX_BATCH_ROWS_SHOWN).forEach(row => {
+        const tr = document.createElement('tr');
+        const cells = [row.line, row.id, row.raw];
+        if (row.result) {
+            cells.push(formatNumber(row.result.total_delivered), formatNumber(row.result.over_delivery), formatPackages(row.result.packages));
+        }
+        cells.forEach(value => {
+            const td = document.createElement('td');
+            td.textContent = value;
+            tr.appendChild(td);
+        });
+        // Failed orders show their error across the result columns
+        if (!row.result) {
+            const td = document.createElement('td');
+            td.colSpan = 3;
+            td.textContent = row.error;
+            tr.appendChild(td);
+            tr.className = 'failed';
+        }
+        tbody.appendChild(tr);
+    });
+    
+    const note = document.getElementById('batch-rows-note');
+    if (batchRows.length > MAX_BATCH_ROWS_SHOWN) {
+        note.textContent = `Showing the first ${formatNumber(MAX_BATCH_ROWS_SHOWN)} orders; the downloads hold all ${formatNumber(batchRows.length)}.`;
+        note.style.display = 'block';
+    } else {
+        note.style.display = 'none';
+    }
+    document.getElementById('batch-result').style.display = 'block';
+}
+
+// Format packages like the CLI, largest first: "1 x 1000, 1 x 250"
+function formatPackages(packages) {
+    return Object.keys(packages)
+        .map(Number)
+        .sort((a, b) => b - a)
+        .map(size => `${packages[size]} x ${size}`)
+        .join(', ');
+}
+
+// Download the results as CSV, with the columns of the CLI's bulk command
+function downloadBatchCSV() {
+    const records = [['order_id', 'quantity', 'total_delivered', 'over_delivery', 'packages', 'error']];
+    batchRows.forEach(row => {
+        if (row.result) {
+            records.push([row.id, row.raw, row.result.total_delivered, row.result.over_delivery, formatPackages(row.result.packages), '']);
+        } else {
+            records.push([row.id, row.raw, '', '', '', row.error]);
+        }
+    });
+    const text = records.map(fields => fields.map(csvField).join(',')).join('\n') + '\n';
+    downloadFile(text, 'text/csv', `${batchName}-results.csv`);
+}
+
+// Quote a CSV field if it holds a comma, a quote or a line break
+function csvField(value) {
+    const text = String(value);
+    return /[",\r\n]/.test(text) ? `"${text.replace(/"/g, '""')}"` : text;
+}
+
+// Download the results as JSON, one object per order like the CLI's jsonl output
+function downloadBatchJSON() {
+    const records = batchRows.map(row => {
+        const record = { line: row.line, order_id: row.id };
+        if (row.quantity !== null) {
+            record.quantity = row.quantity;
+        }
+        if (row.result) {
+            record.result = row.result;
+        } else {
+            record.error = row.error;
+        }
+        return record;
+    });
+    downloadFile(JSON.stringify(records, null, 2) + '\n', 'application/json', `${batchName}-results.json`);
+}
+
+// Save text as a file through a temporary link
+function downloadFile(text, type, filename) {
+    const url = URL.createObjectURL(new Blob([text], { type: type }));
+    const link = document.createElement('a');
+    link.href = url;
+    link.download = filename;
+    document.body.appendChild(link);
+    link.click();
+    link.remove();
+    // Some browsers start the download asynchronously; free the file afterwards
+    setTimeout(() => URL.revokeObjectURL(url), 1000);
+}
+
+function showBatchMessage(message, kind) {
+    const element = document.getElementById('batch-message');
+    element.textContent = message;
+    element.className = `batch-message ${kind}`;
+    element.style.display = 'block';
+}
+
+function hideBatchMessage() {
+    document.getElementById('batch-message').style.display = 'none';
+}
+
+// SVG namespace for the comparison chart elements
+const SVG_NS = 'http://www.w3.org/2000/svg';
+
+// Setup the catalog editor's event listeners
+function setupCatalogEditor() {
+    document.getElementById('reset-draft-btn').addEventListener('click', resetDraftSizes);
+    document.getElementById('compare-btn').addEventListener('click', compareCatalogs);
+    document.getElementById('publish-btn').addEventListener('click', publishDraft);
+}
+
+// Replace the draft with the current package sizes
+function resetDraftSizes() {
+    document.getElementById('draft-sizes').value = packageSizes.join(', ');
+}
+
+// Parse the draft package sizes, e.g. "250, 500 1000"
+function parseDraftSizes() {
+    const parts = document.getElementById('draft-sizes').value.split(/[\s,]+/).filter(Boolean);
+    const sizes = parts.map(part => Number(part));
+    if (sizes.length === 0 || sizes.some(size => !Number.isInteger(size) || size <= 0)) {
+        throw new Error('Draft package sizes must be positive whole numbers separated by commas');
+    }
+    return sizes;
+}
+
+// Compare the draft catalog with the current one across the sweep
+async function compareCatalogs() {
+    const compareBtn = document.getElementById('compare-btn');
+    hideCatalogMessage();
+    
+    try {
+        const request = {
+            package_sizes: parseDraftSizes(),
+            from: parseInt(document.getElementById('sweep-from').value) || 1,
+            to: parseInt(document.getElementById('sweep-to').value)
+        };
+        const step = parseInt(document.getElementById('sweep-step').value);
+        if (step > 0) {
+            request.step = step;
+        }
+        if (!request.to || request.to < request.from) {
+            throw new Error('The sweep needs a "to" quantity of at least "from"');
+        }
+        
+        compareBtn.disabled = true;
+        const response = await fetch('/api/catalog/compare', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json', ...apiHeaders() },
+            body: JSON.stringify(request)
+        });
+        if (!response.ok) {
+            throw await responseError(response, 'Failed to compare catalogs');
+        }
+        displayComparison(await response.json());
+        
+    } catch (error) {
+        console.error('Comparison error:', error);
+        showCatalogMessage(error.message, 'error');
+    } finally {
+        compareBtn.disabled = false;
+    }
+}
+
+// Display the comparison summary and chart
+function displayComparison(comparison) {
+    const rows = [
+        ['Version', c => c.version, null],
+        ['Package sizes', c => c.package_sizes.join(', '), null],
+        ['Exact quantities', c => `${formatNumber(c.exact)} / ${formatNumber(comparison.points.length)}`, c => c.exact, 'max'],
+        ['Average over-delivery', c => formatNumber(Math.round(c.average_over_delivery * 10) / 10), c => c.average_over_delivery, 'min'],
+        ['Max over-delivery', c => formatNumber(c.max_over_delivery), c => c.max_over_delivery, 'min']
+    ];
+    
+    const summary = document.getElementById('comparison-summary');
+    summary.innerHTML = '';
+    rows.forEach(([label, format, metric, better]) => {
+        const row = document.createElement('tr');
+        const labelCell = document.createElement('td');
+        labelCell.textContent = label;
+        row.appendChild(labelCell);
+        
+        [comparison.current, comparison.draft].forEach((side, i) => {
+            const cell = document.createElement('td');
+            cell.textContent = format(side);
+            // Highlight the catalog that does better on this metric
+            if (metric) {
+                const mine = metric(side);
+                const other = metric(i === 0 ? comparison.draft : comparison.current);
+                if ((better === 'min' && mine < other) || (better === 'max' && mine > other)) {
+                    cell.className = 'better';
+                }
+            }
+            row.appendChild(cell);
+        });
+        summary.appendChild(row);
+    });
+    
+    drawComparisonChart(comparison.points);
+    document.getElementById('comparison-result').style.display = 'block';
+}
+
+// Draw the over-delivery of both catalogs as lines over the swept quantities
+function drawComparisonChart(points) {
+    const svg = document.getElementById('comparison-chart');
+    const width = 800, height = 300;
+    const pad = { left: 50, right: 10, top: 10, bottom: 10 };
+    const plotWidth = width - pad.left - pad.right;
+    const plotHeight = height - pad.top - pad.bottom;
+    svg.innerHTML = '';
+    
+    const first = points[0].quantity;
+    const last = points[points.length - 1].quantity;
+    const maxOver = Math.max(1, ...points.map(p => Math.max(p.current, p.draft)));
+    const x = q => pad.left + (last > first ? (q - first) / (last - first) : 0.5) * plotWidth;
+    const y = v => pad.top + plotHeight - (v / maxOver) * plotHeight;
+    
+    // Horizontal grid lines labelled with the over-delivery
+    for (let i = 0; i <= 4; i++) {
+        const value = Math.round(maxOver * i / 4);
+        const line = document.createElementNS(SVG_NS, 'line');
+        line.setAttribute('class', 'chart-grid');
+        line.setAttribute('x1', pad.left);
+        line.setAttribute('x2', width - pad.right);
+        line.setAttribute('y1', y(value));
+        line.setAttribute('y2', y(value));
+        svg.appendChild(line);
+        
+        const label = document.createElementNS(SVG_NS, 'text');
+        label.setAttribute('class', 'chart-label');
+        label.setAttribute('x', pad.left - 8);
+        label.setAttribute('y', y(value) + 4);
+        label.setAttribute('text-anchor', 'end');
+        label.textContent = formatNumber(value);
+        svg.appendChild(label);
+    }
+    
+    // One line per catalog
+    ['current', 'draft'].forEach(series => {
+        const line = document.createElementNS(SVG_NS, 'polyline');
+        line.setAttribute('class', `chart-line ${series}`);
+        line.setAttribute('points', points.map(p => `${x(p.quantity)},${y(p[series])}`).join(' '));
+        const title = document.createElementNS(SVG_NS, 'title');
+        title.textContent = `${series === 'current' ? 'Current' : 'Draft'} catalog over-delivery`;
+        line.appendChild(title);
+        svg.appendChild(line);
+    });
+    
+    document.getElementById('chart-x-min').textContent = formatNumber(first);
+    document.getElementById('chart-x-max').textContent = formatNumber(last);
+}
+
+// Publish the draft as the current catalog (requires the catalog-admin scope)
+async function publishDraft() {
+    const publishBtn = document.getElementById('publish-btn');
+    hideCatalogMessage();
+    
+    try {
+        const sizes = parseDraftSizes();
+        if (!confirm(`Replace the current package sizes with ${sizes.join(', ')}? Every later calculation will use them.`)) {
+            return;
+        }
+        
+        publishBtn.disabled = true;
+        const response = await fetch('/api/catalog', {
+            method: 'PUT',
+            headers: { 'Content-Type': 'application/json', ...apiHeaders() },
+            body: JSON.stringify({ package_sizes: sizes })
+        });
+        if (response.status === 401 || response.status === 403) {
+            throw new Error('Publishing requires an API key with the catalog-admin scope');
+        }
+        if (!response.ok) {
+            throw await responseError(response, 'Failed to publish the catalog');
+        }
+        
+        const catalog = await response.json();
+        showCatalogMessage(`Published catalog ${catalog.version}: ${catalog.package_sizes.join(', ')}`, 'success');
+        await loadPackageSizes();
+        
+    } catch (error) {
+        console.error('Publish error:', error);
+        showCatalogMessage(error.message, 'error');
+    } finally {
+        publishBtn.disabled = false;
+    }
+}
+
+function showCatalogMessage(message, kind) {
+    const element = document.getElementById('catalog-message');
+    element.textContent = message;
+    element.className = `catalog-message ${kind}`;
+    element.style.display = 'block';
+}
+
+function hideCatalogMessage() {
+    document.getElementById('catalog-message').style.display = 'none';
+}
+
 // Show/hide functions
 function showLoading() {
+    // Reset progress from any previous calculation
+    document.getElementById('progress-fill').style.width = '0';
+    document.getElementById('progress-text').textContent = '';
+    document.getElementById('progress-best').textContent = '';
     document.getElementById('loading-section').style.display = 'block';
 }
 
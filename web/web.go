@@ -0,0 +1,24 @@
+// Package web holds the files of the web UI. They are embedded into the binary,
+// so the server doesn't depend on its working directory or on files shipped
+// next to it.
+package web
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// static holds the web/static directory.
+//
+//go:embed static
+var static embed.FS
+
+// Static returns the web UI files, rooted at web/static (e.g., "index.html").
+func Static() fs.FS {
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		// fs.Sub only fails for invalid paths; "static" is a constant
+		panic(err)
+	}
+	return files
+}